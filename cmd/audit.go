@@ -2,11 +2,16 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/dso-cli/dso-cli/internal/llm"
+	"github.com/dso-cli/dso-cli/internal/sarif"
 	"github.com/dso-cli/dso-cli/internal/scanner"
 	"github.com/dso-cli/dso-cli/internal/tools"
 	"github.com/dso-cli/dso-cli/internal/ui"
@@ -14,9 +19,19 @@ import (
 )
 
 var (
-	auditFormat     string
-	auditVerbose    bool
+	auditFormat      string
+	auditVerbose     bool
 	auditInteractive bool
+	auditJobs        int
+	auditImportSARIF []string
+	auditSince       string
+	auditStaged      bool
+	auditNoCache     bool
+	auditBaseline    bool
+	auditGroupBy     string
+	auditHistory     bool
+	auditSinceCommit string
+	auditBranch      string
 )
 
 var auditCmd = &cobra.Command{
@@ -37,72 +52,168 @@ and analyzes results with local AI to give you the 3-5 critical issues.`,
 			os.Exit(1)
 		}
 
+		if auditFormat != "text" && auditFormat != "json" && auditFormat != "sarif" {
+			fmt.Fprintf(os.Stderr, "❌ Error: unknown format %q (text, json, sarif)\n", auditFormat)
+			os.Exit(1)
+		}
+		if auditGroupBy != "" && !slices.Contains(scanner.GroupByValues, auditGroupBy) {
+			fmt.Fprintf(os.Stderr, "❌ Error: unknown grouping %q (%s)\n", auditGroupBy, strings.Join(scanner.GroupByValues, ", "))
+			os.Exit(1)
+		}
+
+		if !auditHistory && (auditSinceCommit != "" || auditBranch != "") {
+			fmt.Fprintln(os.Stderr, "❌ Error: --since-commit and --branch require --history")
+			os.Exit(1)
+		}
+		if auditHistory && (auditSince != "" || auditStaged) {
+			fmt.Fprintln(os.Stderr, "❌ Error: --history cannot be combined with --since or --staged")
+			os.Exit(1)
+		}
+		var history *scanner.HistoryScope
+		if auditHistory {
+			history = &scanner.HistoryScope{SinceCommit: auditSinceCommit, Branch: auditBranch}
+		}
+
+		// Machine-readable formats keep stdout for the report
+		var status io.Writer = os.Stdout
+		if auditFormat != "text" {
+			status = os.Stderr
+		}
+
 		if auditVerbose {
-			fmt.Printf("📁 Analyzing directory: %s\n\n", absPath)
+			fmt.Fprintf(status, "📁 Analyzing directory: %s\n\n", absPath)
+		}
+
+		var baseline *scanner.Baseline
+		if auditBaseline {
+			baseline, err = scanner.LoadBaseline(absPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+				os.Exit(1)
+			}
 		}
 
 		// Check available tools
 		_, missing := tools.CheckTools(false)
 		if len(missing) > 0 && auditVerbose {
-			fmt.Println("⚠️  Some tools are missing (scan will continue with available tools):")
+			fmt.Fprintln(status, "⚠️  Some tools are missing (scan will continue with available tools):")
 			for _, tool := range missing {
-				fmt.Printf("   • %s\n", tool.Name)
+				fmt.Fprintf(status, "   • %s\n", tool.Name)
 			}
-			fmt.Println()
+			fmt.Fprintln(status)
 		}
 
 		// Phase 1: Full scan
-		fmt.Println("🔍 Scanning... (Trivy, grype, gitleaks, tfsec...)")
+		fmt.Fprintln(status, "🔍 Scanning... (Trivy, grype, gitleaks, tfsec...)")
 		start := time.Now()
 
 		tracker := scanner.NewProgressTracker(auditVerbose)
-		results, err := scanner.RunFullScanInteractive(absPath, auditVerbose, tracker)
+		tracker.SetOutput(status)
+		results, err := scanner.Run(cmd.Context(), absPath, scanner.Options{
+			Interactive:  auditVerbose,
+			Tracker:      tracker,
+			Jobs:         auditJobs,
+			SARIFImports: auditImportSARIF,
+			Since:        auditSince,
+			Staged:       auditStaged,
+			Cache:        openScanCache(absPath, auditNoCache, status),
+			History:      history,
+		})
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "❌ Error during scan: %v\n", err)
 			os.Exit(1)
 		}
 
-		scanDuration := time.Since(start)
-		if auditVerbose {
-			fmt.Printf("✅ Scan completed in %v\n\n", scanDuration.Round(time.Millisecond))
-		}
-
-		// Phase 2: AI Analysis
-		fmt.Println("🧠 Analyzing with local AI (Ollama)...")
-		if auditVerbose {
-			fmt.Println("   💡 Use 'dso check' to verify Ollama status")
+		if baseline != nil {
+			baseline.Apply(results)
 		}
-		start = time.Now()
-
-		summary, err := llm.Analyze(results, absPath)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "\n⚠️  Error during AI analysis: %v\n", err)
-			fmt.Println("\n💡 Check Ollama status with: dso check")
-			fmt.Println("\nDisplaying raw scan results:")
-			ui.PrintRawResults(results)
-			os.Exit(1)
+		if auditGroupBy != "" {
+			results.Grouping, _ = scanner.GroupFindings(results, auditGroupBy)
 		}
 
-		analysisDuration := time.Since(start)
+		scanDuration := time.Since(start)
 		if auditVerbose {
-			fmt.Printf("✅ Analysis completed in %v\n\n", analysisDuration.Round(time.Millisecond))
+			fmt.Fprintf(status, "✅ Scan completed in %v\n\n", scanDuration.Round(time.Millisecond))
 		}
 
-		// Phase 3: Display
-		if auditInteractive && auditFormat != "json" {
-			if err := ui.ShowInteractiveUI(summary, results); err != nil {
-				fmt.Fprintf(os.Stderr, "Error displaying interactive UI: %v\n", err)
-				ui.PrintBeautifulSummary(summary, results, false)
-			}
-		} else {
-			fmt.Println()
-			ui.PrintBeautifulSummary(summary, results, auditFormat == "json")
-		}
+		// Phase 2 and 3: AI analysis and display
+		analyzeAndReport(results, absPath, reportOptions{
+			format:      auditFormat,
+			interactive: auditInteractive,
+			verbose:     auditVerbose,
+			status:      status,
+		})
 	},
 }
 
 func init() {
-	auditCmd.Flags().StringVarP(&auditFormat, "format", "f", "text", "Output format (text, json)")
+	auditCmd.Flags().StringVarP(&auditFormat, "format", "f", "text", "Output format (text, json, sarif)")
 	auditCmd.Flags().BoolVarP(&auditVerbose, "verbose", "v", false, "Verbose mode")
 	auditCmd.Flags().BoolVarP(&auditInteractive, "interactive", "i", false, "Interactive TUI mode")
+	auditCmd.Flags().IntVarP(&auditJobs, "jobs", "j", runtime.NumCPU(), "Maximum number of scanners running in parallel")
+	auditCmd.Flags().StringArrayVar(&auditImportSARIF, "import-sarif", nil, "Add the results of a SARIF file from another scanner (repeatable)")
+	auditCmd.Flags().StringVar(&auditSince, "since", "", "Only scan the files and lines changed since a git ref")
+	auditCmd.Flags().BoolVar(&auditStaged, "staged", false, "Only scan the staged changes")
+	auditCmd.Flags().BoolVar(&auditNoCache, "no-cache", false, "Rescan every file instead of reusing cached results")
+	auditCmd.Flags().BoolVar(&auditBaseline, "baseline", false, "Only report the findings absent from .dso/baseline.json")
+	auditCmd.Flags().StringVar(&auditGroupBy, "group-by", "", "Group the findings by classification (cwe, owasp)")
+	auditCmd.Flags().BoolVar(&auditHistory, "history", false, "Also scan the git history for secrets (gitleaks, trufflehog)")
+	auditCmd.Flags().StringVar(&auditSinceCommit, "since-commit", "", "With --history, only scan the commits after this one")
+	auditCmd.Flags().StringVar(&auditBranch, "branch", "", "With --history, only scan the commits of this branch")
+}
+
+// reportOptions are the output flags shared by the scanning commands
+type reportOptions struct {
+	format      string // text, json or sarif
+	interactive bool
+	verbose     bool
+	status      io.Writer // Progress messages, kept out of machine-readable reports
+}
+
+// analyzeAndReport analyzes results with the local AI and prints them. target
+// is the scanned directory or image. Without the AI, the raw results are
+// printed and the command exits with status 1, except for SARIF reports.
+func analyzeAndReport(results *scanner.ScanResults, target string, opts reportOptions) {
+	// AI analysis
+	fmt.Fprintln(opts.status, "🧠 Analyzing with local AI (Ollama)...")
+	if opts.verbose {
+		fmt.Fprintln(opts.status, "   💡 Use 'dso check' to verify Ollama status")
+	}
+	start := time.Now()
+
+	summary, err := llm.Analyze(results, target)
+	if err != nil && opts.format == "sarif" {
+		// The findings are still worth uploading without the analysis
+		fmt.Fprintf(os.Stderr, "⚠️  Error during AI analysis, SARIF report written without it: %v\n", err)
+		summary = nil
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "\n⚠️  Error during AI analysis: %v\n", err)
+		fmt.Println("\n💡 Check Ollama status with: dso check")
+		fmt.Println("\nDisplaying raw scan results:")
+		ui.PrintRawResults(results)
+		os.Exit(1)
+	}
+
+	analysisDuration := time.Since(start)
+	if opts.verbose {
+		fmt.Fprintf(opts.status, "✅ Analysis completed in %v\n\n", analysisDuration.Round(time.Millisecond))
+	}
+
+	// Display
+	if opts.format == "sarif" {
+		data, err := sarif.Marshal(sarif.FromResults(results, summary))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	} else if opts.interactive && opts.format != "json" {
+		if err := ui.ShowInteractiveUI(summary, results); err != nil {
+			fmt.Fprintf(os.Stderr, "Error displaying interactive UI: %v\n", err)
+			ui.PrintBeautifulSummary(summary, results, false)
+		}
+	} else {
+		fmt.Println()
+		ui.PrintBeautifulSummary(summary, results, opts.format == "json")
+	}
 }
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dso-cli/dso-cli/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+var baselineNoCache bool
+
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Accept the existing findings of a project",
+	Long: `Manages the baseline stored in .dso/baseline.json: the findings accepted
+when adopting dso on an existing project. With 'dso audit --baseline', only the
+findings absent from the baseline are reported, so that CI only fails on new ones.
+
+Commit the baseline file so that every checkout shares it.`,
+}
+
+var baselineCreateCmd = &cobra.Command{
+	Use:   "create [path]",
+	Short: "Record the current findings in the baseline",
+	Long: `Scans the project and writes every finding to .dso/baseline.json,
+replacing the existing baseline. Findings suppressed with a dso:ignore comment
+are not recorded.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		absPath, results := scanForBaseline(cmd, args)
+
+		b := scanner.NewBaseline(results)
+		if err := b.Save(absPath); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: cannot write baseline: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Baseline of %d findings written to %s\n", len(b.Entries), scanner.BaselineFile)
+		printBaselineWarnings(results)
+	},
+}
+
+var baselineUpdateCmd = &cobra.Command{
+	Use:   "update [path]",
+	Short: "Remove the fixed findings from the baseline",
+	Long: `Scans the project and removes the baseline entries that are no longer
+found. New findings are not added: fix them, suppress them with a dso:ignore
+comment, or recreate the baseline.
+
+Entries of tools that did not run (not installed, failed, timed out) are kept.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		absPath, err := filepath.Abs(pathArg(args))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: cannot resolve path %s\n", pathArg(args))
+			os.Exit(1)
+		}
+		b, err := scanner.LoadBaseline(absPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		_, results := scanForBaseline(cmd, args)
+		removed := b.Update(results)
+		if err := b.Save(absPath); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: cannot write baseline: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, e := range removed {
+			fmt.Printf("  ✅ Fixed: %s (%s)\n", e.Title, baselineLocation(e))
+		}
+		fmt.Printf("✅ Removed %d fixed findings, %d remaining in %s\n", len(removed), len(b.Entries), scanner.BaselineFile)
+		printBaselineWarnings(results)
+	},
+}
+
+func init() {
+	baselineCmd.PersistentFlags().BoolVar(&baselineNoCache, "no-cache", false, "Rescan every file instead of reusing cached results")
+	baselineCmd.AddCommand(baselineCreateCmd)
+	baselineCmd.AddCommand(baselineUpdateCmd)
+}
+
+// pathArg returns the optional path argument of a command
+func pathArg(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return "."
+}
+
+// scanForBaseline scans the path argument of a baseline command
+func scanForBaseline(cmd *cobra.Command, args []string) (string, *scanner.ScanResults) {
+	absPath, err := filepath.Abs(pathArg(args))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: cannot resolve path %s\n", pathArg(args))
+		os.Exit(1)
+	}
+
+	fmt.Println("🔍 Scanning...")
+	results, err := scanner.Run(cmd.Context(), absPath, scanner.Options{
+		Cache: openScanCache(absPath, baselineNoCache, os.Stdout),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error during scan: %v\n", err)
+		os.Exit(1)
+	}
+	return absPath, results
+}
+
+// printBaselineWarnings reports the scanners whose findings are missing
+// from the baseline because they did not complete
+func printBaselineWarnings(results *scanner.ScanResults) {
+	for _, warning := range results.Warnings {
+		fmt.Printf("⚠️  %s\n", warning)
+	}
+}
+
+// baselineLocation returns "file:line" of a baseline entry
+func baselineLocation(e scanner.BaselineEntry) string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d", e.File, e.Line)
+	}
+	return e.File
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dso-cli/dso-cli/internal/cache"
+	"github.com/dso-cli/dso-cli/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cachePruneAll       bool
+	cachePruneMaxUnused time.Duration
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the scan result cache",
+	Long: `Manages the result cache stored in .dso/cache of a scanned directory.
+Results are reused while the scanned files, the tool version and its rules
+or vulnerability database are unchanged.`,
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune [path]",
+	Short: "Remove outdated cache entries",
+	Long: `Removes the results of uninstalled or upgraded tools, the results
+unused for --max-unused and the hashes of deleted files. With --all, the
+whole cache is removed.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := "."
+		if len(args) > 0 {
+			path = args[0]
+		}
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: cannot resolve path %s\n", path)
+			os.Exit(1)
+		}
+
+		c, err := cache.Open(absPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if cachePruneAll {
+			if err := c.Clear(); err != nil {
+				fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("✅ Removed %s\n", c.Path())
+			return
+		}
+
+		stats, err := c.Prune(scanner.CacheKeys(), cachePruneMaxUnused)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Removed %d outdated tool caches, %d unused results and %d deleted files\n",
+			stats.Tools, stats.Entries, stats.Files)
+	},
+}
+
+func init() {
+	cachePruneCmd.Flags().BoolVar(&cachePruneAll, "all", false, "Remove the whole cache")
+	cachePruneCmd.Flags().DurationVar(&cachePruneMaxUnused, "max-unused", cache.DefaultMaxUnused, "Remove results unused for this long")
+	cacheCmd.AddCommand(cachePruneCmd)
+}
+
+// openScanCache opens the result cache of path, or returns nil when it is
+// disabled or cannot be opened
+func openScanCache(path string, disabled bool, status io.Writer) *cache.Cache {
+	if disabled {
+		return nil
+	}
+	c, err := cache.Open(path)
+	if err != nil {
+		fmt.Fprintf(status, "⚠️  Scanning without cache: %v\n", err)
+		return nil
+	}
+	return c
+}
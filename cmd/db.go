@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dso-cli/dso-cli/internal/osv"
+	"github.com/spf13/cobra"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage the local vulnerability database",
+	Long: `Manages the local OSV advisory database used to scan dependencies
+without network access (for example on air-gapped build agents).`,
+}
+
+var dbImportCmd = &cobra.Command{
+	Use:   "import <dir-or-zip>",
+	Short: "Import OSV advisories into the local database",
+	Long: `Imports OSV-format advisories from a directory of JSON files or a zip
+archive (such as https://osv-vulnerabilities.storage.googleapis.com/<ecosystem>/all.zip)
+into ~/.dso/osv. Advisories already imported are replaced by newer copies.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		store, err := osv.OpenDefaultStore()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("📥 Importing advisories from %s\n", args[0])
+		stats, err := store.Import(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✅ Imported %d advisories affecting %d packages into %s\n", stats.Advisories, stats.Packages, store.Dir())
+		if stats.Skipped > 0 {
+			fmt.Printf("⚠️  %d files were not valid OSV advisories and were skipped\n", stats.Skipped)
+		}
+	},
+}
+
+func init() {
+	dbCmd.AddCommand(dbImportCmd)
+}
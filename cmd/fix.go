@@ -34,7 +34,7 @@ fixes .env files, etc.). Use --auto to apply without confirmation.`,
 		}
 
 		fmt.Println("🔍 Quick scan to identify fixes...")
-		results, err := scanner.RunFullScan(absPath)
+		results, err := scanner.RunFullScan(cmd.Context(), absPath)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "❌ Error during scan: %v\n", err)
 			os.Exit(1)
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/dso-cli/dso-cli/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	imageFormat      string
+	imageVerbose     bool
+	imageInteractive bool
+)
+
+var imageCmd = &cobra.Command{
+	Use:   "image <ref|path.tar|oci-dir>",
+	Short: "Scan a container image + AI analysis",
+	Long: `Scans a container image for vulnerable OS and language packages (trivy, grype),
+secrets baked into its layers, and configuration problems such as running as
+root or exposing SSH or database ports.
+
+The image is a docker save tarball, an OCI image layout directory, or the
+reference of an image of the local docker daemon.`,
+	Example: `  docker save myapp:1.4 -o myapp.tar && dso image myapp.tar
+  dso image ./build/oci
+  dso image myapp:1.4 --format sarif > image.sarif`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if imageFormat != "text" && imageFormat != "json" && imageFormat != "sarif" {
+			fmt.Fprintf(os.Stderr, "❌ Error: unknown format %q (text, json, sarif)\n", imageFormat)
+			os.Exit(1)
+		}
+
+		img, err := scanner.ResolveImage(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Machine-readable formats keep stdout for the report
+		var status io.Writer = os.Stdout
+		if imageFormat != "text" {
+			status = os.Stderr
+		}
+
+		fmt.Fprintf(status, "🐳 Scanning image %s...\n", img.Describe())
+		start := time.Now()
+
+		tracker := scanner.NewProgressTracker(imageVerbose)
+		tracker.SetOutput(status)
+		results, err := scanner.ScanImage(cmd.Context(), img, tracker)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error during scan: %v\n", err)
+			os.Exit(1)
+		}
+
+		if imageVerbose {
+			fmt.Fprintf(status, "✅ Scan completed in %v\n\n", time.Since(start).Round(time.Millisecond))
+		}
+
+		analyzeAndReport(results, img.Target, reportOptions{
+			format:      imageFormat,
+			interactive: imageInteractive,
+			verbose:     imageVerbose,
+			status:      status,
+		})
+	},
+}
+
+func init() {
+	imageCmd.Flags().StringVarP(&imageFormat, "format", "f", "text", "Output format (text, json, sarif)")
+	imageCmd.Flags().BoolVarP(&imageVerbose, "verbose", "v", false, "Verbose mode")
+	imageCmd.Flags().BoolVarP(&imageInteractive, "interactive", "i", false, "Interactive TUI mode")
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dso-cli/dso-cli/internal/license"
+	"github.com/dso-cli/dso-cli/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+var licensesFormat string
+
+var licensesCmd = &cobra.Command{
+	Use:   "licenses [path]",
+	Short: "Summarize the licenses of the dependencies",
+	Long: `Lists the licenses of the project dependencies and of the license files of
+code copied into the project, normalized to SPDX expressions, and evaluates
+them against the license policy of the project.
+
+Licenses are read from the lockfiles that record them (package-lock.json,
+composer.lock), then from the installed packages: node_modules, Python
+virtual environments, the Go vendor directory and module cache, and the Cargo
+registry. The policy is the DSO_LICENSES_ALLOW, DSO_LICENSES_DENY and
+DSO_LICENSES_REVIEW lists of .dso/config.
+
+Exits with status 1 when a license is denied.`,
+	Example: `  dso licenses
+  dso licenses ./services/api --format json`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if licensesFormat != "text" && licensesFormat != "json" {
+			fmt.Fprintf(os.Stderr, "❌ Error: unknown format %q (text, json)\n", licensesFormat)
+			os.Exit(1)
+		}
+
+		absPath, err := filepath.Abs(pathArg(args))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: cannot resolve path %s\n", pathArg(args))
+			os.Exit(1)
+		}
+
+		report, err := scanner.CollectLicenses(cmd.Context(), absPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if licensesFormat == "json" {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+		} else {
+			printLicenseReport(report)
+		}
+
+		if report.Count(license.Denied) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// printLicenseReport prints the licenses grouped by SPDX expression, then
+// the licenses that are not allowed
+func printLicenseReport(report *scanner.LicenseReport) {
+	fmt.Printf("📜 Licenses of %s\n", report.Path)
+	if len(report.Project) == 0 {
+		fmt.Println("📁 Project license: none (no LICENSE file)")
+	}
+	for _, use := range report.Project {
+		fmt.Printf("📁 Project license: %s (%s)\n", use.Expression(), use.File)
+	}
+	fmt.Println()
+
+	type group struct {
+		license  string
+		count    int
+		decision license.Decision
+	}
+	var groups []*group
+	index := make(map[string]*group)
+	for _, use := range report.Uses {
+		name := use.Expression()
+		g, ok := index[name]
+		if !ok {
+			g = &group{license: name, decision: use.Verdict.Decision}
+			index[name] = g
+			groups = append(groups, g)
+		}
+		g.count++
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].count != groups[j].count {
+			return groups[i].count > groups[j].count
+		}
+		return groups[i].license < groups[j].license
+	})
+
+	if len(groups) == 0 {
+		fmt.Println("No licenses found")
+	} else {
+		fmt.Printf("  %-40s %6s  %s\n", "LICENSE", "COUNT", "POLICY")
+		for _, g := range groups {
+			fmt.Printf("  %-40s %6d  %s\n", g.license, g.count, g.decision)
+		}
+	}
+
+	sections := []struct {
+		decision license.Decision
+		title    string
+	}{
+		{license.Denied, "❌ Denied"},
+		{license.Review, "⚠️  Needs a review"},
+		{license.Unknown, "❓ Unrecognized"},
+	}
+	for _, section := range sections {
+		if report.Count(section.decision) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s (%d):\n", section.title, report.Count(section.decision))
+		for _, use := range report.Uses {
+			if use.Verdict.Decision == section.decision {
+				fmt.Printf("  - %s\n", describeLicenseUse(use))
+			}
+		}
+	}
+
+	if n := len(report.Undeclared); n > 0 {
+		fmt.Printf("\nℹ️  %d packages without license information: the lockfile does not record it and the package is not installed\n", n)
+	}
+	for _, w := range report.Warnings {
+		fmt.Printf("⚠️  %s\n", w)
+	}
+}
+
+// describeLicenseUse describes a license that is not allowed, with the
+// policy entry that decided
+func describeLicenseUse(use scanner.LicenseUse) string {
+	subject := use.Subject()
+	if use.Package != nil {
+		subject += fmt.Sprintf(" (%s)", use.Package.Ecosystem)
+	}
+	parts := []string{subject + ": " + use.Expression()}
+	switch {
+	case use.Verdict.Rule != "":
+		parts = append(parts, fmt.Sprintf("%q in the %s list", use.Verdict.Rule, use.Verdict.List))
+	case use.Verdict.Decision == license.Review:
+		parts = append(parts, "not in the allow list")
+	}
+	if use.From != "" {
+		parts = append(parts, use.From)
+	} else {
+		parts = append(parts, use.File)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func init() {
+	licensesCmd.Flags().StringVarP(&licensesFormat, "format", "f", "text", "Output format (text, json)")
+}
@@ -35,7 +35,7 @@ on GitHub/GitLab with the changes.`,
 		}
 
 		fmt.Println("🔍 Scanning and applying fixes...")
-		results, err := scanner.RunFullScan(absPath)
+		results, err := scanner.RunFullScan(cmd.Context(), absPath)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "❌ Error during scan: %v\n", err)
 			os.Exit(1)
@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 )
@@ -25,7 +28,11 @@ Analyzes your code, tells you what really matters and proposes fixes in 1 comman
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	// Cancel running scans (and their tool processes) on Ctrl+C or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -33,6 +40,7 @@ func Execute() {
 
 func init() {
 	rootCmd.AddCommand(auditCmd)
+	rootCmd.AddCommand(imageCmd)
 	rootCmd.AddCommand(fixCmd)
 	rootCmd.AddCommand(whyCmd)
 	rootCmd.AddCommand(prCmd)
@@ -40,8 +48,12 @@ func init() {
 	rootCmd.AddCommand(watchCmd)
 	rootCmd.AddCommand(policyCmd)
 	rootCmd.AddCommand(sbomCmd)
+	rootCmd.AddCommand(licensesCmd)
 	rootCmd.AddCommand(toolsCmd)
 	rootCmd.AddCommand(ciCmd)
+	rootCmd.AddCommand(dbCmd)
+	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(baselineCmd)
 
 	// Override version template to include build info
 	rootCmd.SetVersionTemplate(fmt.Sprintf(`{{with .Name}}{{printf "%%s " .}}{{end}}{{printf "version %%s" .Version}}
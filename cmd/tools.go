@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/dso-cli/dso-cli/internal/scanner"
 	"github.com/dso-cli/dso-cli/internal/tools"
 	"github.com/spf13/cobra"
 )
@@ -73,6 +74,13 @@ Useful to verify that all scanners are available.`,
 			fmt.Println()
 		}
 
+		// Registered scanners
+		fmt.Println("🧩 Registered scanners:")
+		for _, s := range scanner.Registered() {
+			fmt.Printf("   • %s [%s]\n", s.Name(), s.Category())
+		}
+		fmt.Println()
+
 		// Installation prompt
 		if len(missing) > 0 {
 			if toolsInstall {
@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"time"
 
 	"github.com/dso-cli/dso-cli/internal/scanner"
@@ -13,6 +15,8 @@ import (
 var (
 	watchInterval time.Duration
 	watchQuiet    bool
+	watchJobs     int
+	watchNoCache  bool
 )
 
 var watchCmd = &cobra.Command{
@@ -38,6 +42,10 @@ and only notifies about newly detected security issues.`,
 		fmt.Println("💡 Press Ctrl+C to stop")
 		fmt.Println()
 
+		ctx := cmd.Context()
+		// Unchanged files are not rescanned between intervals
+		scanCache := openScanCache(absPath, watchNoCache, os.Stderr)
+
 		// First scan
 		lastResults := &scanner.ScanResults{}
 		firstScan := true
@@ -47,12 +55,19 @@ and only notifies about newly detected security issues.`,
 				fmt.Printf("\n[%s] 🔍 Scanning...\n", time.Now().Format("15:04:05"))
 			}
 
-			results, err := scanner.RunFullScan(absPath)
+			results, err := scanner.Run(ctx, absPath, scanner.Options{Jobs: watchJobs, Cache: scanCache})
+			if ctx.Err() != nil {
+				fmt.Println("\n👋 Watch stopped")
+				return
+			}
 			if err != nil {
 				if !watchQuiet {
 					fmt.Fprintf(os.Stderr, "❌ Error during scan: %v\n", err)
 				}
-				time.Sleep(watchInterval)
+				if !sleepContext(ctx, watchInterval) {
+					fmt.Println("\n👋 Watch stopped")
+					return
+				}
 				continue
 			}
 
@@ -76,7 +91,10 @@ and only notifies about newly detected security issues.`,
 			}
 
 			lastResults = results
-			time.Sleep(watchInterval)
+			if !sleepContext(ctx, watchInterval) {
+				fmt.Println("\n👋 Watch stopped")
+				return
+			}
 		}
 	},
 }
@@ -84,9 +102,23 @@ and only notifies about newly detected security issues.`,
 func init() {
 	watchCmd.Flags().DurationVarP(&watchInterval, "interval", "i", 5*time.Minute, "Interval between scans")
 	watchCmd.Flags().BoolVarP(&watchQuiet, "quiet", "q", false, "Quiet mode (only shows new issues)")
+	watchCmd.Flags().IntVarP(&watchJobs, "jobs", "j", runtime.NumCPU(), "Maximum number of scanners running in parallel")
+	watchCmd.Flags().BoolVar(&watchNoCache, "no-cache", false, "Rescan every file instead of reusing cached results")
 	// Command already added in root.go
 }
 
+// sleepContext waits for d and reports whether ctx is still active
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
 // findNewFindings finds findings that weren't in the previous scan
 func findNewFindings(old, current *scanner.ScanResults) []scanner.Finding {
 	newFindings := []scanner.Finding{}
@@ -99,7 +131,7 @@ func findNewFindings(old, current *scanner.ScanResults) []scanner.Finding {
 	}
 
 	// Find new ones
-	for _, f := range current.Findings {
+	for _, f := range current.Active() {
 		key := fmt.Sprintf("%s:%s:%d", f.ID, f.File, f.Line)
 		if !oldMap[key] {
 			newFindings = append(newFindings, f)
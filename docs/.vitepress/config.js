@@ -55,6 +55,7 @@ export default defineConfig({
           items: [
             { text: 'Overview', link: '/commands/' },
             { text: 'audit', link: '/commands/audit' },
+            { text: 'image', link: '/commands/image' },
             { text: 'check', link: '/commands/check' },
             { text: 'fix', link: '/commands/fix' },
             { text: 'why', link: '/commands/why' },
@@ -63,7 +64,11 @@ export default defineConfig({
             { text: 'watch', link: '/commands/watch' },
             { text: 'policy', link: '/commands/policy' },
             { text: 'sbom', link: '/commands/sbom' },
-            { text: 'ci', link: '/commands/ci' }
+            { text: 'licenses', link: '/commands/licenses' },
+            { text: 'ci', link: '/commands/ci' },
+            { text: 'db', link: '/commands/db' },
+            { text: 'cache', link: '/commands/cache' },
+            { text: 'baseline', link: '/commands/baseline' }
           ]
         }
       ]
@@ -0,0 +1,345 @@
+// Package cache stores scan results under .dso/cache in the scanned
+// directory, so that unchanged files are not scanned again.
+//
+// Results are stored per tool and keyed by file content hashes. Each tool
+// cache records the tool version and rule set it was filled with, and is
+// invalidated as a whole when they change.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dir is the cache directory, relative to the scanned directory
+const Dir = ".dso/cache"
+
+// DefaultMaxUnused is the age after which Prune removes unused entries
+const DefaultMaxUnused = 7 * 24 * time.Hour
+
+const indexFile = "files.json"
+
+// Cache is the result cache of a scanned directory. It is safe for
+// concurrent use; each Tool must only be used by one goroutine.
+type Cache struct {
+	root string
+	dir  string
+
+	mu    sync.Mutex
+	index map[string]fileEntry // by slash-separated path relative to root
+	dirty bool
+	tools []*Tool
+}
+
+// fileEntry is the content hash of a file, valid while its size and
+// modification time are unchanged
+type fileEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	Hash    string    `json:"sha256"`
+}
+
+// Open opens the cache of root, creating it if needed
+func Open(root string) (*Cache, error) {
+	c := &Cache{
+		root:  root,
+		dir:   filepath.Join(root, filepath.FromSlash(Dir)),
+		index: make(map[string]fileEntry),
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return nil, fmt.Errorf("cannot create cache: %w", err)
+	}
+	// The cache is local to each checkout
+	ignore := filepath.Join(c.dir, ".gitignore")
+	if _, err := os.Stat(ignore); errors.Is(err, fs.ErrNotExist) {
+		os.WriteFile(ignore, []byte("*\n"), 0644)
+	}
+
+	// A corrupted index only costs a rehash
+	if data, err := os.ReadFile(filepath.Join(c.dir, indexFile)); err == nil {
+		json.Unmarshal(data, &c.index)
+	}
+	return c, nil
+}
+
+// Path returns the cache directory
+func (c *Cache) Path() string {
+	return c.dir
+}
+
+// FileHash returns the SHA-256 of a file, rehashing it only when its size
+// or modification time changed since the last call
+func (c *Cache) FileHash(path, rel string, info fs.FileInfo) (string, error) {
+	c.mu.Lock()
+	entry, ok := c.index[rel]
+	c.mu.Unlock()
+	if ok && entry.Size == info.Size() && entry.ModTime.Equal(info.ModTime()) {
+		return entry.Hash, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+
+	c.mu.Lock()
+	c.index[rel] = fileEntry{Size: info.Size(), ModTime: info.ModTime(), Hash: hash}
+	c.dirty = true
+	c.mu.Unlock()
+	return hash, nil
+}
+
+// Tool returns the cache of a tool. key identifies the tool version and
+// rule set: when it differs from the stored one, the tool cache is emptied.
+// Entries older than maxAge are ignored; zero means they never expire.
+func (c *Cache) Tool(name, key string, maxAge time.Duration) *Tool {
+	t := &Tool{
+		path:   filepath.Join(c.dir, url.PathEscape(name)+".json"),
+		maxAge: maxAge,
+		file:   toolFile{Key: key, Entries: make(map[string]*entry)},
+	}
+
+	var stored toolFile
+	if data, err := os.ReadFile(t.path); err == nil && json.Unmarshal(data, &stored) == nil && stored.Key == key {
+		if stored.Entries != nil {
+			t.file.Entries = stored.Entries
+		}
+	} else if err == nil {
+		// Upgraded tool or rule set: previous results are obsolete
+		t.dirty = true
+	}
+
+	c.mu.Lock()
+	c.tools = append(c.tools, t)
+	c.mu.Unlock()
+	return t
+}
+
+// Save writes the file index and the modified tool caches
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []error
+	if c.dirty {
+		if err := writeJSON(filepath.Join(c.dir, indexFile), c.index); err != nil {
+			errs = append(errs, err)
+		} else {
+			c.dirty = false
+		}
+	}
+	for _, t := range c.tools {
+		if err := t.save(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	c.tools = nil
+	return errors.Join(errs...)
+}
+
+// PruneStats reports what Prune removed
+type PruneStats struct {
+	Tools   int // Tool caches of uninstalled or upgraded tools
+	Entries int // Unused or expired results
+	Files   int // Hashes of deleted files
+}
+
+// Prune removes the tool caches whose key is not in current (the key of
+// each installed tool), the entries unused for maxUnused and the hashes of
+// files that no longer exist
+func (c *Cache) Prune(current map[string]string, maxUnused time.Duration) (PruneStats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var stats PruneStats
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		return stats, err
+	}
+
+	now := time.Now()
+	for _, file := range files {
+		name, isTool := strings.CutSuffix(file.Name(), ".json")
+		if !isTool || file.Name() == indexFile {
+			continue
+		}
+		path := filepath.Join(c.dir, file.Name())
+		tool, _ := url.PathUnescape(name)
+
+		var t toolFile
+		data, err := os.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal(data, &t)
+		}
+		if key, ok := current[tool]; err != nil || !ok || key != t.Key {
+			if err := os.Remove(path); err != nil {
+				return stats, err
+			}
+			stats.Tools++
+			continue
+		}
+
+		removed := 0
+		for hash, e := range t.Entries {
+			if now.Sub(e.Used) > maxUnused {
+				delete(t.Entries, hash)
+				removed++
+			}
+		}
+		if removed > 0 {
+			if err := writeJSON(path, &t); err != nil {
+				return stats, err
+			}
+			stats.Entries += removed
+		}
+	}
+
+	for rel := range c.index {
+		if _, err := os.Stat(filepath.Join(c.root, filepath.FromSlash(rel))); errors.Is(err, fs.ErrNotExist) {
+			delete(c.index, rel)
+			stats.Files++
+		}
+	}
+	if stats.Files > 0 {
+		if err := writeJSON(filepath.Join(c.dir, indexFile), c.index); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// Clear removes the whole cache
+func (c *Cache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.index = make(map[string]fileEntry)
+	c.tools = nil
+	return os.RemoveAll(c.dir)
+}
+
+// Tool is the result cache of a single tool
+type Tool struct {
+	file   toolFile
+	path   string
+	maxAge time.Duration
+	dirty  bool
+}
+
+// toolFile is the stored cache of a tool
+type toolFile struct {
+	Key     string            `json:"key"`
+	Entries map[string]*entry `json:"entries"`
+}
+
+// entry is a cached result
+type entry struct {
+	Created time.Time       `json:"created"`
+	Used    time.Time       `json:"used"`
+	Value   json.RawMessage `json:"value"`
+}
+
+// Get decodes the result stored under key into v and reports whether it was found
+func (t *Tool) Get(key string, v interface{}) bool {
+	e, ok := t.file.Entries[key]
+	if !ok {
+		return false
+	}
+	now := time.Now()
+	if t.maxAge > 0 && now.Sub(e.Created) > t.maxAge {
+		return false
+	}
+	if err := json.Unmarshal(e.Value, v); err != nil {
+		return false
+	}
+	e.Used = now
+	t.dirty = true
+	return true
+}
+
+// Put stores the result of key
+func (t *Tool) Put(key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	t.file.Entries[key] = &entry{Created: now, Used: now, Value: data}
+	t.dirty = true
+	return nil
+}
+
+func (t *Tool) save() error {
+	if !t.dirty {
+		return nil
+	}
+	if err := writeJSON(t.path, &t.file); err != nil {
+		return err
+	}
+	t.dirty = false
+	return nil
+}
+
+// Key returns a cache key derived from parts
+func Key(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// TreeHash returns a hash of a set of files, given as relative path to content hash
+func TreeHash(files map[string]string) string {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	parts := make([]string, 0, 2*len(paths))
+	for _, path := range paths {
+		parts = append(parts, path, files[path])
+	}
+	return Key(parts...)
+}
+
+// writeJSON atomically replaces path with the JSON encoding of v
+func writeJSON(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
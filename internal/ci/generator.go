@@ -19,53 +19,68 @@ on:
     - cron: '0 9 * * 1'
   workflow_dispatch:
 
+# Least privilege for the GITHUB_TOKEN, jobs request what they need
+permissions:
+  contents: read
+
 jobs:
   security-audit:
     runs-on: ubuntu-latest
     name: Security Audit with DSO
-    
+    permissions:
+      contents: read
+      pull-requests: write # Comment the results on the pull request
+
+    services:
+      ollama:
+        image: ollama/ollama:0.5.7
+        ports:
+          - 11434:11434
+
+    # Actions are pinned to the commit SHA of their release
     steps:
     - name: Checkout code
-      uses: actions/checkout@v4
-      
+      uses: actions/checkout@11bd71901bbe5b1630ceea73d27597364c9af683 # v4.2.2
+      with:
+        persist-credentials: false
+
     - name: Set up Go
-      uses: actions/setup-go@v4
+      uses: actions/setup-go@0a12ed9d6a96ab950c8f026ed9f722fe0da7ef32 # v5.0.2
       with:
-        go-version: '1.21'
-        
+        go-version: '1.24'
+
     - name: Install DSO
       run: |
         go install github.com/dso-cli/dso-cli@latest
-        
-    - name: Install Ollama
-      run: |
-        curl -fsSL https://ollama.ai/install.sh | sh
-        
+
     - name: Pull Ollama model
       run: |
-        ollama pull llama3.1:8b
-        
+        curl -fsS http://localhost:11434/api/pull -d '{"name": "llama3.1:8b", "stream": false}'
+
     - name: Install security tools
+      env:
+        TRIVY_VERSION: 0.58.1
+        GITLEAKS_VERSION: 8.21.2
       run: |
-        sudo apt-get update
-        sudo apt-get install -y wget
-        # Install Trivy
-        wget -qO - https://aquasecurity.github.io/trivy-repo/deb/public.key | sudo apt-key add -
-        echo "deb https://aquasecurity.github.io/trivy-repo/deb $(lsb_release -sc) main" | sudo tee -a /etc/apt/sources.list.d/trivy.list
-        sudo apt-get update
-        sudo apt-get install -y trivy
-        # Install gitleaks
-        wget https://github.com/gitleaks/gitleaks/releases/latest/download/gitleaks-linux-amd64 -O /tmp/gitleaks
-        chmod +x /tmp/gitleaks
-        sudo mv /tmp/gitleaks /usr/local/bin/gitleaks
-        
+        # Install pinned releases of Trivy and gitleaks, verified with their published checksums
+        cd "$(mktemp -d)"
+        curl -fsSLO "https://github.com/aquasecurity/trivy/releases/download/v${TRIVY_VERSION}/trivy_${TRIVY_VERSION}_Linux-64bit.tar.gz"
+        curl -fsSLO "https://github.com/aquasecurity/trivy/releases/download/v${TRIVY_VERSION}/trivy_${TRIVY_VERSION}_checksums.txt"
+        grep " trivy_${TRIVY_VERSION}_Linux-64bit.tar.gz$" "trivy_${TRIVY_VERSION}_checksums.txt" | sha256sum -c -
+        curl -fsSLO "https://github.com/gitleaks/gitleaks/releases/download/v${GITLEAKS_VERSION}/gitleaks_${GITLEAKS_VERSION}_linux_x64.tar.gz"
+        curl -fsSLO "https://github.com/gitleaks/gitleaks/releases/download/v${GITLEAKS_VERSION}/gitleaks_${GITLEAKS_VERSION}_checksums.txt"
+        grep " gitleaks_${GITLEAKS_VERSION}_linux_x64.tar.gz$" "gitleaks_${GITLEAKS_VERSION}_checksums.txt" | sha256sum -c -
+        tar -xzf "trivy_${TRIVY_VERSION}_Linux-64bit.tar.gz" trivy
+        tar -xzf "gitleaks_${GITLEAKS_VERSION}_linux_x64.tar.gz" gitleaks
+        sudo install trivy gitleaks /usr/local/bin/
+
     - name: Run DSO audit
       run: |
         export PATH=$PATH:$(go env GOPATH)/bin
         dso audit . --format json > dso-results.json || true
-        
+
     - name: Upload results
-      uses: actions/upload-artifact@v3
+      uses: actions/upload-artifact@b4b15b8c7c6ac21ea08fcf65892d2ee8f75cf882 # v4.4.3
       if: always()
       with:
         name: dso-results
@@ -73,7 +88,7 @@ jobs:
         
     - name: Comment PR with results
       if: github.event_name == 'pull_request'
-      uses: actions/github-script@v6
+      uses: actions/github-script@60a0d83039c74a4aee543508d2ffcb1c3799cdea # v7.0.1
       with:
         script: |
           const fs = require('fs');
@@ -125,22 +140,32 @@ func GenerateGitLabCI(projectPath string, customOutput string) (string, string,
 
 dso-audit:
   stage: security
-  image: golang:1.21
+  image: golang:1.24
+  services:
+    - name: ollama/ollama:0.5.7
+      alias: ollama
+  variables:
+    OLLAMA_HOST: http://ollama:11434
+    TRIVY_VERSION: 0.58.1
+    GITLEAKS_VERSION: 8.21.2
   before_script:
-    - apt-get update && apt-get install -y wget curl
-    # Install Ollama
-    - curl -fsSL https://ollama.ai/install.sh | sh
-    - ollama serve &
-    - sleep 5
-    - ollama pull llama3.1:8b
-    # Install Trivy
-    - wget -qO - https://aquasecurity.github.io/trivy-repo/deb/public.key | apt-key add -
-    - echo "deb https://aquasecurity.github.io/trivy-repo/deb $(lsb_release -sc) main" | tee -a /etc/apt/sources.list.d/trivy.list
-    - apt-get update
-    - apt-get install -y trivy
-    # Install gitleaks
-    - wget https://github.com/gitleaks/gitleaks/releases/latest/download/gitleaks-linux-amd64 -O /usr/local/bin/gitleaks
-    - chmod +x /usr/local/bin/gitleaks
+    - apt-get update && apt-get install -y jq
+    # Pull the model in the Ollama service
+    - |
+      curl -fsS "$OLLAMA_HOST/api/pull" -d '{"name": "llama3.1:8b", "stream": false}'
+    # Install pinned releases of Trivy and gitleaks, verified with their published checksums
+    - |
+      cd "$(mktemp -d)"
+      curl -fsSLO "https://github.com/aquasecurity/trivy/releases/download/v${TRIVY_VERSION}/trivy_${TRIVY_VERSION}_Linux-64bit.tar.gz"
+      curl -fsSLO "https://github.com/aquasecurity/trivy/releases/download/v${TRIVY_VERSION}/trivy_${TRIVY_VERSION}_checksums.txt"
+      grep " trivy_${TRIVY_VERSION}_Linux-64bit.tar.gz$" "trivy_${TRIVY_VERSION}_checksums.txt" | sha256sum -c -
+      curl -fsSLO "https://github.com/gitleaks/gitleaks/releases/download/v${GITLEAKS_VERSION}/gitleaks_${GITLEAKS_VERSION}_linux_x64.tar.gz"
+      curl -fsSLO "https://github.com/gitleaks/gitleaks/releases/download/v${GITLEAKS_VERSION}/gitleaks_${GITLEAKS_VERSION}_checksums.txt"
+      grep " gitleaks_${GITLEAKS_VERSION}_linux_x64.tar.gz$" "gitleaks_${GITLEAKS_VERSION}_checksums.txt" | sha256sum -c -
+      tar -xzf "trivy_${TRIVY_VERSION}_Linux-64bit.tar.gz" trivy
+      tar -xzf "gitleaks_${GITLEAKS_VERSION}_linux_x64.tar.gz" gitleaks
+      install trivy gitleaks /usr/local/bin/
+      cd "$CI_PROJECT_DIR"
     # Install DSO
     - go install github.com/dso-cli/dso-cli@latest
     - export PATH=$PATH:$(go env GOPATH)/bin
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 const (
@@ -13,6 +14,9 @@ const (
 	configFileName = "config"
 )
 
+// DefaultToolTimeout is the timeout applied to an external scanner when none is configured
+const DefaultToolTimeout = 10 * time.Minute
+
 // GetConfigDir returns the DSO configuration directory
 func GetConfigDir() (string, error) {
 	homeDir, err := os.UserHomeDir()
@@ -100,12 +104,22 @@ func GetAllConfig() (map[string]string, error) {
 		return config, nil
 	}
 
-	data, err := os.ReadFile(configFile)
+	for key, value := range readConfigFile(configFile) {
+		config[key] = value
+	}
+
+	return config, nil
+}
+
+// readConfigFile reads a key=value config file, returning nil if it
+// does not exist
+func readConfigFile(path string) map[string]string {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return config, nil
+		return nil
 	}
 
-	// Parse config file
+	config := make(map[string]string)
 	scanner := bufio.NewScanner(strings.NewReader(string(data)))
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -119,6 +133,77 @@ func GetAllConfig() (map[string]string, error) {
 			}
 		}
 	}
+	return config
+}
 
-	return config, nil
+// GetToolTimeout returns the timeout for an external scanner.
+// DSO_TIMEOUT_<TOOL> (e.g. DSO_TIMEOUT_TRIVY=15m) takes precedence over
+// DSO_TIMEOUT, and environment variables take precedence over the config file.
+func GetToolTimeout(tool string) time.Duration {
+	toolKey := "DSO_TIMEOUT_" + strings.ToUpper(strings.ReplaceAll(tool, "-", "_"))
+
+	var fileConfig map[string]string
+	for _, key := range []string{toolKey, "DSO_TIMEOUT"} {
+		value := os.Getenv(key)
+		if value == "" {
+			if fileConfig == nil {
+				fileConfig, _ = GetAllConfig()
+			}
+			value = fileConfig[key]
+		}
+		if value == "" {
+			continue
+		}
+		if timeout, err := time.ParseDuration(value); err == nil && timeout > 0 {
+			return timeout
+		}
+	}
+
+	return DefaultToolTimeout
+}
+
+// GetIgnorePatterns returns the gitignore patterns of DSO_IGNORE, a
+// comma-separated list (e.g. DSO_IGNORE=testdata/,*.min.js). The
+// environment variable takes precedence over the config file.
+func GetIgnorePatterns() []string {
+	value := os.Getenv("DSO_IGNORE")
+	if value == "" {
+		fileConfig, _ := GetAllConfig()
+		value = fileConfig["DSO_IGNORE"]
+	}
+
+	return splitList(value)
+}
+
+// ProjectConfigFile returns the path of the config file of the project at
+// dir, which holds the settings shared by everyone working on the project
+func ProjectConfigFile(dir string) string {
+	return filepath.Join(dir, configDirName, configFileName)
+}
+
+// GetProjectList returns the comma-separated list key for the project at
+// dir. The environment variable takes precedence over the project config
+// file, which takes precedence over the user config file. ok is false when
+// the list is not set anywhere; a list set to "" is set and empty.
+func GetProjectList(dir, key string) (list []string, ok bool) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		value, ok = readConfigFile(ProjectConfigFile(dir))[key]
+	}
+	if !ok {
+		fileConfig, _ := GetAllConfig()
+		value, ok = fileConfig[key]
+	}
+	return splitList(value), ok
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	var list []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
 }
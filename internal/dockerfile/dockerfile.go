@@ -0,0 +1,418 @@
+// Package dockerfile parses Dockerfiles into instructions that keep their
+// line numbers, for the native scanners. It supports parser directives,
+// line continuations, comments inside continuations, the exec (JSON) form
+// of the arguments and heredocs. Variables are not expanded while parsing;
+// see Expand.
+package dockerfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Commands are the instructions of the Dockerfile reference
+var Commands = map[string]bool{
+	"ADD": true, "ARG": true, "CMD": true, "COPY": true, "ENTRYPOINT": true, "ENV": true,
+	"EXPOSE": true, "FROM": true, "HEALTHCHECK": true, "LABEL": true, "MAINTAINER": true,
+	"ONBUILD": true, "RUN": true, "SHELL": true, "STOPSIGNAL": true, "USER": true,
+	"VOLUME": true, "WORKDIR": true,
+}
+
+// flagCommands are the instructions that take --name=value options
+var flagCommands = map[string]bool{"ADD": true, "COPY": true, "FROM": true, "HEALTHCHECK": true, "RUN": true}
+
+// jsonCommands are the instructions whose arguments can be a JSON array
+var jsonCommands = map[string]bool{
+	"ADD": true, "CMD": true, "COPY": true, "ENTRYPOINT": true, "RUN": true, "SHELL": true, "VOLUME": true,
+}
+
+// heredocCommands are the instructions that accept heredocs
+var heredocCommands = map[string]bool{"ADD": true, "COPY": true, "RUN": true}
+
+var (
+	directiveRegex = regexp.MustCompile(`^#\s*([a-zA-Z][a-zA-Z0-9]*)\s*=\s*(.+?)\s*$`)
+	heredocRegex   = regexp.MustCompile(`<<(-?)(["']?)([A-Za-z_][A-Za-z0-9_]*)(["']?)`)
+)
+
+// Instruction is an instruction of a Dockerfile
+type Instruction struct {
+	// Command is the instruction keyword, in upper case
+	Command string
+	// Flags are the --name=value options before the arguments
+	Flags []string
+	// Value is the text after the command and its flags, with the line
+	// continuations removed
+	Value string
+	// Args are the words of Value, unquoted, or the items of the exec form
+	Args []string
+	// JSON is true for the exec form: ["executable", "param"]
+	JSON bool
+	// Heredocs are the heredocs of the instruction, in order
+	Heredocs []Heredoc
+	// Line and EndLine are the first and last lines of the instruction,
+	// heredocs included, 1-based
+	Line    int
+	EndLine int
+	// Original is the source of the instruction, from Line to EndLine
+	Original string
+}
+
+// Heredoc is the content of a heredoc (<<EOF)
+type Heredoc struct {
+	Name string
+	Body string
+}
+
+// Flag returns the value of the --name option, and whether it is set
+func (in *Instruction) Flag(name string) (string, bool) {
+	for _, flag := range in.Flags {
+		key, value, hasValue := strings.Cut(strings.TrimPrefix(flag, "--"), "=")
+		if key != name {
+			continue
+		}
+		if !hasValue {
+			return "true", true
+		}
+		return unquote(value), true
+	}
+	return "", false
+}
+
+// Stage is a build stage: a FROM instruction and the instructions that
+// follow it
+type Stage struct {
+	// Name is the name given with FROM ... AS name, in lower case
+	Name string
+	// Image is the base image, as written (variables are not expanded)
+	Image string
+	From  *Instruction
+	// Instructions are the instructions of the stage, after FROM
+	Instructions []*Instruction
+}
+
+// Dockerfile is a parsed Dockerfile
+type Dockerfile struct {
+	// Directives are the parser directives (# syntax=..., # escape=...),
+	// with lower-case names
+	Directives map[string]string
+	// Escape is the escape character, \ by default
+	Escape byte
+	// Args are the ARG instructions before the first FROM
+	Args   []*Instruction
+	Stages []*Stage
+	// Instructions are all the instructions, in order
+	Instructions []*Instruction
+}
+
+// Stage returns the stage named name, or nil
+func (d *Dockerfile) Stage(name string) *Stage {
+	for _, s := range d.Stages {
+		if s.Name != "" && s.Name == strings.ToLower(name) {
+			return s
+		}
+	}
+	return nil
+}
+
+// GlobalArgs returns the default values of the ARG instructions before the
+// first FROM, which are the variables available to FROM
+func (d *Dockerfile) GlobalArgs() map[string]string {
+	vars := make(map[string]string)
+	for _, arg := range d.Args {
+		for _, word := range arg.Args {
+			name, value, _ := strings.Cut(word, "=")
+			vars[name] = value
+		}
+	}
+	return vars
+}
+
+// Parse parses a Dockerfile. An unknown instruction, or a Dockerfile
+// without FROM, is an error.
+func Parse(data []byte) (*Dockerfile, error) {
+	lines := strings.Split(string(data), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], "\r")
+	}
+	d := &Dockerfile{Directives: make(map[string]string), Escape: '\\'}
+
+	i := 0
+	for ; i < len(lines); i++ {
+		m := directiveRegex.FindStringSubmatch(lines[i])
+		if m == nil {
+			break
+		}
+		name := strings.ToLower(m[1])
+		d.Directives[name] = m[2]
+		if name == "escape" && (m[2] == "`" || m[2] == "\\") {
+			d.Escape = m[2][0]
+		}
+	}
+
+	var stage *Stage
+	for i < len(lines) {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			i++
+			continue
+		}
+
+		start := i
+		var text strings.Builder
+		for {
+			line := lines[i]
+			if text.Len() == 0 {
+				line = strings.TrimLeft(line, " \t")
+			}
+			body, continued := d.continuation(line)
+			text.WriteString(body)
+			if !continued {
+				break
+			}
+			// Comment and empty lines inside a continuation are skipped
+			for i+1 < len(lines) {
+				next := strings.TrimSpace(lines[i+1])
+				if next != "" && !strings.HasPrefix(next, "#") {
+					break
+				}
+				i++
+			}
+			if i+1 >= len(lines) {
+				break
+			}
+			i++
+		}
+
+		in, err := d.instruction(text.String(), start+1)
+		if err != nil {
+			return nil, err
+		}
+		// The heredocs start on the line after the instruction
+		for _, m := range heredocMatches(in) {
+			strip, name := m[1] == "-", m[3]
+			var body []string
+			for i++; i < len(lines); i++ {
+				line := lines[i]
+				if strip {
+					line = strings.TrimLeft(line, "\t")
+				}
+				if line == name {
+					break
+				}
+				body = append(body, line)
+			}
+			if i >= len(lines) {
+				return nil, fmt.Errorf("line %d: unterminated heredoc %s", in.Line, name)
+			}
+			in.Heredocs = append(in.Heredocs, Heredoc{Name: name, Body: strings.Join(body, "\n")})
+		}
+		in.EndLine = min(i, len(lines)-1) + 1
+		in.Original = strings.Join(lines[start:in.EndLine], "\n")
+		i++
+
+		d.Instructions = append(d.Instructions, in)
+		switch {
+		case in.Command == "FROM":
+			stage = &Stage{From: in}
+			if len(in.Args) > 0 {
+				stage.Image = in.Args[0]
+			}
+			if len(in.Args) == 3 && strings.EqualFold(in.Args[1], "AS") {
+				stage.Name = strings.ToLower(in.Args[2])
+			}
+			d.Stages = append(d.Stages, stage)
+		case stage != nil:
+			stage.Instructions = append(stage.Instructions, in)
+		case in.Command == "ARG":
+			d.Args = append(d.Args, in)
+		default:
+			return nil, fmt.Errorf("line %d: %s before FROM", in.Line, in.Command)
+		}
+	}
+	if len(d.Stages) == 0 {
+		return nil, fmt.Errorf("no FROM instruction")
+	}
+	return d, nil
+}
+
+// continuation removes the escape character ending a continued line
+func (d *Dockerfile) continuation(line string) (string, bool) {
+	trimmed := strings.TrimRight(line, " \t")
+	if strings.HasSuffix(trimmed, string(d.Escape)) {
+		return trimmed[:len(trimmed)-1], true
+	}
+	return line, false
+}
+
+// instruction parses the text of an instruction, its continuations joined
+func (d *Dockerfile) instruction(text string, line int) (*Instruction, error) {
+	keyword, rest, _ := strings.Cut(text, " ")
+	if tab := strings.IndexByte(keyword, '\t'); tab >= 0 {
+		keyword, rest = keyword[:tab], keyword[tab+1:]+" "+rest
+	}
+	in := &Instruction{Command: strings.ToUpper(keyword), Line: line}
+	if !Commands[in.Command] {
+		return nil, fmt.Errorf("line %d: unknown instruction %s", line, keyword)
+	}
+
+	rest = strings.TrimSpace(rest)
+	if flagCommands[in.Command] {
+		for strings.HasPrefix(rest, "--") {
+			flag, after, _ := strings.Cut(rest, " ")
+			in.Flags = append(in.Flags, flag)
+			rest = strings.TrimSpace(after)
+		}
+	}
+	in.Value = rest
+
+	if jsonCommands[in.Command] && strings.HasPrefix(rest, "[") {
+		var items []string
+		if err := json.Unmarshal([]byte(rest), &items); err == nil {
+			in.Args, in.JSON = items, true
+			return in, nil
+		}
+	}
+	in.Args = Split(rest, d.Escape)
+	return in, nil
+}
+
+// heredocMatches returns the heredoc markers of an instruction
+func heredocMatches(in *Instruction) [][]string {
+	if !heredocCommands[in.Command] || in.JSON {
+		return nil
+	}
+	var markers [][]string
+	for _, m := range heredocRegex.FindAllStringSubmatch(in.Value, -1) {
+		if m[2] == m[4] {
+			markers = append(markers, m)
+		}
+	}
+	return markers
+}
+
+// Split splits s into words at unquoted blanks, removing the quotes and
+// the escape characters
+func Split(s string, escape byte) []string {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == escape && quote != '\'' && i+1 < len(s):
+			i++
+			word.WriteByte(s[i])
+			inWord = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				word.WriteByte(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words
+}
+
+// unquote removes the quotes around a flag value
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// Expand expands the $name and ${name} variables of s with vars,
+// including the ${name:-default} and ${name:+alternative} forms. It
+// reports whether every variable was set.
+func Expand(s string, vars map[string]string) (string, bool) {
+	var out strings.Builder
+	complete := true
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' && i+1 < len(s) && s[i+1] == '$' {
+			out.WriteByte('$')
+			i++
+			continue
+		}
+		if c != '$' || i+1 >= len(s) {
+			out.WriteByte(c)
+			continue
+		}
+
+		if s[i+1] == '{' {
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				out.WriteString(s[i:])
+				return out.String(), false
+			}
+			expr := s[i+2 : i+end]
+			i += end
+			name, op, word := expr, "", ""
+			if j := strings.IndexAny(expr, ":-+"); j > 0 {
+				name, op = expr[:j], expr[j:]
+				if strings.HasPrefix(op, ":") && len(op) > 1 {
+					op, word = op[:2], op[2:]
+				} else {
+					op, word = op[:1], op[1:]
+				}
+			}
+			value, set := vars[name]
+			switch op {
+			case ":-":
+				if value == "" {
+					value, set = word, true
+				}
+			case "-":
+				if !set {
+					value, set = word, true
+				}
+			case ":+":
+				if value != "" {
+					value = word
+				}
+				set = true
+			case "+":
+				if set {
+					value = word
+				}
+				set = true
+			}
+			complete = complete && set
+			out.WriteString(value)
+			continue
+		}
+
+		j := i + 1
+		for j < len(s) && (s[j] == '_' || s[j] >= 'a' && s[j] <= 'z' || s[j] >= 'A' && s[j] <= 'Z' || j > i+1 && s[j] >= '0' && s[j] <= '9') {
+			j++
+		}
+		if j == i+1 {
+			out.WriteByte(c)
+			continue
+		}
+		value, set := vars[s[i+1:j]]
+		complete = complete && set
+		out.WriteString(value)
+		i = j - 1
+	}
+	return out.String(), complete
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/dso-cli/dso-cli/internal/scanner"
@@ -12,15 +13,17 @@ import (
 
 // AutoFix applies automatic fixes
 func AutoFix(results *scanner.ScanResults, projectPath string, auto bool) ([]string, error) {
-	var appliedFixes []string
+	// Suggested replacements are applied first; the lines of the files
+	// they patch move, so the line-based fixes skip these files
+	appliedFixes, patched := fixReplacements(results.Active(), projectPath, auto)
 
-	for _, finding := range results.Findings {
+	for _, finding := range results.Active() {
 		if !finding.Fixable {
 			continue
 		}
 
 		// Fixes for secrets
-		if finding.Type == "SECRET" {
+		if finding.Type == "SECRET" && !patched[finding.File] {
 			if fix, err := fixSecret(finding, projectPath, auto); err == nil && fix != "" {
 				appliedFixes = append(appliedFixes, fix)
 			}
@@ -37,6 +40,96 @@ func AutoFix(results *scanner.ScanResults, projectPath string, auto bool) ([]str
 	return appliedFixes, nil
 }
 
+// fixReplacements applies the replacements suggested by the findings,
+// from the bottom of each file up so that the lines of the next ones do
+// not move. A replacement that overlaps one already applied is skipped,
+// and is suggested again by the next scan. It returns the applied fixes
+// and the patched files.
+func fixReplacements(findings []scanner.Finding, projectPath string, auto bool) ([]string, map[string]bool) {
+	byFile := make(map[string][]scanner.Finding)
+	var files []string
+	for _, f := range findings {
+		if !f.Fixable || f.Replacement == nil || f.File == "" {
+			continue
+		}
+		if _, ok := byFile[f.File]; !ok {
+			files = append(files, f.File)
+		}
+		byFile[f.File] = append(byFile[f.File], f)
+	}
+	sort.Strings(files)
+
+	var applied []string
+	patched := make(map[string]bool)
+	for _, file := range files {
+		filePath := filepath.Join(projectPath, file)
+		info, err := os.Stat(filePath)
+		if err != nil {
+			continue
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			continue
+		}
+		lines := strings.Split(string(content), "\n")
+
+		fileFindings := byFile[file]
+		sort.SliceStable(fileFindings, func(i, j int) bool {
+			return fileFindings[i].Replacement.Line > fileFindings[j].Replacement.Line
+		})
+		limit := len(lines) + 1
+		var fileFixes []string
+		for _, finding := range fileFindings {
+			r := finding.Replacement
+			if r.Line < 1 || r.EndLine < r.Line || r.EndLine >= limit || r.EndLine > len(lines) {
+				continue
+			}
+
+			if !auto {
+				fmt.Printf("🔧 %s in %s:%d\n", finding.Title, finding.File, r.Line)
+				for _, line := range lines[r.Line-1 : r.EndLine] {
+					fmt.Printf("   - %s\n", line)
+				}
+				if r.Text == "" {
+					fmt.Println("   (lines removed)")
+				} else {
+					for _, line := range strings.Split(r.Text, "\n") {
+						fmt.Printf("   + %s\n", line)
+					}
+				}
+				fmt.Print("   Apply this fix? (y/N): ")
+				var response string
+				if _, err := fmt.Scanln(&response); err != nil {
+					continue
+				}
+				if !strings.EqualFold(response, "y") && !strings.EqualFold(response, "yes") {
+					continue
+				}
+			}
+
+			newLines := make([]string, 0, len(lines))
+			newLines = append(newLines, lines[:r.Line-1]...)
+			if r.Text != "" {
+				newLines = append(newLines, strings.Split(r.Text, "\n")...)
+			}
+			newLines = append(newLines, lines[r.EndLine:]...)
+			lines = newLines
+			limit = r.Line
+			fileFixes = append(fileFixes, fmt.Sprintf("%s fixed in %s:%d", finding.Title, finding.File, r.Line))
+		}
+		if len(fileFixes) == 0 {
+			continue
+		}
+
+		if err := os.WriteFile(filePath, []byte(strings.Join(lines, "\n")), info.Mode().Perm()); err != nil {
+			continue
+		}
+		patched[file] = true
+		applied = append(applied, fileFixes...)
+	}
+	return applied, patched
+}
+
 // fixSecret fixes an exposed secret
 func fixSecret(finding scanner.Finding, projectPath string, auto bool) (string, error) {
 	filePath := filepath.Join(projectPath, finding.File)
@@ -82,17 +175,21 @@ func fixSecret(finding scanner.Finding, projectPath string, auto bool) (string,
 
 // fixDependency attempts to update a vulnerable dependency
 func fixDependency(finding scanner.Finding, projectPath string, auto bool) (string, error) {
-	// Detect dependency manager
-	if strings.Contains(finding.File, "package.json") {
+	// Detect dependency manager, from the package ecosystem when a tool reported it
+	ecosystem := ""
+	if finding.Package != nil {
+		ecosystem = string(finding.Package.Ecosystem)
+	}
+	if ecosystem == "npm" || strings.Contains(finding.File, "package.json") || strings.Contains(finding.File, "package-lock.json") {
 		return fixNPMDependency(finding, projectPath, auto)
 	}
-	if strings.Contains(finding.File, "go.mod") {
+	if ecosystem == "Go" || strings.Contains(finding.File, "go.mod") {
 		return fixGoDependency(finding, projectPath, auto)
 	}
-	if strings.Contains(finding.File, "requirements.txt") || strings.Contains(finding.File, "Pipfile") {
+	if ecosystem == "PyPI" || strings.Contains(finding.File, "requirements.txt") || strings.Contains(finding.File, "Pipfile") {
 		return fixPythonDependency(finding, projectPath, auto)
 	}
-	if strings.Contains(finding.File, "pom.xml") || strings.Contains(finding.File, "build.gradle") {
+	if ecosystem == "Maven" || strings.Contains(finding.File, "pom.xml") || strings.Contains(finding.File, "build.gradle") {
 		return fixJavaDependency(finding, projectPath, auto)
 	}
 
@@ -101,27 +198,44 @@ func fixDependency(finding scanner.Finding, projectPath string, auto bool) (stri
 
 // fixNPMDependency updates an npm dependency
 func fixNPMDependency(finding scanner.Finding, projectPath string, _ bool) (string, error) {
-	// Extract package name from finding
-	// Expected format: "vulnerability-id in package-name"
-	parts := strings.Fields(finding.Title)
-	if len(parts) < 3 {
-		return "", fmt.Errorf("cannot extract package name")
+	pkg := finding.Package
+	if pkg == nil || pkg.Name == "" {
+		return "", fmt.Errorf("cannot identify the vulnerable package")
 	}
 
-	packageName := parts[len(parts)-1]
+	// A direct dependency is upgraded to the fixed version; a transitive one
+	// is left to npm audit, which upgrades the dependencies pulling it in
+	version := pkg.UpgradeVersion()
+	if version != "" && pkg.Direct() {
+		cmd := exec.Command("npm", "install", "--package-lock-only", pkg.Name+"@"+version)
+		cmd.Dir = projectPath
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("npm install %s@%s failed: %w", pkg.Name, version, err)
+		}
+		return fmt.Sprintf("npm dependency %s upgraded from %s to %s", pkg.Name, pkg.InstalledVersion, version), nil
+	}
 
-	// Run npm audit fix
 	cmd := exec.Command("npm", "audit", "fix", "--package-lock-only")
 	cmd.Dir = projectPath
 	if err := cmd.Run(); err != nil {
 		// npm audit fix may fail, continue anyway
 	}
 
-	return fmt.Sprintf("npm dependency %s updated", packageName), nil
+	return fmt.Sprintf("npm dependency %s updated", pkg.Name), nil
 }
 
 // fixGoDependency updates a Go dependency
-func fixGoDependency(_ scanner.Finding, projectPath string, _ bool) (string, error) {
+func fixGoDependency(finding scanner.Finding, projectPath string, _ bool) (string, error) {
+	if pkg := finding.Package; pkg != nil && pkg.Name != "" && pkg.UpgradeVersion() != "" {
+		version := "v" + strings.TrimPrefix(pkg.UpgradeVersion(), "v")
+		cmd := exec.Command("go", "get", pkg.Name+"@"+version)
+		cmd.Dir = projectPath
+		if err := cmd.Run(); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Go module %s upgraded from %s to %s", pkg.Name, pkg.InstalledVersion, version), nil
+	}
+
 	// go get -u to update
 	cmd := exec.Command("go", "get", "-u", "./...")
 	cmd.Dir = projectPath
@@ -0,0 +1,897 @@
+// Package hcl parses the native syntax of HCL (Terraform configuration
+// files) into bodies of attributes and blocks that keep their line
+// numbers, for the native scanners. Expressions are parsed into a small
+// syntax tree that Eval evaluates when their variables are known; for
+// expressions and template directives are not evaluated.
+package hcl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Body is the content of a file or a block
+type Body struct {
+	Attributes []*Attribute
+	Blocks     []*Block
+}
+
+// Attribute returns the attribute name of the body, or nil
+func (b *Body) Attribute(name string) *Attribute {
+	if b == nil {
+		return nil
+	}
+	for _, a := range b.Attributes {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// Attribute is a name = expression assignment
+type Attribute struct {
+	Name string
+	Expr Expr
+	// Line and EndLine are the first and last lines of the attribute, 1-based
+	Line    int
+	EndLine int
+}
+
+// Block is a type "label" { body } block
+type Block struct {
+	Type   string
+	Labels []string
+	Body   *Body
+	// Line and EndLine are the lines of the block header and of its closing brace
+	Line    int
+	EndLine int
+}
+
+// Expr is an expression
+type Expr interface {
+	// Pos returns the line of the expression
+	Pos() int
+}
+
+type (
+	// Literal is a number, a bool, null or a string without interpolation
+	Literal struct {
+		Value Value
+		Line  int
+	}
+	// Template is a string with interpolations. Parts are the literal
+	// strings and the interpolated expressions.
+	Template struct {
+		Parts []Expr
+		Line  int
+	}
+	// Tuple is a [a, b] constructor
+	Tuple struct {
+		Items []Expr
+		Line  int
+	}
+	// Object is a { key = value } constructor
+	Object struct {
+		Items []ObjectItem
+		Line  int
+	}
+	// Variable is a root name, such as var, local or aws_s3_bucket
+	Variable struct {
+		Name string
+		Line int
+	}
+	// Traversal is an attribute access or an index on an expression
+	Traversal struct {
+		Source Expr
+		Steps  []Step
+		Line   int
+	}
+	// Call is a function call
+	Call struct {
+		Name string
+		Args []Expr
+		// Expand is true when the last argument is expanded with ...
+		Expand bool
+		Line   int
+	}
+	// Unary is a ! or - operation
+	Unary struct {
+		Op   string
+		X    Expr
+		Line int
+	}
+	// Binary is an arithmetic, comparison or logical operation
+	Binary struct {
+		Op   string
+		X, Y Expr
+		Line int
+	}
+	// Conditional is a cond ? a : b expression
+	Conditional struct {
+		Cond, True, False Expr
+		Line              int
+	}
+	// Unsupported is an expression that is not evaluated: a for
+	// expression or a template directive
+	Unsupported struct {
+		Line int
+	}
+)
+
+// ObjectItem is a key = value item of an object constructor
+type ObjectItem struct {
+	Key   Expr
+	Value Expr
+	Line  int
+}
+
+// Step is an attribute access (.name), an index ([expr]) or a splat ([*])
+type Step struct {
+	Name  string
+	Index Expr
+	Splat bool
+}
+
+func (e *Literal) Pos() int     { return e.Line }
+func (e *Template) Pos() int    { return e.Line }
+func (e *Tuple) Pos() int       { return e.Line }
+func (e *Object) Pos() int      { return e.Line }
+func (e *Variable) Pos() int    { return e.Line }
+func (e *Traversal) Pos() int   { return e.Line }
+func (e *Call) Pos() int        { return e.Line }
+func (e *Unary) Pos() int       { return e.Line }
+func (e *Binary) Pos() int      { return e.Line }
+func (e *Conditional) Pos() int { return e.Line }
+func (e *Unsupported) Pos() int { return e.Line }
+
+// Parse parses a configuration file
+func Parse(data []byte) (*Body, error) {
+	p := &parser{src: data, line: 1}
+	body, err := p.parseBody(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos])
+	}
+	return body, nil
+}
+
+type parser struct {
+	src  []byte
+	pos  int
+	line int
+	// nested counts the brackets around the expression being parsed,
+	// inside which newlines are not significant
+	nested int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) peek(offset int) byte {
+	if p.pos+offset < len(p.src) {
+		return p.src[p.pos+offset]
+	}
+	return 0
+}
+
+func (p *parser) hasPrefix(s string) bool {
+	return strings.HasPrefix(string(p.src[p.pos:min(len(p.src), p.pos+len(s))]), s)
+}
+
+// skip skips blanks and comments, and newlines when newlines is true or
+// the parser is inside brackets
+func (p *parser) skip(newlines bool) {
+	newlines = newlines || p.nested > 0
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case c == '\n':
+			if !newlines {
+				return
+			}
+			p.line++
+			p.pos++
+		case c == '#' || (c == '/' && p.peek(1) == '/'):
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case c == '/' && p.peek(1) == '*':
+			end := strings.Index(string(p.src[p.pos+2:]), "*/")
+			if end < 0 {
+				p.pos = len(p.src)
+				return
+			}
+			p.line += strings.Count(string(p.src[p.pos:p.pos+2+end]), "\n")
+			p.pos += end + 4
+		default:
+			return
+		}
+	}
+}
+
+// parseBody parses attributes and blocks until end, or the end of the file
+// when end is 0
+func (p *parser) parseBody(end byte) (*Body, error) {
+	body := &Body{}
+	for {
+		p.skip(true)
+		if p.pos >= len(p.src) {
+			if end != 0 {
+				return nil, p.errorf("missing %q", end)
+			}
+			return body, nil
+		}
+		if p.src[p.pos] == end {
+			return body, nil
+		}
+
+		line := p.line
+		name := p.identifier()
+		if name == "" {
+			return nil, p.errorf("unexpected %q", p.src[p.pos])
+		}
+		p.skip(false)
+
+		if p.peek(0) == '=' && p.peek(1) != '=' {
+			p.pos++
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			body.Attributes = append(body.Attributes, &Attribute{Name: name, Expr: expr, Line: line, EndLine: p.line})
+			p.skip(false)
+			if c := p.peek(0); c != '\n' && c != end && p.pos < len(p.src) {
+				return nil, p.errorf("unexpected %q after attribute %s", c, name)
+			}
+			continue
+		}
+
+		block := &Block{Type: name, Line: line}
+		for p.peek(0) != '{' {
+			switch {
+			case p.peek(0) == '"':
+				label, err := p.parseQuoted()
+				if err != nil {
+					return nil, err
+				}
+				s, ok := label.(*Literal)
+				if !ok {
+					return nil, p.errorf("interpolation in the label of block %s", name)
+				}
+				block.Labels = append(block.Labels, s.Value.Str)
+			case isIdentStart(p.peek(0)):
+				block.Labels = append(block.Labels, p.identifier())
+			default:
+				return nil, p.errorf("unexpected %q in block %s", p.peek(0), name)
+			}
+			p.skip(false)
+		}
+		p.pos++
+		inner, err := p.parseBody('}')
+		if err != nil {
+			return nil, err
+		}
+		p.pos++
+		block.Body, block.EndLine = inner, p.line
+		body.Blocks = append(body.Blocks, block)
+	}
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || c == '-' || c >= '0' && c <= '9'
+}
+
+// identifier reads an identifier, or returns ""
+func (p *parser) identifier() string {
+	if p.pos >= len(p.src) || !isIdentStart(p.src[p.pos]) {
+		return ""
+	}
+	start := p.pos
+	for p.pos < len(p.src) && isIdentChar(p.src[p.pos]) {
+		p.pos++
+	}
+	return string(p.src[start:p.pos])
+}
+
+// binaryLevels are the binary operators, by increasing precedence
+var binaryLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<=", ">=", "<", ">"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+// parseExpr parses an expression
+func (p *parser) parseExpr() (Expr, error) {
+	cond, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	p.skip(false)
+	if p.peek(0) != '?' {
+		return cond, nil
+	}
+	p.pos++
+	t, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	p.skip(false)
+	if p.peek(0) != ':' {
+		return nil, p.errorf("missing : in conditional expression")
+	}
+	p.pos++
+	f, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	return &Conditional{Cond: cond, True: t, False: f, Line: cond.Pos()}, nil
+}
+
+// parseBinary parses the binary operations of a precedence level
+func (p *parser) parseBinary(level int) (Expr, error) {
+	if level == len(binaryLevels) {
+		return p.parseUnary()
+	}
+	x, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skip(false)
+		op := ""
+		for _, candidate := range binaryLevels[level] {
+			if p.hasPrefix(candidate) && !(candidate == "/" && (p.peek(1) == '/' || p.peek(1) == '*')) {
+				op = candidate
+				break
+			}
+		}
+		if op == "" {
+			return x, nil
+		}
+		p.pos += len(op)
+		y, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		x = &Binary{Op: op, X: x, Y: y, Line: x.Pos()}
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	p.skip(false)
+	if c := p.peek(0); (c == '!' && p.peek(1) != '=') || c == '-' {
+		line := p.line
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Unary{Op: string(c), X: x, Line: line}, nil
+	}
+	return p.parsePostfix()
+}
+
+// parsePostfix parses a primary expression and its attribute accesses,
+// indexes and splats
+func (p *parser) parsePostfix() (Expr, error) {
+	x, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	var steps []Step
+	for {
+		switch {
+		case p.peek(0) == '.' && p.peek(1) == '*':
+			p.pos += 2
+			steps = append(steps, Step{Splat: true})
+		case p.peek(0) == '.' && isIdentStart(p.peek(1)):
+			p.pos++
+			steps = append(steps, Step{Name: p.identifier()})
+		case p.peek(0) == '.' && p.peek(1) >= '0' && p.peek(1) <= '9':
+			p.pos++
+			start := p.pos
+			for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+				p.pos++
+			}
+			steps = append(steps, Step{Index: &Literal{Value: NumberValue(string(p.src[start:p.pos]), p.line), Line: p.line}})
+		case p.peek(0) == '[':
+			p.pos++
+			p.nested++
+			p.skip(true)
+			if p.peek(0) == '*' {
+				p.pos++
+				steps = append(steps, Step{Splat: true})
+			} else {
+				index, err := p.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+				steps = append(steps, Step{Index: index})
+			}
+			p.skip(true)
+			p.nested--
+			if p.peek(0) != ']' {
+				return nil, p.errorf("missing ]")
+			}
+			p.pos++
+		default:
+			if len(steps) == 0 {
+				return x, nil
+			}
+			return &Traversal{Source: x, Steps: steps, Line: x.Pos()}, nil
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	p.skip(false)
+	line := p.line
+	c := p.peek(0)
+	switch {
+	case p.pos >= len(p.src):
+		return nil, p.errorf("missing expression")
+	case c >= '0' && c <= '9':
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] >= '0' && p.src[p.pos] <= '9' || p.src[p.pos] == '.' ||
+			p.src[p.pos] == 'e' || p.src[p.pos] == 'E' ||
+			(p.src[p.pos] == '+' || p.src[p.pos] == '-') && (p.src[p.pos-1] == 'e' || p.src[p.pos-1] == 'E')) {
+			p.pos++
+		}
+		return &Literal{Value: NumberValue(string(p.src[start:p.pos]), line), Line: line}, nil
+	case c == '"':
+		return p.parseQuoted()
+	case c == '<' && p.peek(1) == '<':
+		return p.parseHeredoc()
+	case c == '(':
+		p.pos++
+		p.nested++
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		p.skip(true)
+		p.nested--
+		if p.peek(0) != ')' {
+			return nil, p.errorf("missing )")
+		}
+		p.pos++
+		return x, nil
+	case c == '[':
+		return p.parseTuple()
+	case c == '{':
+		return p.parseObject()
+	case isIdentStart(c):
+		name := p.identifier()
+		switch name {
+		case "true", "false":
+			return &Literal{Value: BoolValue(name == "true", line), Line: line}, nil
+		case "null":
+			return &Literal{Value: Value{Kind: NullKind, Line: line}, Line: line}, nil
+		}
+		if p.peek(0) == '(' || (p.peek(0) == ':' && p.peek(1) == ':') {
+			return p.parseCall(name, line)
+		}
+		return &Variable{Name: name, Line: line}, nil
+	}
+	return nil, p.errorf("unexpected %q", c)
+}
+
+// parseCall parses the arguments of a function call
+func (p *parser) parseCall(name string, line int) (Expr, error) {
+	// Provider functions: provider::name::function
+	for p.peek(0) == ':' && p.peek(1) == ':' {
+		p.pos += 2
+		name += "::" + p.identifier()
+	}
+	if p.peek(0) != '(' {
+		return nil, p.errorf("missing ( after %s", name)
+	}
+	p.pos++
+	p.nested++
+	defer func() { p.nested-- }()
+
+	call := &Call{Name: name, Line: line}
+	for {
+		p.skip(true)
+		if p.peek(0) == ')' {
+			p.pos++
+			return call, nil
+		}
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		call.Args = append(call.Args, arg)
+		p.skip(true)
+		if p.hasPrefix("...") {
+			p.pos += 3
+			call.Expand = true
+			p.skip(true)
+		}
+		switch p.peek(0) {
+		case ',':
+			p.pos++
+		case ')':
+		default:
+			return nil, p.errorf("missing , or ) in call to %s", name)
+		}
+	}
+}
+
+// isFor reports whether a for expression starts after the bracket
+func (p *parser) isFor() bool {
+	p.skip(true)
+	return p.hasPrefix("for") && !isIdentChar(p.peek(3))
+}
+
+// skipBrackets skips a bracketed expression, from after its opening
+// bracket to after its closing one
+func (p *parser) skipBrackets(open, close byte) error {
+	depth := 1
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == '"':
+			if _, err := p.parseQuoted(); err != nil {
+				return err
+			}
+			continue
+		case c == '\n':
+			p.line++
+		case c == open:
+			depth++
+		case c == close:
+			depth--
+			if depth == 0 {
+				p.pos++
+				return nil
+			}
+		}
+		p.pos++
+	}
+	return p.errorf("missing %q", close)
+}
+
+func (p *parser) parseTuple() (Expr, error) {
+	line := p.line
+	p.pos++
+	p.nested++
+	defer func() { p.nested-- }()
+	if p.isFor() {
+		if err := p.skipBrackets('[', ']'); err != nil {
+			return nil, err
+		}
+		return &Unsupported{Line: line}, nil
+	}
+
+	tuple := &Tuple{Line: line}
+	for {
+		p.skip(true)
+		if p.peek(0) == ']' {
+			p.pos++
+			return tuple, nil
+		}
+		item, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		tuple.Items = append(tuple.Items, item)
+		p.skip(true)
+		switch p.peek(0) {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, p.errorf("missing , or ] in tuple")
+		}
+	}
+}
+
+func (p *parser) parseObject() (Expr, error) {
+	line := p.line
+	p.pos++
+	p.nested++
+	defer func() { p.nested-- }()
+	if p.isFor() {
+		if err := p.skipBrackets('{', '}'); err != nil {
+			return nil, err
+		}
+		return &Unsupported{Line: line}, nil
+	}
+
+	object := &Object{Line: line}
+	for {
+		p.skip(true)
+		if p.peek(0) == '}' {
+			p.pos++
+			return object, nil
+		}
+		itemLine := p.line
+		var key Expr
+		if isIdentStart(p.peek(0)) {
+			// A bare identifier is a literal key, unless it is followed by
+			// an accessor
+			start, startLine := p.pos, p.line
+			name := p.identifier()
+			p.skip(true)
+			if c := p.peek(0); (c == '=' && p.peek(1) != '=') || c == ':' {
+				key = &Literal{Value: StringValue(name, itemLine), Line: itemLine}
+			} else {
+				p.pos, p.line = start, startLine
+			}
+		}
+		if key == nil {
+			var err error
+			if key, err = p.parseExpr(); err != nil {
+				return nil, err
+			}
+		}
+		p.skip(true)
+		if c := p.peek(0); c != '=' && c != ':' {
+			return nil, p.errorf("missing = in object")
+		}
+		p.pos++
+		value, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		object.Items = append(object.Items, ObjectItem{Key: key, Value: value, Line: itemLine})
+
+		// Items are separated by commas or newlines
+		p.nested--
+		p.skip(false)
+		p.nested++
+		if p.peek(0) == ',' {
+			p.pos++
+		}
+	}
+}
+
+// parseQuoted parses a quoted template
+func (p *parser) parseQuoted() (Expr, error) {
+	line := p.line
+	p.pos++
+	tmpl := &Template{Line: line}
+	var lit strings.Builder
+	flush := func() {
+		if lit.Len() > 0 {
+			tmpl.Parts = append(tmpl.Parts, &Literal{Value: StringValue(lit.String(), line), Line: line})
+			lit.Reset()
+		}
+	}
+	for {
+		if p.pos >= len(p.src) || p.src[p.pos] == '\n' {
+			return nil, p.errorf("unterminated string")
+		}
+		c := p.src[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			flush()
+			return simplify(tmpl), nil
+		case c == '\\':
+			r, size, err := unescape(p.src[p.pos:])
+			if err != nil {
+				return nil, p.errorf("%v", err)
+			}
+			lit.WriteString(r)
+			p.pos += size
+		case (c == '$' || c == '%') && p.peek(1) == c && p.peek(2) == '{':
+			lit.WriteString(string(c) + "{")
+			p.pos += 3
+		case (c == '$' || c == '%') && p.peek(1) == '{':
+			flush()
+			part, err := p.parseInterpolation()
+			if err != nil {
+				return nil, err
+			}
+			tmpl.Parts = append(tmpl.Parts, part)
+		default:
+			lit.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// parseInterpolation parses a ${ expr } interpolation, or skips a %{ }
+// directive
+func (p *parser) parseInterpolation() (Expr, error) {
+	line := p.line
+	directive := p.src[p.pos] == '%'
+	p.pos += 2
+	p.nested++
+	defer func() { p.nested-- }()
+	if directive {
+		if err := p.skipBrackets('{', '}'); err != nil {
+			return nil, err
+		}
+		return &Unsupported{Line: line}, nil
+	}
+	if p.peek(0) == '~' {
+		p.pos++
+	}
+	x, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	p.skip(true)
+	if p.peek(0) == '~' {
+		p.pos++
+	}
+	if p.peek(0) != '}' {
+		return nil, p.errorf("missing } in interpolation")
+	}
+	p.pos++
+	return x, nil
+}
+
+// parseHeredoc parses a <<EOF or <<-EOF template
+func (p *parser) parseHeredoc() (Expr, error) {
+	line := p.line
+	p.pos += 2
+	p.skip(false)
+	indented := p.peek(0) == '-'
+	if indented {
+		p.pos++
+	}
+	marker := p.identifier()
+	if marker == "" || (p.peek(0) != '\n' && !(p.peek(0) == '\r' && p.peek(1) == '\n')) {
+		return nil, p.errorf("invalid heredoc")
+	}
+	for p.src[p.pos] != '\n' {
+		p.pos++
+	}
+	p.pos++
+	p.line++
+
+	start, startLine := p.pos, p.line
+	for p.pos < len(p.src) {
+		end := strings.IndexByte(string(p.src[p.pos:]), '\n')
+		if end < 0 {
+			end = len(p.src) - p.pos
+		}
+		text := strings.TrimSpace(string(p.src[p.pos : p.pos+end]))
+		if text == marker {
+			content := p.src[start:p.pos]
+			if indented {
+				content = dedent(content)
+			}
+			p.pos += end
+			sub := &parser{src: content, line: startLine}
+			tmpl, err := sub.parseTemplate()
+			if err != nil {
+				return nil, err
+			}
+			tmpl.Line = line
+			return simplify(tmpl), nil
+		}
+		p.pos += end + 1
+		p.line++
+	}
+	return nil, p.errorf("unterminated heredoc %s", marker)
+}
+
+// dedent removes the indentation common to the non-blank lines of the
+// content of a <<- heredoc
+func dedent(content []byte) []byte {
+	lines := strings.SplitAfter(string(content), "\n")
+	indent := -1
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		n := len(l) - len(strings.TrimLeft(l, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent <= 0 {
+		return content
+	}
+	var b strings.Builder
+	for _, l := range lines {
+		if len(l) >= indent && strings.TrimSpace(l[:indent]) == "" {
+			l = l[indent:]
+		} else {
+			l = strings.TrimLeft(l, " \t")
+		}
+		b.WriteString(l)
+	}
+	return []byte(b.String())
+}
+
+// parseTemplate parses the content of a heredoc, where quotes and
+// backslashes are literal
+func (p *parser) parseTemplate() (*Template, error) {
+	tmpl := &Template{Line: p.line}
+	var lit strings.Builder
+	litLine := p.line
+	flush := func() {
+		if lit.Len() > 0 {
+			tmpl.Parts = append(tmpl.Parts, &Literal{Value: StringValue(lit.String(), litLine), Line: litLine})
+			lit.Reset()
+		}
+	}
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case (c == '$' || c == '%') && p.peek(1) == c && p.peek(2) == '{':
+			lit.WriteString(string(c) + "{")
+			p.pos += 3
+		case (c == '$' || c == '%') && p.peek(1) == '{':
+			flush()
+			part, err := p.parseInterpolation()
+			if err != nil {
+				return nil, err
+			}
+			tmpl.Parts = append(tmpl.Parts, part)
+			litLine = p.line
+		default:
+			if c == '\n' {
+				p.line++
+			}
+			lit.WriteByte(c)
+			p.pos++
+		}
+	}
+	flush()
+	return tmpl, nil
+}
+
+// simplify returns a template without interpolation as a string literal
+func simplify(tmpl *Template) Expr {
+	switch len(tmpl.Parts) {
+	case 0:
+		return &Literal{Value: StringValue("", tmpl.Line), Line: tmpl.Line}
+	case 1:
+		if lit, ok := tmpl.Parts[0].(*Literal); ok {
+			lit.Line, lit.Value.Line = tmpl.Line, tmpl.Line
+			return lit
+		}
+	}
+	return tmpl
+}
+
+// unescape decodes the escape sequence at the start of s
+func unescape(s []byte) (string, int, error) {
+	if len(s) < 2 {
+		return "", 0, fmt.Errorf("invalid escape sequence")
+	}
+	switch s[1] {
+	case 'n':
+		return "\n", 2, nil
+	case 'r':
+		return "\r", 2, nil
+	case 't':
+		return "\t", 2, nil
+	case '"', '\\':
+		return string(s[1]), 2, nil
+	case 'u', 'U':
+		size := 4
+		if s[1] == 'U' {
+			size = 8
+		}
+		if len(s) < 2+size {
+			return "", 0, fmt.Errorf("invalid escape sequence")
+		}
+		n, err := strconv.ParseUint(string(s[2:2+size]), 16, 32)
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid escape sequence")
+		}
+		return string(rune(n)), 2 + size, nil
+	}
+	return "", 0, fmt.Errorf("invalid escape sequence \\%c", s[1])
+}
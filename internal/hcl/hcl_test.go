@@ -0,0 +1,325 @@
+package hcl
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// evalString parses "x = expr" and evaluates expr, returning its value as
+// JSON, or "unknown"
+func evalString(t *testing.T, expr string, ctx *Context) string {
+	t.Helper()
+	body, err := Parse([]byte("x = " + expr + "\n"))
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", expr, err)
+	}
+	a := body.Attribute("x")
+	if a == nil {
+		t.Fatalf("Parse(%q): attribute not found", expr)
+	}
+	v := Eval(a.Expr, ctx)
+	if !v.Known() {
+		return "unknown"
+	}
+	data, err := json.Marshal(ToJSON(v))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestParseBody(t *testing.T) {
+	src := `# comment
+// comment
+/* multi-line
+   comment */
+terraform {
+  required_version = ">= 1.5"
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs" # trailing comment
+
+  versioning {
+    enabled = true
+  }
+
+  tags = {
+    Name = "logs"
+  }
+}
+
+module child {
+  source = "./child"
+}
+`
+	body, err := Parse([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Attributes) != 0 || len(body.Blocks) != 3 {
+		t.Fatalf("got %d attributes and %d blocks, want 0 and 3", len(body.Attributes), len(body.Blocks))
+	}
+
+	tests := []struct {
+		block          *Block
+		typ            string
+		labels         string
+		line, end      int
+		attrs, nblocks int
+	}{
+		{body.Blocks[0], "terraform", "", 5, 7, 1, 0},
+		{body.Blocks[1], "resource", "aws_s3_bucket logs", 9, 19, 2, 1},
+		{body.Blocks[2], "module", "child", 21, 23, 1, 0},
+	}
+	for _, tt := range tests {
+		b := tt.block
+		if b.Type != tt.typ || strings.Join(b.Labels, " ") != tt.labels {
+			t.Errorf("got block %s %v, want %s %s", b.Type, b.Labels, tt.typ, tt.labels)
+		}
+		if b.Line != tt.line || b.EndLine != tt.end {
+			t.Errorf("%s: got lines %d-%d, want %d-%d", b.Type, b.Line, b.EndLine, tt.line, tt.end)
+		}
+		if len(b.Body.Attributes) != tt.attrs || len(b.Body.Blocks) != tt.nblocks {
+			t.Errorf("%s: got %d attributes and %d blocks", b.Type, len(b.Body.Attributes), len(b.Body.Blocks))
+		}
+	}
+
+	bucket := body.Blocks[1].Body
+	if a := bucket.Attribute("bucket"); a == nil || a.Line != 10 || a.EndLine != 10 {
+		t.Errorf("bucket: got %+v, want line 10", a)
+	}
+	if a := bucket.Attribute("tags"); a == nil || a.Line != 16 || a.EndLine != 18 {
+		t.Errorf("tags: got %+v, want lines 16-18", a)
+	}
+	if v := bucket.Blocks[0]; v.Type != "versioning" || v.Line != 12 || v.Body.Attribute("enabled") == nil {
+		t.Errorf("versioning: got %+v", v)
+	}
+	if bucket.Attribute("missing") != nil {
+		t.Error("Attribute must return nil for a missing attribute")
+	}
+	var none *Body
+	if none.Attribute("x") != nil {
+		t.Error("Attribute must accept a nil body")
+	}
+}
+
+func TestParseExpressions(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{`"text"`, `"text"`},
+		{`42`, `42`},
+		{`1.5e3`, `1500`},
+		{`true`, `true`},
+		{`null`, `null`},
+		{`"a\"b\né"`, `"a\"b\né"`},
+		{`"$${literal}"`, `"${literal}"`},
+		{`[1, "two", false]`, `[1,"two",false]`},
+		{`[\n  1,\n  2,\n]`, `[1,2]`},
+		{`{ a = 1, "b" = 2 }`, `{"a":1,"b":2}`},
+		{`{\n  a = 1\n  b: 2\n}`, `{"a":1,"b":2}`},
+		{`!true`, `false`},
+		{`-(2 + 3)`, `-5`},
+		{`1 + 2 * 3`, `7`},
+		{`(1 + 2) * 3`, `9`},
+		{`7 % 4`, `3`},
+		{`1 < 2 && 2 >= 2`, `true`},
+		{`false || 1 == 1`, `true`},
+		{`"1" == 1`, `false`},
+		{`1 != 2`, `true`},
+		{`true ? "a" : "b"`, `"a"`},
+		{`1 > 2 ? "a" : "b"`, `"b"`},
+		{`[1, 2][1]`, `2`},
+		{`{ a = { b = "c" } }.a.b`, `"c"`},
+		{`{ a = 1 }["a"]`, `1`},
+		{`"${1 + 1} items"`, `"2 items"`},
+		{`[for s in ["a"] : upper(s)]`, `unknown`},
+		{`"%{ if true }a%{ endif }"`, `unknown`},
+		{`[1, 2][5]`, `unknown`},
+		{`1 / 0`, `unknown`},
+	}
+	for _, tt := range tests {
+		expr := strings.ReplaceAll(tt.expr, `\n`, "\n")
+		if strings.HasPrefix(tt.expr, `"`) {
+			expr = tt.expr
+		}
+		if got := evalString(t, expr, nil); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestHeredoc(t *testing.T) {
+	src := "a = <<EOT\n  line one\n  ${var.name}\nEOT\nb = <<-EOT\n    indented\n      more\n    EOT\nc = 1\n"
+	body, err := Parse([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := &Context{Variables: map[string]Value{"var": objectOf(map[string]Value{"name": StringValue("x", 0)})}}
+
+	tests := []struct {
+		name string
+		want string
+		line int
+	}{
+		{"a", "  line one\n  x\n", 1},
+		{"b", "indented\n  more\n", 5},
+		{"c", "1", 9},
+	}
+	for _, tt := range tests {
+		a := body.Attribute(tt.name)
+		if a == nil {
+			t.Fatalf("%s: not found", tt.name)
+		}
+		got, ok := Eval(a.Expr, ctx).AsString()
+		if !ok || got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+		if a.Line != tt.line {
+			t.Errorf("%s: got line %d, want %d", tt.name, a.Line, tt.line)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		`a = `,
+		`a = "unterminated`,
+		`a = [1, 2`,
+		`resource "x" {`,
+		`a = 1 }`,
+		`a = (1`,
+		`a = <<EOT` + "\nno end\n",
+	}
+	for _, src := range tests {
+		if _, err := Parse([]byte(src)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", src)
+		}
+	}
+}
+
+// objectOf builds an object value
+func objectOf(attrs map[string]Value) Value {
+	object := ObjectValue(0)
+	for k, v := range attrs {
+		object.Set(k, v)
+	}
+	return object
+}
+
+func TestEvalVariables(t *testing.T) {
+	ctx := &Context{
+		Variables: map[string]Value{
+			"var": objectOf(map[string]Value{
+				"name":    StringValue("app", 1),
+				"enabled": BoolValue(true, 1),
+				"cidrs":   ListValue([]Value{StringValue("10.0.0.0/8", 1)}, 1),
+			}),
+		},
+		Resolve: func(name, attr string) (Value, bool) {
+			if name != "local" {
+				return Value{}, false
+			}
+			if attr == "prefix" {
+				return StringValue("acme", 2), true
+			}
+			return Unknown(2), true
+		},
+	}
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{`var.name`, `"app"`},
+		{`"${local.prefix}-${var.name}"`, `"acme-app"`},
+		{`var.cidrs[0]`, `"10.0.0.0/8"`},
+		{`var.enabled ? "on" : "off"`, `"on"`},
+		{`local.missing`, `unknown`},
+		{`var.missing`, `unknown`},
+		{`aws_kms_key.k.arn`, `unknown`},
+		{`aws_instance.web[*].id`, `unknown`},
+		// Comparisons with unknown values stay unknown
+		{`aws_kms_key.k.arn != ""`, `unknown`},
+		{`"${aws_kms_key.k.arn}"`, `unknown`},
+		{`[aws_kms_key.k.arn, "x"]`, `unknown`},
+		// Unless the other operand decides
+		{`false && aws_kms_key.k.enabled`, `false`},
+		{`true || aws_kms_key.k.enabled`, `true`},
+		{`aws_kms_key.k.enabled ? "a" : "b"`, `unknown`},
+	}
+	for _, tt := range tests {
+		if got := evalString(t, tt.expr, ctx); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestEvalFunctions(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{`jsonencode({ Effect = "Allow", Action = ["s3:*"] })`, `"{\"Action\":[\"s3:*\"],\"Effect\":\"Allow\"}"`},
+		{`jsondecode("{\"a\": [1, true]}")`, `{"a":[1,true]}`},
+		{`jsondecode("not json")`, `unknown`},
+		{`lower("ABC")`, `"abc"`},
+		{`upper("abc")`, `"ABC"`},
+		{`trimspace("  a ")`, `"a"`},
+		{`tostring(1)`, `"1"`},
+		{`tonumber("2.5")`, `2.5`},
+		{`tobool("true")`, `true`},
+		{`tolist(["a"])`, `["a"]`},
+		{`toset(["a"])`, `["a"]`},
+		{`concat(["a"], ["b", "c"])`, `["a","b","c"]`},
+		{`merge({ a = 1, b = 1 }, { b = 2 }, null)`, `{"a":1,"b":2}`},
+		{`concat([["a"], ["b"]]...)`, `["a","b"]`},
+		{`lower(aws_s3_bucket.b.id)`, `unknown`},
+		{`cidrsubnet("10.0.0.0/8", 8, 1)`, `unknown`},
+	}
+	for _, tt := range tests {
+		if got := evalString(t, tt.expr, nil); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestValueLines(t *testing.T) {
+	body, err := Parse([]byte("x = {\n  a = 1\n  b = [\n    \"c\",\n  ]\n}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := Eval(body.Attribute("x").Expr, nil)
+	if v.Line != 1 || v.Attr("a").Line != 2 || v.Attr("b").Line != 3 || v.Attr("b").Items[0].Line != 4 {
+		t.Errorf("got lines %d, %d, %d, %d; want 1, 2, 3, 4", v.Line, v.Attr("a").Line, v.Attr("b").Line, v.Attr("b").Items[0].Line)
+	}
+	if keys := strings.Join(v.Keys, ","); keys != "a,b" {
+		t.Errorf("got keys %s, want declaration order a,b", keys)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	v, ok := ParseJSON(`{"Statement": [{"Effect": "Allow", "Action": "*"}], "Version": "2012-10-17"}`, 7)
+	if !ok {
+		t.Fatal("ParseJSON failed")
+	}
+	statement := v.Attr("Statement").Items[0]
+	if s, _ := statement.Attr("Action").AsString(); s != "*" || statement.Line != 7 {
+		t.Errorf("got %+v", statement)
+	}
+	if _, ok := ParseJSON("{", 1); ok {
+		t.Error("ParseJSON must fail on invalid JSON")
+	}
+
+	x := objectOf(map[string]Value{"a": ListValue([]Value{NumberValue("1", 0)}, 0)})
+	y := FromJSON(map[string]any{"a": []any{1.0}}, 0)
+	if !Equal(x, y) {
+		t.Error("values decoded from JSON must equal the same values built from HCL")
+	}
+	if Equal(StringValue("1", 0), NumberValue("1", 0)) {
+		t.Error("a string is not equal to a number")
+	}
+}
@@ -0,0 +1,507 @@
+package hcl
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kind is the type of a value
+type Kind int
+
+const (
+	// UnknownKind is a value that cannot be known before terraform apply,
+	// or that Eval does not evaluate
+	UnknownKind Kind = iota
+	NullKind
+	StringKind
+	NumberKind
+	BoolKind
+	ListKind
+	ObjectKind
+)
+
+// Value is an evaluated expression. Line is the line of the expression
+// that produced it, so that findings point to the attribute at fault.
+type Value struct {
+	Kind  Kind
+	Str   string
+	Num   float64
+	Bool  bool
+	Items []Value
+	// Keys are the keys of an object, in their declaration order
+	Keys  []string
+	Attrs map[string]Value
+	Line  int
+}
+
+// Unknown returns an unknown value
+func Unknown(line int) Value {
+	return Value{Kind: UnknownKind, Line: line}
+}
+
+// StringValue returns a string value
+func StringValue(s string, line int) Value {
+	return Value{Kind: StringKind, Str: s, Line: line}
+}
+
+// NumberValue returns the value of a number literal
+func NumberValue(s string, line int) Value {
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return Unknown(line)
+	}
+	return Value{Kind: NumberKind, Num: n, Line: line}
+}
+
+// BoolValue returns a bool value
+func BoolValue(b bool, line int) Value {
+	return Value{Kind: BoolKind, Bool: b, Line: line}
+}
+
+// ListValue returns a list value
+func ListValue(items []Value, line int) Value {
+	return Value{Kind: ListKind, Items: items, Line: line}
+}
+
+// ObjectValue returns an empty object value, filled with Set
+func ObjectValue(line int) Value {
+	return Value{Kind: ObjectKind, Attrs: map[string]Value{}, Line: line}
+}
+
+// Set sets the attribute key of an object
+func (v *Value) Set(key string, value Value) {
+	if _, ok := v.Attrs[key]; !ok {
+		v.Keys = append(v.Keys, key)
+	}
+	v.Attrs[key] = value
+}
+
+// Known reports whether the value and everything it contains is known
+func (v Value) Known() bool {
+	switch v.Kind {
+	case UnknownKind:
+		return false
+	case ListKind:
+		for _, item := range v.Items {
+			if !item.Known() {
+				return false
+			}
+		}
+	case ObjectKind:
+		for _, item := range v.Attrs {
+			if !item.Known() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// AsString returns the value of a primitive as a string, the way Terraform
+// converts it. ok is false for unknown, null and collection values.
+func (v Value) AsString() (s string, ok bool) {
+	switch v.Kind {
+	case StringKind:
+		return v.Str, true
+	case NumberKind:
+		return strconv.FormatFloat(v.Num, 'f', -1, 64), true
+	case BoolKind:
+		return strconv.FormatBool(v.Bool), true
+	}
+	return "", false
+}
+
+// Context gives the values of the variables of the expressions
+type Context struct {
+	// Variables are the root names, such as var
+	Variables map[string]Value
+	// Resolve returns the attribute attr of the root name, for the names
+	// that are computed lazily such as local. ok is false when the name
+	// is not resolved lazily, to look it up in Variables.
+	Resolve func(name, attr string) (Value, bool)
+}
+
+// Eval evaluates an expression. Parts of the value that depend on unknown
+// variables, resources or unsupported functions are unknown.
+func Eval(expr Expr, ctx *Context) Value {
+	if ctx == nil {
+		ctx = &Context{}
+	}
+	switch e := expr.(type) {
+	case *Literal:
+		return e.Value
+	case *Template:
+		var b strings.Builder
+		for _, part := range e.Parts {
+			s, ok := Eval(part, ctx).AsString()
+			if !ok {
+				return Unknown(e.Line)
+			}
+			b.WriteString(s)
+		}
+		return StringValue(b.String(), e.Line)
+	case *Tuple:
+		items := make([]Value, 0, len(e.Items))
+		for _, item := range e.Items {
+			items = append(items, Eval(item, ctx))
+		}
+		return ListValue(items, e.Line)
+	case *Object:
+		object := ObjectValue(e.Line)
+		for _, item := range e.Items {
+			var key string
+			if v, ok := item.Key.(*Variable); ok {
+				key = v.Name
+			} else if s, ok := Eval(item.Key, ctx).AsString(); ok {
+				key = s
+			} else {
+				continue
+			}
+			object.Set(key, Eval(item.Value, ctx))
+		}
+		return object
+	case *Variable:
+		if v, ok := ctx.Variables[e.Name]; ok {
+			return v
+		}
+		return Unknown(e.Line)
+	case *Traversal:
+		return evalTraversal(e, ctx)
+	case *Call:
+		return evalCall(e, ctx)
+	case *Unary:
+		x := Eval(e.X, ctx)
+		switch {
+		case e.Op == "!" && x.Kind == BoolKind:
+			return BoolValue(!x.Bool, e.Line)
+		case e.Op == "-" && x.Kind == NumberKind:
+			return Value{Kind: NumberKind, Num: -x.Num, Line: e.Line}
+		}
+		return Unknown(e.Line)
+	case *Binary:
+		return evalBinary(e, ctx)
+	case *Conditional:
+		cond := Eval(e.Cond, ctx)
+		if cond.Kind != BoolKind {
+			return Unknown(e.Line)
+		}
+		if cond.Bool {
+			return Eval(e.True, ctx)
+		}
+		return Eval(e.False, ctx)
+	}
+	return Unknown(expr.Pos())
+}
+
+func evalTraversal(e *Traversal, ctx *Context) Value {
+	steps := e.Steps
+	v := Unknown(e.Line)
+	resolved := false
+	if root, ok := e.Source.(*Variable); ok && ctx.Resolve != nil && steps[0].Name != "" {
+		if v, resolved = ctx.Resolve(root.Name, steps[0].Name); resolved {
+			steps = steps[1:]
+		}
+	}
+	if !resolved {
+		v = Eval(e.Source, ctx)
+	}
+
+	for _, step := range steps {
+		switch {
+		case step.Splat:
+			return Unknown(e.Line)
+		case step.Name != "":
+			v = v.Attr(step.Name)
+		default:
+			index := Eval(step.Index, ctx)
+			if v.Kind == ListKind && index.Kind == NumberKind {
+				i := int(index.Num)
+				if i < 0 || i >= len(v.Items) {
+					return Unknown(e.Line)
+				}
+				v = v.Items[i]
+				continue
+			}
+			s, ok := index.AsString()
+			if !ok {
+				return Unknown(e.Line)
+			}
+			v = v.Attr(s)
+		}
+		if v.Kind == UnknownKind {
+			return Unknown(e.Line)
+		}
+	}
+	return v
+}
+
+// Attr returns the attribute of an object, or an unknown value
+func (v Value) Attr(name string) Value {
+	if v.Kind == ObjectKind {
+		if a, ok := v.Attrs[name]; ok {
+			return a
+		}
+	}
+	return Unknown(v.Line)
+}
+
+func evalBinary(e *Binary, ctx *Context) Value {
+	x, y := Eval(e.X, ctx), Eval(e.Y, ctx)
+	switch e.Op {
+	case "&&", "||":
+		// false && unknown is false, true || unknown is true
+		for _, v := range []Value{x, y} {
+			if v.Kind == BoolKind && v.Bool == (e.Op == "||") {
+				return BoolValue(v.Bool, e.Line)
+			}
+		}
+		if x.Kind == BoolKind && y.Kind == BoolKind {
+			return BoolValue(e.Op == "&&", e.Line)
+		}
+		return Unknown(e.Line)
+	case "==", "!=":
+		if !x.Known() || !y.Known() {
+			return Unknown(e.Line)
+		}
+		return BoolValue(Equal(x, y) == (e.Op == "=="), e.Line)
+	}
+
+	a, ok := toNumber(x)
+	b, ok2 := toNumber(y)
+	if !ok || !ok2 {
+		return Unknown(e.Line)
+	}
+	var n float64
+	switch e.Op {
+	case "<":
+		return BoolValue(a < b, e.Line)
+	case "<=":
+		return BoolValue(a <= b, e.Line)
+	case ">":
+		return BoolValue(a > b, e.Line)
+	case ">=":
+		return BoolValue(a >= b, e.Line)
+	case "+":
+		n = a + b
+	case "-":
+		n = a - b
+	case "*":
+		n = a * b
+	case "/":
+		if b == 0 {
+			return Unknown(e.Line)
+		}
+		n = a / b
+	case "%":
+		if b == 0 {
+			return Unknown(e.Line)
+		}
+		n = math.Mod(a, b)
+	}
+	return Value{Kind: NumberKind, Num: n, Line: e.Line}
+}
+
+func toNumber(v Value) (float64, bool) {
+	switch v.Kind {
+	case NumberKind:
+		return v.Num, true
+	case StringKind:
+		n, err := strconv.ParseFloat(v.Str, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// Equal reports whether two known values are equal
+func Equal(x, y Value) bool {
+	if x.Kind != y.Kind {
+		return false
+	}
+	switch x.Kind {
+	case NullKind:
+		return true
+	case ListKind:
+		if len(x.Items) != len(y.Items) {
+			return false
+		}
+		for i := range x.Items {
+			if !Equal(x.Items[i], y.Items[i]) {
+				return false
+			}
+		}
+		return true
+	case ObjectKind:
+		if len(x.Attrs) != len(y.Attrs) {
+			return false
+		}
+		for k, v := range x.Attrs {
+			if w, ok := y.Attrs[k]; !ok || !Equal(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+	s, _ := x.AsString()
+	t, _ := y.AsString()
+	return s == t
+}
+
+// evalCall evaluates the functions that are commonly used to build
+// resource arguments. Other functions return an unknown value.
+func evalCall(e *Call, ctx *Context) Value {
+	args := make([]Value, 0, len(e.Args))
+	for _, arg := range e.Args {
+		args = append(args, Eval(arg, ctx))
+	}
+	if e.Expand {
+		last := args[len(args)-1]
+		if last.Kind != ListKind {
+			return Unknown(e.Line)
+		}
+		args = append(args[:len(args)-1], last.Items...)
+	}
+
+	single := func() (Value, bool) {
+		if len(args) != 1 || !args[0].Known() {
+			return Value{}, false
+		}
+		return args[0], true
+	}
+	switch e.Name {
+	case "jsonencode":
+		if v, ok := single(); ok {
+			data, err := json.Marshal(ToJSON(v))
+			if err == nil {
+				return StringValue(string(data), e.Line)
+			}
+		}
+	case "jsondecode":
+		if v, ok := single(); ok && v.Kind == StringKind {
+			if decoded, ok := ParseJSON(v.Str, e.Line); ok {
+				return decoded
+			}
+		}
+	case "lower", "upper", "tostring", "trimspace":
+		if v, ok := single(); ok {
+			if s, ok := v.AsString(); ok {
+				switch e.Name {
+				case "lower":
+					s = strings.ToLower(s)
+				case "upper":
+					s = strings.ToUpper(s)
+				case "trimspace":
+					s = strings.TrimSpace(s)
+				}
+				return StringValue(s, e.Line)
+			}
+		}
+	case "tonumber":
+		if v, ok := single(); ok {
+			if n, ok := toNumber(v); ok {
+				return Value{Kind: NumberKind, Num: n, Line: e.Line}
+			}
+		}
+	case "tobool":
+		if v, ok := single(); ok {
+			if s, _ := v.AsString(); s == "true" || s == "false" {
+				return BoolValue(s == "true", e.Line)
+			}
+		}
+	case "tolist", "toset":
+		if v, ok := single(); ok && v.Kind == ListKind {
+			return v
+		}
+	case "concat":
+		var items []Value
+		for _, arg := range args {
+			if arg.Kind != ListKind {
+				return Unknown(e.Line)
+			}
+			items = append(items, arg.Items...)
+		}
+		return ListValue(items, e.Line)
+	case "merge":
+		object := ObjectValue(e.Line)
+		for _, arg := range args {
+			if arg.Kind == NullKind {
+				continue
+			}
+			if arg.Kind != ObjectKind {
+				return Unknown(e.Line)
+			}
+			for _, k := range arg.Keys {
+				object.Set(k, arg.Attrs[k])
+			}
+		}
+		return object
+	}
+	return Unknown(e.Line)
+}
+
+// ToJSON converts a known value to the types of encoding/json
+func ToJSON(v Value) any {
+	switch v.Kind {
+	case StringKind:
+		return v.Str
+	case NumberKind:
+		return v.Num
+	case BoolKind:
+		return v.Bool
+	case ListKind:
+		items := make([]any, 0, len(v.Items))
+		for _, item := range v.Items {
+			items = append(items, ToJSON(item))
+		}
+		return items
+	case ObjectKind:
+		object := make(map[string]any, len(v.Attrs))
+		for k, item := range v.Attrs {
+			object[k] = ToJSON(item)
+		}
+		return object
+	}
+	return nil
+}
+
+// ParseJSON decodes a JSON document, such as an IAM policy, into a value
+// whose items all have the given line
+func ParseJSON(s string, line int) (Value, bool) {
+	var data any
+	if err := json.Unmarshal([]byte(s), &data); err != nil {
+		return Value{}, false
+	}
+	return FromJSON(data, line), true
+}
+
+// FromJSON converts a decoded JSON value
+func FromJSON(data any, line int) Value {
+	switch d := data.(type) {
+	case string:
+		return StringValue(d, line)
+	case float64:
+		return Value{Kind: NumberKind, Num: d, Line: line}
+	case bool:
+		return BoolValue(d, line)
+	case []any:
+		items := make([]Value, 0, len(d))
+		for _, item := range d {
+			items = append(items, FromJSON(item, line))
+		}
+		return ListValue(items, line)
+	case map[string]any:
+		keys := make([]string, 0, len(d))
+		for k := range d {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		object := ObjectValue(line)
+		for _, k := range keys {
+			object.Set(k, FromJSON(d[k], line))
+		}
+		return object
+	}
+	return Value{Kind: NullKind, Line: line}
+}
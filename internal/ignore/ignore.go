@@ -0,0 +1,326 @@
+// Package ignore decides which files of a project are out of scope, with
+// the pattern syntax of gitignore. Patterns come from built-in defaults,
+// the DSO_IGNORE configuration entry and every .gitignore and .dsoignore
+// file of the project.
+package ignore
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dso-cli/dso-cli/internal/config"
+)
+
+// FileName is the ignore file specific to dso
+const FileName = ".dsoignore"
+
+// Defaults are the directories that never contain project sources. They
+// can be re-included with a negated pattern such as "!vendor/". Build
+// output directories are only ignored at the project root: a build or bin
+// package deeper in the tree is source code.
+var Defaults = []string{
+	".git/",
+	".dso/",
+	"node_modules/",
+	"vendor/",
+	".cache/",
+	"venv/",
+	".venv/",
+	"__pycache__/",
+	".pip/",
+	".npm/",
+	".yarn/",
+	"bower_components/",
+	".pnp/",
+	".nyc_output/",
+	"/dist/",
+	"/build/",
+	"/temp/",
+	"/tmp/",
+	"/out/",
+	"/target/",
+	"/bin/",
+	"/obj/",
+	"/coverage/",
+	"/logs/",
+}
+
+// maxExcludedFiles is the number of excluded files above which Excluded
+// only lists directories, to keep tool command lines short
+const maxExcludedFiles = 200
+
+// pattern is a compiled gitignore pattern
+type pattern struct {
+	base    string // Directory of the ignore file, "" for the project root
+	negate  bool
+	dirOnly bool
+	re      *regexp.Regexp
+}
+
+// Matcher matches paths relative to the project root against patterns
+type Matcher struct {
+	patterns []pattern
+	excluded []Entry
+}
+
+// Entry is an excluded path, relative to the project root
+type Entry struct {
+	Path  string // Slash-separated
+	IsDir bool
+}
+
+// New returns a matcher of patterns relative to the project root
+func New(patterns ...string) *Matcher {
+	m := &Matcher{}
+	m.Add("", patterns...)
+	return m
+}
+
+// Default returns a matcher of the built-in defaults only
+func Default() *Matcher {
+	return New(Defaults...)
+}
+
+// Load returns the matcher of the project in root: the defaults, the
+// DSO_IGNORE configuration entry, then the .gitignore and .dsoignore files
+// of each directory. It also lists the excluded paths, see Excluded.
+func Load(ctx context.Context, root string) (*Matcher, error) {
+	m := Default()
+	m.Add("", config.GetIgnorePatterns()...)
+
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		rel, relErr := filepath.Rel(root, p)
+		if relErr != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if rel == "." {
+			m.addFiles(p, "")
+			return nil
+		}
+		if m.Ignored(rel, d.IsDir()) {
+			m.excluded = append(m.excluded, Entry{Path: rel, IsDir: d.IsDir()})
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			m.addFiles(p, rel)
+		}
+		return nil
+	})
+	return m, err
+}
+
+// addFiles adds the patterns of the ignore files of a directory
+func (m *Matcher) addFiles(dir, rel string) {
+	base := rel
+	if base == "." {
+		base = ""
+	}
+	for _, name := range []string{".gitignore", FileName} {
+		if lines, err := readLines(filepath.Join(dir, name)); err == nil {
+			m.Add(base, lines...)
+		}
+	}
+}
+
+// Add adds gitignore patterns relative to base, a slash-separated
+// directory ("" for the project root). Later patterns take precedence.
+func (m *Matcher) Add(base string, lines ...string) {
+	for _, line := range lines {
+		if p, ok := compile(base, line); ok {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+}
+
+// Ignored reports whether a path is excluded by the patterns, without
+// looking at its parent directories (for walks that skip ignored directories)
+func (m *Matcher) Ignored(rel string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	ignored := false
+	for _, p := range m.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		sub := rel
+		if p.base != "" {
+			if !strings.HasPrefix(rel, p.base+"/") {
+				continue
+			}
+			sub = rel[len(p.base)+1:]
+		}
+		if p.re.MatchString(sub) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// Match reports whether a path is out of scope, either excluded itself or
+// inside an excluded directory
+func (m *Matcher) Match(rel string, isDir bool) bool {
+	rel = path.Clean(filepath.ToSlash(rel))
+	if m == nil || rel == "." || strings.HasPrefix(rel, "../") || path.IsAbs(rel) {
+		return false
+	}
+	// A file cannot be re-included if a parent directory is excluded
+	for i := 0; i < len(rel); i++ {
+		if rel[i] == '/' && m.Ignored(rel[:i], true) {
+			return true
+		}
+	}
+	return m.Ignored(rel, isDir)
+}
+
+// Excluded returns the excluded paths found by Load, top-most first: the
+// content of an excluded directory is not listed. Above a few hundred
+// paths, only directories are returned.
+func (m *Matcher) Excluded() []Entry {
+	if m == nil {
+		return nil
+	}
+	files := 0
+	for _, e := range m.excluded {
+		if !e.IsDir {
+			files++
+		}
+	}
+	if files <= maxExcludedFiles {
+		return m.excluded
+	}
+	var dirs []Entry
+	for _, e := range m.excluded {
+		if e.IsDir {
+			dirs = append(dirs, e)
+		}
+	}
+	return dirs
+}
+
+// compile parses a line of an ignore file
+func compile(base, line string) (pattern, bool) {
+	// Trailing spaces are ignored unless escaped
+	line = strings.TrimRight(line, "\r")
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return pattern{}, false
+	}
+
+	p := pattern{base: base}
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return pattern{}, false
+	}
+
+	// A separator at the beginning or in the middle anchors the pattern
+	// to the directory of the ignore file
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return pattern{}, false
+	}
+	p.re = re
+	return p, true
+}
+
+// globToRegexp converts a gitignore glob to a regular expression
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				atStart := i == 0 || glob[i-1] == '/'
+				switch {
+				case atStart && i+2 == len(glob):
+					// "foo/**" matches everything inside foo
+					sb.WriteString(".*")
+					i++
+					continue
+				case atStart && i+2 < len(glob) && glob[i+2] == '/':
+					// "**/foo" and "a/**/b" match zero or more directories
+					sb.WriteString("(?:.*/)?")
+					i += 2
+					continue
+				}
+				// Other consecutive asterisks are regular asterisks
+				for i+1 < len(glob) && glob[i+1] == '*' {
+					i++
+				}
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+func readLines(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	return lines, sc.Err()
+}
@@ -0,0 +1,39 @@
+package inventory
+
+import (
+	"strings"
+)
+
+// parseCargoLock reads the [[package]] entries of a Cargo.lock file.
+// Packages without a source are the workspace members themselves; the
+// packages they depend on are direct.
+func parseCargoLock(path string, data []byte) ([]Package, error) {
+	direct := make(map[string]bool)
+	var pkgs []Package
+
+	for _, table := range parseTOML(data) {
+		if table.name != "package" {
+			continue
+		}
+		name := tomlString(table.values["name"])
+		if _, ok := table.values["source"]; !ok {
+			for _, dep := range tomlStrings(table.values["dependencies"]) {
+				// "name", "name version" or "name version (source)"
+				if fields := strings.Fields(dep); len(fields) > 0 {
+					direct[fields[0]] = true
+				}
+			}
+			continue
+		}
+		pkgs = append(pkgs, Package{
+			Ecosystem: EcosystemCargo,
+			Name:      name,
+			Version:   tomlString(table.values["version"]),
+		})
+	}
+
+	for i := range pkgs {
+		pkgs[i].Direct = direct[pkgs[i].Name]
+	}
+	return pkgs, nil
+}
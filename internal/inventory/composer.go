@@ -0,0 +1,52 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// parseComposerLock reads the packages and packages-dev lists of a
+// composer.lock file. Direct dependencies are the ones required by the
+// composer.json next to it.
+func parseComposerLock(path string, data []byte) ([]Package, error) {
+	type composerPackage struct {
+		Name    string          `json:"name"`
+		Version string          `json:"version"`
+		License json.RawMessage `json:"license"`
+	}
+	var lock struct {
+		Packages    []composerPackage `json:"packages"`
+		PackagesDev []composerPackage `json:"packages-dev"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("invalid composer.lock: %w", err)
+	}
+
+	direct := make(map[string]bool)
+	if manifest := readSibling(path, "composer.json"); manifest != nil {
+		var composer struct {
+			Require    map[string]string `json:"require"`
+			RequireDev map[string]string `json:"require-dev"`
+		}
+		if json.Unmarshal(manifest, &composer) == nil {
+			for name := range composer.Require {
+				direct[name] = true
+			}
+			for name := range composer.RequireDev {
+				direct[name] = true
+			}
+		}
+	}
+
+	var pkgs []Package
+	for _, p := range append(lock.Packages, lock.PackagesDev...) {
+		pkgs = append(pkgs, Package{
+			Ecosystem: EcosystemPackagist,
+			Name:      p.Name,
+			Version:   p.Version,
+			Direct:    direct[p.Name],
+			License:   declaredLicense(p.License),
+		})
+	}
+	return pkgs, nil
+}
@@ -0,0 +1,189 @@
+package inventory
+
+import (
+	"strings"
+)
+
+// parseGoMod reads the require and replace directives of a go.mod file.
+// Requirements marked "// indirect" are transitive.
+func parseGoMod(path string, data []byte) ([]Package, error) {
+	var pkgs []Package
+	replaces := make(map[string][2]string) // module path -> replacement path, version
+
+	block := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		indirect := strings.Contains(line, "// indirect")
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if block != "" {
+			if fields[0] == ")" {
+				block = ""
+				continue
+			}
+			fields = append([]string{block}, fields...)
+		} else if len(fields) == 2 && fields[1] == "(" {
+			block = fields[0]
+			continue
+		}
+
+		switch fields[0] {
+		case "require":
+			if len(fields) >= 3 {
+				pkgs = append(pkgs, Package{
+					Ecosystem: EcosystemGo,
+					Name:      fields[1],
+					Version:   fields[2],
+					Direct:    !indirect,
+				})
+			}
+		case "replace":
+			// replace old [version] => new [version]
+			arrow := indexOf(fields, "=>")
+			if arrow < 2 || arrow+1 >= len(fields) {
+				continue
+			}
+			version := ""
+			if arrow+2 < len(fields) {
+				version = fields[arrow+2]
+			}
+			replaces[fields[1]] = [2]string{fields[arrow+1], version}
+		}
+	}
+
+	result := pkgs[:0]
+	for _, p := range pkgs {
+		if r, ok := replaces[p.Name]; ok {
+			if r[1] == "" {
+				// Replaced by a local directory, which is part of the project
+				continue
+			}
+			p.Name, p.Version = r[0], r[1]
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+// parseGoSum reads the modules whose content is checksummed in go.sum.
+// When a go.mod file is next to it, the modules it requires are left to the
+// go.mod parser and the remaining ones are reported as transitive.
+func parseGoSum(path string, data []byte) ([]Package, error) {
+	required := make(map[string]bool)
+	if goMod := readSibling(path, "go.mod"); goMod != nil {
+		mods, _ := parseGoMod(path, goMod)
+		for _, m := range mods {
+			required[m.Name] = true
+		}
+	}
+
+	// go.sum also keeps the hashes of versions that were not selected:
+	// report the highest one, as minimal version selection would
+	versions := make(map[string]string)
+	var order []string
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || strings.HasSuffix(fields[1], "/go.mod") {
+			// Hashes of go.mod files only are needed for the module graph
+			continue
+		}
+		if required[fields[0]] {
+			continue
+		}
+		version, seen := versions[fields[0]]
+		if !seen {
+			order = append(order, fields[0])
+		}
+		if !seen || compareGoVersions(fields[1], version) > 0 {
+			versions[fields[0]] = fields[1]
+		}
+	}
+
+	var pkgs []Package
+	for _, name := range order {
+		pkgs = append(pkgs, Package{
+			Ecosystem: EcosystemGo,
+			Name:      name,
+			Version:   versions[name],
+		})
+	}
+	return pkgs, nil
+}
+
+// compareGoVersions compares two module versions in semantic versioning
+// order: v1.10.0 is above v1.9.0, and a pre-release or pseudo-version is
+// below its release. The +incompatible suffix is ignored.
+func compareGoVersions(a, b string) int {
+	a, _, _ = strings.Cut(strings.TrimPrefix(a, "v"), "+")
+	b, _, _ = strings.Cut(strings.TrimPrefix(b, "v"), "+")
+	coreA, preA, hasPreA := strings.Cut(a, "-")
+	coreB, preB, hasPreB := strings.Cut(b, "-")
+
+	if c := compareIdentifiers(strings.Split(coreA, "."), strings.Split(coreB, ".")); c != 0 {
+		return c
+	}
+	switch {
+	case hasPreA && !hasPreB:
+		return -1
+	case !hasPreA && hasPreB:
+		return 1
+	}
+	return compareIdentifiers(strings.Split(preA, "."), strings.Split(preB, "."))
+}
+
+// compareIdentifiers compares dot-separated version identifiers: numbers
+// numerically and below the alphanumeric ones, compared in ASCII order
+func compareIdentifiers(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		numA, numB := isNumber(a[i]), isNumber(b[i])
+		switch {
+		case numA && !numB:
+			return -1
+		case !numA && numB:
+			return 1
+		case numA && len(a[i]) != len(b[i]):
+			// Without leading zeros, the longer number is the larger
+			if len(a[i]) < len(b[i]) {
+				return -1
+			}
+			return 1
+		}
+		if c := strings.Compare(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+// isNumber reports whether s is a non-empty string of ASCII digits
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func indexOf(fields []string, s string) int {
+	for i, f := range fields {
+		if f == s {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,244 @@
+// Package inventory builds a normalized package inventory from the lockfiles
+// and manifests of a project, without relying on external tools.
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Ecosystem identifies a package ecosystem. Values match the OSV ecosystem names.
+type Ecosystem string
+
+const (
+	EcosystemGo        Ecosystem = "Go"
+	EcosystemNPM       Ecosystem = "npm"
+	EcosystemPyPI      Ecosystem = "PyPI"
+	EcosystemCargo     Ecosystem = "crates.io"
+	EcosystemPackagist Ecosystem = "Packagist"
+	EcosystemRubyGems  Ecosystem = "RubyGems"
+	EcosystemMaven     Ecosystem = "Maven"
+)
+
+// purlTypes maps ecosystems to their package URL type
+var purlTypes = map[Ecosystem]string{
+	EcosystemGo:        "golang",
+	EcosystemNPM:       "npm",
+	EcosystemPyPI:      "pypi",
+	EcosystemCargo:     "cargo",
+	EcosystemPackagist: "composer",
+	EcosystemRubyGems:  "gem",
+	EcosystemMaven:     "maven",
+}
+
+// Package is a dependency found in a lockfile or manifest
+type Package struct {
+	Ecosystem Ecosystem `json:"ecosystem"`
+	Name      string    `json:"name"`
+	Version   string    `json:"version,omitempty"`
+	// Direct is true when the project depends on the package itself,
+	// false when it is only pulled in by another dependency
+	Direct bool `json:"direct"`
+	// Source is the slash-separated path of the file the package was read
+	// from, relative to the scanned directory
+	Source string `json:"source"`
+	// License is the license declared by the lockfile, as written there;
+	// most lockfiles do not record it
+	License string `json:"license,omitempty"`
+}
+
+// PURL returns the package URL of the package
+func (p Package) PURL() string {
+	name := p.Name
+	if p.Ecosystem == EcosystemMaven {
+		name = strings.Replace(name, ":", "/", 1)
+	}
+	if p.Ecosystem == EcosystemNPM {
+		name = strings.Replace(name, "@", "%40", 1)
+	}
+	purl := fmt.Sprintf("pkg:%s/%s", purlTypes[p.Ecosystem], name)
+	if p.Version != "" {
+		purl += "@" + p.Version
+	}
+	return purl
+}
+
+// parseFunc parses the lockfile at path. The returned packages have no Source.
+type parseFunc func(path string, data []byte) ([]Package, error)
+
+// parsers maps lockfile and manifest names to their parser
+var parsers = map[string]parseFunc{
+	"go.mod":            parseGoMod,
+	"go.sum":            parseGoSum,
+	"package-lock.json": parsePackageLock,
+	"yarn.lock":         parseYarnLock,
+	"pnpm-lock.yaml":    parsePnpmLock,
+	"poetry.lock":       parsePoetryLock,
+	"Pipfile.lock":      parsePipfileLock,
+	"requirements.txt":  parseRequirements,
+	"Cargo.lock":        parseCargoLock,
+	"composer.lock":     parseComposerLock,
+	"Gemfile.lock":      parseGemfileLock,
+}
+
+// skipDirs are directories whose lockfiles do not belong to the project
+var skipDirs = map[string]bool{
+	"node_modules":     true,
+	"vendor":           true,
+	"bower_components": true,
+	".git":             true,
+	".venv":            true,
+	"venv":             true,
+	"__pycache__":      true,
+	"target":           true,
+	"dist":             true,
+	"build":            true,
+}
+
+// parserFor returns the parser for a file name, or nil if the file is not a
+// supported lockfile. requirements-*.txt files are parsed like requirements.txt.
+func parserFor(name string) parseFunc {
+	if parse, ok := parsers[name]; ok {
+		return parse
+	}
+	if strings.HasPrefix(name, "requirements") && strings.HasSuffix(name, ".txt") {
+		return parseRequirements
+	}
+	return nil
+}
+
+// IsLockfile reports whether name is a lockfile or manifest read by the inventory
+func IsLockfile(name string) bool {
+	return parserFor(filepath.Base(name)) != nil
+}
+
+// Load walks root and returns the packages of every supported lockfile,
+// ordered by source file. Files that cannot be parsed are reported in the
+// returned error; the packages of the other files are still returned.
+func Load(ctx context.Context, root string) ([]Package, error) {
+	return LoadSkipping(ctx, root, func(rel string, isDir bool) bool {
+		return isDir && skipDirs[filepath.Base(rel)]
+	})
+}
+
+// LoadSkipping is like Load, with the files and directories to skip
+// decided by skip instead of the default directory list. rel is the
+// slash-separated path relative to root.
+func LoadSkipping(ctx context.Context, root string, skip func(rel string, isDir bool) bool) ([]Package, error) {
+	var packages []Package
+	var errs []error
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if path == root && info.IsDir() {
+			return nil
+		}
+		rel, relErr := filepath.Rel(root, path)
+		if relErr != nil {
+			return nil
+		}
+		if skip(filepath.ToSlash(rel), info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() || parserFor(info.Name()) == nil {
+			return nil
+		}
+
+		pkgs, parseErr := ParseFile(path)
+		if parseErr != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.ToSlash(rel), parseErr))
+			return nil
+		}
+		for i := range pkgs {
+			pkgs[i].Source = filepath.ToSlash(rel)
+		}
+		packages = append(packages, pkgs...)
+		return nil
+	})
+	if err != nil {
+		return packages, err
+	}
+
+	return packages, errors.Join(errs...)
+}
+
+// ParseFile parses a single lockfile or manifest. The returned packages are
+// sorted by name and have no Source.
+func ParseFile(path string) ([]Package, error) {
+	parse := parserFor(filepath.Base(path))
+	if parse == nil {
+		return nil, fmt.Errorf("unsupported lockfile: %s", filepath.Base(path))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pkgs, err := parse(path, data)
+	if err != nil {
+		return nil, err
+	}
+	return dedupe(pkgs), nil
+}
+
+// dedupe removes duplicate name/version pairs, keeping a package direct if
+// any of its occurrences is and the first declared license, and sorts the
+// result
+func dedupe(pkgs []Package) []Package {
+	index := make(map[string]int, len(pkgs))
+	var result []Package
+	for _, p := range pkgs {
+		if p.Name == "" {
+			continue
+		}
+		key := string(p.Ecosystem) + "|" + p.Name + "|" + p.Version
+		if i, ok := index[key]; ok {
+			result[i].Direct = result[i].Direct || p.Direct
+			if result[i].License == "" {
+				result[i].License = p.License
+			}
+			continue
+		}
+		index[key] = len(result)
+		result = append(result, p)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Version < result[j].Version
+	})
+	return result
+}
+
+// readSibling reads a file next to path, returning nil if it does not exist
+func readSibling(path, name string) []byte {
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(path), name))
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// unquote removes the quotes around a YAML or TOML scalar
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,223 @@
+package inventory
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dso-cli/dso-cli/internal/license"
+)
+
+// InstalledLicense looks up the license of p in the metadata of the
+// installed package: node_modules, Python virtual environments, the Go
+// vendor directory and module cache, the Cargo registry and the Composer
+// vendor directory. root is the scanned directory. It returns the license
+// as declared there and the file it was read from, or "" when the package
+// is not installed or declares no license. The license of Go modules, which
+// have no license metadata, is identified from their LICENSE file.
+func InstalledLicense(root string, p Package) (declared, file string) {
+	dir := filepath.Join(root, filepath.FromSlash(filepath.Dir(p.Source)))
+	switch p.Ecosystem {
+	case EcosystemNPM:
+		return manifestLicense(filepath.Join(dir, "node_modules", filepath.FromSlash(p.Name), "package.json"))
+	case EcosystemPackagist:
+		return manifestLicense(filepath.Join(dir, "vendor", filepath.FromSlash(p.Name), "composer.json"))
+	case EcosystemPyPI:
+		return pythonLicense(dir, p)
+	case EcosystemGo:
+		return goLicense(dir, p)
+	case EcosystemCargo:
+		return cargoLicense(p)
+	}
+	return "", ""
+}
+
+// manifestLicense reads the "license" or legacy "licenses" field of a
+// package.json or composer.json file
+func manifestLicense(path string) (string, string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", ""
+	}
+	var manifest struct {
+		License  json.RawMessage `json:"license"`
+		Licenses json.RawMessage `json:"licenses"`
+	}
+	if json.Unmarshal(data, &manifest) != nil {
+		return "", ""
+	}
+	declared := declaredLicense(manifest.License)
+	if declared == "" {
+		declared = declaredLicense(manifest.Licenses)
+	}
+	if declared == "" {
+		return "", ""
+	}
+	return declared, path
+}
+
+// pythonLicense reads the METADATA file of the distribution installed in
+// a .venv or venv directory next to the lockfile
+func pythonLicense(dir string, p Package) (string, string) {
+	name := normalizePythonName(p.Name)
+	for _, venv := range []string{".venv", "venv"} {
+		patterns := []string{
+			filepath.Join(dir, venv, "lib", "python*", "site-packages", "*.dist-info"),
+			filepath.Join(dir, venv, "Lib", "site-packages", "*.dist-info"),
+		}
+		for _, pattern := range patterns {
+			infos, _ := filepath.Glob(pattern)
+			for _, info := range infos {
+				// <name>-<version>.dist-info
+				base := strings.TrimSuffix(filepath.Base(info), ".dist-info")
+				i := strings.LastIndex(base, "-")
+				if i < 0 || normalizePythonName(base[:i]) != name {
+					continue
+				}
+				path := filepath.Join(info, "METADATA")
+				if declared := pythonMetadataLicense(path); declared != "" {
+					return declared, path
+				}
+			}
+		}
+	}
+	return "", ""
+}
+
+// pythonMetadataLicense reads the license of a core metadata file: the
+// License-Expression field, else the License field, else the license
+// classifiers
+func pythonMetadataLicense(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	var expression, field string
+	var classifiers []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break // End of the headers, the description follows
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "License-Expression":
+			expression = value
+		case "License":
+			field = value
+		case "Classifier":
+			// "License :: OSI Approved :: MIT License"
+			if parts := strings.Split(value, " :: "); len(parts) > 2 && parts[0] == "License" {
+				classifiers = append(classifiers, parts[len(parts)-1])
+			}
+		}
+	}
+
+	switch {
+	case expression != "":
+		return expression
+	case field != "" && field != "UNKNOWN" && len(field) < 100:
+		// Old packages put the whole license text in the field
+		return field
+	case len(classifiers) > 0:
+		return license.Join(classifiers)
+	}
+	return ""
+}
+
+// goLicense identifies the LICENSE file of a Go module, in the vendor
+// directory next to go.mod or in the module cache
+func goLicense(dir string, p Package) (string, string) {
+	dirs := []string{filepath.Join(dir, "vendor", filepath.FromSlash(p.Name))}
+	if cache := goModCache(); cache != "" && p.Version != "" {
+		dirs = append(dirs, filepath.Join(cache, filepath.FromSlash(escapeModulePath(p.Name))+"@"+p.Version))
+	}
+	for _, d := range dirs {
+		entries, err := os.ReadDir(d)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !license.IsLicenseFile(entry.Name()) {
+				continue
+			}
+			path := filepath.Join(d, entry.Name())
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			if id := license.Identify(string(data)); id != "" {
+				return id, path
+			}
+		}
+	}
+	return "", ""
+}
+
+// goModCache returns the Go module cache directory, without running go
+func goModCache() string {
+	if cache := os.Getenv("GOMODCACHE"); cache != "" {
+		return cache
+	}
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		gopath = filepath.Join(home, "go")
+	}
+	return filepath.Join(filepath.SplitList(gopath)[0], "pkg", "mod")
+}
+
+// escapeModulePath escapes the upper case letters of a module path like
+// the module cache does: "github.com/BurntSushi" is "github.com/!burnt!sushi"
+func escapeModulePath(path string) string {
+	var sb strings.Builder
+	for _, r := range path {
+		if r >= 'A' && r <= 'Z' {
+			sb.WriteByte('!')
+			r += 'a' - 'A'
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// cargoLicense reads the license of a crate downloaded to the Cargo
+// registry
+func cargoLicense(p Package) (string, string) {
+	home := os.Getenv("CARGO_HOME")
+	if home == "" {
+		userHome, err := os.UserHomeDir()
+		if err != nil {
+			return "", ""
+		}
+		home = filepath.Join(userHome, ".cargo")
+	}
+
+	manifests, _ := filepath.Glob(filepath.Join(home, "registry", "src", "*", p.Name+"-"+p.Version, "Cargo.toml"))
+	for _, path := range manifests {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		for _, table := range parseTOML(data) {
+			if table.name == "package" {
+				if declared := tomlString(table.values["license"]); declared != "" {
+					return declared, path
+				}
+			}
+		}
+	}
+	return "", ""
+}
@@ -0,0 +1,331 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dso-cli/dso-cli/internal/license"
+)
+
+// npmDirectDependencies returns the dependency names declared in the
+// package.json next to path
+func npmDirectDependencies(path string) map[string]bool {
+	direct := make(map[string]bool)
+	data := readSibling(path, "package.json")
+	if data == nil {
+		return direct
+	}
+
+	var manifest map[string]json.RawMessage
+	if json.Unmarshal(data, &manifest) != nil {
+		return direct
+	}
+	for _, key := range []string{"dependencies", "devDependencies", "optionalDependencies", "peerDependencies"} {
+		var deps map[string]string
+		if raw, ok := manifest[key]; ok && json.Unmarshal(raw, &deps) == nil {
+			for name := range deps {
+				direct[name] = true
+			}
+		}
+	}
+	return direct
+}
+
+// declaredLicense reads a "license" field of package metadata: an SPDX
+// expression, a legacy {"type": ...} object, or a list of licenses to
+// choose from
+func declaredLicense(raw json.RawMessage) string {
+	var expr string
+	if json.Unmarshal(raw, &expr) == nil {
+		return strings.TrimSpace(expr)
+	}
+	var legacy struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(raw, &legacy) == nil {
+		return strings.TrimSpace(legacy.Type)
+	}
+	var list []json.RawMessage
+	if json.Unmarshal(raw, &list) != nil {
+		return ""
+	}
+	var licenses []string
+	for _, item := range list {
+		if l := declaredLicense(item); l != "" {
+			licenses = append(licenses, l)
+		}
+	}
+	if len(licenses) == 1 {
+		return licenses[0]
+	}
+	return license.Join(licenses)
+}
+
+// packageLock covers the lockfileVersion 1 "dependencies" tree and the
+// lockfileVersion 2 and 3 "packages" map
+type packageLock struct {
+	LockfileVersion int                           `json:"lockfileVersion"`
+	Packages        map[string]packageLockPackage `json:"packages"`
+	Dependencies    map[string]packageLockDep     `json:"dependencies"`
+}
+
+type packageLockPackage struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Link                 bool              `json:"link"`
+	License              json.RawMessage   `json:"license"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+}
+
+type packageLockDep struct {
+	Version      string                    `json:"version"`
+	Dependencies map[string]packageLockDep `json:"dependencies"`
+}
+
+// parsePackageLock reads an npm package-lock.json (lockfileVersion 1 to 3)
+func parsePackageLock(path string, data []byte) ([]Package, error) {
+	var lock packageLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("invalid package-lock.json: %w", err)
+	}
+
+	var pkgs []Package
+	if len(lock.Packages) > 0 {
+		direct := make(map[string]bool)
+		if root, ok := lock.Packages[""]; ok {
+			for _, deps := range []map[string]string{root.Dependencies, root.DevDependencies, root.OptionalDependencies} {
+				for name := range deps {
+					direct[name] = true
+				}
+			}
+		}
+
+		for key, p := range lock.Packages {
+			i := strings.LastIndex(key, "node_modules/")
+			if i < 0 || p.Link || p.Version == "" {
+				// The root project, workspaces and links are not dependencies
+				continue
+			}
+			name := key[i+len("node_modules/"):]
+			if p.Name != "" {
+				name = p.Name
+			}
+			pkgs = append(pkgs, Package{
+				Ecosystem: EcosystemNPM,
+				Name:      name,
+				Version:   p.Version,
+				// Only packages installed at the top level can be direct
+				Direct:  i == 0 && direct[name],
+				License: declaredLicense(p.License),
+			})
+		}
+		return pkgs, nil
+	}
+
+	direct := npmDirectDependencies(path)
+	var walk func(deps map[string]packageLockDep, top bool)
+	walk = func(deps map[string]packageLockDep, top bool) {
+		for name, dep := range deps {
+			version := dep.Version
+			if strings.HasPrefix(version, "npm:") {
+				// Aliased package: "npm:real-name@version"
+				alias := strings.TrimPrefix(version, "npm:")
+				if at := strings.LastIndex(alias, "@"); at > 0 {
+					name, version = alias[:at], alias[at+1:]
+				}
+			}
+			if !strings.Contains(version, ":") && !strings.Contains(version, "/") {
+				// Versions such as "file:../lib" or git URLs are not from the registry
+				pkgs = append(pkgs, Package{
+					Ecosystem: EcosystemNPM,
+					Name:      name,
+					Version:   version,
+					Direct:    top && direct[name],
+				})
+			}
+			walk(dep.Dependencies, false)
+		}
+	}
+	walk(lock.Dependencies, true)
+	return pkgs, nil
+}
+
+// parseYarnLock reads a yarn.lock file, both the classic (v1) format and
+// the YAML format of yarn 2 and later
+func parseYarnLock(path string, data []byte) ([]Package, error) {
+	direct := npmDirectDependencies(path)
+
+	var pkgs []Package
+	name := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if line[0] != ' ' {
+			// Entry header: "lodash@^4.17.0, lodash@^4.17.21:"
+			name = ""
+			spec := strings.TrimSuffix(strings.TrimSpace(line), ":")
+			spec = unquote(strings.TrimSpace(strings.SplitN(spec, ",", 2)[0]))
+			if strings.Contains(spec, "@workspace:") || strings.Contains(spec, "@link:") || strings.Contains(spec, "@portal:") {
+				continue
+			}
+			if at := strings.LastIndex(spec, "@"); at > 0 {
+				name = spec[:at]
+			}
+			continue
+		}
+
+		// Only the "version" field of the entry, not a dependency named version*
+		if name == "" || !(strings.HasPrefix(line, "  version ") || strings.HasPrefix(line, "  version:")) {
+			continue
+		}
+		version := strings.TrimPrefix(strings.TrimSpace(line), "version")
+		version = unquote(strings.TrimPrefix(strings.TrimSpace(version), ":"))
+		if version == "" || version == "0.0.0-use.local" {
+			continue
+		}
+		if at := strings.LastIndex(name, "@npm:"); at > 0 {
+			// yarn 2 descriptor: "lodash@npm:^4.17.21"
+			name = name[:at]
+		}
+		pkgs = append(pkgs, Package{
+			Ecosystem: EcosystemNPM,
+			Name:      name,
+			Version:   version,
+			Direct:    direct[name],
+		})
+		name = ""
+	}
+	return pkgs, nil
+}
+
+// parsePnpmLock reads a pnpm-lock.yaml file (lockfile formats 5 to 9).
+// Direct dependencies are the ones listed by the root project or, in a
+// workspace, by any importer.
+func parsePnpmLock(path string, data []byte) ([]Package, error) {
+	direct := make(map[string]bool)
+	var keys []string
+
+	var stack []yamlKey
+	for _, line := range strings.Split(string(data), "\n") {
+		key, ok := parseYAMLKey(line)
+		if !ok {
+			continue
+		}
+		for len(stack) > 0 && stack[len(stack)-1].indent >= key.indent {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, key)
+
+		path := make([]string, len(stack))
+		for i, k := range stack {
+			path[i] = k.name
+		}
+
+		switch {
+		case len(path) == 2 && path[0] == "packages":
+			keys = append(keys, path[1])
+		case len(path) == 2 && isPnpmDependencyGroup(path[0]):
+			direct[path[1]] = true
+		case len(path) == 4 && path[0] == "importers" && isPnpmDependencyGroup(path[2]):
+			direct[path[3]] = true
+		}
+	}
+
+	var pkgs []Package
+	for _, key := range keys {
+		name, version := parsePnpmPackageKey(key)
+		if name == "" || version == "" {
+			continue
+		}
+		pkgs = append(pkgs, Package{
+			Ecosystem: EcosystemNPM,
+			Name:      name,
+			Version:   version,
+			Direct:    direct[name],
+		})
+	}
+	return pkgs, nil
+}
+
+func isPnpmDependencyGroup(key string) bool {
+	return key == "dependencies" || key == "devDependencies" || key == "optionalDependencies"
+}
+
+// parsePnpmPackageKey splits a key of the pnpm "packages" map into name and
+// version. Keys look like "/lodash/4.17.21" (v5), "/lodash@4.17.21" (v6)
+// or "lodash@4.17.21" (v9), optionally followed by a peer suffix.
+func parsePnpmPackageKey(key string) (string, string) {
+	key = strings.TrimPrefix(key, "/")
+	if i := strings.Index(key, "("); i >= 0 {
+		key = key[:i]
+	}
+	if strings.Contains(key, ":") {
+		// Tarball, git or file dependency
+		return "", ""
+	}
+
+	// Skip the scope so that the separator found below follows the name
+	scope := 0
+	if strings.HasPrefix(key, "@") {
+		scope = strings.Index(key, "/") + 1
+	}
+	rest := key[scope:]
+
+	if slash := strings.Index(rest, "/"); slash > 0 {
+		// v5: name/version, with an optional "_peer" suffix
+		version := rest[slash+1:]
+		if i := strings.Index(version, "_"); i >= 0 {
+			version = version[:i]
+		}
+		return key[:scope+slash], version
+	}
+	if at := strings.Index(rest, "@"); at > 0 {
+		return key[:scope+at], rest[at+1:]
+	}
+	return "", ""
+}
+
+// yamlKey is a mapping key of a block-style YAML document
+type yamlKey struct {
+	indent int
+	name   string
+	value  string
+}
+
+// parseYAMLKey parses a "key: value" line. It only supports the subset of
+// YAML written by lockfile generators: block mappings with plain or quoted keys.
+func parseYAMLKey(line string) (yamlKey, bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "- ") {
+		return yamlKey{}, false
+	}
+
+	indent := len(line) - len(trimmed)
+	var name, rest string
+	if trimmed[0] == '"' || trimmed[0] == '\'' {
+		end := strings.IndexByte(trimmed[1:], trimmed[0])
+		if end < 0 {
+			return yamlKey{}, false
+		}
+		name, rest = trimmed[1:end+1], trimmed[end+2:]
+		if !strings.HasPrefix(rest, ":") {
+			return yamlKey{}, false
+		}
+		rest = rest[1:]
+	} else {
+		i := strings.Index(trimmed, ": ")
+		if i < 0 {
+			if !strings.HasSuffix(trimmed, ":") {
+				return yamlKey{}, false
+			}
+			i = len(trimmed) - 1
+		}
+		name, rest = trimmed[:i], trimmed[i+1:]
+	}
+	return yamlKey{indent: indent, name: name, value: unquote(rest)}, true
+}
@@ -0,0 +1,204 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// pythonNameRegex matches the project name at the start of a PEP 508 requirement
+var pythonNameRegex = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)`)
+
+var pythonNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// normalizePythonName normalizes a project name as described in PEP 503
+func normalizePythonName(name string) string {
+	return strings.ToLower(pythonNameSeparators.ReplaceAllString(name, "-"))
+}
+
+// pythonRequirementName returns the normalized name of a PEP 508 requirement
+func pythonRequirementName(req string) string {
+	m := pythonNameRegex.FindStringSubmatch(strings.TrimSpace(req))
+	if m == nil {
+		return ""
+	}
+	return normalizePythonName(m[1])
+}
+
+// pyprojectDirectDependencies returns the normalized names of the
+// dependencies declared in the pyproject.toml next to path, either in the
+// PEP 621 [project] table or in the Poetry tables
+func pyprojectDirectDependencies(path string) map[string]bool {
+	direct := make(map[string]bool)
+	data := readSibling(path, "pyproject.toml")
+	if data == nil {
+		return direct
+	}
+
+	for _, table := range parseTOML(data) {
+		switch {
+		case table.name == "project":
+			for _, req := range tomlStrings(table.values["dependencies"]) {
+				direct[pythonRequirementName(req)] = true
+			}
+		case table.name == "project.optional-dependencies":
+			for _, value := range table.values {
+				for _, req := range tomlStrings(value) {
+					direct[pythonRequirementName(req)] = true
+				}
+			}
+		case table.name == "tool.poetry.dependencies" || table.name == "tool.poetry.dev-dependencies" ||
+			(strings.HasPrefix(table.name, "tool.poetry.group.") && strings.HasSuffix(table.name, ".dependencies")):
+			for _, key := range table.keys {
+				if key != "python" {
+					direct[normalizePythonName(key)] = true
+				}
+			}
+		}
+	}
+	return direct
+}
+
+// parsePoetryLock reads the [[package]] entries of a poetry.lock file
+func parsePoetryLock(path string, data []byte) ([]Package, error) {
+	direct := pyprojectDirectDependencies(path)
+
+	var pkgs []Package
+	for _, table := range parseTOML(data) {
+		if table.name != "package" {
+			continue
+		}
+		name := normalizePythonName(tomlString(table.values["name"]))
+		pkgs = append(pkgs, Package{
+			Ecosystem: EcosystemPyPI,
+			Name:      name,
+			Version:   tomlString(table.values["version"]),
+			Direct:    direct[name],
+		})
+	}
+	return pkgs, nil
+}
+
+// parsePipfileLock reads the default and develop sections of a Pipfile.lock.
+// Direct dependencies are the ones declared in the Pipfile next to it.
+func parsePipfileLock(path string, data []byte) ([]Package, error) {
+	var lock map[string]json.RawMessage
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("invalid Pipfile.lock: %w", err)
+	}
+
+	direct := make(map[string]bool)
+	if pipfile := readSibling(path, "Pipfile"); pipfile != nil {
+		for _, table := range parseTOML(pipfile) {
+			if table.name == "packages" || table.name == "dev-packages" {
+				for _, key := range table.keys {
+					direct[normalizePythonName(key)] = true
+				}
+			}
+		}
+	}
+
+	var pkgs []Package
+	for _, section := range []string{"default", "develop"} {
+		var deps map[string]struct {
+			Version string `json:"version"`
+		}
+		if raw, ok := lock[section]; !ok || json.Unmarshal(raw, &deps) != nil {
+			continue
+		}
+		for name, dep := range deps {
+			name = normalizePythonName(name)
+			pkgs = append(pkgs, Package{
+				Ecosystem: EcosystemPyPI,
+				Name:      name,
+				Version:   strings.TrimPrefix(dep.Version, "=="),
+				Direct:    direct[name],
+			})
+		}
+	}
+	return pkgs, nil
+}
+
+// parseRequirements reads a pip requirements file. Only exact pins (==)
+// give a version. In files generated by pip-compile, requirements annotated
+// with "# via" other packages are transitive; all others are direct.
+func parseRequirements(path string, data []byte) ([]Package, error) {
+	var pkgs []Package
+	var via []string // annotations of the last requirement
+
+	flush := func() {
+		if len(pkgs) == 0 || len(via) == 0 {
+			return
+		}
+		direct := false
+		for _, v := range via {
+			if strings.HasPrefix(v, "-r ") || strings.HasPrefix(v, "-c ") {
+				direct = true
+			}
+		}
+		pkgs[len(pkgs)-1].Direct = direct
+		via = nil
+	}
+
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		for strings.HasSuffix(line, `\`) && i+1 < len(lines) {
+			i++
+			line = strings.TrimSuffix(line, `\`) + " " + strings.TrimSpace(lines[i])
+		}
+
+		if strings.HasPrefix(line, "#") {
+			comment := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+			switch {
+			case strings.HasPrefix(comment, "via "):
+				via = append(via, strings.TrimSpace(strings.TrimPrefix(comment, "via ")))
+			case comment == "via":
+				via = append(via, "")
+			case len(via) > 0 && comment != "":
+				via = append(via, comment)
+			}
+			continue
+		}
+		if line == "" {
+			continue
+		}
+
+		flush()
+		via = nil
+		if strings.HasPrefix(line, "-") || strings.Contains(line, "://") {
+			// Options, nested requirement files and URL requirements
+			continue
+		}
+		if j := strings.Index(line, " #"); j >= 0 {
+			line = line[:j]
+		}
+		if j := strings.Index(line, ";"); j >= 0 {
+			// Environment markers
+			line = line[:j]
+		}
+
+		name := pythonRequirementName(line)
+		if name == "" {
+			continue
+		}
+		version := ""
+		if j := strings.Index(line, "=="); j >= 0 {
+			fields := strings.FieldsFunc(strings.TrimLeft(line[j+2:], "="), func(r rune) bool {
+				return r == ',' || r == ' '
+			})
+			if len(fields) > 0 && !strings.Contains(fields[0], "*") {
+				version = fields[0]
+			}
+		}
+		pkgs = append(pkgs, Package{
+			Ecosystem: EcosystemPyPI,
+			Name:      name,
+			Version:   version,
+			Direct:    true,
+		})
+	}
+	flush()
+	return pkgs, nil
+}
@@ -0,0 +1,59 @@
+package inventory
+
+import (
+	"strings"
+)
+
+// parseGemfileLock reads the specs of the GEM, GIT and PATH sections of a
+// Gemfile.lock. Direct dependencies are listed in its DEPENDENCIES section.
+func parseGemfileLock(path string, data []byte) ([]Package, error) {
+	direct := make(map[string]bool)
+	var pkgs []Package
+
+	section := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line[0] != ' ' {
+			section = strings.TrimSpace(line)
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		name, version := parseGemSpec(strings.TrimSpace(line))
+		switch section {
+		case "GEM", "GIT", "PATH":
+			// Specs are indented by 4 spaces, their dependencies by 6
+			if indent == 4 && version != "" {
+				pkgs = append(pkgs, Package{
+					Ecosystem: EcosystemRubyGems,
+					Name:      name,
+					Version:   version,
+				})
+			}
+		case "DEPENDENCIES":
+			direct[strings.TrimSuffix(name, "!")] = true
+		}
+	}
+
+	for i := range pkgs {
+		pkgs[i].Direct = direct[pkgs[i].Name]
+	}
+	return pkgs, nil
+}
+
+// parseGemSpec splits "name (version)" into its parts. Platform suffixes
+// such as "-x86_64-linux" are removed from the version.
+func parseGemSpec(spec string) (string, string) {
+	open := strings.Index(spec, " (")
+	if open < 0 {
+		return spec, ""
+	}
+	version := strings.TrimSuffix(spec[open+2:], ")")
+	if i := strings.Index(version, "-"); i >= 0 {
+		version = version[:i]
+	}
+	return spec[:open], version
+}
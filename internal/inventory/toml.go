@@ -0,0 +1,120 @@
+package inventory
+
+import (
+	"strings"
+)
+
+// tomlTable is a table or array-of-tables element of a TOML document.
+// Values are kept as raw TOML text; multi-line arrays are joined.
+type tomlTable struct {
+	name   string
+	values map[string]string
+	keys   []string // in document order
+}
+
+// parseTOML splits a TOML document into its tables. It only supports the
+// subset of TOML used by lockfiles and package manifests: the values are
+// not decoded, see tomlString and tomlStrings.
+func parseTOML(data []byte) []*tomlTable {
+	root := &tomlTable{values: make(map[string]string)}
+	tables := []*tomlTable{root}
+	current := root
+
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			name := strings.Trim(line, "[] ")
+			current = &tomlTable{name: name, values: make(map[string]string)}
+			tables = append(tables, current)
+			continue
+		}
+
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			continue
+		}
+		key := unquote(strings.TrimSpace(line[:eq]))
+		value := strings.TrimSpace(line[eq+1:])
+
+		// Join multi-line arrays and strings
+		for (strings.HasPrefix(value, "[") && bracketDepth(value) > 0) ||
+			(strings.HasPrefix(value, `"""`) && strings.Count(value, `"""`) == 1) {
+			i++
+			if i >= len(lines) {
+				break
+			}
+			value += " " + strings.TrimSpace(lines[i])
+		}
+
+		if _, exists := current.values[key]; !exists {
+			current.keys = append(current.keys, key)
+		}
+		current.values[key] = value
+	}
+	return tables
+}
+
+// bracketDepth returns the number of unclosed brackets in s, ignoring the
+// brackets inside strings and comments
+func bracketDepth(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return depth
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		}
+	}
+	return depth
+}
+
+// tomlString decodes a basic or literal TOML string value
+func tomlString(value string) string {
+	strs := tomlStrings(value)
+	if len(strs) == 0 {
+		return ""
+	}
+	return strs[0]
+}
+
+// tomlStrings returns the strings found in a raw TOML value, such as the
+// elements of an array of strings
+func tomlStrings(value string) []string {
+	var strs []string
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c == '#' {
+			break
+		}
+		if c != '"' && c != '\'' {
+			continue
+		}
+		var sb strings.Builder
+		for i++; i < len(value) && value[i] != c; i++ {
+			if value[i] == '\\' && c == '"' && i+1 < len(value) {
+				i++
+			}
+			sb.WriteByte(value[i])
+		}
+		strs = append(strs, sb.String())
+	}
+	return strs
+}
@@ -0,0 +1,80 @@
+package license
+
+import (
+	"regexp"
+	"strings"
+)
+
+// licenseFileRegex matches the names of license files
+var licenseFileRegex = regexp.MustCompile(`(?i)^((un)?licen[cs]e|copying(\.lesser)?)([-_][a-z0-9.-]+)?(\.(md|txt|rst))?$`)
+
+// IsLicenseFile reports whether name is the name of a license file, like
+// LICENSE, LICENSE.md, LICENCE-MIT or COPYING
+func IsLicenseFile(name string) bool {
+	return licenseFileRegex.MatchString(name)
+}
+
+// fileSignature identifies a license by phrases of its text, which must
+// all appear
+type fileSignature struct {
+	id  string
+	all []string
+}
+
+// fileSignatures are checked in order, the most specific texts first.
+// Phrases are lowercase with single spaces.
+var fileSignatures = []fileSignature{
+	{id: "AGPL-3.0-only", all: []string{"gnu affero general public license", "version 3"}},
+	{id: "LGPL-3.0-only", all: []string{"gnu lesser general public license", "version 3"}},
+	{id: "LGPL-2.1-only", all: []string{"gnu lesser general public license", "version 2.1"}},
+	{id: "LGPL-2.0-only", all: []string{"gnu library general public license", "version 2"}},
+	{id: "GPL-3.0-only", all: []string{"gnu general public license", "version 3"}},
+	{id: "GPL-2.0-only", all: []string{"gnu general public license", "version 2"}},
+	{id: "SSPL-1.0", all: []string{"server side public license"}},
+	{id: "BUSL-1.1", all: []string{"business source license 1.1"}},
+	{id: "MPL-2.0", all: []string{"mozilla public license", "2.0"}},
+	{id: "EPL-2.0", all: []string{"eclipse public license - v 2.0"}},
+	{id: "EPL-1.0", all: []string{"eclipse public license - v 1.0"}},
+	{id: "EUPL-1.2", all: []string{"european union public licence", "v. 1.2"}},
+	{id: "CDDL-1.1", all: []string{"common development and distribution license", "version 1.1"}},
+	{id: "CDDL-1.0", all: []string{"common development and distribution license", "version 1.0"}},
+	{id: "Apache-2.0", all: []string{"apache license", "version 2.0"}},
+	{id: "BSL-1.0", all: []string{"boost software license - version 1.0"}},
+	{id: "Unlicense", all: []string{"this is free and unencumbered software released into the public domain"}},
+	{id: "CC0-1.0", all: []string{"cc0 1.0 universal"}},
+	{id: "WTFPL", all: []string{"do what the fuck you want to public license"}},
+	{id: "BlueOak-1.0.0", all: []string{"blue oak model license"}},
+	{id: "UPL-1.0", all: []string{"universal permissive license"}},
+	{id: "Zlib", all: []string{"this software is provided 'as-is', without any express or implied warranty", "permission is granted to anyone to use this software for any purpose"}},
+	{id: "ISC", all: []string{"permission to use, copy, modify, and/or distribute this software for any purpose with or without fee is hereby granted", "provided that the above copyright notice and this permission notice appear in all copies"}},
+	{id: "0BSD", all: []string{"permission to use, copy, modify, and/or distribute this software for any purpose with or without fee is hereby granted"}},
+	{id: "MIT", all: []string{"permission is hereby granted, free of charge, to any person obtaining a copy", "the above copyright notice and this permission notice shall be included"}},
+	{id: "MIT-0", all: []string{"permission is hereby granted, free of charge, to any person obtaining a copy"}},
+	{id: "BSD-4-Clause", all: []string{"redistribution and use in source and binary forms", "all advertising materials mentioning features"}},
+	{id: "BSD-3-Clause", all: []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{id: "BSD-2-Clause", all: []string{"redistribution and use in source and binary forms"}},
+}
+
+// Identify returns the SPDX identifier of the license of a license file
+// text, or "" when it is not recognized. Files holding several licenses,
+// like the LICENSE of dual-licensed projects, are identified by the first
+// matching one only.
+func Identify(text string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(text)), " ")
+	normalized = strings.NewReplacer("’", "'", "‘", "'").Replace(normalized)
+	for _, sig := range fileSignatures {
+		if containsAll(normalized, sig.all) {
+			return sig.id
+		}
+	}
+	return ""
+}
+
+func containsAll(s string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if !strings.Contains(s, phrase) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+package license
+
+import (
+	"path"
+	"strings"
+)
+
+// Decision is the result of the evaluation of a license against a policy
+type Decision string
+
+const (
+	Allowed Decision = "allowed"
+	Review  Decision = "review"  // Needs a legal review before use
+	Unknown Decision = "unknown" // Declared license that is not a recognized SPDX expression
+	Denied  Decision = "denied"
+)
+
+// decisionRank orders decisions from the most to the least acceptable
+var decisionRank = map[Decision]int{Allowed: 0, Review: 1, Unknown: 2, Denied: 3}
+
+// DefaultReview are the licenses to review when the project configures no
+// review list: strong and network copyleft, and non-open source licenses
+var DefaultReview = []string{"AGPL-*", "GPL-*", "SSPL-*", "BUSL-*", "CC-BY-NC-*", "CPAL-*", "OSL-*", "EUPL-*"}
+
+// Policy holds the license lists of a project. Entries are SPDX
+// identifiers or patterns such as "GPL-*", matched case-insensitively; an
+// entry may also name a license with its exception
+// ("GPL-2.0-only WITH Classpath-exception-2.0").
+type Policy struct {
+	Allow  []string `json:"allow,omitempty"`
+	Deny   []string `json:"deny,omitempty"`
+	Review []string `json:"review,omitempty"`
+}
+
+// Verdict is the evaluation of a license expression
+type Verdict struct {
+	Decision Decision `json:"decision"`
+	// License is the license that decided, "" for an unknown license
+	License string `json:"license,omitempty"`
+	// Rule is the policy entry that matched License, "" when no entry did
+	Rule string `json:"rule,omitempty"`
+	// List is the list of Rule: allow, deny or review
+	List string `json:"list,omitempty"`
+}
+
+// Evaluate evaluates a license expression. A single license is denied,
+// reviewed or allowed by the first list that matches it, in that order.
+// When an allow list is set, the other licenses need a review; otherwise
+// they are allowed. A choice (OR) gets the most acceptable verdict of its
+// licenses, a combination (AND) the least acceptable one.
+func (p *Policy) Evaluate(expr *Expression) Verdict {
+	if expr == nil {
+		return Verdict{Decision: Unknown}
+	}
+	if expr.Op == "" {
+		return p.evaluateLicense(expr)
+	}
+
+	var verdict Verdict
+	for i, operand := range expr.Operands {
+		v := p.Evaluate(operand)
+		better := decisionRank[v.Decision] < decisionRank[verdict.Decision]
+		if expr.Op == "AND" {
+			better = decisionRank[v.Decision] > decisionRank[verdict.Decision]
+		}
+		if i == 0 || better {
+			verdict = v
+		}
+	}
+	return verdict
+}
+
+// EvaluateDeclared evaluates a declared license, see Parse
+func (p *Policy) EvaluateDeclared(declared string) Verdict {
+	expr, err := Parse(declared)
+	if err != nil {
+		return Verdict{Decision: Unknown}
+	}
+	return p.Evaluate(expr)
+}
+
+func (p *Policy) evaluateLicense(expr *Expression) Verdict {
+	license := expr.License()
+	lists := []struct {
+		name     string
+		entries  []string
+		decision Decision
+	}{
+		{"deny", p.Deny, Denied},
+		{"review", p.Review, Review},
+		{"allow", p.Allow, Allowed},
+	}
+	for _, list := range lists {
+		if rule := matchLicense(list.entries, expr); rule != "" {
+			return Verdict{Decision: list.decision, License: license, Rule: rule, List: list.name}
+		}
+	}
+	if len(p.Allow) > 0 {
+		return Verdict{Decision: Review, License: license}
+	}
+	return Verdict{Decision: Allowed, License: license}
+}
+
+// matchLicense returns the first entry matching the license of expr, with
+// its exception first, then alone
+func matchLicense(entries []string, expr *Expression) string {
+	candidates := []string{expr.License(), expr.ID}
+	for _, entry := range entries {
+		pattern := strings.ToLower(strings.TrimSpace(entry))
+		for _, candidate := range candidates {
+			if ok, _ := path.Match(pattern, strings.ToLower(candidate)); ok {
+				return entry
+			}
+		}
+	}
+	return ""
+}
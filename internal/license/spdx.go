@@ -0,0 +1,436 @@
+// Package license normalizes declared licenses to SPDX expressions,
+// identifies license files, and evaluates licenses against the allow, deny
+// and review lists of a project.
+package license
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// spdxIDs are the SPDX license identifiers recognized by the normalizer,
+// the common licenses of package registries. Keys are lowercase.
+var spdxIDs = indexIDs(
+	"0BSD", "AFL-2.1", "AFL-3.0", "AGPL-1.0-only", "AGPL-1.0-or-later", "AGPL-3.0-only", "AGPL-3.0-or-later",
+	"Apache-1.0", "Apache-1.1", "Apache-2.0", "APSL-2.0", "Artistic-1.0", "Artistic-2.0",
+	"Beerware", "BlueOak-1.0.0", "BSD-1-Clause", "BSD-2-Clause", "BSD-2-Clause-Patent", "BSD-3-Clause",
+	"BSD-3-Clause-Clear", "BSD-4-Clause", "BSL-1.0", "BUSL-1.1",
+	"CC-BY-3.0", "CC-BY-4.0", "CC-BY-NC-4.0", "CC-BY-NC-SA-4.0", "CC-BY-ND-4.0", "CC-BY-SA-3.0", "CC-BY-SA-4.0", "CC0-1.0",
+	"CDDL-1.0", "CDDL-1.1", "CECILL-2.1", "CPAL-1.0", "CPL-1.0", "curl", "ECL-2.0", "EPL-1.0", "EPL-2.0",
+	"EUPL-1.1", "EUPL-1.2", "GFDL-1.3-only", "GFDL-1.3-or-later",
+	"GPL-1.0-only", "GPL-1.0-or-later", "GPL-2.0-only", "GPL-2.0-or-later", "GPL-3.0-only", "GPL-3.0-or-later",
+	"HPND", "ICU", "IJG", "ISC", "JSON",
+	"LGPL-2.0-only", "LGPL-2.0-or-later", "LGPL-2.1-only", "LGPL-2.1-or-later", "LGPL-3.0-only", "LGPL-3.0-or-later",
+	"Libpng", "MIT", "MIT-0", "MPL-1.0", "MPL-1.1", "MPL-2.0", "MPL-2.0-no-copyleft-exception", "MS-PL", "MS-RL", "MulanPSL-2.0",
+	"NCSA", "ODbL-1.0", "OFL-1.1", "OLDAP-2.8", "OpenSSL", "OSL-3.0", "PHP-3.01", "PostgreSQL", "PSF-2.0", "Python-2.0",
+	"Ruby", "SSPL-1.0", "Unicode-3.0", "Unicode-DFS-2016", "Unlicense", "UPL-1.0", "Vim", "W3C", "WTFPL",
+	"X11", "Zlib", "ZPL-2.1",
+)
+
+// spdxExceptions are the SPDX exception identifiers accepted after WITH
+var spdxExceptions = indexIDs(
+	"Autoconf-exception-3.0", "Bison-exception-2.2", "Classpath-exception-2.0", "GCC-exception-3.1",
+	"LLVM-exception", "OpenJDK-assembly-exception-1.0", "openvpn-openssl-exception", "Universal-FOSS-exception-1.0",
+)
+
+// deprecatedIDs maps deprecated SPDX identifiers to their replacement
+var deprecatedIDs = map[string]string{
+	"agpl-1.0":                         "AGPL-1.0-only",
+	"agpl-3.0":                         "AGPL-3.0-only",
+	"gpl-1.0":                          "GPL-1.0-only",
+	"gpl-2.0":                          "GPL-2.0-only",
+	"gpl-3.0":                          "GPL-3.0-only",
+	"lgpl-2.0":                         "LGPL-2.0-only",
+	"lgpl-2.1":                         "LGPL-2.1-only",
+	"lgpl-3.0":                         "LGPL-3.0-only",
+	"gfdl-1.3":                         "GFDL-1.3-only",
+	"gpl-2.0-with-classpath-exception": "GPL-2.0-only WITH Classpath-exception-2.0",
+}
+
+// aliases maps the license names found in package metadata to SPDX
+// expressions. Keys are lowercase, with runs of spaces, dashes and
+// underscores collapsed to a single space (see aliasKey).
+var aliases = map[string]string{
+	"mit license":     "MIT",
+	"the mit license": "MIT",
+	"mit licence":     "MIT",
+	"expat":           "MIT",
+	"mit/x11":         "MIT",
+
+	"apache":                          "Apache-2.0",
+	"apache 2":                        "Apache-2.0",
+	"apache 2.0":                      "Apache-2.0",
+	"apache2":                         "Apache-2.0",
+	"apache license":                  "Apache-2.0",
+	"apache license 2.0":              "Apache-2.0",
+	"apache license v2":               "Apache-2.0",
+	"apache license v2.0":             "Apache-2.0",
+	"apache license version 2.0":      "Apache-2.0",
+	"apache license, version 2.0":     "Apache-2.0",
+	"the apache license, version 2.0": "Apache-2.0",
+	"the apache software license, version 2.0": "Apache-2.0",
+	"apache software license":                  "Apache-2.0",
+	"apache software license 2.0":              "Apache-2.0",
+	"asl 2.0":                                  "Apache-2.0",
+	"asf 2.0":                                  "Apache-2.0",
+
+	// "BSD" alone is ambiguous; the 3-clause variant is the most common
+	"bsd":             "BSD-3-Clause",
+	"bsd license":     "BSD-3-Clause",
+	"new bsd":         "BSD-3-Clause",
+	"new bsd license": "BSD-3-Clause",
+	"bsd new":         "BSD-3-Clause",
+	"modified bsd":    "BSD-3-Clause",
+	"bsd 3 clause":    "BSD-3-Clause",
+	"3 clause bsd":    "BSD-3-Clause",
+	"bsd 3":           "BSD-3-Clause",
+	"simplified bsd":  "BSD-2-Clause",
+	"freebsd":         "BSD-2-Clause",
+	"bsd 2 clause":    "BSD-2-Clause",
+	"2 clause bsd":    "BSD-2-Clause",
+	"bsd 2":           "BSD-2-Clause",
+
+	"isc license":        "ISC",
+	"isc license (iscl)": "ISC",
+	"iscl":               "ISC",
+
+	// Without a version, any version of the GPL family may be chosen
+	"gpl":                                   "GPL-1.0-or-later",
+	"gnu gpl":                               "GPL-1.0-or-later",
+	"gplv2":                                 "GPL-2.0-only",
+	"gpl v2":                                "GPL-2.0-only",
+	"gpl 2":                                 "GPL-2.0-only",
+	"gplv2+":                                "GPL-2.0-or-later",
+	"gplv3":                                 "GPL-3.0-only",
+	"gpl v3":                                "GPL-3.0-only",
+	"gpl 3":                                 "GPL-3.0-only",
+	"gplv3+":                                "GPL-3.0-or-later",
+	"gnu general public license v2 (gplv2)": "GPL-2.0-only",
+	"gnu general public license v2 or later (gplv2+)": "GPL-2.0-or-later",
+	"gnu general public license v3 (gplv3)":           "GPL-3.0-only",
+	"gnu general public license v3 or later (gplv3+)": "GPL-3.0-or-later",
+	"gnu general public license (gpl)":                "GPL-1.0-or-later",
+
+	"lgpl":     "LGPL-2.0-or-later",
+	"gnu lgpl": "LGPL-2.0-or-later",
+	"lgplv2":   "LGPL-2.0-only",
+	"lgplv2+":  "LGPL-2.0-or-later",
+	"lgplv2.1": "LGPL-2.1-only",
+	"lgplv3":   "LGPL-3.0-only",
+	"lgplv3+":  "LGPL-3.0-or-later",
+	"gnu lesser general public license v2 (lgplv2)":           "LGPL-2.0-only",
+	"gnu lesser general public license v2 or later (lgplv2+)": "LGPL-2.0-or-later",
+	"gnu lesser general public license v3 (lgplv3)":           "LGPL-3.0-only",
+	"gnu lesser general public license v3 or later (lgplv3+)": "LGPL-3.0-or-later",
+	"gnu library or lesser general public license (lgpl)":     "LGPL-2.0-or-later",
+
+	"agpl":                                 "AGPL-3.0-or-later",
+	"agplv3":                               "AGPL-3.0-only",
+	"agplv3+":                              "AGPL-3.0-or-later",
+	"gnu affero general public license v3": "AGPL-3.0-only",
+	"gnu affero general public license v3 or later (agplv3+)": "AGPL-3.0-or-later",
+
+	"mozilla public license 2.0":           "MPL-2.0",
+	"mozilla public license 2.0 (mpl 2.0)": "MPL-2.0",
+	"mpl 2.0":                              "MPL-2.0",
+	"mpl2":                                 "MPL-2.0",
+
+	"eclipse public license 1.0":           "EPL-1.0",
+	"eclipse public license 2.0":           "EPL-2.0",
+	"eclipse public license v2.0":          "EPL-2.0",
+	"eclipse public license 2.0 (epl 2.0)": "EPL-2.0",
+
+	"boost software license 1.0 (bsl 1.0)": "BSL-1.0",
+	"boost":                                "BSL-1.0",
+	"python software foundation license":   "PSF-2.0",
+	"psf":                                  "PSF-2.0",
+	"psfl":                                 "PSF-2.0",
+
+	"the unlicense":             "Unlicense",
+	"the unlicense (unlicense)": "Unlicense",
+	"unlicence":                 "Unlicense",
+	"cc0":                       "CC0-1.0",
+	"cc0 1.0 universal (cc0 1.0) public domain dedication": "CC0-1.0",
+	"zlib/libpng":                                  "Zlib",
+	"zlib/libpng license":                          "Zlib",
+	"zlib license":                                 "Zlib",
+	"the postgresql license":                       "PostgreSQL",
+	"universal permissive license (upl)":           "UPL-1.0",
+	"european union public licence 1.2 (eupl 1.2)": "EUPL-1.2",
+	"server side public license":                   "SSPL-1.0",
+	"sspl":                                         "SSPL-1.0",
+	"wtfpl":                                        "WTFPL",
+	"ruby's":                                       "Ruby",
+	"artistic":                                     "Artistic-2.0",
+}
+
+// aliasSeparators are collapsed in alias keys
+var aliasSeparators = regexp.MustCompile(`[\s_-]+`)
+
+// aliasKey returns the aliases key of a license name
+func aliasKey(name string) string {
+	return aliasSeparators.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), " ")
+}
+
+func indexIDs(ids ...string) map[string]string {
+	index := make(map[string]string, len(ids))
+	for _, id := range ids {
+		index[strings.ToLower(id)] = id
+	}
+	return index
+}
+
+// Expression is a parsed SPDX license expression. A single license has an
+// ID and optionally an exception; a compound expression has an operator
+// (AND, OR) and at least two operands.
+type Expression struct {
+	ID        string        `json:"id,omitempty"`
+	Exception string        `json:"exception,omitempty"`
+	Op        string        `json:"op,omitempty"`
+	Operands  []*Expression `json:"operands,omitempty"`
+}
+
+// License returns the single license with its exception, like
+// "GPL-2.0-only WITH Classpath-exception-2.0"
+func (e *Expression) License() string {
+	if e.Exception != "" {
+		return e.ID + " WITH " + e.Exception
+	}
+	return e.ID
+}
+
+// String returns the expression in SPDX syntax. AND binds tighter than
+// OR, so only OR operands of an AND are parenthesized.
+func (e *Expression) String() string {
+	if e.Op == "" {
+		return e.License()
+	}
+	parts := make([]string, len(e.Operands))
+	for i, operand := range e.Operands {
+		parts[i] = operand.String()
+		if e.Op == "AND" && operand.Op == "OR" {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, " "+e.Op+" ")
+}
+
+// IDs returns the license identifiers of the expression, in order
+func (e *Expression) IDs() []string {
+	if e.Op == "" {
+		return []string{e.ID}
+	}
+	var ids []string
+	for _, operand := range e.Operands {
+		ids = append(ids, operand.IDs()...)
+	}
+	return ids
+}
+
+// ErrUnknownLicense is returned by Parse for a license that is neither an
+// SPDX identifier nor a known license name
+var ErrUnknownLicense = errors.New("unknown license")
+
+// Parse parses a declared license: an SPDX expression, possibly with
+// deprecated identifiers ("GPL-2.0+"), the legacy "MIT/Apache-2.0" syntax,
+// or a common license name ("Apache License, Version 2.0").
+func Parse(declared string) (*Expression, error) {
+	declared = strings.TrimSpace(declared)
+	if declared == "" {
+		return nil, ErrUnknownLicense
+	}
+	if expr, ok := aliases[aliasKey(declared)]; ok {
+		declared = expr
+	}
+
+	p := &parser{tokens: tokenize(declared)}
+	expr, err := p.or()
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", declared, err)
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("%q: unexpected %q", declared, p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+// Normalize returns the SPDX expression of a declared license, or "" when
+// it cannot be recognized
+func Normalize(declared string) string {
+	expr, err := Parse(declared)
+	if err != nil {
+		return ""
+	}
+	return expr.String()
+}
+
+// Join combines several declared licenses of a package, which may be
+// chosen from: composer and legacy npm metadata list them separately
+func Join(declared []string) string {
+	var parts []string
+	for _, d := range declared {
+		if d = strings.TrimSpace(d); d != "" {
+			if strings.ContainsAny(d, " /") {
+				d = "(" + d + ")"
+			}
+			parts = append(parts, d)
+		}
+	}
+	return strings.Join(parts, " OR ")
+}
+
+// tokenize splits an expression into parentheses, operators and licenses.
+// "/" is the OR of legacy Cargo metadata.
+func tokenize(s string) []string {
+	var tokens []string
+	for _, field := range strings.Fields(s) {
+		start := 0
+		for i := 0; i < len(field); i++ {
+			if c := field[i]; c == '(' || c == ')' || c == '/' {
+				if i > start {
+					tokens = append(tokens, field[start:i])
+				}
+				token := string(c)
+				if c == '/' {
+					token = "OR"
+				}
+				tokens = append(tokens, token)
+				start = i + 1
+			}
+		}
+		if start < len(field) {
+			tokens = append(tokens, field[start:])
+		}
+	}
+	return tokens
+}
+
+// parser is a recursive descent parser of SPDX expressions:
+//
+//	or   = and { "OR" and }
+//	and  = term { "AND" term }
+//	term = "(" or ")" | license [ "WITH" exception ]
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) or() (*Expression, error) {
+	return p.binary("OR", p.and)
+}
+
+func (p *parser) and() (*Expression, error) {
+	return p.binary("AND", p.term)
+}
+
+// binary parses operands of next separated by op, flattening nested
+// operations of the same operator
+func (p *parser) binary(op string, next func() (*Expression, error)) (*Expression, error) {
+	first, err := next()
+	if err != nil {
+		return nil, err
+	}
+	expr := &Expression{Op: op}
+	add := func(e *Expression) {
+		if e.Op == op {
+			expr.Operands = append(expr.Operands, e.Operands...)
+		} else {
+			expr.Operands = append(expr.Operands, e)
+		}
+	}
+	add(first)
+	for strings.EqualFold(p.peek(), op) {
+		p.pos++
+		operand, err := next()
+		if err != nil {
+			return nil, err
+		}
+		add(operand)
+	}
+	if len(expr.Operands) == 1 {
+		return expr.Operands[0], nil
+	}
+	return expr, nil
+}
+
+func (p *parser) term() (*Expression, error) {
+	token := p.peek()
+	switch {
+	case token == "":
+		return nil, errors.New("unexpected end of expression")
+	case token == "(":
+		p.pos++
+		expr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, errors.New("missing )")
+		}
+		p.pos++
+		return expr, nil
+	case token == ")" || isOperator(token):
+		return nil, fmt.Errorf("unexpected %q", token)
+	}
+
+	p.pos++
+	expr, err := licenseID(token)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(p.peek(), "WITH") {
+		p.pos++
+		exception, ok := spdxExceptions[strings.ToLower(p.peek())]
+		if !ok {
+			return nil, fmt.Errorf("unknown license exception %q", p.peek())
+		}
+		p.pos++
+		expr.Exception = exception
+	}
+	return expr, nil
+}
+
+func isOperator(token string) bool {
+	return strings.EqualFold(token, "AND") || strings.EqualFold(token, "OR") || strings.EqualFold(token, "WITH")
+}
+
+// licenseID returns the license of an identifier. A trailing "+" means
+// "or any later version", and LicenseRef- identifiers are kept as is.
+func licenseID(token string) (*Expression, error) {
+	if strings.HasPrefix(token, "LicenseRef-") {
+		return &Expression{ID: token}, nil
+	}
+	key := strings.ToLower(token)
+	orLater := strings.HasSuffix(key, "+")
+	key = strings.TrimSuffix(key, "+")
+
+	id, ok := spdxIDs[key]
+	if replacement, deprecated := deprecatedIDs[key]; deprecated {
+		if strings.Contains(replacement, " WITH ") {
+			return Parse(replacement)
+		}
+		id, ok = replacement, true
+	}
+	if !ok {
+		if alias, known := aliases[aliasKey(token)]; known && !strings.ContainsAny(alias, " ") {
+			id, ok = alias, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownLicense, token)
+	}
+	if orLater && (strings.HasSuffix(id, "-only") || strings.HasSuffix(id, "-or-later")) {
+		// Only the GNU licenses have an "or later" identifier
+		id = strings.TrimSuffix(strings.TrimSuffix(id, "-only"), "-or-later") + "-or-later"
+	}
+	return &Expression{ID: id}, nil
+}
@@ -136,6 +136,12 @@ Respond in JSON with this structure:
 func formatScanResultsForAI(results *scanner.ScanResults, projectPath string) string {
 	var sb strings.Builder
 	sb.WriteString("Project: " + projectPath + "\n")
+	if results.Diff != nil {
+		sb.WriteString("Scope: only the changes, " + results.Diff.Describe() + "\n")
+	}
+	if results.Image != nil {
+		sb.WriteString("Scope: container image " + results.Image.Describe() + ", files are paths inside the image\n")
+	}
 	sb.WriteString("Total findings: " + strconv.Itoa(results.Summary.Total) + "\n")
 	sb.WriteString("Critical: " + strconv.Itoa(results.Summary.Critical) +
 		", High: " + strconv.Itoa(results.Summary.High) +
@@ -147,7 +153,7 @@ func formatScanResultsForAI(results *scanner.ScanResults, projectPath string) st
 	high := []scanner.Finding{}
 	medium := []scanner.Finding{}
 
-	for _, f := range results.Findings {
+	for _, f := range results.Active() {
 		switch f.Severity {
 		case scanner.SeverityCritical:
 			critical = append(critical, f)
@@ -172,14 +178,14 @@ func formatScanResultsForAI(results *scanner.ScanResults, projectPath string) st
 
 	sb.WriteString("=== CRITICAL ===\n")
 	for _, f := range critical {
-		sb.WriteString(fmt.Sprintf("- [%s] %s\n  File: %s:%d\n  %s\n\n",
-			f.ID, f.Title, f.File, f.Line, f.Description))
+		sb.WriteString(fmt.Sprintf("- [%s] %s\n  File: %s:%d\n%s%s  %s\n\n",
+			f.ID, f.Title, f.File, f.Line, formatPackageForAI(f.Package), formatCommitForAI(f.Commit), f.Description))
 	}
 
 	sb.WriteString("=== HIGH ===\n")
 	for _, f := range high {
-		sb.WriteString(fmt.Sprintf("- [%s] %s\n  File: %s:%d\n  %s\n\n",
-			f.ID, f.Title, f.File, f.Line, f.Description))
+		sb.WriteString(fmt.Sprintf("- [%s] %s\n  File: %s:%d\n%s%s  %s\n\n",
+			f.ID, f.Title, f.File, f.Line, formatPackageForAI(f.Package), formatCommitForAI(f.Commit), f.Description))
 	}
 
 	sb.WriteString("=== MEDIUM (top 10) ===\n")
@@ -194,6 +200,47 @@ func formatScanResultsForAI(results *scanner.ScanResults, projectPath string) st
 	return sb.String()
 }
 
+// formatPackageForAI describes the vulnerable package of a finding, so that
+// the AI recommends the exact upgrade instead of guessing it
+func formatPackageForAI(pkg *scanner.Package) string {
+	if pkg == nil || pkg.Name == "" {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("  Package: " + pkg.Name)
+	if pkg.InstalledVersion != "" {
+		sb.WriteString(" " + pkg.InstalledVersion)
+	}
+	if pkg.Ecosystem != "" {
+		sb.WriteString(" (" + string(pkg.Ecosystem) + ")")
+	}
+	sb.WriteString("\n")
+	if len(pkg.FixedVersions) > 0 {
+		sb.WriteString("  Fixed in: " + strings.Join(pkg.FixedVersions, ", ") + "\n")
+	}
+	if fix := pkg.UpgradeCommand(); fix != "" {
+		sb.WriteString("  Upgrade: " + fix + "\n")
+	}
+	if len(pkg.Path) > 1 {
+		sb.WriteString("  Dependency path: " + strings.Join(pkg.Path, " > ") + "\n")
+	} else if pkg.Direct() {
+		sb.WriteString("  Direct dependency\n")
+	}
+	return sb.String()
+}
+
+// formatCommitForAI tells whether a secret found in the git history is still
+// in the code, so that the AI does not ask to delete a line that is gone
+func formatCommitForAI(c *scanner.Commit) string {
+	if c == nil {
+		return ""
+	}
+	if c.InHead {
+		return fmt.Sprintf("  Commit: %s (still in HEAD, also in the git history)\n", c.SHA)
+	}
+	return fmt.Sprintf("  Commit: %s (history only: already removed from the code, rotate the secret and rewrite the history)\n", c.SHA)
+}
+
 // parseAIResponse parses the AI response and returns the analysis result.
 func parseAIResponse(response string, results *scanner.ScanResults) (result *AnalysisResult, err error) {
 	// Try to parse as JSON first
@@ -226,7 +273,7 @@ func createFallbackAnalysis(results *scanner.ScanResults) *AnalysisResult {
 
 	// Extract top 3 critical/high findings
 	count := 0
-	for _, f := range results.Findings {
+	for _, f := range results.Active() {
 		if count >= 3 {
 			break
 		}
@@ -234,6 +281,7 @@ func createFallbackAnalysis(results *scanner.ScanResults) *AnalysisResult {
 			analysis.TopFixes = append(analysis.TopFixes, Fix{
 				Title:       f.Title,
 				Description: f.Description,
+				Command:     f.Fix,
 				File:        f.File,
 				Line:        f.Line,
 				Priority:    count + 1,
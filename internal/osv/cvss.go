@@ -0,0 +1,90 @@
+package osv
+
+import (
+	"math"
+	"strings"
+)
+
+// cvss3Weights are the metric weights of the CVSS v3.x base score
+var cvss3Weights = map[string]map[string]float64{
+	"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+	"AC": {"L": 0.77, "H": 0.44},
+	"UI": {"N": 0.85, "R": 0.62},
+	"C":  {"H": 0.56, "L": 0.22, "N": 0},
+	"I":  {"H": 0.56, "L": 0.22, "N": 0},
+	"A":  {"H": 0.56, "L": 0.22, "N": 0},
+}
+
+// CVSS3BaseScore computes the base score of a CVSS v3.0 or v3.1 vector such
+// as "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"
+func CVSS3BaseScore(vector string) (float64, bool) {
+	parts := strings.Split(vector, "/")
+	if len(parts) == 0 || !strings.HasPrefix(parts[0], "CVSS:3") {
+		return 0, false
+	}
+
+	metrics := make(map[string]string)
+	for _, part := range parts[1:] {
+		if k, v, ok := strings.Cut(part, ":"); ok {
+			metrics[k] = v
+		}
+	}
+
+	changed := metrics["S"] == "C"
+	weight := func(metric string) (float64, bool) {
+		if metric == "PR" {
+			switch metrics["PR"] {
+			case "N":
+				return 0.85, true
+			case "L":
+				if changed {
+					return 0.68, true
+				}
+				return 0.62, true
+			case "H":
+				if changed {
+					return 0.5, true
+				}
+				return 0.27, true
+			}
+			return 0, false
+		}
+		w, ok := cvss3Weights[metric][metrics[metric]]
+		return w, ok
+	}
+
+	values := make(map[string]float64)
+	for _, metric := range []string{"AV", "AC", "PR", "UI", "C", "I", "A"} {
+		w, ok := weight(metric)
+		if !ok {
+			return 0, false
+		}
+		values[metric] = w
+	}
+
+	iss := 1 - (1-values["C"])*(1-values["I"])*(1-values["A"])
+	var impact float64
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	} else {
+		impact = 6.42 * iss
+	}
+	if impact <= 0 {
+		return 0, true
+	}
+
+	exploitability := 8.22 * values["AV"] * values["AC"] * values["PR"] * values["UI"]
+	if changed {
+		return roundUp(math.Min(1.08*(impact+exploitability), 10)), true
+	}
+	return roundUp(math.Min(impact+exploitability, 10)), true
+}
+
+// roundUp is the CVSS v3.1 Roundup function
+func roundUp(x float64) float64 {
+	i := int(math.Round(x * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return float64(i/10000+1) / 10
+}
@@ -0,0 +1,137 @@
+package osv
+
+import (
+	"context"
+	"sort"
+
+	"github.com/dso-cli/dso-cli/internal/inventory"
+)
+
+// Match is an advisory affecting a package of the inventory
+type Match struct {
+	Package  inventory.Package
+	Advisory Advisory
+	// FixedVersions are the versions that fix the advisory and are newer
+	// than the installed one, in ascending order
+	FixedVersions []string
+}
+
+// Match returns the advisories affecting the packages. Packages without a
+// version cannot be matched and are ignored.
+func (s *Store) Match(ctx context.Context, packages []inventory.Package) ([]Match, error) {
+	var matches []Match
+	for _, pkg := range packages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if pkg.Version == "" {
+			continue
+		}
+
+		ecosystem := string(pkg.Ecosystem)
+		advisories, err := s.Advisories(ecosystem, pkg.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, adv := range advisories {
+			if adv.Withdrawn != nil {
+				continue
+			}
+			if affected, fixed := adv.affects(ecosystem, pkg.Name, pkg.Version); affected {
+				matches = append(matches, Match{Package: pkg, Advisory: adv, FixedVersions: fixed})
+			}
+		}
+	}
+	return matches, nil
+}
+
+// affects reports whether version of a package is affected by the advisory,
+// and returns the newer versions that fix it
+func (a *Advisory) affects(ecosystem, name, version string) (bool, []string) {
+	compare := comparatorFor(ecosystem)
+	affected := false
+	var fixed []string
+
+	for _, aff := range a.Affected {
+		if aff.Package.Ecosystem != ecosystem || normalizeName(ecosystem, aff.Package.Name) != normalizeName(ecosystem, name) {
+			continue
+		}
+
+		hit := false
+		for _, v := range aff.Versions {
+			if compare(v, version) == 0 {
+				hit = true
+			}
+		}
+		for _, r := range aff.Ranges {
+			rangeCompare := compare
+			switch r.Type {
+			case "SEMVER":
+				rangeCompare = compareSemver
+			case "ECOSYSTEM":
+			default:
+				// GIT ranges are expressed as commits, not versions
+				continue
+			}
+			if inRange(r.Events, version, rangeCompare) {
+				hit = true
+			}
+			for _, e := range r.Events {
+				if e.Fixed != "" && rangeCompare(e.Fixed, version) > 0 {
+					fixed = appendUnique(fixed, e.Fixed)
+				}
+			}
+		}
+		affected = affected || hit
+	}
+
+	sort.Slice(fixed, func(i, j int) bool { return compare(fixed[i], fixed[j]) < 0 })
+	return affected, fixed
+}
+
+// inRange evaluates the events of a range as described by the OSV schema:
+// events are applied in version order, "introduced" enters the affected
+// state, "fixed" and "last_affected" leave it
+func inRange(events []Event, version string, compare compareFunc) bool {
+	eventVersion := func(e Event) string {
+		switch {
+		case e.Introduced != "":
+			return e.Introduced
+		case e.Fixed != "":
+			return e.Fixed
+		case e.LastAffected != "":
+			return e.LastAffected
+		default:
+			return e.Limit
+		}
+	}
+
+	sorted := make([]Event, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := eventVersion(sorted[i]), eventVersion(sorted[j])
+		if a == "0" || b == "0" {
+			return a == "0" && b != "0"
+		}
+		return compare(a, b) < 0
+	})
+
+	affected := false
+	for _, e := range sorted {
+		switch {
+		case e.Introduced != "":
+			if e.Introduced == "0" || compare(version, e.Introduced) >= 0 {
+				affected = true
+			}
+		case e.Fixed != "":
+			if compare(version, e.Fixed) >= 0 {
+				affected = false
+			}
+		case e.LastAffected != "":
+			if compare(version, e.LastAffected) > 0 {
+				affected = false
+			}
+		}
+	}
+	return affected
+}
@@ -0,0 +1,119 @@
+// Package osv stores OSV-format advisories locally and matches the package
+// inventory against them, so that dependency scanning works offline.
+// See https://ossf.github.io/osv-schema/ for the advisory format.
+package osv
+
+import (
+	"strings"
+	"time"
+)
+
+// Advisory is an OSV advisory. Only the fields used for matching and
+// reporting are decoded.
+type Advisory struct {
+	ID               string           `json:"id"`
+	Modified         time.Time        `json:"modified"`
+	Published        time.Time        `json:"published,omitempty"`
+	Withdrawn        *time.Time       `json:"withdrawn,omitempty"`
+	Aliases          []string         `json:"aliases,omitempty"`
+	Summary          string           `json:"summary,omitempty"`
+	Details          string           `json:"details,omitempty"`
+	Severity         []Severity       `json:"severity,omitempty"`
+	Affected         []Affected       `json:"affected"`
+	References       []Reference      `json:"references,omitempty"`
+	DatabaseSpecific DatabaseSpecific `json:"database_specific,omitempty"`
+}
+
+// Severity is a severity score of an advisory, such as a CVSS vector
+type Severity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+// Affected describes the affected versions of a package
+type Affected struct {
+	Package           AffectedPackage  `json:"package"`
+	Ranges            []Range          `json:"ranges,omitempty"`
+	Versions          []string         `json:"versions,omitempty"`
+	EcosystemSpecific DatabaseSpecific `json:"ecosystem_specific,omitempty"`
+	DatabaseSpecific  DatabaseSpecific `json:"database_specific,omitempty"`
+}
+
+// AffectedPackage identifies a package in an ecosystem
+type AffectedPackage struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	PURL      string `json:"purl,omitempty"`
+}
+
+// Range is a version range made of introduced, fixed and last_affected events
+type Range struct {
+	Type   string  `json:"type"`
+	Events []Event `json:"events"`
+}
+
+// Event is a version range event; exactly one field is set
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+// Reference is a link to more information about an advisory
+type Reference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// DatabaseSpecific holds the database-specific fields used by dso
+type DatabaseSpecific struct {
+	// Severity is a qualitative severity such as "HIGH" or "MODERATE" (GitHub advisories)
+	Severity string `json:"severity,omitempty"`
+	// CWEIDs are the weaknesses of the vulnerability (GitHub advisories)
+	CWEIDs []string `json:"cwe_ids,omitempty"`
+}
+
+// SeverityLevel returns the qualitative severity of the advisory and its CVSS
+// base score. The level is taken from the database-specific severity when
+// present, otherwise it is derived from the CVSS v3 score. It is empty when
+// the advisory has no usable severity.
+func (a *Advisory) SeverityLevel() (string, float64) {
+	score := 0.0
+	for _, s := range a.Severity {
+		if s.Type == "CVSS_V3" {
+			if v, ok := CVSS3BaseScore(s.Score); ok && v > score {
+				score = v
+			}
+		}
+	}
+
+	if level := a.DatabaseSpecific.Severity; level != "" {
+		return strings.ToUpper(level), score
+	}
+	for _, affected := range a.Affected {
+		if level := affected.EcosystemSpecific.Severity; level != "" {
+			return strings.ToUpper(level), score
+		}
+		if level := affected.DatabaseSpecific.Severity; level != "" {
+			return strings.ToUpper(level), score
+		}
+	}
+	return scoreLevel(score), score
+}
+
+// scoreLevel maps a CVSS v3 score to its qualitative rating
+func scoreLevel(score float64) string {
+	switch {
+	case score >= 9:
+		return "CRITICAL"
+	case score >= 7:
+		return "HIGH"
+	case score >= 4:
+		return "MEDIUM"
+	case score > 0:
+		return "LOW"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,321 @@
+package osv
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dso-cli/dso-cli/internal/config"
+)
+
+const (
+	storeDirName     = "osv"
+	metadataFileName = "metadata.json"
+)
+
+// Store is a local advisory database. Advisories are stored as JSON files,
+// one per affected package: <dir>/<ecosystem>/<package>.json.
+type Store struct {
+	dir string
+}
+
+// Metadata describes the content of a store
+type Metadata struct {
+	UpdatedAt  time.Time      `json:"updated_at"`
+	Sources    []string       `json:"sources"`
+	Advisories map[string]int `json:"advisories"` // by ecosystem
+}
+
+// ImportStats summarizes an import
+type ImportStats struct {
+	Advisories int
+	Packages   int
+	Skipped    int // files that are not valid OSV advisories
+}
+
+// DefaultStoreDir returns the store directory inside the DSO configuration directory
+func DefaultStoreDir() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, storeDirName), nil
+}
+
+// OpenStore returns the store in dir. The store may be empty.
+func OpenStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// OpenDefaultStore returns the store in DefaultStoreDir
+func OpenDefaultStore() (*Store, error) {
+	dir, err := DefaultStoreDir()
+	if err != nil {
+		return nil, err
+	}
+	return OpenStore(dir), nil
+}
+
+// Dir returns the store directory
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Metadata returns the store metadata. It returns an error wrapping
+// fs.ErrNotExist if nothing was imported yet.
+func (s *Store) Metadata() (*Metadata, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, metadataFileName))
+	if err != nil {
+		return nil, err
+	}
+	var meta Metadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("invalid advisory database metadata: %w", err)
+	}
+	return &meta, nil
+}
+
+// Advisories returns the advisories affecting a package
+func (s *Store) Advisories(ecosystem, name string) ([]Advisory, error) {
+	advisories, err := s.readPackage(ecosystem, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Names that differ only by case share a file
+	var result []Advisory
+	for _, adv := range advisories {
+		for _, affected := range adv.Affected {
+			if affected.Package.Ecosystem == ecosystem && normalizeName(ecosystem, affected.Package.Name) == normalizeName(ecosystem, name) {
+				result = append(result, adv)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+func (s *Store) packagePath(ecosystem, name string) string {
+	return filepath.Join(s.dir, url.QueryEscape(ecosystem), url.QueryEscape(strings.ToLower(normalizeName(ecosystem, name)))+".json")
+}
+
+func (s *Store) readPackage(ecosystem, name string) ([]Advisory, error) {
+	data, err := os.ReadFile(s.packagePath(ecosystem, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var advisories []Advisory
+	if err := json.Unmarshal(data, &advisories); err != nil {
+		return nil, fmt.Errorf("corrupted advisory file for %s/%s: %w", ecosystem, name, err)
+	}
+	return advisories, nil
+}
+
+// Import loads the OSV advisories of a directory or a zip archive (such as
+// the per-ecosystem all.zip exports of osv.dev) into the store. Advisories
+// already in the store are replaced by the imported ones with the same ID.
+func (s *Store) Import(source string) (*ImportStats, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &ImportStats{}
+	byPackage := make(map[[2]string][]Advisory)
+	add := func(name string, data []byte) {
+		advisories, ok := decodeAdvisories(data)
+		if !ok {
+			stats.Skipped++
+			return
+		}
+		for _, adv := range advisories {
+			stats.Advisories++
+			seen := make(map[[2]string]bool)
+			for _, affected := range adv.Affected {
+				key := [2]string{affected.Package.Ecosystem, normalizeName(affected.Package.Ecosystem, affected.Package.Name)}
+				if key[0] == "" || key[1] == "" || seen[key] {
+					continue
+				}
+				seen[key] = true
+				byPackage[key] = append(byPackage[key], adv)
+			}
+		}
+	}
+
+	if info.IsDir() {
+		err = filepath.Walk(source, func(path string, fi os.FileInfo, err error) error {
+			if err != nil || fi.IsDir() || !strings.HasSuffix(fi.Name(), ".json") {
+				return nil
+			}
+			data, readErr := os.ReadFile(path)
+			if readErr != nil {
+				return readErr
+			}
+			add(path, data)
+			return nil
+		})
+	} else {
+		err = readZip(source, add)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stats.Advisories == 0 {
+		return nil, fmt.Errorf("no OSV advisories found in %s", source)
+	}
+
+	meta, err := s.Metadata()
+	if err != nil {
+		meta = &Metadata{}
+	}
+	if meta.Advisories == nil {
+		meta.Advisories = make(map[string]int)
+	}
+
+	for key, imported := range byPackage {
+		added, err := s.mergePackage(key[0], key[1], imported)
+		if err != nil {
+			return nil, err
+		}
+		meta.Advisories[key[0]] += added
+	}
+	stats.Packages = len(byPackage)
+
+	abs, err := filepath.Abs(source)
+	if err != nil {
+		abs = source
+	}
+	meta.UpdatedAt = time.Now()
+	meta.Sources = appendUnique(meta.Sources, abs)
+	if err := writeJSON(filepath.Join(s.dir, metadataFileName), meta); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// mergePackage adds advisories to the file of a package and returns the
+// number of advisories that were not in the store yet
+func (s *Store) mergePackage(ecosystem, name string, imported []Advisory) (int, error) {
+	existing, err := s.readPackage(ecosystem, name)
+	if err != nil {
+		return 0, err
+	}
+
+	byID := make(map[string]int, len(existing))
+	for i, adv := range existing {
+		byID[adv.ID] = i
+	}
+	added := 0
+	for _, adv := range imported {
+		if i, ok := byID[adv.ID]; ok {
+			existing[i] = adv
+			continue
+		}
+		byID[adv.ID] = len(existing)
+		existing = append(existing, adv)
+		added++
+	}
+	sort.Slice(existing, func(i, j int) bool { return existing[i].ID < existing[j].ID })
+
+	return added, writeJSON(s.packagePath(ecosystem, name), existing)
+}
+
+// decodeAdvisories decodes a file holding one advisory or a list of advisories
+func decodeAdvisories(data []byte) ([]Advisory, bool) {
+	var adv Advisory
+	if err := json.Unmarshal(data, &adv); err == nil {
+		if adv.ID == "" || len(adv.Affected) == 0 {
+			return nil, false
+		}
+		return []Advisory{adv}, true
+	}
+
+	var list []Advisory
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, false
+	}
+	var valid []Advisory
+	for _, adv := range list {
+		if adv.ID != "" && len(adv.Affected) > 0 {
+			valid = append(valid, adv)
+		}
+	}
+	return valid, len(valid) > 0
+}
+
+// readZip calls fn for every JSON file of a zip archive
+func readZip(path string, fn func(name string, data []byte)) error {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("%s is neither a directory nor a zip archive: %w", path, err)
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !strings.HasSuffix(f.Name, ".json") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("cannot read %s: %w", f.Name, err)
+		}
+		fn(f.Name, data)
+	}
+	return nil
+}
+
+// writeJSON writes v to path, replacing the file atomically
+func writeJSON(path string, v interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, existing := range list {
+		if existing == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// normalizeName returns the canonical form of a package name in an ecosystem
+func normalizeName(ecosystem, name string) string {
+	switch ecosystem {
+	case "PyPI":
+		// PEP 503
+		name = strings.ToLower(name)
+		return strings.NewReplacer("_", "-", ".", "-").Replace(name)
+	case "Packagist":
+		return strings.ToLower(name)
+	default:
+		return name
+	}
+}
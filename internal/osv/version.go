@@ -0,0 +1,780 @@
+package osv
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// compareFunc compares two versions and returns -1, 0 or +1
+type compareFunc func(a, b string) int
+
+// comparatorFor returns the version ordering of an OSV ecosystem.
+// Ecosystems without a dedicated ordering use semantic versioning.
+func comparatorFor(ecosystem string) compareFunc {
+	switch ecosystem {
+	case "PyPI":
+		return comparePEP440
+	case "Maven":
+		return compareMaven
+	case "RubyGems":
+		return compareRubyGems
+	case "Debian", "Ubuntu":
+		return compareDpkg
+	case "Red Hat", "Rocky Linux", "AlmaLinux":
+		return compareRPM
+	case "Alpine":
+		return compareAPK
+	default:
+		return compareSemver
+	}
+}
+
+// CompareVersions compares two versions of a package of an OSV ecosystem
+// and returns -1, 0 or +1
+func CompareVersions(ecosystem, a, b string) int {
+	return comparatorFor(ecosystem)(a, b)
+}
+
+// compareSemver compares semantic versions. It is lenient: a "v" prefix,
+// any number of release components and non-numeric components are accepted,
+// so that Go pseudo-versions and Packagist versions can be compared too.
+func compareSemver(a, b string) int {
+	aRelease, aPre := splitSemver(a)
+	bRelease, bPre := splitSemver(b)
+
+	if c := compareDotted(aRelease, bRelease, true); c != 0 {
+		return c
+	}
+
+	// A pre-release has a lower precedence than the release itself
+	switch {
+	case aPre == "" && bPre == "":
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return compareDotted(aPre, bPre, false)
+}
+
+// splitSemver returns the release and pre-release parts of a version,
+// without the build metadata
+func splitSemver(v string) (string, string) {
+	v = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(v), "v"), "V")
+	if i := strings.Index(v, "+"); i >= 0 {
+		v = v[:i]
+	}
+	release, pre, _ := strings.Cut(v, "-")
+	return release, pre
+}
+
+// compareDotted compares dot-separated identifiers. Numeric identifiers are
+// compared numerically and are lower than alphanumeric ones. When padZeros
+// is set, missing identifiers count as zero (1.2 == 1.2.0); otherwise the
+// shorter list has the lower precedence, as for pre-release identifiers.
+func compareDotted(a, b string, padZeros bool) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y string
+		switch {
+		case i < len(as) && i < len(bs):
+			x, y = as[i], bs[i]
+		case !padZeros && i >= len(as):
+			return -1
+		case !padZeros:
+			return 1
+		case i < len(as):
+			x, y = as[i], "0"
+		default:
+			x, y = "0", bs[i]
+		}
+		if c := compareIdentifier(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareIdentifier compares two version identifiers
+func compareIdentifier(a, b string) int {
+	aNum, bNum := isNumeric(a), isNumeric(b)
+	switch {
+	case aNum && bNum:
+		return compareNumbers(a, b)
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// compareNumbers compares decimal strings of any length
+func compareNumbers(a, b string) int {
+	x, okA := new(big.Int).SetString(a, 10)
+	y, okB := new(big.Int).SetString(b, 10)
+	if !okA || !okB {
+		return strings.Compare(a, b)
+	}
+	return x.Cmp(y)
+}
+
+// pep440Regex matches a PEP 440 version, see
+// https://peps.python.org/pep-0440/#appendix-b-parsing-version-strings-with-regular-expressions
+var pep440Regex = regexp.MustCompile(`(?i)^v?(?:(\d+)!)?(\d+(?:\.\d+)*)` +
+	`(?:[-_.]?(a|b|c|rc|alpha|beta|pre|preview)[-_.]?(\d*))?` +
+	`(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d*))?` +
+	`(?:[-_.]?(dev)[-_.]?(\d*))?` +
+	`(?:\+[a-z0-9]+(?:[-_.][a-z0-9]+)*)?$`)
+
+// pep440Version is a parsed PEP 440 version. Missing pre, post and dev
+// segments are encoded so that a plain comparison gives the PEP 440 order.
+type pep440Version struct {
+	epoch   string
+	release string
+	pre     [2]string // phase rank, number
+	post    string
+	dev     string
+}
+
+const (
+	pep440Absent = "-1" // missing post-release: lower than any post-release
+	pep440Max    = "99999999999999999999"
+)
+
+func parsePEP440(v string) (pep440Version, bool) {
+	m := pep440Regex.FindStringSubmatch(strings.TrimSpace(v))
+	if m == nil {
+		return pep440Version{}, false
+	}
+
+	pv := pep440Version{epoch: "0", release: trimZeroComponents(m[2]), post: pep440Absent, dev: pep440Max}
+	if m[1] != "" {
+		pv.epoch = m[1]
+	}
+
+	switch strings.ToLower(m[3]) {
+	case "a", "alpha":
+		pv.pre = [2]string{"0", numberOrZero(m[4])}
+	case "b", "beta":
+		pv.pre = [2]string{"1", numberOrZero(m[4])}
+	case "c", "rc", "pre", "preview":
+		pv.pre = [2]string{"2", numberOrZero(m[4])}
+	default:
+		pv.pre = [2]string{"3", "0"}
+	}
+	switch {
+	case m[5] != "":
+		pv.post = m[5]
+	case m[6] != "":
+		pv.post = numberOrZero(m[7])
+	}
+	if m[8] != "" {
+		pv.dev = numberOrZero(m[9])
+		if m[3] == "" && pv.post == pep440Absent {
+			// 1.0.dev1 sorts before 1.0a1
+			pv.pre = [2]string{"-1", "0"}
+		}
+	}
+	return pv, true
+}
+
+func trimZeroComponents(release string) string {
+	parts := strings.Split(release, ".")
+	for len(parts) > 1 && strings.Trim(parts[len(parts)-1], "0") == "" {
+		parts = parts[:len(parts)-1]
+	}
+	return strings.Join(parts, ".")
+}
+
+func numberOrZero(s string) string {
+	if s == "" {
+		return "0"
+	}
+	return s
+}
+
+// comparePEP440 compares Python package versions as described in PEP 440.
+// Versions that do not follow PEP 440 are compared as semantic versions.
+func comparePEP440(a, b string) int {
+	x, okA := parsePEP440(a)
+	y, okB := parsePEP440(b)
+	if !okA || !okB {
+		return compareSemver(a, b)
+	}
+
+	signedCompare := func(a, b string) int {
+		if a == b {
+			return 0
+		}
+		if strings.HasPrefix(a, "-") != strings.HasPrefix(b, "-") {
+			if strings.HasPrefix(a, "-") {
+				return -1
+			}
+			return 1
+		}
+		return compareNumbers(a, b)
+	}
+
+	if c := compareNumbers(x.epoch, y.epoch); c != 0 {
+		return c
+	}
+	if c := compareDotted(x.release, y.release, true); c != 0 {
+		return c
+	}
+	if c := signedCompare(x.pre[0], y.pre[0]); c != 0 {
+		return c
+	}
+	if c := compareNumbers(x.pre[1], y.pre[1]); c != 0 {
+		return c
+	}
+	if c := signedCompare(x.post, y.post); c != 0 {
+		return c
+	}
+	return compareNumbers(x.dev, y.dev)
+}
+
+// mavenQualifiers ranks the well-known Maven qualifiers. Unknown
+// qualifiers rank after all of them and are compared lexically.
+var mavenQualifiers = map[string]int{
+	"alpha":     0,
+	"beta":      1,
+	"milestone": 2,
+	"rc":        3,
+	"snapshot":  4,
+	"":          5,
+	"sp":        6,
+}
+
+var mavenAliases = map[string]string{
+	"a":       "alpha",
+	"b":       "beta",
+	"m":       "milestone",
+	"cr":      "rc",
+	"ga":      "",
+	"final":   "",
+	"release": "",
+}
+
+// mavenItems splits a Maven version into numeric and qualifier items, on
+// separators and on transitions between digits and letters
+func mavenItems(v string) []string {
+	var items []string
+	var current strings.Builder
+	flush := func() {
+		items = append(items, current.String())
+		current.Reset()
+	}
+
+	v = strings.ToLower(strings.TrimSpace(v))
+	for i, r := range v {
+		switch {
+		case r == '.' || r == '-' || r == '_':
+			flush()
+		case i > 0 && current.Len() > 0 && unicode.IsDigit(r) != unicode.IsDigit(rune(v[i-1])):
+			flush()
+			current.WriteRune(r)
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	for i, item := range items {
+		if alias, ok := mavenAliases[item]; ok {
+			items[i] = alias
+		}
+		if isNumeric(item) {
+			items[i] = strings.TrimLeft(item, "0")
+			if items[i] == "" {
+				items[i] = "0"
+			}
+		}
+	}
+
+	// Trailing zeros and release qualifiers do not change the version
+	for len(items) > 1 && (items[len(items)-1] == "0" || items[len(items)-1] == "") {
+		items = items[:len(items)-1]
+	}
+	return items
+}
+
+// compareMavenItem compares two items; a missing item is passed as nil
+func compareMavenItem(a, b *string) int {
+	rank := func(s string) (int, bool) {
+		r, ok := mavenQualifiers[s]
+		if !ok {
+			return len(mavenQualifiers), false
+		}
+		return r, true
+	}
+
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -compareMavenItem(b, nil)
+	case b == nil:
+		if isNumeric(*a) {
+			if *a == "0" {
+				return 0
+			}
+			return 1
+		}
+		r, _ := rank(*a)
+		return compareInts(r, mavenQualifiers[""])
+	}
+
+	aNum, bNum := isNumeric(*a), isNumeric(*b)
+	switch {
+	case aNum && bNum:
+		return compareNumbers(*a, *b)
+	case aNum:
+		return 1
+	case bNum:
+		return -1
+	}
+	ra, knownA := rank(*a)
+	rb, knownB := rank(*b)
+	if !knownA && !knownB {
+		return strings.Compare(*a, *b)
+	}
+	return compareInts(ra, rb)
+}
+
+// compareMaven compares Maven versions, following the ordering of Maven's
+// ComparableVersion for the common cases
+func compareMaven(a, b string) int {
+	as, bs := mavenItems(a), mavenItems(b)
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y *string
+		if i < len(as) {
+			x = &as[i]
+		}
+		if i < len(bs) {
+			y = &bs[i]
+		}
+		if c := compareMavenItem(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareRubyGems compares RubyGems versions. Segments containing letters
+// mark pre-releases, which sort before the release.
+func compareRubyGems(a, b string) int {
+	segments := func(v string) []string {
+		v = strings.ReplaceAll(strings.TrimSpace(v), "-", ".pre.")
+		var segs []string
+		for _, part := range strings.Split(v, ".") {
+			// "0a1" is split into "0", "a", "1"
+			start := 0
+			for i := 1; i < len(part); i++ {
+				if unicode.IsDigit(rune(part[i])) != unicode.IsDigit(rune(part[i-1])) {
+					segs = append(segs, part[start:i])
+					start = i
+				}
+			}
+			segs = append(segs, part[start:])
+		}
+		return segs
+	}
+
+	as, bs := segments(a), segments(b)
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		x, y := "0", "0"
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		xNum, yNum := isNumeric(x), isNumeric(y)
+		var c int
+		switch {
+		case xNum && yNum:
+			c = compareNumbers(x, y)
+		case xNum:
+			c = 1
+		case yNum:
+			c = -1
+		default:
+			c = strings.Compare(x, y)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareDpkg compares Debian package versions ([epoch:]upstream[-revision])
+func compareDpkg(a, b string) int {
+	split := func(v string) (int, string, string) {
+		v = strings.TrimSpace(v)
+		epoch := 0
+		if e, rest, ok := strings.Cut(v, ":"); ok && isNumeric(e) {
+			fmt.Sscan(e, &epoch)
+			v = rest
+		}
+		if i := strings.LastIndex(v, "-"); i >= 0 {
+			return epoch, v[:i], v[i+1:]
+		}
+		return epoch, v, ""
+	}
+
+	aEpoch, aUpstream, aRevision := split(a)
+	bEpoch, bUpstream, bRevision := split(b)
+	if c := compareInts(aEpoch, bEpoch); c != 0 {
+		return c
+	}
+	if c := compareDpkgPart(aUpstream, bUpstream); c != 0 {
+		return c
+	}
+	return compareDpkgPart(aRevision, bRevision)
+}
+
+// compareDpkgPart compares alternating non-digit and digit runs as dpkg
+// does: letters sort before other characters and "~" before anything, even
+// the end of the string, so that 1.0~rc1 < 1.0
+func compareDpkgPart(a, b string) int {
+	order := func(c byte) int {
+		switch {
+		case c == '~':
+			return -1
+		case unicode.IsLetter(rune(c)):
+			return int(c)
+		default:
+			return int(c) + 256
+		}
+	}
+
+	for a != "" || b != "" {
+		for (a != "" && !unicode.IsDigit(rune(a[0]))) || (b != "" && !unicode.IsDigit(rune(b[0]))) {
+			x, y := 0, 0
+			if a != "" && !unicode.IsDigit(rune(a[0])) {
+				x = order(a[0])
+			}
+			if b != "" && !unicode.IsDigit(rune(b[0])) {
+				y = order(b[0])
+			}
+			if x != y {
+				return compareInts(x, y)
+			}
+			a, b = a[1:], b[1:]
+		}
+
+		i, j := 0, 0
+		for i < len(a) && unicode.IsDigit(rune(a[i])) {
+			i++
+		}
+		for j < len(b) && unicode.IsDigit(rune(b[j])) {
+			j++
+		}
+		x, y := strings.TrimLeft(a[:i], "0"), strings.TrimLeft(b[:j], "0")
+		if x == "" {
+			x = "0"
+		}
+		if y == "" {
+			y = "0"
+		}
+		if c := compareNumbers(x, y); c != 0 {
+			return c
+		}
+		a, b = a[i:], b[j:]
+	}
+	return 0
+}
+
+// compareRPM compares rpm package versions ([epoch:]version[-release]).
+// The release is only compared when both versions have one, as rpm does.
+func compareRPM(a, b string) int {
+	split := func(v string) (int, string, string) {
+		v = strings.TrimSpace(v)
+		epoch := 0
+		if e, rest, ok := strings.Cut(v, ":"); ok && isNumeric(e) {
+			fmt.Sscan(e, &epoch)
+			v = rest
+		}
+		if i := strings.LastIndex(v, "-"); i >= 0 {
+			return epoch, v[:i], v[i+1:]
+		}
+		return epoch, v, ""
+	}
+
+	aEpoch, aVersion, aRelease := split(a)
+	bEpoch, bVersion, bRelease := split(b)
+	if c := compareInts(aEpoch, bEpoch); c != 0 {
+		return c
+	}
+	if c := rpmvercmp(aVersion, bVersion); c != 0 || aRelease == "" || bRelease == "" {
+		return c
+	}
+	return rpmvercmp(aRelease, bRelease)
+}
+
+// rpmvercmp compares alternating alphabetic and numeric segments as rpm
+// does. Other characters only separate segments, numeric segments are
+// newer than alphabetic ones, "~" sorts before anything (1.0~rc1 < 1.0)
+// and "^" after the end of the string but before anything else
+// (1.0 < 1.0^git1 < 1.0.1).
+func rpmvercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+	isAlnum := func(c byte) bool {
+		return c < 128 && (unicode.IsDigit(rune(c)) || unicode.IsLetter(rune(c)))
+	}
+	segment := func(s string, digits bool) int {
+		i := 0
+		for i < len(s) && s[i] < 128 && unicode.IsDigit(rune(s[i])) == digits && (digits || unicode.IsLetter(rune(s[i]))) {
+			i++
+		}
+		return i
+	}
+
+	for a != "" || b != "" {
+		for a != "" && !isAlnum(a[0]) && a[0] != '~' && a[0] != '^' {
+			a = a[1:]
+		}
+		for b != "" && !isAlnum(b[0]) && b[0] != '~' && b[0] != '^' {
+			b = b[1:]
+		}
+
+		if strings.HasPrefix(a, "~") || strings.HasPrefix(b, "~") {
+			if !strings.HasPrefix(a, "~") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "~") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		if strings.HasPrefix(a, "^") || strings.HasPrefix(b, "^") {
+			switch {
+			case a == "":
+				return -1
+			case b == "":
+				return 1
+			case a[0] != '^':
+				return 1
+			case b[0] != '^':
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		if a == "" || b == "" {
+			break
+		}
+
+		digits := unicode.IsDigit(rune(a[0]))
+		i, j := segment(a, digits), segment(b, digits)
+		if j == 0 {
+			// Numeric segments are newer than alphabetic ones
+			if digits {
+				return 1
+			}
+			return -1
+		}
+		x, y := a[:i], b[:j]
+		a, b = a[i:], b[j:]
+		if digits {
+			x, y = strings.TrimLeft(x, "0"), strings.TrimLeft(y, "0")
+			if x == "" {
+				x = "0"
+			}
+			if y == "" {
+				y = "0"
+			}
+			if c := compareNumbers(x, y); c != 0 {
+				return c
+			}
+		} else if c := strings.Compare(x, y); c != 0 {
+			return c
+		}
+	}
+
+	// The version with segments left is newer
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		return -1
+	default:
+		return 1
+	}
+}
+
+// apkToken is a component of an Alpine package version
+type apkToken struct {
+	kind  int
+	value string
+}
+
+// Kinds of apkToken, in the order apk ranks them when two versions differ
+// in their structure: 1.2.1 > 1.2a > 1.2 and 1.2-r1 > 1.2
+const (
+	apkDigit = iota
+	apkLetter
+	apkSuffix
+	apkSuffixNumber
+	apkRevision
+	apkEnd
+)
+
+// apkSuffixes ranks the suffixes of Alpine versions; the negative ones
+// mark pre-releases
+var apkSuffixes = map[string]int{
+	"alpha": -4,
+	"beta":  -3,
+	"pre":   -2,
+	"rc":    -1,
+	"cvs":   1,
+	"svn":   2,
+	"git":   3,
+	"hg":    4,
+	"p":     5,
+}
+
+// apkTokens splits an Alpine version
+// (digits{.digits}[letter]{_suffix[number]}[-rN]) into tokens
+func apkTokens(v string) ([]apkToken, bool) {
+	digitsAt := func(s string) int {
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		return i
+	}
+
+	var tokens []apkToken
+	s := strings.TrimSpace(v)
+	for {
+		n := digitsAt(s)
+		if n == 0 {
+			return nil, false
+		}
+		tokens = append(tokens, apkToken{apkDigit, s[:n]})
+		s = s[n:]
+		if !strings.HasPrefix(s, ".") {
+			break
+		}
+		s = s[1:]
+	}
+	if s != "" && s[0] >= 'a' && s[0] <= 'z' {
+		tokens = append(tokens, apkToken{apkLetter, s[:1]})
+		s = s[1:]
+	}
+	for strings.HasPrefix(s, "_") {
+		s = s[1:]
+		i := 0
+		for i < len(s) && s[i] >= 'a' && s[i] <= 'z' {
+			i++
+		}
+		rank, ok := apkSuffixes[s[:i]]
+		if !ok {
+			return nil, false
+		}
+		tokens = append(tokens, apkToken{apkSuffix, strconv.Itoa(rank)})
+		s = s[i:]
+		if n := digitsAt(s); n > 0 {
+			tokens = append(tokens, apkToken{apkSuffixNumber, s[:n]})
+			s = s[n:]
+		}
+	}
+	if strings.HasPrefix(s, "-r") {
+		n := digitsAt(s[2:])
+		if n == 0 {
+			return nil, false
+		}
+		tokens = append(tokens, apkToken{apkRevision, s[2 : 2+n]})
+		s = s[2+n:]
+	}
+	return tokens, s == ""
+}
+
+// compareAPK compares Alpine package versions as apk does. Versions that
+// apk would reject are compared as Debian versions.
+func compareAPK(a, b string) int {
+	as, okA := apkTokens(a)
+	bs, okB := apkTokens(b)
+	if !okA || !okB {
+		return compareDpkg(a, b)
+	}
+
+	for i := 0; ; i++ {
+		x, y := apkToken{kind: apkEnd}, apkToken{kind: apkEnd}
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+
+		if x.kind == y.kind {
+			if x.kind == apkEnd {
+				return 0
+			}
+			var c int
+			switch x.kind {
+			case apkLetter:
+				c = strings.Compare(x.value, y.value)
+			case apkSuffix:
+				xr, _ := strconv.Atoi(x.value)
+				yr, _ := strconv.Atoi(y.value)
+				c = compareInts(xr, yr)
+			default:
+				c = compareNumbers(x.value, y.value)
+			}
+			if c != 0 {
+				return c
+			}
+			continue
+		}
+
+		// Same leading components: the longer version is newer, unless it
+		// continues with a pre-release suffix
+		switch {
+		case x.kind == apkSuffix && strings.HasPrefix(x.value, "-"):
+			return -1
+		case y.kind == apkSuffix && strings.HasPrefix(y.value, "-"):
+			return 1
+		case x.kind > y.kind:
+			return -1
+		default:
+			return 1
+		}
+	}
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
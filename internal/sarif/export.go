@@ -0,0 +1,367 @@
+package sarif
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dso-cli/dso-cli/internal/llm"
+	"github.com/dso-cli/dso-cli/internal/scanner"
+)
+
+// fingerprintKey is the partial fingerprint holding Finding.Fingerprint
+const fingerprintKey = "dsoFingerprint/v1"
+
+// securitySeverity is the default "security-severity" of each severity,
+// used by GitHub code scanning when a finding has no CVSS score
+var securitySeverity = map[scanner.Severity]float64{
+	scanner.SeverityCritical: 9.5,
+	scanner.SeverityHigh:     8.0,
+	scanner.SeverityMedium:   5.5,
+	scanner.SeverityLow:      2.0,
+	scanner.SeverityInfo:     0.0,
+}
+
+// Level maps a severity to a SARIF result level
+func Level(s scanner.Severity) string {
+	switch s {
+	case scanner.SeverityCritical, scanner.SeverityHigh:
+		return "error"
+	case scanner.SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// FromResults converts scan results to a SARIF log with one run per tool.
+// Every tool that ran gets a run, even without results, so that SARIF
+// consumers can close the alerts it no longer reports. A finding reported by
+// several tools belongs to the run of its primary tool and lists all of them
+// in its properties. The AI analysis, when not nil, is attached to every run.
+func FromResults(results *scanner.ScanResults, analysis *llm.AnalysisResult) *Log {
+	log := &Log{Schema: SchemaURI, Version: Version, Runs: []Run{}}
+
+	runIndex := make(map[string]int)
+	addRun := func(tool string) *Run {
+		if i, ok := runIndex[tool]; ok {
+			return &log.Runs[i]
+		}
+		run := Run{
+			Tool:    Tool{Driver: ToolComponent{Name: tool}},
+			Results: []Result{},
+		}
+		if results.Path != "" {
+			run.OriginalURIBaseIDs = map[string]ArtifactLocation{
+				srcRootID: {URI: directoryURI(results.Path)},
+			}
+		}
+		if analysis != nil || results.Diff != nil || results.History != nil || results.Image != nil {
+			run.Properties = make(map[string]interface{})
+		}
+		if analysis != nil {
+			run.Properties["analysis"] = analysis
+		}
+		if results.Diff != nil {
+			// Incremental scan: results only cover these changes
+			run.Properties["diff"] = results.Diff
+		}
+		if results.History != nil {
+			run.Properties["history"] = results.History
+		}
+		if results.Image != nil {
+			run.Properties["image"] = results.Image
+		}
+		runIndex[tool] = len(log.Runs)
+		log.Runs = append(log.Runs, run)
+		return &log.Runs[len(log.Runs)-1]
+	}
+
+	for _, toolRun := range results.ToolRuns {
+		if toolRun.Status == scanner.ToolStatusSkipped {
+			continue
+		}
+		run := addRun(toolRun.Tool)
+		run.Tool.Driver.Version = toolRun.Version
+		run.Invocations = []Invocation{invocation(toolRun)}
+	}
+
+	ruleIndex := make(map[string]map[string]int) // by tool, then rule ID
+	for _, f := range results.Findings {
+		run := addRun(f.Tool)
+		if ruleIndex[f.Tool] == nil {
+			ruleIndex[f.Tool] = make(map[string]int)
+		}
+
+		ruleID := f.RuleID
+		if ruleID == "" {
+			ruleID = fallbackRuleID(f)
+		}
+		index, ok := ruleIndex[f.Tool][ruleID]
+		if !ok {
+			index = len(run.Tool.Driver.Rules)
+			ruleIndex[f.Tool][ruleID] = index
+			described := f
+			if f.RuleID == "" {
+				// The description of such a finding is specific to its location
+				described.Description = ""
+			}
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule(ruleID, described))
+		} else {
+			// A rule is as severe as its most severe result
+			r := &run.Tool.Driver.Rules[index]
+			if score := findingSecuritySeverity(f); score > r.Properties["security-severity"].(float64) {
+				r.Properties["security-severity"] = score
+				r.DefaultConfiguration.Level = Level(f.Severity)
+			}
+		}
+
+		r := result(ruleID, index, f)
+		if results.Baseline != nil {
+			// Findings of the baseline are not in the results
+			r.BaselineState = "new"
+		}
+		run.Results = append(run.Results, r)
+	}
+
+	// security-severity is a string in the SARIF properties read by GitHub
+	for i := range log.Runs {
+		for j := range log.Runs[i].Tool.Driver.Rules {
+			props := log.Runs[i].Tool.Driver.Rules[j].Properties
+			props["security-severity"] = fmt.Sprintf("%.1f", props["security-severity"].(float64))
+		}
+	}
+	return log
+}
+
+// Marshal returns the indented JSON encoding of a SARIF log
+func Marshal(log *Log) ([]byte, error) {
+	return json.MarshalIndent(log, "", "  ")
+}
+
+// invocation converts the execution details of a tool
+func invocation(run scanner.ToolRun) Invocation {
+	inv := Invocation{
+		CommandLine:         run.Command,
+		ExecutionSuccessful: run.Status == scanner.ToolStatusOK,
+	}
+	if run.Command != "" {
+		exitCode := run.ExitCode
+		inv.ExitCode = &exitCode
+	}
+	if run.Error != "" {
+		inv.ToolExecutionNotifications = []Notification{{
+			Level:   "error",
+			Message: Message{Text: run.Error},
+		}}
+	}
+	for _, unparsed := range run.Unparsed {
+		inv.ToolExecutionNotifications = append(inv.ToolExecutionNotifications, Notification{
+			Level:   "warning",
+			Message: Message{Text: "file not parsed: " + unparsed},
+		})
+	}
+	return inv
+}
+
+// rule builds the rule of a finding
+func rule(id string, f scanner.Finding) ReportingDescriptor {
+	help := f.Description
+	markdown := f.Description
+	if f.Fix != "" {
+		help += "\n\nFix: " + f.Fix
+		markdown += "\n\n**Fix:**\n\n```\n" + f.Fix + "\n```"
+	}
+
+	// Code scanning shows CWE tags in the CodeQL format
+	tags := []string{"security", strings.ToLower(f.Type)}
+	for _, cwe := range f.CWE {
+		if n, err := strconv.Atoi(strings.TrimPrefix(cwe, "CWE-")); err == nil {
+			tags = append(tags, fmt.Sprintf("external/cwe/cwe-%03d", n))
+		}
+	}
+
+	return ReportingDescriptor{
+		ID:                   id,
+		Name:                 ruleName(id),
+		ShortDescription:     &Message{Text: f.Title},
+		FullDescription:      &Message{Text: firstNonEmpty(f.Description, f.Title)},
+		Help:                 &Message{Text: firstNonEmpty(help, f.Title), Markdown: firstNonEmpty(markdown, f.Title)},
+		DefaultConfiguration: &Configuration{Level: Level(f.Severity)},
+		Properties: map[string]interface{}{
+			"security-severity": findingSecuritySeverity(f),
+			"tags":              tags,
+		},
+	}
+}
+
+// result builds the SARIF result of a finding
+func result(ruleID string, ruleIndex int, f scanner.Finding) Result {
+	r := Result{
+		RuleID:    ruleID,
+		RuleIndex: &ruleIndex,
+		Level:     Level(f.Severity),
+		Message:   Message{Text: f.Title},
+		Properties: map[string]interface{}{
+			"severity": string(f.Severity),
+			"type":     f.Type,
+			"fixable":  f.Fixable,
+		},
+	}
+
+	if f.File != "" {
+		loc := &PhysicalLocation{
+			ArtifactLocation: ArtifactLocation{URI: fileURI(f.File), URIBaseID: srcRootID},
+		}
+		if f.Line > 0 {
+			loc.Region = &Region{StartLine: f.Line, StartColumn: f.Column, EndLine: f.EndLine}
+		}
+		if filepath.IsAbs(f.File) {
+			// Outside the scanned directory
+			loc.ArtifactLocation = ArtifactLocation{URI: absoluteURI(f.File)}
+		}
+		r.Locations = []Location{{PhysicalLocation: loc}}
+	}
+	if f.Fingerprint != "" {
+		r.PartialFingerprints = map[string]string{fingerprintKey: f.Fingerprint}
+	}
+
+	if len(f.Tools) > 0 {
+		r.Properties["tools"] = f.Tools
+	}
+	if f.Confidence > 0 {
+		r.Properties["confidence"] = f.Confidence
+	}
+	if f.CVSS > 0 {
+		r.Properties["cvss"] = f.CVSS
+	}
+	if f.Fix != "" {
+		r.Properties["fix"] = f.Fix
+	}
+	if f.Exploitable {
+		r.Properties["exploitable"] = true
+	}
+	if len(f.Aliases) > 0 {
+		r.Properties["aliases"] = f.Aliases
+	}
+	if f.Package != nil {
+		r.Properties["package"] = f.Package
+	}
+	if len(f.CWE) > 0 {
+		r.Properties["cwe"] = f.CWE
+	}
+	if len(f.OWASP) > 0 {
+		r.Properties["owasp"] = f.OWASP
+	}
+	if len(f.ASVS) > 0 {
+		r.Properties["asvs"] = f.ASVS
+	}
+	if f.Commit != nil {
+		r.Properties["commit"] = f.Commit
+	}
+	if s := f.Suppression; s != nil {
+		sup := Suppression{
+			Kind:          "inSource",
+			Status:        "accepted",
+			Justification: s.Reason,
+			Location: &Location{PhysicalLocation: &PhysicalLocation{
+				ArtifactLocation: ArtifactLocation{URI: fileURI(s.File), URIBaseID: srcRootID},
+				Region:           &Region{StartLine: s.Line},
+			}},
+		}
+		if s.Until != "" {
+			sup.Properties = map[string]interface{}{"until": s.Until}
+		}
+		r.Suppressions = []Suppression{sup}
+	}
+	return r
+}
+
+// findingSecuritySeverity returns the CVSS score of a finding, or a score
+// derived from its severity
+func findingSecuritySeverity(f scanner.Finding) float64 {
+	if f.CVSS > 0 {
+		return f.CVSS
+	}
+	return securitySeverity[f.Severity]
+}
+
+// fallbackRuleID identifies the rule of a finding whose tool sets no rule
+// ID. The title names the detector, so that the results of a detector share
+// a rule instead of adding one rule per result.
+func fallbackRuleID(f scanner.Finding) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(firstNonEmpty(f.Title, f.Type)) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			if dash && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			sb.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	if sb.Len() == 0 {
+		return "finding"
+	}
+	return sb.String()
+}
+
+// ruleName turns a rule ID into a PascalCase name, as recommended by SARIF
+func ruleName(id string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9':
+			if upper {
+				sb.WriteString(strings.ToUpper(string(r)))
+			} else {
+				sb.WriteRune(r)
+			}
+			upper = false
+		default:
+			upper = true
+		}
+	}
+	return sb.String()
+}
+
+// fileURI escapes a relative slash-separated path for use in a URI
+func fileURI(path string) string {
+	return (&url.URL{Path: filepath.ToSlash(path)}).EscapedPath()
+}
+
+// absoluteURI returns the file:// URI of an absolute path
+func absoluteURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// Windows drive letter
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// directoryURI returns the file:// URI of an absolute directory. SARIF
+// requires a trailing slash on URI bases.
+func directoryURI(dir string) string {
+	uri := absoluteURI(dir)
+	if !strings.HasSuffix(uri, "/") {
+		uri += "/"
+	}
+	return uri
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
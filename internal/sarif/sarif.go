@@ -0,0 +1,126 @@
+// Package sarif converts scan results to and from SARIF 2.1.0, the Static
+// Analysis Results Interchange Format understood by GitHub code scanning
+// and most security dashboards.
+package sarif
+
+// Version is the SARIF version produced by this package
+const Version = "2.1.0"
+
+// SchemaURI is the JSON schema of SARIF 2.1.0
+const SchemaURI = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// srcRootID is the URI base of the scanned directory
+const srcRootID = "%SRCROOT%"
+
+// Log is a SARIF log file
+type Log struct {
+	Schema  string `json:"$schema,omitempty"`
+	Version string `json:"version"`
+	Runs    []Run  `json:"runs"`
+}
+
+// Run is the output of a single tool
+type Run struct {
+	Tool               Tool                        `json:"tool"`
+	Invocations        []Invocation                `json:"invocations,omitempty"`
+	OriginalURIBaseIDs map[string]ArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	Results            []Result                    `json:"results"`
+	Properties         map[string]interface{}      `json:"properties,omitempty"`
+}
+
+// Tool describes the tool of a run
+type Tool struct {
+	Driver ToolComponent `json:"driver"`
+}
+
+// ToolComponent is the analysis tool and its rules
+type ToolComponent struct {
+	Name           string                `json:"name"`
+	Version        string                `json:"version,omitempty"`
+	InformationURI string                `json:"informationUri,omitempty"`
+	Rules          []ReportingDescriptor `json:"rules,omitempty"`
+}
+
+// ReportingDescriptor is a rule
+type ReportingDescriptor struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name,omitempty"`
+	ShortDescription     *Message               `json:"shortDescription,omitempty"`
+	FullDescription      *Message               `json:"fullDescription,omitempty"`
+	Help                 *Message               `json:"help,omitempty"`
+	HelpURI              string                 `json:"helpUri,omitempty"`
+	DefaultConfiguration *Configuration         `json:"defaultConfiguration,omitempty"`
+	Properties           map[string]interface{} `json:"properties,omitempty"`
+}
+
+// Configuration is the default configuration of a rule
+type Configuration struct {
+	Level string `json:"level,omitempty"`
+}
+
+// Message is a plain text message with an optional Markdown rendering
+type Message struct {
+	Text     string `json:"text,omitempty"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+// Invocation describes how a tool was run
+type Invocation struct {
+	CommandLine                string         `json:"commandLine,omitempty"`
+	ExitCode                   *int           `json:"exitCode,omitempty"`
+	ExecutionSuccessful        bool           `json:"executionSuccessful"`
+	ToolExecutionNotifications []Notification `json:"toolExecutionNotifications,omitempty"`
+}
+
+// Notification is a message reported by a tool about its execution
+type Notification struct {
+	Level   string  `json:"level,omitempty"`
+	Message Message `json:"message"`
+}
+
+// Result is a finding
+type Result struct {
+	RuleID              string                 `json:"ruleId,omitempty"`
+	RuleIndex           *int                   `json:"ruleIndex,omitempty"`
+	Level               string                 `json:"level,omitempty"`
+	Message             Message                `json:"message"`
+	Locations           []Location             `json:"locations,omitempty"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints,omitempty"`
+	BaselineState       string                 `json:"baselineState,omitempty"`
+	Suppressions        []Suppression          `json:"suppressions,omitempty"`
+	Properties          map[string]interface{} `json:"properties,omitempty"`
+}
+
+// Suppression records that a result was suppressed, and why
+type Suppression struct {
+	Kind          string                 `json:"kind"` // inSource or external
+	Status        string                 `json:"status,omitempty"`
+	Justification string                 `json:"justification,omitempty"`
+	Location      *Location              `json:"location,omitempty"`
+	Properties    map[string]interface{} `json:"properties,omitempty"`
+}
+
+// Location is the location of a result
+type Location struct {
+	PhysicalLocation *PhysicalLocation `json:"physicalLocation,omitempty"`
+}
+
+// PhysicalLocation is a region of a file
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+// ArtifactLocation is the URI of a file, optionally relative to a URI base
+type ArtifactLocation struct {
+	URI       string `json:"uri,omitempty"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// Region is a range of lines and columns in a file
+type Region struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
@@ -1,15 +1,17 @@
 package sbom
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/dso-cli/dso-cli/internal/constants"
+	"github.com/dso-cli/dso-cli/internal/inventory"
 )
 
 // Component represents a component in the SBOM
@@ -47,21 +49,10 @@ func GenerateSBOM(projectPath string, format string) (string, error) {
 func detectComponents(projectPath string) []Component {
 	var components []Component
 
-	// Detect dependency managers
-	if hasFile(projectPath, "package.json") {
-		npmComponents := detectNPM(projectPath)
-		components = append(components, npmComponents...)
-	}
-
-	if hasFile(projectPath, "go.mod") {
-		goComponents := detectGo(projectPath)
-		components = append(components, goComponents...)
-	}
-
-	if hasFile(projectPath, "requirements.txt") || hasFile(projectPath, "Pipfile") {
-		pythonComponents := detectPython(projectPath)
-		components = append(components, pythonComponents...)
-	}
+	// Dependencies read from lockfiles and manifests; a file that cannot be
+	// parsed does not prevent the others from being listed
+	packages, _ := inventory.Load(context.Background(), projectPath)
+	components = append(components, inventoryComponents(packages)...)
 
 	if hasFile(projectPath, "pom.xml") {
 		javaComponents := detectMaven(projectPath)
@@ -71,6 +62,35 @@ func detectComponents(projectPath string) []Component {
 	return components
 }
 
+// inventoryComponents converts inventory packages to components, merging the
+// packages found in several lockfiles
+func inventoryComponents(packages []inventory.Package) []Component {
+	var components []Component
+	index := make(map[string]int)
+	for _, pkg := range packages {
+		purl := pkg.PURL()
+		if i, ok := index[purl]; ok {
+			if pkg.Direct {
+				components[i].Properties["direct"] = "true"
+			}
+			continue
+		}
+		index[purl] = len(components)
+		components = append(components, Component{
+			Type:    "library",
+			Name:    pkg.Name,
+			Version: pkg.Version,
+			PURL:    purl,
+			Properties: map[string]string{
+				"ecosystem": string(pkg.Ecosystem),
+				"direct":    strconv.FormatBool(pkg.Direct),
+				"source":    pkg.Source,
+			},
+		})
+	}
+	return components
+}
+
 // generateCycloneDX generates an SBOM in CycloneDX format
 func generateCycloneDX(components []Component, projectPath string) (string, error) {
 	doc := map[string]interface{}{
@@ -127,103 +147,6 @@ func generateSPDX(components []Component, projectPath string) (string, error) {
 	return sb.String(), nil
 }
 
-// detectNPM detects NPM dependencies
-func detectNPM(projectPath string) []Component {
-	var components []Component
-
-	// Use npm list if available
-	if _, err := exec.LookPath("npm"); err == nil {
-		cmd := exec.Command("npm", "list", "--json", "--depth=0")
-		cmd.Dir = projectPath
-		output, err := cmd.Output()
-		if err == nil && len(output) > 0 {
-			var npmList struct {
-				Dependencies map[string]struct {
-					Version string `json:"version"`
-				} `json:"dependencies"`
-			}
-			if json.Unmarshal(output, &npmList) == nil {
-				for name, dep := range npmList.Dependencies {
-					components = append(components, Component{
-						Type:    "library",
-						Name:    name,
-						Version: dep.Version,
-						PURL:    fmt.Sprintf("pkg:npm/%s@%s", name, dep.Version),
-					})
-				}
-			}
-		}
-	}
-
-	return components
-}
-
-func detectGo(projectPath string) []Component {
-	var components []Component
-
-	// Read go.mod
-	goModPath := filepath.Join(projectPath, "go.mod")
-	data, err := os.ReadFile(goModPath)
-	if err != nil {
-		return components
-	}
-
-	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "require ") {
-			parts := strings.Fields(line)
-			if len(parts) >= 2 {
-				name := parts[1]
-				version := ""
-				if len(parts) >= 3 {
-					version = parts[2]
-				}
-				components = append(components, Component{
-					Type:    "library",
-					Name:    name,
-					Version: version,
-					PURL:    fmt.Sprintf("pkg:golang/%s@%s", name, version),
-				})
-			}
-		}
-	}
-
-	return components
-}
-
-func detectPython(projectPath string) []Component {
-	var components []Component
-
-	// Read requirements.txt
-	reqPath := filepath.Join(projectPath, "requirements.txt")
-	if data, err := os.ReadFile(reqPath); err == nil {
-		lines := strings.Split(string(data), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line == "" || strings.HasPrefix(line, "#") {
-				continue
-			}
-			parts := strings.Fields(line)
-			if len(parts) > 0 {
-				name := strings.Split(parts[0], "==")[0]
-				version := ""
-				if strings.Contains(parts[0], "==") {
-					version = strings.Split(parts[0], "==")[1]
-				}
-				components = append(components, Component{
-					Type:    "library",
-					Name:    name,
-					Version: version,
-					PURL:    fmt.Sprintf("pkg:pypi/%s@%s", name, version),
-				})
-			}
-		}
-	}
-
-	return components
-}
-
 func detectMaven(projectPath string) []Component {
 	var components []Component
 
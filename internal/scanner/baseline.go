@@ -0,0 +1,300 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BaselineFile is the baseline of a project, relative to the scanned directory
+const BaselineFile = ".dso/baseline.json"
+
+// baselineVersion is the format version of the baseline file
+const baselineVersion = 1
+
+// Baseline is the set of accepted findings of a project: only findings
+// absent from it are reported by `dso audit --baseline`
+type Baseline struct {
+	Version int             `json:"version"`
+	Created time.Time       `json:"created"`
+	Updated time.Time       `json:"updated"`
+	Entries []BaselineEntry `json:"entries"`
+}
+
+// BaselineEntry is an accepted finding. Only the fingerprint is compared;
+// the other fields describe the finding to reviewers.
+type BaselineEntry struct {
+	Fingerprint string   `json:"fingerprint"`
+	Type        string   `json:"type"`
+	Severity    Severity `json:"severity"`
+	Title       string   `json:"title"`
+	File        string   `json:"file,omitempty"`
+	Line        int      `json:"line,omitempty"` // Line when the entry was recorded
+	Tools       []string `json:"tools"`
+}
+
+// BaselineReport is the comparison of a scan with a baseline
+type BaselineReport struct {
+	File     string          `json:"file"`
+	Entries  int             `json:"entries"`         // Entries in the baseline
+	Existing int             `json:"existing"`        // Findings in the baseline, not reported
+	Fixed    []BaselineEntry `json:"fixed,omitempty"` // Entries no longer found
+}
+
+// NewBaseline returns a baseline accepting the active findings of results
+func NewBaseline(results *ScanResults) *Baseline {
+	now := time.Now().UTC()
+	b := &Baseline{Version: baselineVersion, Created: now, Updated: now, Entries: []BaselineEntry{}}
+	active := results.Active()
+	for i, fp := range baselineFingerprints(results.Path, active) {
+		b.Entries = append(b.Entries, newBaselineEntry(fp, active[i]))
+	}
+	b.sort()
+	return b
+}
+
+func newBaselineEntry(fp string, f Finding) BaselineEntry {
+	return BaselineEntry{
+		Fingerprint: fp,
+		Type:        f.Type,
+		Severity:    f.Severity,
+		Title:       f.Title,
+		File:        f.File,
+		Line:        f.Line,
+		Tools:       findingTools(f),
+	}
+}
+
+// LoadBaseline reads the baseline of root
+func LoadBaseline(root string) (*Baseline, error) {
+	file := filepath.Join(root, filepath.FromSlash(BaselineFile))
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no baseline in %s, create it with: dso baseline create", root)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("invalid baseline %s: %w", file, err)
+	}
+	if b.Version != baselineVersion {
+		return nil, fmt.Errorf("unsupported baseline version %d in %s, recreate it with: dso baseline create", b.Version, file)
+	}
+	return &b, nil
+}
+
+// Save writes the baseline of root
+func (b *Baseline) Save(root string) error {
+	file := filepath.Join(root, filepath.FromSlash(BaselineFile))
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(data, '\n'), 0644)
+}
+
+// Apply removes the findings of the baseline from results and records the
+// comparison in results.Baseline. Entries are only reported fixed when a
+// tool that reported them ran successfully on their file.
+func (b *Baseline) Apply(results *ScanResults) {
+	known := make(map[string]bool, len(b.Entries))
+	for _, e := range b.Entries {
+		known[e.Fingerprint] = true
+	}
+
+	report := &BaselineReport{File: BaselineFile, Entries: len(b.Entries)}
+	found := make(map[string]bool)
+	fps := baselineFingerprints(results.Path, results.Active())
+
+	// Suppressed findings are not part of the baseline and are kept
+	kept := results.Findings[:0]
+	active := 0
+	for _, f := range results.Findings {
+		if f.Suppression == nil {
+			fp := fps[active]
+			active++
+			if known[fp] {
+				found[fp] = true
+				report.Existing++
+				continue
+			}
+		}
+		kept = append(kept, f)
+	}
+	results.Findings = kept
+
+	for _, e := range b.Entries {
+		if !found[e.Fingerprint] && results.verifies(e) {
+			report.Fixed = append(report.Fixed, e)
+		}
+	}
+	results.Baseline = report
+	results.CalculateSummary()
+}
+
+// Update removes the entries that results report fixed, see Apply, and
+// refreshes the location of the others. It returns the removed entries.
+func (b *Baseline) Update(results *ScanResults) []BaselineEntry {
+	current := make(map[string]Finding)
+	active := results.Active()
+	for i, fp := range baselineFingerprints(results.Path, active) {
+		current[fp] = active[i]
+	}
+
+	var removed []BaselineEntry
+	kept := b.Entries[:0]
+	for _, e := range b.Entries {
+		if f, ok := current[e.Fingerprint]; ok {
+			kept = append(kept, newBaselineEntry(e.Fingerprint, f))
+			continue
+		}
+		if results.verifies(e) {
+			removed = append(removed, e)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	b.Entries = kept
+	b.Updated = time.Now().UTC()
+	b.sort()
+	return removed
+}
+
+// verifies reports whether the scan could have found an entry again: one of
+// its tools ran successfully and its file was in scope
+func (sr *ScanResults) verifies(e BaselineEntry) bool {
+	if sr.Diff != nil && !sr.Diff.Contains(e.File, 0) {
+		return false
+	}
+	for _, run := range sr.ToolRuns {
+		if run.Status != ToolStatusOK {
+			continue
+		}
+		for _, tool := range e.Tools {
+			if run.Tool == tool {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (b *Baseline) sort() {
+	sort.SliceStable(b.Entries, func(i, j int) bool {
+		ei, ej := b.Entries[i], b.Entries[j]
+		if ei.File != ej.File {
+			return ei.File < ej.File
+		}
+		if ei.Line != ej.Line {
+			return ei.Line < ej.Line
+		}
+		return ei.Fingerprint < ej.Fingerprint
+	})
+}
+
+// baselineFingerprints returns the baseline fingerprint of each finding.
+// Unlike Finding.Fingerprint, it does not depend on line numbers: it is
+// derived from the content of the finding's line, so that it survives the
+// lines added or removed above it. Identical findings in a file are told
+// apart by their order. File paths must be relative to root, as returned
+// by Correlate.
+func baselineFingerprints(root string, findings []Finding) []string {
+	order := make([]int, len(findings))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		fa, fb := findings[order[a]], findings[order[b]]
+		if fa.File != fb.File {
+			return fa.File < fb.File
+		}
+		return fa.Line < fb.Line
+	})
+
+	files := make(map[string][]string)
+	occurrences := make(map[string]int)
+	fps := make([]string, len(findings))
+	for _, i := range order {
+		f := findings[i]
+		parts := []string{strings.ToUpper(f.Type), f.File}
+		switch strings.ToUpper(f.Type) {
+		case "DEPENDENCY":
+			// The same vulnerability after an upgrade that does not fix it is still accepted
+			id := f.RuleID
+			if ids := vulnerabilityIDs(f); len(ids) > 0 {
+				id = ids[0]
+			}
+			name := ""
+			if f.Package != nil {
+				name = strings.ToLower(f.Package.Name)
+			}
+			parts = append(parts, id, name)
+		case "SECRET":
+			// Tools name the same secret differently: the line identifies it.
+			// Past commits do not change, so their line number is stable.
+			if f.HistoryOnly() {
+				parts = append(parts, f.Commit.SHA, strconv.Itoa(f.Line))
+			} else {
+				parts = append(parts, sourceLine(root, f, files))
+			}
+		case "LICENSE":
+			// An accepted license stays accepted when the package is upgraded
+			parts = append(parts, licenseSubject(f), strings.ToLower(f.RuleID))
+		default:
+			rule := f.RuleID
+			if rule == "" {
+				rule = f.Title
+			}
+			parts = append(parts, strings.ToLower(rule), sourceLine(root, f, files))
+		}
+
+		key := strings.Join(parts, "\x00")
+		parts = append(parts, strconv.Itoa(occurrences[key]))
+		occurrences[key]++
+
+		sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+		fps[i] = hex.EncodeToString(sum[:16])
+	}
+	return fps
+}
+
+// sourceLine returns the whitespace-normalized content of the line of a
+// finding, or "" when it has no line or its file cannot be read. files
+// caches the lines of the files already read.
+func sourceLine(root string, f Finding, files map[string][]string) string {
+	if f.File == "" || f.Line <= 0 {
+		return ""
+	}
+	lines, ok := files[f.File]
+	if !ok {
+		file := f.File
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(root, filepath.FromSlash(file))
+		}
+		if info, err := os.Stat(file); err == nil {
+			if data := readTextFile(file, info); data != nil {
+				lines = strings.Split(string(data), "\n")
+			}
+		}
+		files[f.File] = lines
+	}
+	if f.Line > len(lines) {
+		return ""
+	}
+	return strings.Join(strings.Fields(lines[f.Line-1]), " ")
+}
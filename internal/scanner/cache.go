@@ -0,0 +1,288 @@
+package scanner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dso-cli/dso-cli/internal/cache"
+	"github.com/dso-cli/dso-cli/internal/osv"
+)
+
+// unknownRuleSetTTL is how long the results of an external tool whose
+// rules or database cannot be identified are reused
+const unknownRuleSetTTL = 24 * time.Hour
+
+// RuleSetter is implemented by scanners whose findings depend on rules or
+// databases that can change without a new tool version
+type RuleSetter interface {
+	// RuleSet identifies the current rules and databases, or returns "" if unknown
+	RuleSet() string
+}
+
+// ProjectRuleSetter is implemented by scanners that read rules from the
+// scanned directory, in files that the tree hash ignores
+type ProjectRuleSetter interface {
+	// ProjectRuleSet identifies the rules of the project at root, or returns "" if it has none
+	ProjectRuleSet(root string) string
+}
+
+// TreeScanner is implemented by scanners that read the whole tree even
+// when an incremental scan limits the files to scan, so that their results
+// are cached under the tree hash during incremental scans too
+type TreeScanner interface {
+	// ScansWholeTree reports whether the scanner ignores the file filter
+	ScansWholeTree() bool
+}
+
+// FileScanner is implemented by scanners that examine each file on its own.
+// Their results are cached per file instead of per tree.
+type FileScanner interface {
+	Scanner
+	// ScanFile returns the findings of a single file. rel is its
+	// slash-separated path relative to the scanned directory.
+	ScanFile(path, rel string, info fs.FileInfo) []Finding
+}
+
+// ParsingFileScanner is implemented by scanners that parse each file on its
+// own. Like FileScanner, their results are cached per file, but the files
+// they could not parse are reported in ToolRun.Unparsed and never cached.
+type ParsingFileScanner interface {
+	Scanner
+	// ParseFile returns the findings of a single file, or an error when
+	// the file could not be parsed and was skipped
+	ParseFile(path, rel string, info fs.FileInfo) ([]Finding, error)
+}
+
+// scanCache looks up and stores the results of the scanners of a run
+type scanCache struct {
+	cache *cache.Cache
+	root  string
+
+	treeOnce sync.Once
+	tree     string
+	treeErr  error
+}
+
+// findingFormat is bumped when the findings mapped from tool outputs gain
+// fields or change IDs, or when files that native scanners skipped are
+// handled differently, so that the results cached before are discarded
+const findingFormat = "5"
+
+// cacheKey identifies the version and rule set of a scanner
+func cacheKey(s Scanner, version string) string {
+	ruleSet := ""
+	if rs, ok := s.(RuleSetter); ok {
+		ruleSet = rs.RuleSet()
+	}
+	return cache.Key(version, ruleSet, findingFormat)
+}
+
+// CacheKeys returns the cache key of every available scanner, as expected by cache.Prune
+func CacheKeys() map[string]string {
+	keys := make(map[string]string)
+	for _, s := range Registered() {
+		version := "built-in"
+		if tool, ok := s.(ExternalTool); ok {
+			if tool.Available() != nil {
+				continue
+			}
+			version = tool.Version()
+		}
+		keys[s.Name()] = cacheKey(s, version)
+	}
+	return keys
+}
+
+// treeHash returns the hash of every file of the scanned directory,
+// computed once per run. Whole-tree tools read all files, even during an
+// incremental scan; the results of a scan limited to the changed files are
+// not stored under it (see scan).
+func (sc *scanCache) treeHash(ctx context.Context) (string, error) {
+	sc.treeOnce.Do(func() {
+		files := make(map[string]string)
+		sc.treeErr = walkFiles(withFileFilter(ctx, nil), sc.root, func(p, rel string, info fs.FileInfo) error {
+			hash, err := sc.cache.FileHash(p, rel, info)
+			if err != nil {
+				return nil // unreadable files are unreadable for tools too
+			}
+			files[rel] = hash
+			return nil
+		})
+		sc.tree = cache.TreeHash(files)
+	})
+	return sc.tree, sc.treeErr
+}
+
+// scan runs s, reusing its cached results when the scanned files did not
+// change. It reports whether every result came from the cache.
+func (sc *scanCache) scan(ctx context.Context, s Scanner, path string, run *ToolRun) ([]Finding, bool, error) {
+	maxAge := time.Duration(0)
+	if _, external := s.(ExternalTool); external {
+		if rs, ok := s.(RuleSetter); !ok || rs.RuleSet() == "" {
+			maxAge = unknownRuleSetTTL
+		}
+	}
+	if t, ok := s.(*toolScanner); ok && t.scansHistory(ctx) {
+		// The commits are not part of the tree hash
+		findings, err := s.Scan(ctx, path)
+		return findings, false, err
+	}
+	tc := sc.cache.Tool(s.Name(), cacheKey(s, run.Version), maxAge)
+
+	if fileScanner, ok := s.(FileScanner); ok {
+		scanFile := func(p, rel string, info fs.FileInfo) ([]Finding, error) {
+			return fileScanner.ScanFile(p, rel, info), nil
+		}
+		return sc.scanFiles(ctx, scanFile, tc, path)
+	}
+	if parser, ok := s.(ParsingFileScanner); ok {
+		return sc.scanFiles(ctx, parser.ParseFile, tc, path)
+	}
+
+	tree, err := sc.treeHash(ctx)
+	if err != nil {
+		findings, err := s.Scan(ctx, path)
+		return findings, false, err
+	}
+
+	key := tree
+	if prs, ok := s.(ProjectRuleSetter); ok {
+		key = cache.Key(tree, prs.ProjectRuleSet(path))
+	}
+
+	var findings []Finding
+	if tc.Get(key, &findings) {
+		return findings, true, nil
+	}
+	findings, err = s.Scan(ctx, path)
+	wholeTree := !fileFiltered(ctx)
+	if ts, ok := s.(TreeScanner); ok && ts.ScansWholeTree() {
+		wholeTree = true
+	}
+	if err == nil && ctx.Err() == nil && wholeTree {
+		// Partial results of a timed out scan are not reusable, nor are
+		// the results of a scanner that only read the changed files of an
+		// incremental scan: the key is the hash of the whole tree
+		tc.Put(key, findings)
+	}
+	return findings, false, err
+}
+
+// scanFiles scans the files whose content is not in the cache
+func (sc *scanCache) scanFiles(ctx context.Context, scanFile func(path, rel string, info fs.FileInfo) ([]Finding, error), tc *cache.Tool, path string) ([]Finding, bool, error) {
+	var findings []Finding
+	allCached := true
+	err := walkFiles(ctx, path, func(p, rel string, info fs.FileInfo) error {
+		hash, err := sc.cache.FileHash(p, rel, info)
+		if err != nil {
+			return nil
+		}
+		key := cache.Key(rel, hash)
+
+		var cached []Finding
+		if tc.Get(key, &cached) {
+			findings = append(findings, cached...)
+			return nil
+		}
+		allCached = false
+		fileFindings, err := scanFile(p, rel, info)
+		if err != nil {
+			// Not cached, so that the file is reported on every run
+			recordUnparsed(ctx, rel, err)
+			return nil
+		}
+		tc.Put(key, fileFindings)
+		findings = append(findings, fileFindings...)
+		return nil
+	})
+	return findings, allCached, err
+}
+
+// RuleSet identifies the rules of the native secret detector
+func (secretDetector) RuleSet() string {
+	h := sha256.New()
+	for _, rule := range secretRules {
+		fmt.Fprintf(h, "%s\x00%s\x00%d\x00%s\x00", rule.ID, rule.Regex, rule.Group, strconv.FormatFloat(rule.MinEntropy, 'g', -1, 64))
+	}
+	fmt.Fprintf(h, "%s\x00%s", secretPathAllowlist, secretValueAllowlist)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// RuleSet identifies the checks of the native Kubernetes checker
+func (kubernetesChecker) RuleSet() string {
+	return nativeRuleSet(k8sRules, podSpecPaths, dangerousCapabilities, sensitiveHostPaths)
+}
+
+// RuleSet identifies the checks of the native Dockerfile checker
+func (dockerfileChecker) RuleSet() string {
+	return nativeRuleSet(dockerfileRules, curlPipeShellRegex, aptInstallRegex, secretVariableRegex, publicVariableRegex, nonRootImageRegex)
+}
+
+// RuleSet identifies the checks of the native CI workflow checker
+func (workflowChecker) RuleSet() string {
+	return nativeRuleSet(ciRules, untrustedContextRegex, trustedFieldRegex, pullRequestHeadRegex,
+		curlPipeShellRegex, secretVariableRegex, publicVariableRegex, echoRegex)
+}
+
+// RuleSet identifies the built-in rules of the native Terraform checker
+func (terraformChecker) RuleSet() string {
+	h := sha256.Sum256(builtinTerraformRules)
+	return hex.EncodeToString(h[:])
+}
+
+// nativeRuleSet hashes the rules of a native scanner and the tables they use
+func nativeRuleSet(rules map[string]nativeRule, tables ...any) string {
+	ids := make([]string, 0, len(rules))
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	h := sha256.New()
+	for _, id := range ids {
+		rule := rules[id]
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00", id, rule.Title, rule.Severity, rule.Fix)
+	}
+	for _, table := range tables {
+		fmt.Fprintf(h, "%v\x00", table)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// RuleSet identifies the imported advisory database
+func (osvScanner) RuleSet() string {
+	store, err := osv.OpenDefaultStore()
+	if err != nil {
+		return ""
+	}
+	meta, err := store.Metadata()
+	if err != nil {
+		return ""
+	}
+	return meta.UpdatedAt.Format(time.RFC3339Nano)
+}
+
+// RuleSet identifies the vulnerability database of the tool, when it can
+// report it, looked up once per command
+func (t *toolScanner) RuleSet() string {
+	if len(t.dbVersion) == 0 {
+		return ""
+	}
+	key := t.command + " db"
+	if v, ok := toolVersions.Load(key); ok {
+		return v.(string)
+	}
+	v := ""
+	if output, err := runTool(context.Background(), "", t.command, t.dbVersion...); err == nil {
+		sum := sha256.Sum256(output)
+		v = hex.EncodeToString(sum[:])
+	}
+	toolVersions.Store(key, v)
+	return v
+}
@@ -0,0 +1,265 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// cweRegex matches a CWE identifier with or without its prefix
+var cweRegex = regexp.MustCompile(`(?i)^\s*(?:CWE-?)?(\d+)\b`)
+
+// owaspRegex matches an OWASP Top 10 2021 category ("A03:2021 - Injection")
+var owaspRegex = regexp.MustCompile(`(?i)\b(A(?:0[1-9]|10):2021)\b`)
+
+// normalizeCWE returns "CWE-<n>" for a tool's CWE reference, like "89",
+// "CWE-89" or "CWE-89: Improper Neutralization...", or "" when it is not one
+func normalizeCWE(ref string) string {
+	m := cweRegex.FindStringSubmatch(ref)
+	if m == nil {
+		return ""
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil || n <= 0 {
+		return ""
+	}
+	return "CWE-" + strconv.Itoa(n)
+}
+
+// CWEName returns the short name of a CWE, or "" when it is not known
+func CWEName(cwe string) string {
+	return cweNames[cwe]
+}
+
+// classify sets the CWE, OWASP Top 10 2021 and ASVS references of findings.
+// CWE reported by the tools are kept; the others come from the embedded
+// rule table, then from the type and title of the finding. OWASP and ASVS
+// references are derived from the CWE.
+func classify(findings []Finding) {
+	for i := range findings {
+		f := &findings[i]
+		f.CWE = normalizeCWEs(f.CWE)
+		if len(f.CWE) == 0 {
+			if cwe := defaultCWE(f); cwe != "" {
+				f.CWE = []string{cwe}
+			}
+		}
+
+		owasp := normalizeOWASP(f.OWASP)
+		var asvs []string
+		if strings.EqualFold(f.Type, "DEPENDENCY") {
+			// The problem is the outdated component, whatever its weakness
+			owasp = appendUnique(owasp, owaspCategories["A06:2021"])
+			asvs = appendUnique(asvs, asvsCWE["CWE-1104"]...)
+		}
+		for _, cwe := range f.CWE {
+			if !strings.EqualFold(f.Type, "DEPENDENCY") {
+				owasp = appendUnique(owasp, owaspForCWE(cwe)...)
+			}
+			asvs = appendUnique(asvs, asvsCWE[cwe]...)
+		}
+		sort.Strings(owasp)
+		f.OWASP = owasp
+		f.ASVS = appendUnique(normalizeASVS(f.ASVS), asvs...)
+	}
+}
+
+// normalizeCWEs normalizes and deduplicates CWE references
+func normalizeCWEs(refs []string) []string {
+	var cwes []string
+	for _, ref := range refs {
+		if cwe := normalizeCWE(ref); cwe != "" {
+			cwes = appendUnique(cwes, cwe)
+		}
+	}
+	return cwes
+}
+
+// normalizeOWASP keeps the 2021 categories of OWASP references, with their
+// canonical name
+func normalizeOWASP(refs []string) []string {
+	var categories []string
+	for _, ref := range refs {
+		if m := owaspRegex.FindStringSubmatch(ref); m != nil {
+			if name, ok := owaspCategories[strings.ToUpper(m[1])]; ok {
+				categories = appendUnique(categories, name)
+			}
+		}
+	}
+	return categories
+}
+
+// normalizeASVS returns ASVS requirements as "V<chapter>.<section>.<n>"
+func normalizeASVS(refs []string) []string {
+	var reqs []string
+	for _, ref := range refs {
+		ref = strings.TrimLeft(strings.TrimSpace(ref), "Vv")
+		if ref != "" {
+			reqs = appendUnique(reqs, "V"+ref)
+		}
+	}
+	return reqs
+}
+
+// defaultCWE returns the CWE of a finding whose tool did not report one
+func defaultCWE(f *Finding) string {
+	for _, id := range []string{f.RuleID, f.Title} {
+		if cwe, ok := ruleCWE[id]; ok {
+			return cwe
+		}
+	}
+	switch strings.ToUpper(f.Type) {
+	case "SECRET":
+		return "CWE-798"
+	case "IAC", "CONTAINER":
+		title := strings.ToLower(f.Title)
+		for _, k := range keywordCWE {
+			for _, keyword := range k.keywords {
+				if strings.Contains(title, keyword) {
+					return k.cwe
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// knownCWE returns the CWE of a rule of the embedded table, for tools
+// whose findings do not keep the rule ID
+func knownCWE(rule string) []string {
+	if cwe, ok := ruleCWE[rule]; ok {
+		return []string{cwe}
+	}
+	return nil
+}
+
+// owaspForCWE returns the OWASP Top 10 2021 categories of a CWE
+func owaspForCWE(cwe string) []string {
+	n, err := strconv.Atoi(strings.TrimPrefix(cwe, "CWE-"))
+	if err != nil {
+		return nil
+	}
+	var categories []string
+	for id, cwes := range owaspCWE {
+		for _, c := range cwes {
+			if c == n {
+				categories = append(categories, owaspCategories[id])
+				break
+			}
+		}
+	}
+	return categories
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		if !containsString(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// refList reads the CWE or OWASP references of tool metadata, given as a
+// string, a number, an object with an id, or a list of them
+type refList []string
+
+func (l *refList) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	values, ok := raw.([]interface{})
+	if !ok {
+		values = []interface{}{raw}
+	}
+	for _, v := range values {
+		if obj, ok := v.(map[string]interface{}); ok {
+			// gosec and bandit: {"id": "89", "link": "..."}
+			v = obj["id"]
+		}
+		switch v := v.(type) {
+		case string:
+			*l = append(*l, v)
+		case float64:
+			*l = append(*l, strconv.Itoa(int(v)))
+		}
+	}
+	return nil
+}
+
+// FindingGroup is a group of findings sharing a classification
+type FindingGroup struct {
+	Key      string   `json:"key"`            // CWE-89, A03:2021-Injection, or "unclassified"
+	Name     string   `json:"name,omitempty"` // Name of the CWE
+	Severity Severity `json:"severity"`       // Highest severity of the group
+	Findings []string `json:"findings"`       // Fingerprints of the findings
+}
+
+// Grouping is the report of `dso audit --group-by`
+type Grouping struct {
+	By     string         `json:"by"`
+	Groups []FindingGroup `json:"groups"`
+}
+
+// GroupByValues are the accepted values of --group-by
+var GroupByValues = []string{"cwe", "owasp"}
+
+// GroupFindings groups the active findings of results by CWE or OWASP
+// category. A finding with several references is in each of their groups;
+// findings without one are grouped under "unclassified", listed last.
+func GroupFindings(results *ScanResults, by string) (*Grouping, error) {
+	var keysOf func(f Finding) []string
+	switch by {
+	case "cwe":
+		keysOf = func(f Finding) []string { return f.CWE }
+	case "owasp":
+		keysOf = func(f Finding) []string { return f.OWASP }
+	default:
+		return nil, fmt.Errorf("unknown grouping %q (%s)", by, strings.Join(GroupByValues, ", "))
+	}
+
+	index := make(map[string]int)
+	var groups []FindingGroup
+	for _, f := range results.Active() {
+		keys := keysOf(f)
+		if len(keys) == 0 {
+			keys = []string{"unclassified"}
+		}
+		for _, key := range keys {
+			i, ok := index[key]
+			if !ok {
+				i = len(groups)
+				index[key] = i
+				groups = append(groups, FindingGroup{Key: key, Severity: SeverityInfo})
+				if by == "cwe" {
+					groups[i].Name = CWEName(key)
+				}
+			}
+			g := &groups[i]
+			// IDs are not unique: one CVE affects several packages
+			g.Findings = append(g.Findings, f.Fingerprint)
+			if severityRank[f.Severity] > severityRank[g.Severity] {
+				g.Severity = f.Severity
+			}
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		gi, gj := groups[i], groups[j]
+		if (gi.Key == "unclassified") != (gj.Key == "unclassified") {
+			return gj.Key == "unclassified"
+		}
+		if severityRank[gi.Severity] != severityRank[gj.Severity] {
+			return severityRank[gi.Severity] > severityRank[gj.Severity]
+		}
+		if len(gi.Findings) != len(gj.Findings) {
+			return len(gi.Findings) > len(gj.Findings)
+		}
+		return gi.Key < gj.Key
+	})
+	return &Grouping{By: by, Groups: groups}, nil
+}
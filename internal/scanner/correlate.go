@@ -0,0 +1,312 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultConfidence is the confidence of a finding reported by a single tool
+const defaultConfidence = 0.6
+
+// vulnIDRegex matches the vulnerability identifiers shared between tools
+var vulnIDRegex = regexp.MustCompile(`(?i)\b(CVE-\d{4}-\d{4,}|GHSA(?:-[a-z0-9]{4}){3}|GO-\d{4}-\d{4,}|PYSEC-\d{4}-\d+|RUSTSEC-\d{4}-\d{4}|SNYK-[A-Z0-9]+(?:-[A-Z0-9]+)*)\b`)
+
+// severityRank orders severities from the least to the most severe
+var severityRank = map[Severity]int{
+	SeverityInfo:     0,
+	SeverityLow:      1,
+	SeverityMedium:   2,
+	SeverityHigh:     3,
+	SeverityCritical: 4,
+}
+
+// Correlate merges the findings that several tools reported for the same
+// problem. Findings are correlated by normalized path, line, rule,
+// vulnerability identifiers (including aliases) and package coordinates:
+//   - dependency findings sharing a vulnerability identifier for the same package
+//   - secrets found at the same location
+//   - other findings with the same rule or vulnerability at the same location
+//
+// The merged finding lists every tool in Tools, keeps the highest severity
+// and has a higher confidence than each tool alone. File paths are made
+// relative to root. The order of first occurrence is preserved.
+func Correlate(root string, findings []Finding) []Finding {
+	for i := range findings {
+		findings[i].File = normalizeFindingPath(root, findings[i].File)
+	}
+
+	// Union-find over the findings sharing a correlation key
+	parent := make([]int, len(findings))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	owner := make(map[string]int)
+	for i, f := range findings {
+		for _, key := range correlationKeys(f) {
+			if j, ok := owner[key]; ok {
+				a, b := find(i), find(j)
+				if a < b {
+					a, b = b, a
+				}
+				parent[a] = b
+			} else {
+				owner[key] = i
+			}
+		}
+	}
+
+	groups := make(map[int][]Finding)
+	var order []int
+	for i, f := range findings {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			order = append(order, root)
+		}
+		groups[root] = append(groups[root], f)
+	}
+
+	merged := make([]Finding, 0, len(order))
+	for _, root := range order {
+		merged = append(merged, mergeFindings(groups[root]))
+	}
+	return merged
+}
+
+// normalizeFindingPath returns file as a clean slash-separated path,
+// relative to root when it is inside it
+func normalizeFindingPath(root, file string) string {
+	if file == "" {
+		return ""
+	}
+	if filepath.IsAbs(file) && root != "" {
+		if rel, err := filepath.Rel(root, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+	}
+	file = path.Clean(filepath.ToSlash(file))
+	return strings.TrimPrefix(file, "./")
+}
+
+// vulnerabilityIDs returns the normalized vulnerability identifiers of a
+// finding, taken from its ID, rule and aliases
+func vulnerabilityIDs(f Finding) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, s := range append([]string{f.ID, f.RuleID}, f.Aliases...) {
+		for _, id := range vulnIDRegex.FindAllString(s, -1) {
+			id = strings.ToUpper(id)
+			if strings.HasPrefix(id, "GHSA-") {
+				// GHSA identifiers are lowercase after the prefix
+				id = "GHSA-" + strings.ToLower(id[5:])
+			}
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// packageCoordinates returns "name@version" for dependency findings, or ""
+func packageCoordinates(f Finding) string {
+	if f.Package == nil || f.Package.Name == "" {
+		return ""
+	}
+	version := strings.TrimPrefix(f.Package.InstalledVersion, "v")
+	return strings.ToLower(f.Package.Name) + "@" + version
+}
+
+// correlationKeys returns the keys under which a finding is correlated.
+// Two findings sharing a key describe the same problem.
+func correlationKeys(f Finding) []string {
+	findingType := strings.ToUpper(f.Type)
+	location := ""
+	if f.File != "" && f.Line > 0 {
+		location = f.File + ":" + strconv.Itoa(f.Line)
+	}
+
+	var keys []string
+	switch findingType {
+	case "DEPENDENCY":
+		pkg := packageCoordinates(f)
+		for _, id := range vulnerabilityIDs(f) {
+			keys = append(keys, "vuln|"+id+"|"+pkg)
+		}
+		if pkg != "" && f.RuleID != "" {
+			// Advisories without a well-known identifier
+			keys = append(keys, "rule|"+findingType+"|"+pkg+"|"+strings.ToLower(f.RuleID))
+		}
+		if pkg == "" {
+			keys = append(keys, "id|"+f.ID)
+		}
+	case "SECRET":
+		if location != "" && f.HistoryOnly() {
+			// The location is in the commit, not in the working tree
+			keys = append(keys, "secret|"+f.Commit.SHA+"|"+location)
+		} else if location != "" {
+			keys = append(keys, "secret|"+location)
+		} else {
+			// IDs do not always carry the line: two keys of the same
+			// type in one file would be merged
+			keys = append(keys, "id|"+f.ID)
+		}
+	case "LICENSE":
+		// Tools read the license of a package from different files
+		keys = append(keys, "license|"+licenseSubject(f)+"|"+strings.ToLower(f.RuleID))
+	default:
+		if location != "" {
+			if f.RuleID != "" {
+				keys = append(keys, "rule|"+findingType+"|"+location+"|"+strings.ToLower(f.RuleID))
+			}
+			for _, id := range vulnerabilityIDs(f) {
+				keys = append(keys, "vuln|"+location+"|"+id)
+			}
+		}
+		keys = append(keys, "id|"+f.ID)
+	}
+	return keys
+}
+
+// mergeFindings merges correlated findings into one. The most severe (then
+// most detailed) finding is kept as the base.
+func mergeFindings(group []Finding) Finding {
+	primary := 0
+	for i, f := range group {
+		p := group[primary]
+		if severityRank[f.Severity] > severityRank[p.Severity] ||
+			(f.Severity == p.Severity && len(f.Description) > len(p.Description)) {
+			primary = i
+		}
+	}
+	merged := group[primary]
+	// Classifications are the union of every tool's
+	merged.CWE, merged.OWASP, merged.ASVS = nil, nil, nil
+
+	// Highest confidence reported by each tool
+	toolConfidence := make(map[string]float64)
+	var tools []string
+	aliases := make(map[string]bool)
+	for _, f := range group {
+		for _, tool := range findingTools(f) {
+			if _, ok := toolConfidence[tool]; !ok {
+				tools = append(tools, tool)
+			}
+			c := f.Confidence
+			if c == 0 {
+				c = defaultConfidence
+			}
+			toolConfidence[tool] = math.Max(toolConfidence[tool], c)
+		}
+
+		for _, id := range append(vulnerabilityIDs(f), f.Aliases...) {
+			aliases[id] = true
+		}
+		merged.CWE = appendUnique(merged.CWE, f.CWE...)
+		merged.OWASP = appendUnique(merged.OWASP, f.OWASP...)
+		merged.ASVS = appendUnique(merged.ASVS, f.ASVS...)
+		if f.Line > 0 && (merged.Line == 0 || f.Line < merged.Line) {
+			merged.Line, merged.Column = f.Line, f.Column
+		}
+		if merged.File == "" {
+			merged.File = f.File
+		}
+		merged.Package = mergePackage(merged.Package, f.Package)
+		if f.Commit != nil && (merged.Commit == nil || f.Commit.Date.Before(merged.Commit.Date)) {
+			// The commit that introduced the secret
+			merged.Commit = f.Commit
+		}
+		if merged.Fix == "" {
+			merged.Fix = f.Fix
+		}
+		if merged.Replacement == nil {
+			merged.Replacement = f.Replacement
+		}
+		if merged.CVSS < f.CVSS {
+			merged.CVSS = f.CVSS
+		}
+		merged.Fixable = merged.Fixable || f.Fixable
+		merged.Exploitable = merged.Exploitable || f.Exploitable
+	}
+	// The fixed versions known to every tool give the best upgrade
+	merged.setUpgrade()
+
+	// The confidence of independent tools combines like probabilities
+	doubt := 1.0
+	for _, c := range toolConfidence {
+		doubt *= 1 - c
+	}
+	merged.Confidence = math.Round((1-doubt)*100) / 100
+	merged.Tools = tools
+
+	delete(aliases, merged.ID)
+	delete(aliases, merged.RuleID)
+	merged.Aliases = nil
+	for id := range aliases {
+		merged.Aliases = append(merged.Aliases, id)
+	}
+	sort.Strings(merged.Aliases)
+
+	merged.Fingerprint = fingerprint(merged)
+	return merged
+}
+
+// findingTools returns the tools that reported a finding
+func findingTools(f Finding) []string {
+	if len(f.Tools) > 0 {
+		return f.Tools
+	}
+	return []string{f.Tool}
+}
+
+// fingerprint returns a stable identifier of a finding, derived from the same
+// attributes as the correlation keys
+func fingerprint(f Finding) string {
+	parts := []string{strings.ToUpper(f.Type), f.File}
+	switch strings.ToUpper(f.Type) {
+	case "DEPENDENCY":
+		ids := vulnerabilityIDs(f)
+		id := f.RuleID
+		if len(ids) > 0 {
+			id = ids[0] // sorted: CVE identifiers come first
+		}
+		parts = append(parts, id, packageCoordinates(f))
+	case "SECRET":
+		parts = append(parts, strconv.Itoa(f.Line))
+		if f.HistoryOnly() {
+			parts = append(parts, f.Commit.SHA)
+		}
+	case "LICENSE":
+		parts = append(parts, licenseSubject(f), strings.ToLower(f.RuleID))
+	default:
+		parts = append(parts, strconv.Itoa(f.Line), strings.ToLower(f.RuleID))
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// licenseSubject identifies what a LICENSE finding applies to: the
+// package, or the license file of code copied into the project
+func licenseSubject(f Finding) string {
+	if f.Package != nil && f.Package.Name != "" {
+		return strings.ToLower(f.Package.Name)
+	}
+	return f.File
+}
@@ -0,0 +1,241 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dso-cli/dso-cli/internal/inventory"
+)
+
+// DiffScope is the set of changes an incremental scan is limited to
+type DiffScope struct {
+	Base   string   `json:"base,omitempty"`   // Ref given with --since
+	Commit string   `json:"commit,omitempty"` // Commit the ref resolved to
+	Staged bool     `json:"staged,omitempty"` // Only staged changes (index instead of working tree)
+	Files  []string `json:"files"`            // Changed files, relative to the scanned directory
+
+	lines map[string][]lineRange // Added or modified lines by file
+}
+
+// lineRange is an inclusive range of lines
+type lineRange struct {
+	start, end int
+}
+
+// wholeFile is the range of a new or untracked file
+var wholeFile = lineRange{1, math.MaxInt}
+
+// dependencyManifests are the dependency files not read by the inventory
+var dependencyManifests = map[string]bool{
+	"package.json":     true,
+	"pyproject.toml":   true,
+	"Pipfile":          true,
+	"Cargo.toml":       true,
+	"composer.json":    true,
+	"Gemfile":          true,
+	"pom.xml":          true,
+	"build.gradle":     true,
+	"build.gradle.kts": true,
+}
+
+// ChangedFiles computes the files and lines of root changed since a git ref.
+// Without since, changes are compared to HEAD. With staged, the index is
+// compared instead of the working tree; otherwise untracked files count as
+// changed too. Deleted files are not included.
+func ChangedFiles(ctx context.Context, root, since string, staged bool) (*DiffScope, error) {
+	scope := &DiffScope{Base: since, Staged: staged, Files: []string{}, lines: make(map[string][]lineRange)}
+
+	ref := since
+	if ref == "" {
+		ref = "HEAD"
+	}
+	commit, err := gitOutput(ctx, root, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve git ref %q in %s: %w", ref, root, err)
+	}
+	scope.Commit = strings.TrimSpace(string(commit))
+
+	args := []string{"diff", "--unified=0", "--no-color", "--no-ext-diff", "--diff-filter=d", "--relative", "--src-prefix=a/", "--dst-prefix=b/"}
+	if staged {
+		args = append(args, "--cached")
+	}
+	args = append(args, scope.Commit, "--")
+	diff, err := gitOutput(ctx, root, args...)
+	if err != nil {
+		return nil, err
+	}
+	parseUnifiedDiff(diff, scope)
+
+	if !staged {
+		untracked, err := gitOutput(ctx, root, "ls-files", "--others", "--exclude-standard")
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range strings.Split(string(untracked), "\n") {
+			if file = unquoteGitPath(file); file != "" {
+				scope.addRange(file, wholeFile)
+			}
+		}
+	}
+
+	for file := range scope.lines {
+		scope.Files = append(scope.Files, file)
+	}
+	sort.Strings(scope.Files)
+	return scope, nil
+}
+
+// gitOutput runs git in dir and returns its standard output
+func gitOutput(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	subcommand := args[0]
+	args = append([]string{"-C", dir, "-c", "core.quotePath=false"}, args...)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", subcommand, msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", subcommand, err)
+	}
+	return out, nil
+}
+
+// parseUnifiedDiff records the files and added lines of a diff produced with --unified=0
+func parseUnifiedDiff(diff []byte, scope *DiffScope) {
+	var file string
+	sc := bufio.NewScanner(bytes.NewReader(diff))
+	sc.Buffer(make([]byte, 64*1024), 16<<20)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			file = ""
+		case strings.HasPrefix(line, "+++ "):
+			file = strings.TrimPrefix(unquoteGitPath(line[4:]), "b/")
+			if _, ok := scope.lines[file]; !ok {
+				// A file whose changes only remove lines is still changed
+				scope.lines[file] = nil
+			}
+		case strings.HasPrefix(line, "Binary files ") && strings.HasSuffix(line, " differ"):
+			if i := strings.LastIndex(line, " and "); i >= 0 {
+				name := strings.TrimSuffix(line[i+5:], " differ")
+				scope.addRange(strings.TrimPrefix(unquoteGitPath(name), "b/"), wholeFile)
+			}
+		case strings.HasPrefix(line, "@@ ") && file != "":
+			// @@ -l,s +l,s @@
+			fields := strings.Fields(line)
+			if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+				continue
+			}
+			start, count := parseHunkRange(fields[2][1:])
+			if count > 0 {
+				scope.addRange(file, lineRange{start, start + count - 1})
+			}
+		}
+	}
+}
+
+// parseHunkRange parses "start,count" or "start" (count 1)
+func parseHunkRange(s string) (start, count int) {
+	count = 1
+	if i := strings.IndexByte(s, ','); i >= 0 {
+		count, _ = strconv.Atoi(s[i+1:])
+		s = s[:i]
+	}
+	start, _ = strconv.Atoi(s)
+	return start, count
+}
+
+// unquoteGitPath removes the C-style quotes git adds to unusual paths
+func unquoteGitPath(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, `"`) {
+		if unquoted, err := strconv.Unquote(s); err == nil {
+			return unquoted
+		}
+	}
+	return s
+}
+
+func (d *DiffScope) addRange(file string, r lineRange) {
+	d.lines[file] = append(d.lines[file], r)
+}
+
+// Contains reports whether a finding location is part of the changes.
+// Findings without a line match any change of their file; findings
+// without a file cannot be attributed to a change and always match.
+func (d *DiffScope) Contains(file string, line int) bool {
+	if file == "" {
+		return true
+	}
+	ranges, ok := d.lines[path.Clean(filepath.ToSlash(file))]
+	if !ok {
+		return false
+	}
+	if line <= 0 {
+		return true
+	}
+	for _, r := range ranges {
+		if line >= r.start && line <= r.end {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the findings located in the changes. File paths must be
+// relative to the scanned directory, as returned by Correlate.
+func (d *DiffScope) Filter(findings []Finding) []Finding {
+	kept := findings[:0]
+	for _, f := range findings {
+		if d.Contains(f.File, f.Line) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// HasDependencyChanges reports whether a lockfile or dependency manifest changed
+func (d *DiffScope) HasDependencyChanges() bool {
+	for _, file := range d.Files {
+		name := path.Base(file)
+		if inventory.IsLockfile(name) || dependencyManifests[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// Describe returns a short description of the scope, like "3 files changed since main (1a2b3c4)"
+func (d *DiffScope) Describe() string {
+	files := fmt.Sprintf("%d files", len(d.Files))
+	if len(d.Files) == 1 {
+		files = "1 file"
+	}
+	commit := d.Commit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+
+	switch {
+	case d.Staged && d.Base != "":
+		return fmt.Sprintf("%s staged since %s (%s)", files, d.Base, commit)
+	case d.Staged:
+		return fmt.Sprintf("%s staged", files)
+	case d.Base != "":
+		return fmt.Sprintf("%s changed since %s (%s)", files, d.Base, commit)
+	default:
+		return fmt.Sprintf("%s changed since HEAD (%s)", files, commit)
+	}
+}
@@ -0,0 +1,527 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dso-cli/dso-cli/internal/dockerfile"
+)
+
+func init() {
+	Register(dockerfileChecker{})
+}
+
+// dockerfileRules are the checks of the native Dockerfile checker
+var dockerfileRules = map[string]nativeRule{
+	"dockerfile-root-user": {
+		Title:       "Container runs as root",
+		Severity:    SeverityHigh,
+		Description: "A process that escapes the application gets root privileges in the container, and on the host when user namespaces are not used.",
+		Fix:         "Create an unprivileged user and switch to it before the entrypoint: RUN useradd -r -u 10001 app, then USER 10001",
+	},
+	"dockerfile-unpinned-base": {
+		Title:       "Base image not pinned by digest",
+		Severity:    SeverityLow,
+		Description: "A tag can be moved to another image at any time, so the build is not reproducible and can pull a compromised image.",
+		Fix:         "Pin the base image to its digest: FROM image:tag@sha256:...",
+	},
+	"dockerfile-add-url": {
+		Title:       "ADD downloads a remote file",
+		Severity:    SeverityMedium,
+		Description: "ADD downloads the file without verifying it, so a compromised or hijacked server can inject content in the image.",
+		Fix:         "Download the file with RUN curl and verify its checksum, or use ADD --checksum=sha256:...",
+	},
+	"dockerfile-curl-pipe-shell": {
+		Title:       "Remote script piped to a shell",
+		Severity:    SeverityHigh,
+		Description: "The script is run as soon as it is downloaded, without any verification: a compromised server, or a truncated download, runs arbitrary commands in the build.",
+		Fix:         "Download the script to a file, verify its checksum or signature, then run it; or install the tool from a package",
+	},
+	"dockerfile-secret-env": {
+		Title:       "Secret in ENV",
+		Severity:    SeverityHigh,
+		Description: "ENV values are stored in the image configuration: anyone who can pull the image can read them with docker inspect.",
+		Fix:         "Remove the secret from the Dockerfile and provide it at runtime, with an environment variable or a mounted secret",
+	},
+	"dockerfile-secret-arg": {
+		Title:       "Secret in ARG",
+		Severity:    SeverityMedium,
+		Description: "Build arguments are recorded in the image history: anyone who can pull the image can read them with docker history.",
+		Fix:         "Use a build secret instead: RUN --mount=type=secret,id=token, built with docker build --secret id=token,env=TOKEN",
+	},
+	"dockerfile-missing-healthcheck": {
+		Title:       "Missing HEALTHCHECK",
+		Severity:    SeverityLow,
+		Description: "Without HEALTHCHECK, the runtime cannot tell a hung container from a healthy one, and does not restart or replace it.",
+		Fix:         "Add a HEALTHCHECK instruction that checks the service, such as HEALTHCHECK CMD wget -q --spider http://localhost:8080/health || exit 1",
+	},
+	"dockerfile-apt-cache": {
+		Title:       "apt cache left in the image",
+		Severity:    SeverityLow,
+		Description: "The package lists downloaded by apt-get update stay in the layer, which makes the image larger and its content stale.",
+		Fix:         "Remove the package lists in the same RUN: && rm -rf /var/lib/apt/lists/*",
+	},
+	"dockerfile-copy-all": {
+		Title:       "Build context copied without .dockerignore",
+		Severity:    SeverityMedium,
+		Description: "Without .dockerignore, copying the whole build context adds the .git directory, .env files, keys and local build outputs to the image.",
+		Fix:         "Create a .dockerignore next to the Dockerfile that excludes .git, .env*, *.pem, *.key, node_modules and the build outputs, or copy only the needed files",
+	},
+}
+
+var (
+	// curlPipeShellRegex matches a download piped to a shell, or run by a
+	// shell through a command or process substitution
+	curlPipeShellRegex = regexp.MustCompile(`\b(curl|wget)\b[^|;&\n]*\|\s*(sudo\s+(-\S+\s+)*)?(\S*/)?(ba|z|da|k|a)?sh\b|\b(ba|z|da|k|a)?sh\b[^|;&\n]*(<\(|\$\()\s*(curl|wget)\b`)
+	// aptInstallRegex matches the installation of packages with apt
+	aptInstallRegex = regexp.MustCompile(`\bapt(-get)?\s+(-\S+\s+)*install\b`)
+	// secretVariableRegex matches the variable names that hold secrets
+	secretVariableRegex = regexp.MustCompile(`(?i)(^|_)(PASSWORD|PASSWD|SECRET|TOKEN|API_?KEY|ACCESS_?KEY|PRIVATE_?KEY|CREDENTIALS?|AUTH)($|_)`)
+	// publicVariableRegex matches the variable names that refer to a secret
+	// without holding it
+	publicVariableRegex = regexp.MustCompile(`(?i)_(FILE|PATH|DIR|URL|URI|HOST|PORT|NAME|ID|TYPE|ENABLED|LENGTH|TTL|EXPIRY|HEADER)$`)
+)
+
+// nonRootImageRegex matches the base images that run as a non-root user
+// by default
+var nonRootImageRegex = regexp.MustCompile(`[:-]nonroot\b|^cgr\.dev/chainguard/`)
+
+var (
+	// probeInstallRegex matches the installation of curl or wget with a
+	// package manager
+	probeInstallRegex = regexp.MustCompile(`\b(apt(-get)?|apk|yum|dnf|microdnf|zypper)\s[^;&|\n]*\b(add|install)\s[^;&|\n]*\b(curl|wget)\b`)
+	// busyboxImageRegex matches the images based on BusyBox, whose shell
+	// comes with wget
+	busyboxImageRegex = regexp.MustCompile(`(^|/)(alpine|busybox)(:|$)|[:-]alpine`)
+	// shelllessImageRegex matches the images that have no shell
+	shelllessImageRegex = regexp.MustCompile(`(?i)^scratch$|distroless|^cgr\.dev/chainguard/`)
+)
+
+// httpPorts are the exposed ports that a HEALTHCHECK can probe over HTTP:
+// the ports that web frameworks and servers listen on by default
+var httpPorts = map[string]bool{
+	"80": true, "3000": true, "4000": true, "5000": true, "8000": true,
+	"8008": true, "8080": true, "8081": true, "8088": true, "8888": true,
+}
+
+// nonRootUser is the user suggested for the images that run as root
+const nonRootUser = "10001"
+
+// isDockerfile reports whether name is the name of a Dockerfile:
+// Dockerfile, Dockerfile.prod, api.Dockerfile or Containerfile
+func isDockerfile(name string) bool {
+	lower := strings.ToLower(name)
+	if strings.HasSuffix(lower, ".dockerignore") {
+		return false
+	}
+	for _, base := range []string{"dockerfile", "containerfile"} {
+		if lower == base || strings.HasPrefix(lower, base+".") || strings.HasSuffix(lower, "."+base) {
+			return true
+		}
+	}
+	return false
+}
+
+// dockerfileChecker checks the Dockerfiles of the project, instruction by
+// instruction, without an external tool. It is not a FileScanner: the
+// copy of the build context depends on the .dockerignore files.
+type dockerfileChecker struct{}
+
+func (dockerfileChecker) Name() string               { return "dso-dockerfile" }
+func (dockerfileChecker) Category() Category         { return CategoryContainers }
+func (dockerfileChecker) Applicable(p *Project) bool { return p.HasDocker }
+
+// ScansWholeTree reports that every Dockerfile is checked during an
+// incremental scan too: the findings of the unchanged ones are dropped by
+// the scope, and the results are cached for the whole tree
+func (dockerfileChecker) ScansWholeTree() bool { return true }
+
+// Scan walks root and checks every Dockerfile. Files that cannot be
+// parsed, such as templates, are skipped.
+func (dockerfileChecker) Scan(ctx context.Context, root string) ([]Finding, error) {
+	var findings []Finding
+	now := time.Now()
+	err := walkFiles(withFileFilter(ctx, nil), root, func(p, rel string, info fs.FileInfo) error {
+		if !isDockerfile(info.Name()) {
+			return nil
+		}
+		data := readTextFile(p, info)
+		if data == nil {
+			return nil
+		}
+		d, err := dockerfile.Parse(data)
+		if err != nil {
+			return nil
+		}
+		check := &dockerfileCheck{file: rel, now: now, d: d, dockerignore: hasDockerignore(root, p)}
+		check.check()
+		findings = append(findings, check.findings...)
+		return nil
+	})
+	return findings, err
+}
+
+// hasDockerignore reports whether a .dockerignore applies to the
+// Dockerfile at file: next to it, specific to it (Dockerfile.dockerignore)
+// or at the root of the project
+func hasDockerignore(root, file string) bool {
+	for _, candidate := range []string{
+		filepath.Join(filepath.Dir(file), ".dockerignore"),
+		file + ".dockerignore",
+		filepath.Join(root, ".dockerignore"),
+	} {
+		if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() {
+			return true
+		}
+	}
+	return false
+}
+
+// dockerfileCheck collects the findings of a Dockerfile
+type dockerfileCheck struct {
+	file         string
+	now          time.Time
+	d            *dockerfile.Dockerfile
+	dockerignore bool
+	findings     []Finding
+}
+
+// check checks the instructions of the Dockerfile, then its final stage
+func (c *dockerfileCheck) check() {
+	globals := c.d.GlobalArgs()
+	for i, stage := range c.d.Stages {
+		c.baseImage(stage, i, globals)
+	}
+
+	for _, in := range c.d.Instructions {
+		switch in.Command {
+		case "ADD":
+			c.addURL(in)
+			c.copyAll(in)
+		case "COPY":
+			c.copyAll(in)
+		case "RUN":
+			c.runInstruction(in)
+		case "ENV", "ARG":
+			c.secretVariables(in)
+		}
+	}
+
+	final := c.d.Stages[len(c.d.Stages)-1]
+	c.rootUser(final)
+	c.healthcheck(final)
+}
+
+// baseImage reports a base image that is not pinned by digest
+func (c *dockerfileCheck) baseImage(stage *dockerfile.Stage, index int, globals map[string]string) {
+	image, complete := dockerfile.Expand(stage.Image, globals)
+	if !complete || image == "" || strings.EqualFold(image, "scratch") || strings.Contains(image, "@") {
+		return
+	}
+	for _, previous := range c.d.Stages[:index] {
+		if previous.Name != "" && previous.Name == strings.ToLower(image) {
+			return
+		}
+	}
+
+	f := c.report("dockerfile-unpinned-base", stage.From,
+		fmt.Sprintf("Base image %s not pinned by digest", image), "")
+	f.Fix = fmt.Sprintf("Pin the base image to its digest, given by docker buildx imagetools inspect %s: FROM %s@sha256:...", image, image)
+	if tag, pinned := imageTag(image); !pinned {
+		f.Severity = SeverityMedium
+		if tag == "" {
+			f.Description = "The image has no tag, so the latest tag is pulled. " + f.Description
+		} else {
+			f.Description = "The image is pulled with the latest tag. " + f.Description
+		}
+	}
+}
+
+// addURL reports ADD instructions that download a remote file, with a
+// RUN curl replacement when the download is not verified
+func (c *dockerfileCheck) addURL(in *dockerfile.Instruction) {
+	if _, ok := in.Flag("checksum"); ok || len(in.Args) < 2 {
+		return
+	}
+	sources, dest := in.Args[:len(in.Args)-1], in.Args[len(in.Args)-1]
+	for _, source := range sources {
+		u, err := url.Parse(source)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		f := c.report("dockerfile-add-url", in, fmt.Sprintf("ADD downloads %s", source), "")
+		if len(sources) == 1 && len(in.Flags) == 0 {
+			target := dest
+			if strings.HasSuffix(dest, "/") {
+				target = dest + path.Base(u.Path)
+			}
+			f.Replacement = replaceInstruction(in, fmt.Sprintf("RUN curl -fsSL -o %s %s", target, source))
+		}
+	}
+}
+
+// copyAll reports the copy of the whole build context without .dockerignore
+func (c *dockerfileCheck) copyAll(in *dockerfile.Instruction) {
+	if _, ok := in.Flag("from"); ok || c.dockerignore || len(in.Args) < 2 {
+		return
+	}
+	for _, source := range in.Args[:len(in.Args)-1] {
+		if source == "." || source == "./" || source == "*" {
+			c.report("dockerfile-copy-all", in,
+				fmt.Sprintf("%s %s copies the build context without .dockerignore", in.Command, strings.Join(in.Args, " ")), "")
+			return
+		}
+	}
+}
+
+// runInstruction checks the commands of a RUN instruction
+func (c *dockerfileCheck) runInstruction(in *dockerfile.Instruction) {
+	script := in.Value
+	if in.JSON {
+		script = strings.Join(in.Args, " ")
+	}
+	for _, heredoc := range in.Heredocs {
+		script += "\n" + heredoc.Body
+	}
+
+	if m := curlPipeShellRegex.FindString(script); m != "" {
+		c.report("dockerfile-curl-pipe-shell", in, "", fmt.Sprintf("The instruction runs %q.", m))
+	}
+
+	if aptInstallRegex.MatchString(script) && !strings.Contains(script, "/var/lib/apt/lists") {
+		for _, flag := range in.Flags {
+			if strings.Contains(flag, "type=cache") && strings.Contains(flag, "/var/") {
+				// The lists are in a cache mount, not in the layer
+				return
+			}
+		}
+		f := c.report("dockerfile-apt-cache", in, "", "")
+		if !in.JSON && len(in.Heredocs) == 0 {
+			f.Replacement = replaceInstruction(in, fmt.Sprintf("%s %c\n    && rm -rf /var/lib/apt/lists/*", in.Original, c.d.Escape))
+		}
+	}
+}
+
+// secretVariables reports the ENV and ARG variables whose name denotes a
+// secret. The replacement removes the ENV variables and the ARG defaults.
+func (c *dockerfileCheck) secretVariables(in *dockerfile.Instruction) {
+	type variable struct {
+		name, value string
+		hasValue    bool
+	}
+	var vars []variable
+	if in.Command == "ENV" && len(in.Args) > 0 && !strings.Contains(in.Args[0], "=") {
+		// Legacy form: ENV NAME value
+		vars = append(vars, variable{in.Args[0], strings.Join(in.Args[1:], " "), true})
+	} else {
+		for _, word := range in.Args {
+			name, value, hasValue := strings.Cut(word, "=")
+			vars = append(vars, variable{name, value, hasValue})
+		}
+	}
+
+	var secrets, kept []string
+	secretDefault := false
+	for _, v := range vars {
+		secret := secretVariableRegex.MatchString(v.name) && !publicVariableRegex.MatchString(v.name)
+		if secret && (in.Command == "ARG" || v.value != "") {
+			secrets = append(secrets, v.name)
+			if in.Command == "ARG" {
+				kept = append(kept, v.name)
+				secretDefault = secretDefault || v.value != ""
+			}
+			continue
+		}
+		switch {
+		case !v.hasValue:
+			kept = append(kept, v.name)
+		case strings.ContainsAny(v.value, " \t\"'$\\") || v.value == "":
+			kept = append(kept, v.name+"="+strconv.Quote(v.value))
+		default:
+			kept = append(kept, v.name+"="+v.value)
+		}
+	}
+	if len(secrets) == 0 {
+		return
+	}
+
+	ruleID := "dockerfile-secret-env"
+	if in.Command == "ARG" {
+		ruleID = "dockerfile-secret-arg"
+	}
+	f := c.report(ruleID, in,
+		fmt.Sprintf("Secret in %s %s", in.Command, strings.Join(secrets, ", ")), "")
+	switch {
+	case in.Command == "ENV" && len(kept) == 0:
+		f.Replacement = replaceInstruction(in, "")
+	case in.Command == "ENV":
+		f.Replacement = replaceInstruction(in, "ENV "+strings.Join(kept, " "))
+	case secretDefault:
+		// The default value is written in the Dockerfile
+		f.Severity = SeverityHigh
+		f.Replacement = replaceInstruction(in, "ARG "+strings.Join(kept, " "))
+	}
+}
+
+// rootUser reports a final stage that runs as root, because it has no
+// USER instruction or switches to root
+func (c *dockerfileCheck) rootUser(final *dockerfile.Stage) {
+	user, in, image := c.stageUser(final)
+	if in != nil {
+		name, _, _ := strings.Cut(user, ":")
+		if name == "root" || name == "0" {
+			f := c.report("dockerfile-root-user", in, fmt.Sprintf("Container runs as root (USER %s)", user), "")
+			f.Replacement = replaceInstruction(in, "USER "+nonRootUser)
+		}
+		return
+	}
+	if expanded, _ := dockerfile.Expand(image, c.d.GlobalArgs()); nonRootImageRegex.MatchString(expanded) {
+		return
+	}
+
+	anchor, before := insertionPoint(final)
+	f := c.report("dockerfile-root-user", anchor, "Container runs as root: no USER instruction",
+		"The final stage has no USER instruction, so its processes run as root.")
+	f.Replacement = insertInstruction(anchor, before, "USER "+nonRootUser)
+}
+
+// stageUser returns the last USER of a stage, or of the stage it is built
+// from, and the base image of the stage
+func (c *dockerfileCheck) stageUser(stage *dockerfile.Stage) (string, *dockerfile.Instruction, string) {
+	for seen := map[*dockerfile.Stage]bool{}; stage != nil && !seen[stage]; {
+		seen[stage] = true
+		for i := len(stage.Instructions) - 1; i >= 0; i-- {
+			if in := stage.Instructions[i]; in.Command == "USER" && len(in.Args) > 0 {
+				return in.Args[0], in, stage.Image
+			}
+		}
+		parent := c.d.Stage(stage.Image)
+		if parent == nil {
+			return "", nil, stage.Image
+		}
+		stage = parent
+	}
+	return "", nil, ""
+}
+
+// healthcheck reports a final stage without HEALTHCHECK. The suggested
+// instruction probes the first exposed port over HTTP, only when the port
+// is a usual HTTP port and the image has a shell with wget or curl: a probe
+// that cannot run would mark a healthy container unhealthy.
+func (c *dockerfileCheck) healthcheck(final *dockerfile.Stage) {
+	port, probe, image := "", "", ""
+	for seen, stage := map[*dockerfile.Stage]bool{}, final; stage != nil && !seen[stage]; stage = c.d.Stage(stage.Image) {
+		seen[stage] = true
+		image = stage.Image
+		for _, in := range stage.Instructions {
+			switch {
+			case in.Command == "HEALTHCHECK":
+				return
+			case in.Command == "EXPOSE" && len(in.Args) > 0 && port == "":
+				number, _, _ := strings.Cut(in.Args[0], "/")
+				if _, err := strconv.Atoi(number); err == nil {
+					port = number
+				}
+			case in.Command == "RUN" && probe == "":
+				if m := probeInstallRegex.FindStringSubmatch(in.Value); m != nil {
+					probe = m[len(m)-1]
+				}
+			}
+		}
+	}
+	image, _ = dockerfile.Expand(image, c.d.GlobalArgs())
+	if shelllessImageRegex.MatchString(image) {
+		probe = ""
+	} else if probe == "" && busyboxImageRegex.MatchString(image) {
+		probe = "wget"
+	}
+
+	anchor, before := insertionPoint(final)
+	f := c.report("dockerfile-missing-healthcheck", anchor, "", "")
+	if !httpPorts[port] {
+		return
+	}
+	command := ""
+	switch probe {
+	case "wget":
+		command = "wget -q --spider http://localhost:%s/ || exit 1"
+	case "curl":
+		command = "curl -fsS -o /dev/null http://localhost:%s/ || exit 1"
+	default:
+		return
+	}
+	f.Replacement = insertInstruction(anchor, before,
+		"HEALTHCHECK --interval=30s --timeout=5s --retries=3 CMD "+fmt.Sprintf(command, port))
+}
+
+// insertionPoint returns where an instruction is added to a stage: before
+// its final CMD and ENTRYPOINT, or after its last instruction
+func insertionPoint(stage *dockerfile.Stage) (*dockerfile.Instruction, bool) {
+	tail := len(stage.Instructions)
+	for tail > 0 && (stage.Instructions[tail-1].Command == "CMD" || stage.Instructions[tail-1].Command == "ENTRYPOINT") {
+		tail--
+	}
+	if tail < len(stage.Instructions) {
+		return stage.Instructions[tail], true
+	}
+	if tail > 0 {
+		return stage.Instructions[tail-1], false
+	}
+	return stage.From, false
+}
+
+// replaceInstruction returns the replacement of an instruction with text
+func replaceInstruction(in *dockerfile.Instruction, text string) *Replacement {
+	return &Replacement{Line: in.Line, EndLine: in.EndLine, Text: text}
+}
+
+// insertInstruction returns the replacement that adds text before or
+// after an instruction
+func insertInstruction(in *dockerfile.Instruction, before bool, text string) *Replacement {
+	if before {
+		return replaceInstruction(in, text+"\n"+in.Original)
+	}
+	return replaceInstruction(in, in.Original+"\n"+text)
+}
+
+// report adds a finding of rule located at the instruction in. title
+// defaults to the title of the rule; detail is added to its description.
+func (c *dockerfileCheck) report(ruleID string, in *dockerfile.Instruction, title, detail string) *Finding {
+	rule := dockerfileRules[ruleID]
+	if title == "" {
+		title = rule.Title
+	}
+	description := rule.Description
+	if detail != "" {
+		description = detail + " " + description
+	}
+	endLine := 0
+	if in.EndLine > in.Line {
+		endLine = in.EndLine
+	}
+	c.findings = append(c.findings, Finding{
+		ID:          fmt.Sprintf("dso-%s-%s-%d", ruleID, c.file, in.Line),
+		Type:        "CONTAINER",
+		Severity:    rule.Severity,
+		Title:       title,
+		Description: description,
+		File:        c.file,
+		Line:        in.Line,
+		EndLine:     endLine,
+		RuleID:      ruleID,
+		Tool:        "dso-dockerfile",
+		Fixable:     true,
+		Fix:         rule.Fix,
+		Timestamp:   c.now,
+	})
+	return &c.findings[len(c.findings)-1]
+}
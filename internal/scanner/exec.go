@@ -0,0 +1,145 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// killGracePeriod is how long a cancelled tool gets to exit before it is killed
+const killGracePeriod = 5 * time.Second
+
+// maxStderrExcerpt is the number of trailing stderr bytes kept in a ToolRun
+const maxStderrExcerpt = 1024
+
+// runTool runs an external scanner and returns its standard output.
+// The tool runs in its own process group so that the whole process tree is
+// terminated when ctx is cancelled or its deadline expires. When that
+// happens the context error is returned instead of the exit error.
+func runTool(ctx context.Context, dir string, name string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	setProcessGroup(cmd)
+	cmd.WaitDelay = killGracePeriod
+
+	output, err := cmd.Output()
+
+	if rec := recorderFromContext(ctx); rec != nil {
+		exitCode := 0
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.ExitCode()
+		} else if err != nil {
+			exitCode = -1
+		}
+		rec.record(strings.Join(cmd.Args, " "), exitCode, stderr.String())
+	}
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return output, ctxErr
+	}
+	return output, err
+}
+
+// toolError returns the error of a tool run that produced no usable output.
+// Many scanners exit with a non-zero code when they report findings, so a
+// run that failed but still wrote a report to stdout is not an error.
+func toolError(name string, output []byte, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	if len(output) > 0 {
+		return nil
+	}
+	return fmt.Errorf("%s failed: %w", name, err)
+}
+
+// runRecorder collects the command lines, exit code and stderr of the
+// tools run by a scanner
+type runRecorder struct {
+	mu       sync.Mutex
+	commands []string
+	exitCode int
+	stderr   string
+	unparsed []string
+}
+
+type runRecorderKey struct{}
+
+// withRunRecorder returns a context that records the tools run with it
+func withRunRecorder(ctx context.Context) (context.Context, *runRecorder) {
+	rec := &runRecorder{}
+	return context.WithValue(ctx, runRecorderKey{}, rec), rec
+}
+
+func recorderFromContext(ctx context.Context) *runRecorder {
+	rec, _ := ctx.Value(runRecorderKey{}).(*runRecorder)
+	return rec
+}
+
+// record stores a tool invocation. The first non-zero exit code is kept
+// so that a failing invocation is not hidden by later successful ones.
+func (r *runRecorder) record(command string, exitCode int, stderr string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.commands = append(r.commands, command)
+	if r.exitCode == 0 {
+		r.exitCode = exitCode
+	}
+	if stderr = strings.TrimSpace(stderr); stderr != "" {
+		r.stderr = stderrExcerpt(stderr)
+	}
+}
+
+// apply copies the recorded execution details into run
+func (r *runRecorder) apply(run *ToolRun) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run.Command = strings.Join(r.commands, "; ")
+	run.ExitCode = r.exitCode
+	run.Stderr = r.stderr
+	run.Unparsed = r.unparsed
+}
+
+// recordUnparsed records a file that a native scanner skipped because it
+// could not be parsed
+func recordUnparsed(ctx context.Context, rel string, err error) {
+	if r := recorderFromContext(ctx); r != nil {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.unparsed = append(r.unparsed, rel+": "+err.Error())
+	}
+}
+
+// stderrExcerpt keeps the end of stderr, where tools usually print the error
+func stderrExcerpt(stderr string) string {
+	if len(stderr) <= maxStderrExcerpt {
+		return stderr
+	}
+	return "…" + strings.ToValidUTF8(stderr[len(stderr)-maxStderrExcerpt:], "")
+}
+
+// decodeToolOutput unmarshals the JSON report of a tool into v.
+// An empty report means the tool had nothing to say.
+func decodeToolOutput(name string, output []byte, v interface{}) error {
+	if len(bytes.TrimSpace(output)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(output, v); err != nil {
+		return fmt.Errorf("cannot parse %s output: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+
+package scanner
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// setProcessGroup starts cmd in a new process group and makes cancellation
+// send SIGTERM to the whole group, followed by SIGKILL after the grace period
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		pgid := -cmd.Process.Pid
+		if err := syscall.Kill(pgid, syscall.SIGTERM); err != nil {
+			return err
+		}
+		time.AfterFunc(killGracePeriod, func() {
+			_ = syscall.Kill(pgid, syscall.SIGKILL)
+		})
+		return nil
+	}
+}
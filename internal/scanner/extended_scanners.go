@@ -1,394 +1,481 @@
 package scanner
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"io/fs"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/dso-cli/dso-cli/internal/ignore"
 )
 
-// scanSecretsExtended scans with multiple secret detection tools
-func scanSecretsExtended(path string) ([]Finding, error) {
+func init() {
+	// Secrets
+	Register(&toolScanner{name: "gitleaks", category: CategorySecrets, command: "gitleaks", applies: always, scan: scanWithGitleaks, history: scanGitleaksHistory})
+	Register(&toolScanner{name: "trufflehog", category: CategorySecrets, command: "trufflehog", applies: always, scan: scanWithTruffleHog, history: scanTruffleHogHistory})
+	Register(&toolScanner{name: "detect-secrets", category: CategorySecrets, command: "detect-secrets", applies: always, scan: scanWithDetectSecrets})
+
+	// SAST
+	Register(&toolScanner{name: "semgrep", category: CategorySAST, command: "semgrep", applies: (*Project).HasCode, scan: scanWithSemgrep})
+	Register(&toolScanner{name: "gosec", category: CategorySAST, command: "gosec", applies: func(p *Project) bool { return p.HasGo }, scan: scanWithGosec})
+	Register(&toolScanner{name: "eslint", category: CategorySAST, command: "eslint", applies: func(p *Project) bool { return p.HasJS }, scan: scanWithESLint})
+	Register(&toolScanner{name: "bandit", category: CategorySAST, command: "bandit", applies: func(p *Project) bool { return p.HasPython }, scan: scanWithBandit})
+	Register(&toolScanner{name: "brakeman", category: CategorySAST, command: "brakeman", applies: func(p *Project) bool { return p.HasRuby }, scan: scanWithBrakeman})
+
+	// Dependencies
+	Register(&toolScanner{name: "snyk", category: CategoryDependencies, command: "snyk", applies: always, scan: scanWithSnyk})
+	Register(&toolScanner{name: "dependency-check", category: CategoryDependencies, command: "dependency-check", applies: always, scan: scanWithDependencyCheck})
+
+	// IaC
+	Register(&toolScanner{name: "checkov", category: CategoryIaC, command: "checkov", applies: (*Project).HasIaC, scan: scanWithCheckov})
+	Register(&toolScanner{name: "terrascan", category: CategoryIaC, command: "terrascan", applies: (*Project).HasIaC, scan: scanWithTerrascan})
+	Register(&toolScanner{name: "kics", category: CategoryIaC, command: "kics", applies: (*Project).HasIaC, scan: scanWithKics})
+
+	// Containers
+	Register(&toolScanner{name: "hadolint", category: CategoryContainers, command: "hadolint", applies: func(p *Project) bool { return p.HasDocker }, scan: scanWithHadolint})
+}
+
+// gitleaksResult is a finding of the gitleaks report. The commit fields
+// are only set when the git history is scanned.
+type gitleaksResult struct {
+	RuleID    string `json:"RuleID"`
+	File      string `json:"File"`
+	StartLine int    `json:"StartLine"`
+	Secret    string `json:"Secret"`
+	Commit    string `json:"Commit"`
+	Author    string `json:"Author"`
+	Email     string `json:"Email"`
+	Date      string `json:"Date"`
+	Message   string `json:"Message"`
+}
+
+// scanWithGitleaks enhanced gitleaks scanning
+func scanWithGitleaks(ctx context.Context, path string) ([]Finding, error) {
 	var findings []Finding
+	args := []string{"detect", "--source", path, "--no-git", "--format", "json"}
+	args = append(args, excludeArgs(ctx, "--exclude-path", relPath)...)
+	output, err := runTool(ctx, "", "gitleaks", args...)
+	if err := toolError("gitleaks", output, err); err != nil {
+		return nil, err
+	}
 
-	// Gitleaks (already in main scanner, but we'll enhance it)
-	if _, err := exec.LookPath("gitleaks"); err == nil {
-		if gitleaksFindings, err := scanWithGitleaks(path); err == nil {
-			findings = append(findings, gitleaksFindings...)
-		}
+	var results []gitleaksResult
+	if err := decodeToolOutput("gitleaks", output, &results); err != nil {
+		return nil, err
+	}
+	for _, r := range results {
+		findings = append(findings, Finding{
+			ID:          fmt.Sprintf("gitleaks-%s-%s-%d", r.RuleID, r.File, r.StartLine),
+			Type:        "SECRET",
+			Severity:    SeverityCritical,
+			Title:       fmt.Sprintf("Exposed secret: %s", r.RuleID),
+			Description: fmt.Sprintf("Secret detected in %s at line %d", r.File, r.StartLine),
+			File:        r.File,
+			Line:        r.StartLine,
+			RuleID:      r.RuleID,
+			Tool:        "gitleaks",
+			Fixable:     true,
+			Exploitable: true,
+			Timestamp:   time.Now(),
+		})
 	}
+	return findings, nil
+}
 
-	// TruffleHog
-	if _, err := exec.LookPath("trufflehog"); err == nil {
-		if truffleFindings, err := scanWithTruffleHog(path); err == nil {
-			findings = append(findings, truffleFindings...)
-		}
+// scanGitleaksHistory scans the commits of the history scope with gitleaks
+func scanGitleaksHistory(ctx context.Context, h *historyScan) ([]Finding, error) {
+	var findings []Finding
+	args := []string{"detect", "--source", h.root, "--format", "json"}
+	if logRange := h.logRange(); logRange != "" {
+		args = append(args, "--log-opts", logRange)
+	}
+	output, err := runTool(ctx, "", "gitleaks", args...)
+	if err := toolError("gitleaks", output, err); err != nil {
+		return nil, err
 	}
 
-	// detect-secrets
-	if _, err := exec.LookPath("detect-secrets"); err == nil {
-		if detectFindings, err := scanWithDetectSecrets(path); err == nil {
-			findings = append(findings, detectFindings...)
+	var results []gitleaksResult
+	if err := decodeToolOutput("gitleaks", output, &results); err != nil {
+		return nil, err
+	}
+	for _, r := range results {
+		f := Finding{
+			ID:          fmt.Sprintf("gitleaks-%s-%s-%s-%d", r.RuleID, r.Commit, r.File, r.StartLine),
+			Type:        "SECRET",
+			Severity:    SeverityCritical,
+			Title:       fmt.Sprintf("Exposed secret: %s", r.RuleID),
+			Description: fmt.Sprintf("Secret committed to %s at line %d", r.File, r.StartLine),
+			File:        r.File,
+			Line:        r.StartLine,
+			RuleID:      r.RuleID,
+			Tool:        "gitleaks",
+			Exploitable: true,
+			Timestamp:   time.Now(),
+			Commit: &Commit{
+				SHA:     r.Commit,
+				Author:  r.Author,
+				Email:   r.Email,
+				Date:    parseCommitDate(r.Date),
+				Message: strings.TrimSpace(r.Message),
+			},
+		}
+		if h.locate(ctx, &f, r.Secret) {
+			findings = append(findings, f)
 		}
 	}
-
 	return findings, nil
 }
 
-// scanWithGitleaks enhanced gitleaks scanning
-func scanWithGitleaks(path string) ([]Finding, error) {
-	var findings []Finding
-	cmd := exec.Command("gitleaks", "detect", "--source", path, "--no-git", "--format", "json",
-		"--exclude-path", "node_modules", "--exclude-path", "vendor", "--exclude-path", "dist",
-		"--exclude-path", "build", "--exclude-path", ".git", "--exclude-path", ".cache")
-	output, err := cmd.Output()
-	if err != nil && len(output) > 0 {
-		var results []struct {
-			RuleID    string `json:"RuleID"`
-			File      string `json:"File"`
-			StartLine string `json:"StartLine"`
-			Secret    string `json:"Secret"`
+// truffleHogResult is a finding of TruffleHog. The git fields are only set
+// when the git history is scanned.
+type truffleHogResult struct {
+	DetectorName   string `json:"DetectorName"`
+	Raw            string `json:"Raw"`
+	Redacted       string `json:"Redacted"`
+	SourceMetadata struct {
+		Data struct {
+			File string `json:"file"`
+			Line int    `json:"line"`
+			Git  struct {
+				Commit    string `json:"commit"`
+				File      string `json:"file"`
+				Email     string `json:"email"` // Name <email>
+				Timestamp string `json:"timestamp"`
+				Line      int    `json:"line"`
+			} `json:"Git"`
+		} `json:"Data"`
+	} `json:"SourceMetadata"`
+}
+
+// decodeTruffleHog reads the output of TruffleHog, one JSON object per line
+func decodeTruffleHog(output []byte) ([]truffleHogResult, error) {
+	var results []truffleHogResult
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
 		}
-		if json.Unmarshal(output, &results) == nil {
-			for _, r := range results {
-				line, _ := strconv.Atoi(r.StartLine)
-				findings = append(findings, Finding{
-					ID:          fmt.Sprintf("gitleaks-%s-%s-%s", r.RuleID, r.File, r.StartLine),
-					Type:        "SECRET",
-					Severity:    SeverityCritical,
-					Title:       fmt.Sprintf("Exposed secret: %s", r.RuleID),
-					Description: fmt.Sprintf("Secret detected in %s at line %s", r.File, r.StartLine),
-					File:        r.File,
-					Line:        line,
-					Tool:        "gitleaks",
-					Fixable:     true,
-					Exploitable: true,
-					Timestamp:   time.Now(),
-				})
-			}
+		var result truffleHogResult
+		if err := decodeToolOutput("trufflehog", []byte(line), &result); err != nil {
+			return nil, err
 		}
+		results = append(results, result)
 	}
-	return findings, nil
+	return results, nil
 }
 
 // scanWithTruffleHog scans with TruffleHog
-func scanWithTruffleHog(path string) ([]Finding, error) {
+func scanWithTruffleHog(ctx context.Context, path string) ([]Finding, error) {
 	var findings []Finding
-	cmd := exec.Command("trufflehog", "filesystem", path, "--json", "--no-verification")
-	output, err := cmd.Output()
-	if err != nil && len(output) > 0 {
-		lines := strings.Split(string(output), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line == "" {
-				continue
-			}
-			var result struct {
-				DetectorName string `json:"DetectorName"`
-				Raw          string `json:"Raw"`
-				Redacted     string `json:"Redacted"`
-				SourceMetadata struct {
-					Data struct {
-						File string `json:"file"`
-						Line int    `json:"line"`
-					} `json:"Data"`
-				} `json:"SourceMetadata"`
-			}
-			if json.Unmarshal([]byte(line), &result) == nil {
-				findings = append(findings, Finding{
-					ID:          fmt.Sprintf("trufflehog-%s-%s", result.DetectorName, result.SourceMetadata.Data.File),
-					Type:        "SECRET",
-					Severity:    SeverityCritical,
-					Title:       fmt.Sprintf("Secret detected: %s", result.DetectorName),
-					Description: fmt.Sprintf("Potential secret found: %s", result.Redacted),
-					File:        result.SourceMetadata.Data.File,
-					Line:        result.SourceMetadata.Data.Line,
-					Tool:        "trufflehog",
-					Fixable:     true,
-					Exploitable: true,
-				})
-			}
-		}
+	output, err := runTool(ctx, "", "trufflehog", "filesystem", path, "--json", "--no-verification")
+	if err := toolError("trufflehog", output, err); err != nil {
+		return nil, err
+	}
+
+	results, err := decodeTruffleHog(output)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		findings = append(findings, Finding{
+			ID:          fmt.Sprintf("trufflehog-%s-%s-%d", result.DetectorName, result.SourceMetadata.Data.File, result.SourceMetadata.Data.Line),
+			Type:        "SECRET",
+			Severity:    SeverityCritical,
+			Title:       fmt.Sprintf("Secret detected: %s", result.DetectorName),
+			Description: fmt.Sprintf("Potential secret found: %s", result.Redacted),
+			File:        result.SourceMetadata.Data.File,
+			Line:        result.SourceMetadata.Data.Line,
+			Tool:        "trufflehog",
+			Fixable:     true,
+			Exploitable: true,
+		})
 	}
 	return findings, nil
 }
 
-// scanWithDetectSecrets scans with detect-secrets
-func scanWithDetectSecrets(path string) ([]Finding, error) {
+// scanTruffleHogHistory scans the commits of the history scope with TruffleHog
+func scanTruffleHogHistory(ctx context.Context, h *historyScan) ([]Finding, error) {
 	var findings []Finding
-	// detect-secrets scan --baseline .secrets.baseline
-	cmd := exec.Command("detect-secrets", "scan", path, "--baseline", filepath.Join(path, ".secrets.baseline"))
-	output, err := cmd.Output()
-	if err == nil && len(output) > 0 {
-		var result struct {
-			Results map[string][]struct {
-				Type       string `json:"type"`
-				LineNumber int    `json:"line_number"`
-				Filename   string `json:"filename"`
-			} `json:"results"`
-		}
-		if json.Unmarshal(output, &result) == nil {
-			for file, secrets := range result.Results {
-				for _, secret := range secrets {
-					findings = append(findings, Finding{
-						ID:          fmt.Sprintf("detect-secrets-%s-%d", file, secret.LineNumber),
-						Type:        "SECRET",
-						Severity:    SeverityCritical,
-						Title:       fmt.Sprintf("Secret detected: %s", secret.Type),
-						Description: fmt.Sprintf("Potential secret of type %s found", secret.Type),
-						File:        secret.Filename,
-						Line:        secret.LineNumber,
-						Tool:        "detect-secrets",
-						Fixable:     true,
-						Exploitable: true,
-					})
-				}
-			}
+	args := []string{"git", "file://" + filepath.ToSlash(h.root), "--json", "--no-verification"}
+	if h.SinceCommit != "" {
+		args = append(args, "--since-commit", h.SinceCommit)
+	}
+	if h.Branch != "" {
+		args = append(args, "--branch", h.Branch)
+	}
+	output, err := runTool(ctx, "", "trufflehog", args...)
+	if err := toolError("trufflehog", output, err); err != nil {
+		return nil, err
+	}
+
+	results, err := decodeTruffleHog(output)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		git := result.SourceMetadata.Data.Git
+		author, email := parseAuthor(git.Email)
+		f := Finding{
+			ID:          fmt.Sprintf("trufflehog-%s-%s-%s-%d", result.DetectorName, git.Commit, git.File, git.Line),
+			Type:        "SECRET",
+			Severity:    SeverityCritical,
+			Title:       fmt.Sprintf("Secret detected: %s", result.DetectorName),
+			Description: fmt.Sprintf("Potential secret found: %s", result.Redacted),
+			File:        git.File,
+			Line:        git.Line,
+			Tool:        "trufflehog",
+			Exploitable: true,
+			Commit:      &Commit{SHA: git.Commit, Author: author, Email: email, Date: parseCommitDate(git.Timestamp)},
+		}
+		if h.locate(ctx, &f, result.Raw) {
+			findings = append(findings, f)
 		}
 	}
 	return findings, nil
 }
 
-// scanSASTExtended scans with multiple SAST tools
-func scanSASTExtended(path string, language string) ([]Finding, error) {
+// scanWithDetectSecrets scans with detect-secrets
+func scanWithDetectSecrets(ctx context.Context, path string) ([]Finding, error) {
 	var findings []Finding
-
-	// Semgrep (universal SAST)
-	if _, err := exec.LookPath("semgrep"); err == nil {
-		if semgrepFindings, err := scanWithSemgrep(path); err == nil {
-			findings = append(findings, semgrepFindings...)
-		}
+	// detect-secrets scan --baseline .secrets.baseline
+	args := []string{"scan", path, "--baseline", filepath.Join(path, ".secrets.baseline")}
+	if exclude := excludeRegexp(ctx, path); exclude != "" {
+		args = append(args, "--exclude-files", exclude)
+	}
+	output, err := runTool(ctx, "", "detect-secrets", args...)
+	if err := toolError("detect-secrets", output, err); err != nil {
+		return nil, err
 	}
 
-	// Language-specific scanners
-	switch language {
-	case "python":
-		if _, err := exec.LookPath("bandit"); err == nil {
-			if banditFindings, err := scanWithBandit(path); err == nil {
-				findings = append(findings, banditFindings...)
-			}
-		}
-	case "go":
-		if _, err := exec.LookPath("gosec"); err == nil {
-			if gosecFindings, err := scanWithGosec(path); err == nil {
-				findings = append(findings, gosecFindings...)
-			}
-		}
-	case "javascript", "typescript":
-		if _, err := exec.LookPath("eslint"); err == nil {
-			if eslintFindings, err := scanWithESLint(path); err == nil {
-				findings = append(findings, eslintFindings...)
-			}
-		}
-	case "ruby":
-		if _, err := exec.LookPath("brakeman"); err == nil {
-			if brakemanFindings, err := scanWithBrakeman(path); err == nil {
-				findings = append(findings, brakemanFindings...)
-			}
+	var result struct {
+		Results map[string][]struct {
+			Type       string `json:"type"`
+			LineNumber int    `json:"line_number"`
+			Filename   string `json:"filename"`
+		} `json:"results"`
+	}
+	if err := decodeToolOutput("detect-secrets", output, &result); err != nil {
+		return nil, err
+	}
+	for file, secrets := range result.Results {
+		for _, secret := range secrets {
+			findings = append(findings, Finding{
+				ID:          fmt.Sprintf("detect-secrets-%s-%d", file, secret.LineNumber),
+				Type:        "SECRET",
+				Severity:    SeverityCritical,
+				Title:       fmt.Sprintf("Secret detected: %s", secret.Type),
+				Description: fmt.Sprintf("Potential secret of type %s found", secret.Type),
+				File:        secret.Filename,
+				Line:        secret.LineNumber,
+				Tool:        "detect-secrets",
+				Fixable:     true,
+				Exploitable: true,
+			})
 		}
 	}
-
 	return findings, nil
 }
 
 // scanWithSemgrep scans with Semgrep
-func scanWithSemgrep(path string) ([]Finding, error) {
+func scanWithSemgrep(ctx context.Context, path string) ([]Finding, error) {
 	var findings []Finding
-	cmd := exec.Command("semgrep", "--config", "auto", "--json", path)
-	output, err := cmd.Output()
-	if err == nil && len(output) > 0 {
-		var result struct {
-			Results []struct {
-				CheckID  string `json:"check_id"`
-				Path     string `json:"path"`
-				Start    struct {
-					Line int `json:"line"`
-				} `json:"start"`
-				End struct {
-					Line int `json:"line"`
-				} `json:"end"`
-				Message string `json:"message"`
+	args := []string{"--config", "auto", "--json"}
+	args = append(args, excludeArgs(ctx, "--exclude", relPath)...)
+	output, err := runTool(ctx, "", "semgrep", append(args, path)...)
+	if err := toolError("semgrep", output, err); err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Results []struct {
+			CheckID string `json:"check_id"`
+			Path    string `json:"path"`
+			Start   struct {
+				Line int `json:"line"`
+			} `json:"start"`
+			End struct {
+				Line int `json:"line"`
+			} `json:"end"`
+			Message  string `json:"message"`
+			Metadata struct {
+				Severity string `json:"severity"`
+			} `json:"metadata"`
+			Extra struct {
+				Message  string `json:"message"`
+				Severity string `json:"severity"`
 				Metadata struct {
-					Severity string `json:"severity"`
+					CWE   refList `json:"cwe"`
+					OWASP refList `json:"owasp"`
 				} `json:"metadata"`
-			} `json:"results"`
-		}
-		if json.Unmarshal(output, &result) == nil {
-			for _, r := range result.Results {
-				severity := mapSeverity(r.Metadata.Severity)
-				findings = append(findings, Finding{
-					ID:          fmt.Sprintf("semgrep-%s-%s-%d", r.CheckID, r.Path, r.Start.Line),
-					Type:        "SAST",
-					Severity:    severity,
-					Title:       r.CheckID,
-					Description: r.Message,
-					File:        r.Path,
-					Line:        r.Start.Line,
-					Tool:        "semgrep",
-					Fixable:     true,
-				})
-			}
-		}
+			} `json:"extra"`
+		} `json:"results"`
+	}
+	if err := decodeToolOutput("semgrep", output, &result); err != nil {
+		return nil, err
+	}
+	for _, r := range result.Results {
+		// Current semgrep versions report the message and metadata under extra
+		severity := mapSeverity(firstNonEmpty(r.Metadata.Severity, r.Extra.Severity))
+		findings = append(findings, Finding{
+			ID:          fmt.Sprintf("semgrep-%s-%s-%d", r.CheckID, r.Path, r.Start.Line),
+			Type:        "SAST",
+			Severity:    severity,
+			Title:       r.CheckID,
+			Description: firstNonEmpty(r.Message, r.Extra.Message),
+			File:        r.Path,
+			Line:        r.Start.Line,
+			RuleID:      r.CheckID,
+			Tool:        "semgrep",
+			Fixable:     true,
+			CWE:         r.Extra.Metadata.CWE,
+			OWASP:       r.Extra.Metadata.OWASP,
+		})
 	}
 	return findings, nil
 }
 
 // scanWithBandit scans Python code with Bandit
-func scanWithBandit(path string) ([]Finding, error) {
+func scanWithBandit(ctx context.Context, path string) ([]Finding, error) {
 	var findings []Finding
-	cmd := exec.Command("bandit", "-r", "-f", "json", path)
-	output, err := cmd.Output()
-	if err == nil && len(output) > 0 {
-		var result struct {
-			Results []struct {
-				TestID   string `json:"test_id"`
-				IssueSeverity string `json:"issue_severity"`
-				IssueConfidence string `json:"issue_confidence"`
-				Text     string `json:"text"`
-				Filename string `json:"filename"`
-				LineNumber int  `json:"line_number"`
-			} `json:"results"`
-		}
-		if json.Unmarshal(output, &result) == nil {
-			for _, r := range result.Results {
-				severity := mapSeverity(r.IssueSeverity)
-				findings = append(findings, Finding{
-					ID:          fmt.Sprintf("bandit-%s-%s-%d", r.TestID, r.Filename, r.LineNumber),
-					Type:        "SAST",
-					Severity:    severity,
-					Title:       r.TestID,
-					Description: r.Text,
-					File:        r.Filename,
-					Line:        r.LineNumber,
-					Tool:        "bandit",
-					Fixable:     true,
-				})
-			}
-		}
+	args := []string{"-r", "-f", "json", path}
+	if exclude := excludeValues(ctx, absPaths(path)); len(exclude) > 0 {
+		args = append(args, "-x", strings.Join(exclude, ","))
+	}
+	output, err := runTool(ctx, "", "bandit", args...)
+	if err := toolError("bandit", output, err); err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Results []struct {
+			TestID          string  `json:"test_id"`
+			IssueSeverity   string  `json:"issue_severity"`
+			IssueConfidence string  `json:"issue_confidence"`
+			Text            string  `json:"text"`
+			Filename        string  `json:"filename"`
+			LineNumber      int     `json:"line_number"`
+			IssueCWE        refList `json:"issue_cwe"`
+		} `json:"results"`
+	}
+	if err := decodeToolOutput("bandit", output, &result); err != nil {
+		return nil, err
+	}
+	for _, r := range result.Results {
+		severity := mapSeverity(r.IssueSeverity)
+		findings = append(findings, Finding{
+			ID:          fmt.Sprintf("bandit-%s-%s-%d", r.TestID, r.Filename, r.LineNumber),
+			Type:        "SAST",
+			Severity:    severity,
+			Title:       r.TestID,
+			Description: r.Text,
+			File:        r.Filename,
+			Line:        r.LineNumber,
+			RuleID:      r.TestID,
+			Tool:        "bandit",
+			Fixable:     true,
+			CWE:         r.IssueCWE,
+		})
 	}
 	return findings, nil
 }
 
 // scanWithGosec scans Go code with Gosec
-func scanWithGosec(path string) ([]Finding, error) {
+func scanWithGosec(ctx context.Context, path string) ([]Finding, error) {
 	var findings []Finding
-	cmd := exec.Command("gosec", "-fmt", "json", "./...")
-	cmd.Dir = path
-	output, err := cmd.Output()
-	if err == nil && len(output) > 0 {
-		var result struct {
-			Issues []struct {
-				Severity   string `json:"severity"`
-				Confidence string `json:"confidence"`
-				RuleID     string `json:"rule_id"`
-				Details    string `json:"details"`
-				File       string `json:"file"`
-				Line       string `json:"line"`
-			} `json:"Issues"`
-		}
-		if json.Unmarshal(output, &result) == nil {
-			for _, issue := range result.Issues {
-				line, _ := strconv.Atoi(issue.Line)
-				severity := mapSeverity(issue.Severity)
-				findings = append(findings, Finding{
-					ID:          fmt.Sprintf("gosec-%s-%s-%s", issue.RuleID, issue.File, issue.Line),
-					Type:        "SAST",
-					Severity:    severity,
-					Title:       issue.RuleID,
-					Description: issue.Details,
-					File:        issue.File,
-					Line:        line,
-					Tool:        "gosec",
-					Fixable:     true,
-				})
-			}
-		}
+	args := []string{"-fmt", "json"}
+	args = append(args, excludeArgs(ctx, "-exclude-dir", excludedDirs)...)
+	output, err := runTool(ctx, path, "gosec", append(args, "./...")...)
+	if err := toolError("gosec", output, err); err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Issues []struct {
+			Severity   string  `json:"severity"`
+			Confidence string  `json:"confidence"`
+			RuleID     string  `json:"rule_id"`
+			Details    string  `json:"details"`
+			File       string  `json:"file"`
+			Line       string  `json:"line"`
+			CWE        refList `json:"cwe"`
+		} `json:"Issues"`
+	}
+	if err := decodeToolOutput("gosec", output, &result); err != nil {
+		return nil, err
+	}
+	for _, issue := range result.Issues {
+		line, _ := strconv.Atoi(issue.Line)
+		severity := mapSeverity(issue.Severity)
+		findings = append(findings, Finding{
+			ID:          fmt.Sprintf("gosec-%s-%s-%s", issue.RuleID, issue.File, issue.Line),
+			Type:        "SAST",
+			Severity:    severity,
+			Title:       issue.RuleID,
+			Description: issue.Details,
+			File:        issue.File,
+			Line:        line,
+			RuleID:      issue.RuleID,
+			Tool:        "gosec",
+			Fixable:     true,
+			CWE:         issue.CWE,
+		})
 	}
 	return findings, nil
 }
 
 // scanWithESLint scans JavaScript/TypeScript with ESLint security plugin
-func scanWithESLint(path string) ([]Finding, error) {
+func scanWithESLint(ctx context.Context, path string) ([]Finding, error) {
 	var findings []Finding
 	// ESLint with security plugin
-	cmd := exec.Command("eslint", "--format", "json", path)
-	output, err := cmd.Output()
-	if err == nil && len(output) > 0 {
-		var results []struct {
-			FilePath string `json:"filePath"`
-			Messages []struct {
-				RuleId   string `json:"ruleId"`
-				Severity int    `json:"severity"`
-				Message  string `json:"message"`
-				Line     int    `json:"line"`
-			} `json:"messages"`
-		}
-		if json.Unmarshal(output, &results) == nil {
-			for _, file := range results {
-				for _, msg := range file.Messages {
-					// Only include security-related rules
-					if strings.Contains(msg.RuleId, "security") || strings.Contains(msg.RuleId, "no-eval") ||
-						strings.Contains(msg.RuleId, "no-implied-eval") {
-						severity := SeverityLow
-						if msg.Severity == 2 {
-							severity = SeverityHigh
-						} else if msg.Severity == 1 {
-							severity = SeverityMedium
-						}
-						findings = append(findings, Finding{
-							ID:          fmt.Sprintf("eslint-%s-%s-%d", msg.RuleId, file.FilePath, msg.Line),
-							Type:        "SAST",
-							Severity:    severity,
-							Title:       msg.RuleId,
-							Description: msg.Message,
-							File:        file.FilePath,
-							Line:        msg.Line,
-							Tool:        "eslint",
-							Fixable:     true,
-						})
-					}
-				}
-			}
-		}
+	args := []string{"--format", "json"}
+	args = append(args, excludeArgs(ctx, "--ignore-pattern", func(e ignore.Entry) string {
+		if e.IsDir {
+			return "/" + e.Path + "/"
+		}
+		return "/" + e.Path
+	})...)
+	output, err := runTool(ctx, "", "eslint", append(args, path)...)
+	if err := toolError("eslint", output, err); err != nil {
+		return nil, err
 	}
-	return findings, nil
-}
 
-// scanWithBrakeman scans Ruby on Rails code with Brakeman
-func scanWithBrakeman(path string) ([]Finding, error) {
-	var findings []Finding
-	cmd := exec.Command("brakeman", "-f", "json", path)
-	output, err := cmd.Output()
-	if err == nil && len(output) > 0 {
-		var result struct {
-			Warnings []struct {
-				WarningType string `json:"warning_type"`
-				WarningCode int    `json:"warning_code"`
-				Message     string `json:"message"`
-				File        string `json:"file"`
-				Line        int    `json:"line"`
-				Confidence  string `json:"confidence"`
-			} `json:"warnings"`
-		}
-		if json.Unmarshal(output, &result) == nil {
-			for _, warning := range result.Warnings {
-				severity := SeverityMedium
-				if warning.Confidence == "High" {
+	var results []struct {
+		FilePath string `json:"filePath"`
+		Messages []struct {
+			RuleId   string `json:"ruleId"`
+			Severity int    `json:"severity"`
+			Message  string `json:"message"`
+			Line     int    `json:"line"`
+		} `json:"messages"`
+	}
+	if err := decodeToolOutput("eslint", output, &results); err != nil {
+		return nil, err
+	}
+	for _, file := range results {
+		for _, msg := range file.Messages {
+			// Only include security-related rules
+			if strings.Contains(msg.RuleId, "security") || strings.Contains(msg.RuleId, "no-eval") ||
+				strings.Contains(msg.RuleId, "no-implied-eval") {
+				severity := SeverityLow
+				if msg.Severity == 2 {
 					severity = SeverityHigh
+				} else if msg.Severity == 1 {
+					severity = SeverityMedium
 				}
 				findings = append(findings, Finding{
-					ID:          fmt.Sprintf("brakeman-%d-%s-%d", warning.WarningCode, warning.File, warning.Line),
+					ID:          fmt.Sprintf("eslint-%s-%s-%d", msg.RuleId, file.FilePath, msg.Line),
 					Type:        "SAST",
 					Severity:    severity,
-					Title:       warning.WarningType,
-					Description: warning.Message,
-					File:        warning.File,
-					Line:        warning.Line,
-					Tool:        "brakeman",
+					Title:       msg.RuleId,
+					Description: msg.Message,
+					File:        file.FilePath,
+					Line:        msg.Line,
+					RuleID:      msg.RuleId,
+					Tool:        "eslint",
 					Fixable:     true,
 				})
 			}
@@ -397,312 +484,370 @@ func scanWithBrakeman(path string) ([]Finding, error) {
 	return findings, nil
 }
 
-// scanDependenciesExtended scans with multiple dependency scanners
-func scanDependenciesExtended(path string) ([]Finding, error) {
+// scanWithBrakeman scans Ruby on Rails code with Brakeman
+func scanWithBrakeman(ctx context.Context, path string) ([]Finding, error) {
 	var findings []Finding
-
-	// Snyk
-	if _, err := exec.LookPath("snyk"); err == nil {
-		if snykFindings, err := scanWithSnyk(path); err == nil {
-			findings = append(findings, snykFindings...)
-		}
+	output, err := runTool(ctx, "", "brakeman", "-f", "json", path)
+	if err := toolError("brakeman", output, err); err != nil {
+		return nil, err
 	}
 
-	// OWASP Dependency-Check
-	if _, err := exec.LookPath("dependency-check"); err == nil {
-		if depCheckFindings, err := scanWithDependencyCheck(path); err == nil {
-			findings = append(findings, depCheckFindings...)
-		}
+	var result struct {
+		Warnings []struct {
+			WarningType string  `json:"warning_type"`
+			WarningCode int     `json:"warning_code"`
+			Message     string  `json:"message"`
+			File        string  `json:"file"`
+			Line        int     `json:"line"`
+			Confidence  string  `json:"confidence"`
+			CWE         refList `json:"cwe_id"`
+		} `json:"warnings"`
+	}
+	if err := decodeToolOutput("brakeman", output, &result); err != nil {
+		return nil, err
+	}
+	for _, warning := range result.Warnings {
+		severity := SeverityMedium
+		if warning.Confidence == "High" {
+			severity = SeverityHigh
+		}
+		findings = append(findings, Finding{
+			ID:          fmt.Sprintf("brakeman-%d-%s-%d", warning.WarningCode, warning.File, warning.Line),
+			Type:        "SAST",
+			Severity:    severity,
+			Title:       warning.WarningType,
+			Description: warning.Message,
+			File:        warning.File,
+			Line:        warning.Line,
+			Tool:        "brakeman",
+			Fixable:     true,
+			CWE:         warning.CWE,
+		})
 	}
-
 	return findings, nil
 }
 
 // scanWithSnyk scans with Snyk
-func scanWithSnyk(path string) ([]Finding, error) {
+func scanWithSnyk(ctx context.Context, path string) ([]Finding, error) {
 	var findings []Finding
-	cmd := exec.Command("snyk", "test", "--json", path)
-	output, err := cmd.Output()
-	if err == nil && len(output) > 0 {
-		var result struct {
-			Vulnerabilities []struct {
-				ID          string `json:"id"`
-				Title       string `json:"title"`
-				Severity    string `json:"severity"`
-				Description string `json:"description"`
-				PackageName string `json:"packageName"`
-			} `json:"vulnerabilities"`
-		}
-		if json.Unmarshal(output, &result) == nil {
-			for _, vuln := range result.Vulnerabilities {
-				severity := mapSeverity(vuln.Severity)
-				findings = append(findings, Finding{
-					ID:          fmt.Sprintf("snyk-%s-%s", vuln.ID, vuln.PackageName),
-					Type:        "DEPENDENCY",
-					Severity:    severity,
-					Title:       vuln.Title,
-					Description: vuln.Description,
-					Tool:        "snyk",
-					Fixable:     true,
-				})
-			}
-		}
+	output, err := runTool(ctx, "", "snyk", "test", "--json", path)
+	if err := toolError("snyk", output, err); err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		PackageManager    string `json:"packageManager"`
+		DisplayTargetFile string `json:"displayTargetFile"`
+		Vulnerabilities   []struct {
+			ID          string   `json:"id"`
+			Title       string   `json:"title"`
+			Severity    string   `json:"severity"`
+			Description string   `json:"description"`
+			PackageName string   `json:"packageName"`
+			Version     string   `json:"version"`
+			FixedIn     []string `json:"fixedIn"`
+			From        []string `json:"from"` // The project first, then each dependency down to the package
+			Identifiers struct {
+				CVE []string `json:"CVE"`
+			} `json:"identifiers"`
+		} `json:"vulnerabilities"`
+	}
+	if err := decodeToolOutput("snyk", output, &result); err != nil {
+		return nil, err
+	}
+	for _, vuln := range result.Vulnerabilities {
+		severity := mapSeverity(vuln.Severity)
+		pkg := &Package{
+			Ecosystem:        packageEcosystem(result.PackageManager),
+			Name:             vuln.PackageName,
+			InstalledVersion: vuln.Version,
+			FixedVersions:    vuln.FixedIn,
+		}
+		if len(vuln.From) > 1 {
+			pkg.Path = vuln.From[1:]
+		}
+		f := Finding{
+			ID:          fmt.Sprintf("snyk-%s-%s", vuln.ID, vuln.PackageName),
+			Type:        "DEPENDENCY",
+			Severity:    severity,
+			Title:       vuln.Title,
+			Description: vuln.Description,
+			File:        result.DisplayTargetFile,
+			RuleID:      vuln.ID,
+			Tool:        "snyk",
+			Aliases:     vuln.Identifiers.CVE,
+			Package:     pkg,
+		}
+		f.setUpgrade()
+		findings = append(findings, f)
 	}
 	return findings, nil
 }
 
 // scanWithDependencyCheck scans with OWASP Dependency-Check
-func scanWithDependencyCheck(path string) ([]Finding, error) {
+func scanWithDependencyCheck(ctx context.Context, path string) ([]Finding, error) {
 	var findings []Finding
 	// Create report directory
 	reportDir := filepath.Join(path, ".dependency-check-reports")
-	cmd := exec.Command("dependency-check", "--project", "DSO-Scan", "--scan", path, "--format", "JSON", "--out", reportDir)
-	_, err := cmd.Output()
-	if err == nil {
-		reportFile := filepath.Join(reportDir, "dependency-check-report.json")
-		if data, readErr := os.ReadFile(reportFile); readErr == nil {
-			var result struct {
-				Dependencies []struct {
-					Vulnerabilities []struct {
-						Name        string `json:"name"`
-						Severity    string `json:"severity"`
-						Description string `json:"description"`
-					} `json:"vulnerabilities"`
-					FileName string `json:"fileName"`
-				} `json:"dependencies"`
+	args := []string{"--project", "DSO-Scan", "--scan", path, "--format", "JSON", "--out", reportDir}
+	args = append(args, excludeArgs(ctx, "--exclude", func(e ignore.Entry) string {
+		if e.IsDir {
+			return filepath.ToSlash(filepath.Join(path, e.Path)) + "/**"
+		}
+		return filepath.ToSlash(filepath.Join(path, e.Path))
+	})...)
+	_, err := runTool(ctx, "", "dependency-check", args...)
+	data, readErr := os.ReadFile(filepath.Join(reportDir, "dependency-check-report.json"))
+	if err := toolError("dependency-check", data, err); err != nil {
+		return nil, err
+	}
+	if readErr != nil {
+		return nil, fmt.Errorf("cannot read dependency-check report: %w", readErr)
+	}
+
+	var result struct {
+		Dependencies []struct {
+			Vulnerabilities []struct {
+				Name               string `json:"name"`
+				Severity           string `json:"severity"`
+				Description        string `json:"description"`
+				VulnerableSoftware []struct {
+					Software struct {
+						VersionEndExcluding    string `json:"versionEndExcluding"`
+						VulnerabilityIDMatched string `json:"vulnerabilityIdMatched"`
+					} `json:"software"`
+				} `json:"vulnerableSoftware"`
+			} `json:"vulnerabilities"`
+			FileName string `json:"fileName"`
+			Packages []struct {
+				ID string `json:"id"` // Package URL
+			} `json:"packages"`
+		} `json:"dependencies"`
+	}
+	if err := decodeToolOutput("dependency-check", data, &result); err != nil {
+		return nil, err
+	}
+	for _, dep := range result.Dependencies {
+		var pkg *Package
+		for _, p := range dep.Packages {
+			if pkg = purlPackage(p.ID); pkg != nil {
+				break
+			}
+		}
+		for _, vuln := range dep.Vulnerabilities {
+			severity := mapSeverity(vuln.Severity)
+			f := Finding{
+				ID:          fmt.Sprintf("depcheck-%s-%s", vuln.Name, dep.FileName),
+				Type:        "DEPENDENCY",
+				Severity:    severity,
+				Title:       vuln.Name,
+				Description: vuln.Description,
+				File:        dep.FileName,
+				Tool:        "dependency-check",
 			}
-			if json.Unmarshal(data, &result) == nil {
-				for _, dep := range result.Dependencies {
-					for _, vuln := range dep.Vulnerabilities {
-						severity := mapSeverity(vuln.Severity)
-						findings = append(findings, Finding{
-							ID:          fmt.Sprintf("depcheck-%s-%s", vuln.Name, dep.FileName),
-							Type:        "DEPENDENCY",
-							Severity:    severity,
-							Title:       vuln.Name,
-							Description: vuln.Description,
-							File:        dep.FileName,
-							Tool:        "dependency-check",
-							Fixable:     true,
-						})
+			if pkg != nil {
+				// The first version outside of the matched range fixes it
+				p := *pkg
+				for _, sw := range vuln.VulnerableSoftware {
+					if sw.Software.VulnerabilityIDMatched == "true" && sw.Software.VersionEndExcluding != "" {
+						p.FixedVersions = append(p.FixedVersions, sw.Software.VersionEndExcluding)
 					}
 				}
+				f.Package = &p
+				f.setUpgrade()
 			}
+			findings = append(findings, f)
 		}
 	}
 	return findings, nil
 }
 
-// scanIaCExtended scans with multiple IaC scanners
-func scanIaCExtended(path string) ([]Finding, error) {
+// scanWithCheckov scans with Checkov
+func scanWithCheckov(ctx context.Context, path string) ([]Finding, error) {
 	var findings []Finding
-
-	// Checkov
-	if _, err := exec.LookPath("checkov"); err == nil {
-		if checkovFindings, err := scanWithCheckov(path); err == nil {
-			findings = append(findings, checkovFindings...)
-		}
+	args := []string{"-d", path, "-o", "json", "--quiet"}
+	if exclude := excludeRegexp(ctx, path); exclude != "" {
+		args = append(args, "--skip-path", exclude)
 	}
-
-	// Terrascan
-	if _, err := exec.LookPath("terrascan"); err == nil {
-		if terrascanFindings, err := scanWithTerrascan(path); err == nil {
-			findings = append(findings, terrascanFindings...)
-		}
+	output, err := runTool(ctx, "", "checkov", args...)
+	if err := toolError("checkov", output, err); err != nil {
+		return nil, err
 	}
 
-	// Kics
-	if _, err := exec.LookPath("kics"); err == nil {
-		if kicsFindings, err := scanWithKics(path); err == nil {
-			findings = append(findings, kicsFindings...)
-		}
+	var result struct {
+		Results struct {
+			FailedChecks []struct {
+				CheckID   string `json:"check_id"`
+				CheckName string `json:"check_name"`
+				Severity  string `json:"severity"`
+				File      string `json:"file_path"`
+				Line      []int  `json:"file_line_range"`
+			} `json:"failed_checks"`
+		} `json:"results"`
 	}
-
-	return findings, nil
-}
-
-// scanWithCheckov scans with Checkov
-func scanWithCheckov(path string) ([]Finding, error) {
-	var findings []Finding
-	cmd := exec.Command("checkov", "-d", path, "-o", "json", "--quiet")
-	output, err := cmd.Output()
-	if err == nil && len(output) > 0 {
-		var result struct {
-			Results struct {
-				FailedChecks []struct {
-					CheckID   string `json:"check_id"`
-					CheckName string `json:"check_name"`
-					Severity  string `json:"severity"`
-					File      string `json:"file_path"`
-					Line      []int  `json:"file_line_range"`
-				} `json:"failed_checks"`
-			} `json:"results"`
-		}
-		if json.Unmarshal(output, &result) == nil {
-			for _, check := range result.Results.FailedChecks {
-				line := 0
-				if len(check.Line) > 0 {
-					line = check.Line[0]
-				}
-				severity := mapSeverity(check.Severity)
-				findings = append(findings, Finding{
-					ID:          fmt.Sprintf("checkov-%s-%s-%d", check.CheckID, check.File, line),
-					Type:        "IAC",
-					Severity:    severity,
-					Title:       check.CheckName,
-					Description: check.CheckID,
-					File:        check.File,
-					Line:        line,
-					Tool:        "checkov",
-					Fixable:     true,
-				})
-			}
-		}
+	if err := decodeToolOutput("checkov", output, &result); err != nil {
+		return nil, err
+	}
+	for _, check := range result.Results.FailedChecks {
+		line := 0
+		if len(check.Line) > 0 {
+			line = check.Line[0]
+		}
+		severity := mapSeverity(check.Severity)
+		findings = append(findings, Finding{
+			ID:          fmt.Sprintf("checkov-%s-%s-%d", check.CheckID, check.File, line),
+			Type:        "IAC",
+			Severity:    severity,
+			Title:       check.CheckName,
+			Description: check.CheckID,
+			File:        check.File,
+			Line:        line,
+			Tool:        "checkov",
+			Fixable:     true,
+			CWE:         knownCWE(check.CheckID),
+		})
 	}
 	return findings, nil
 }
 
 // scanWithTerrascan scans with Terrascan
-func scanWithTerrascan(path string) ([]Finding, error) {
+func scanWithTerrascan(ctx context.Context, path string) ([]Finding, error) {
 	var findings []Finding
-	cmd := exec.Command("terrascan", "scan", "-i", "all", "-t", "all", "-o", "json", "-d", path)
-	output, err := cmd.Output()
-	if err == nil && len(output) > 0 {
-		var result struct {
-			Violations []struct {
-				RuleID    string `json:"rule_id"`
-				RuleName  string `json:"rule_name"`
-				Severity  string `json:"severity"`
-				File      string `json:"file"`
-				Line      int    `json:"line_number"`
-				Description string `json:"description"`
-			} `json:"violations"`
-		}
-		if json.Unmarshal(output, &result) == nil {
-			for _, violation := range result.Violations {
-				severity := mapSeverity(violation.Severity)
-				findings = append(findings, Finding{
-					ID:          fmt.Sprintf("terrascan-%s-%s-%d", violation.RuleID, violation.File, violation.Line),
-					Type:        "IAC",
-					Severity:    severity,
-					Title:       violation.RuleName,
-					Description: violation.Description,
-					File:        violation.File,
-					Line:        violation.Line,
-					Tool:        "terrascan",
-					Fixable:     true,
-				})
-			}
-		}
+	output, err := runTool(ctx, "", "terrascan", "scan", "-i", "all", "-t", "all", "-o", "json", "-d", path)
+	if err := toolError("terrascan", output, err); err != nil {
+		return nil, err
 	}
-	return findings, nil
-}
 
-// scanWithKics scans with Kics
-func scanWithKics(path string) ([]Finding, error) {
-	var findings []Finding
-	cmd := exec.Command("kics", "scan", "-p", path, "-o", filepath.Join(path, ".kics-results"), "--report-formats", "json")
-	_, err := cmd.Output()
-	if err == nil {
-		reportFile := filepath.Join(path, ".kics-results", "results.json")
-		if data, readErr := os.ReadFile(reportFile); readErr == nil {
-			var result struct {
-				Queries []struct {
-					QueryID   string `json:"query_id"`
-					QueryName string `json:"query_name"`
-					Severity  string `json:"severity"`
-					Files     []struct {
-						FileName string `json:"file_name"`
-						Line     int    `json:"line"`
-					} `json:"files"`
-				} `json:"queries"`
-			}
-			if json.Unmarshal(data, &result) == nil {
-				for _, query := range result.Queries {
-					severity := mapSeverity(query.Severity)
-					for _, file := range query.Files {
-						findings = append(findings, Finding{
-							ID:          fmt.Sprintf("kics-%s-%s-%d", query.QueryID, file.FileName, file.Line),
-							Type:        "IAC",
-							Severity:    severity,
-							Title:       query.QueryName,
-							Description: query.QueryID,
-							File:        file.FileName,
-							Line:        file.Line,
-							Tool:        "kics",
-							Fixable:     true,
-						})
-					}
-				}
-			}
-		}
+	var result struct {
+		Violations []struct {
+			RuleID      string `json:"rule_id"`
+			RuleName    string `json:"rule_name"`
+			Severity    string `json:"severity"`
+			File        string `json:"file"`
+			Line        int    `json:"line_number"`
+			Description string `json:"description"`
+		} `json:"violations"`
+	}
+	if err := decodeToolOutput("terrascan", output, &result); err != nil {
+		return nil, err
+	}
+	for _, violation := range result.Violations {
+		severity := mapSeverity(violation.Severity)
+		findings = append(findings, Finding{
+			ID:          fmt.Sprintf("terrascan-%s-%s-%d", violation.RuleID, violation.File, violation.Line),
+			Type:        "IAC",
+			Severity:    severity,
+			Title:       violation.RuleName,
+			Description: violation.Description,
+			File:        violation.File,
+			Line:        violation.Line,
+			Tool:        "terrascan",
+			Fixable:     true,
+		})
 	}
 	return findings, nil
 }
 
-// scanContainersExtended scans with multiple container security tools
-func scanContainersExtended(path string) ([]Finding, error) {
+// scanWithKics scans with Kics
+func scanWithKics(ctx context.Context, path string) ([]Finding, error) {
 	var findings []Finding
+	// Kics exits with a non-zero code when it finds issues, so rely on the report
+	args := []string{"scan", "-p", path, "-o", filepath.Join(path, ".kics-results"), "--report-formats", "json"}
+	if exclude := excludeValues(ctx, absPaths(path)); len(exclude) > 0 {
+		args = append(args, "--exclude-paths", strings.Join(exclude, ","))
+	}
+	_, err := runTool(ctx, "", "kics", args...)
+	data, readErr := os.ReadFile(filepath.Join(path, ".kics-results", "results.json"))
+	if err := toolError("kics", data, err); err != nil {
+		return nil, err
+	}
+	if readErr != nil {
+		return nil, fmt.Errorf("cannot read kics report: %w", readErr)
+	}
 
-	// Hadolint for Dockerfiles
-	if _, err := exec.LookPath("hadolint"); err == nil {
-		if hadolintFindings, err := scanWithHadolint(path); err == nil {
-			findings = append(findings, hadolintFindings...)
+	var result struct {
+		Queries []struct {
+			QueryID   string  `json:"query_id"`
+			QueryName string  `json:"query_name"`
+			Severity  string  `json:"severity"`
+			CWE       refList `json:"cwe"`
+			Files     []struct {
+				FileName string `json:"file_name"`
+				Line     int    `json:"line"`
+			} `json:"files"`
+		} `json:"queries"`
+	}
+	if err := decodeToolOutput("kics", data, &result); err != nil {
+		return nil, err
+	}
+	for _, query := range result.Queries {
+		severity := mapSeverity(query.Severity)
+		for _, file := range query.Files {
+			findings = append(findings, Finding{
+				ID:          fmt.Sprintf("kics-%s-%s-%d", query.QueryID, file.FileName, file.Line),
+				Type:        "IAC",
+				Severity:    severity,
+				Title:       query.QueryName,
+				Description: query.QueryID,
+				File:        file.FileName,
+				Line:        file.Line,
+				Tool:        "kics",
+				Fixable:     true,
+				CWE:         query.CWE,
+			})
 		}
 	}
-
 	return findings, nil
 }
 
 // scanWithHadolint scans Dockerfiles with Hadolint
-func scanWithHadolint(path string) ([]Finding, error) {
+func scanWithHadolint(ctx context.Context, path string) ([]Finding, error) {
 	var findings []Finding
 	// Find all Dockerfiles
 	dockerfiles := []string{}
-	filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-		if info.Name() == "Dockerfile" || strings.HasSuffix(info.Name(), ".dockerfile") {
+	walkFiles(ctx, path, func(p, rel string, info fs.FileInfo) error {
+		if isDockerfile(info.Name()) {
 			dockerfiles = append(dockerfiles, p)
 		}
 		return nil
 	})
 
 	for _, dockerfile := range dockerfiles {
-		cmd := exec.Command("hadolint", "--format", "json", dockerfile)
-		output, err := cmd.Output()
-		if err == nil && len(output) > 0 {
-			var results []struct {
-				Code     string `json:"code"`
-				Level    string `json:"level"`
-				Message  string `json:"message"`
-				Line     int    `json:"line"`
-				Column   int    `json:"column"`
-			}
-			if json.Unmarshal(output, &results) == nil {
-				for _, r := range results {
-					severity := SeverityLow
-					if r.Level == "error" {
-						severity = SeverityHigh
-					} else if r.Level == "warning" {
-						severity = SeverityMedium
-					}
-					findings = append(findings, Finding{
-						ID:          fmt.Sprintf("hadolint-%s-%s-%d", r.Code, dockerfile, r.Line),
-						Type:        "CONTAINER",
-						Severity:    severity,
-						Title:       r.Code,
-						Description: r.Message,
-						File:        dockerfile,
-						Line:        r.Line,
-						Tool:        "hadolint",
-						Fixable:     true,
-					})
-				}
+		output, err := runTool(ctx, "", "hadolint", "--format", "json", dockerfile)
+		if err := toolError("hadolint", output, err); err != nil {
+			return nil, err
+		}
+
+		var results []struct {
+			Code    string `json:"code"`
+			Level   string `json:"level"`
+			Message string `json:"message"`
+			Line    int    `json:"line"`
+			Column  int    `json:"column"`
+		}
+		if err := decodeToolOutput("hadolint", output, &results); err != nil {
+			return nil, err
+		}
+		for _, r := range results {
+			severity := SeverityLow
+			if r.Level == "error" {
+				severity = SeverityHigh
+			} else if r.Level == "warning" {
+				severity = SeverityMedium
 			}
+			findings = append(findings, Finding{
+				ID:          fmt.Sprintf("hadolint-%s-%s-%d", r.Code, dockerfile, r.Line),
+				Type:        "CONTAINER",
+				Severity:    severity,
+				Title:       r.Code,
+				Description: r.Message,
+				File:        dockerfile,
+				Line:        r.Line,
+				RuleID:      r.Code,
+				Tool:        "hadolint",
+				Fixable:     true,
+			})
 		}
 	}
 	return findings, nil
 }
-
@@ -0,0 +1,227 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HistoryScope is the range of commits scanned for secrets by
+// `dso audit --history`. Without SinceCommit and Branch, every commit
+// reachable from a ref is scanned.
+type HistoryScope struct {
+	SinceCommit string `json:"since_commit,omitempty"` // Only the commits after this one
+	Branch      string `json:"branch,omitempty"`       // Only the commits of this branch
+}
+
+// Describe returns a short description of the scope, like "commits of main since v1.2.0"
+func (h *HistoryScope) Describe() string {
+	switch {
+	case h.Branch != "" && h.SinceCommit != "":
+		return fmt.Sprintf("commits of %s since %s", h.Branch, h.SinceCommit)
+	case h.Branch != "":
+		return fmt.Sprintf("commits of %s", h.Branch)
+	case h.SinceCommit != "":
+		return fmt.Sprintf("commits since %s", h.SinceCommit)
+	default:
+		return "every commit"
+	}
+}
+
+// historyScan is the state of a history scan shared by the scanners of a run
+type historyScan struct {
+	*HistoryScope
+	root   string // Top-level directory of the repository
+	prefix string // Scanned directory relative to root, "" or ending with "/"
+
+	mu   sync.Mutex
+	head map[string][]headMatch // Lines of HEAD containing each secret
+}
+
+// headMatch is a line of HEAD, relative to the repository root
+type headMatch struct {
+	file string
+	line int
+}
+
+type historyKey struct{}
+
+// newHistoryScan checks that dir is in a git repository and that the refs of
+// scope exist
+func newHistoryScan(ctx context.Context, dir string, scope *HistoryScope) (*historyScan, error) {
+	out, err := gitOutput(ctx, dir, "rev-parse", "--show-toplevel", "--show-prefix")
+	if err != nil {
+		return nil, fmt.Errorf("cannot scan the history of %s: %w", dir, err)
+	}
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	h := &historyScan{HistoryScope: scope, root: lines[0], head: make(map[string][]headMatch)}
+	if len(lines) > 1 {
+		h.prefix = lines[1]
+	}
+
+	for _, ref := range []string{scope.SinceCommit, scope.Branch} {
+		if ref == "" {
+			continue
+		}
+		if _, err := gitOutput(ctx, dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+			return nil, fmt.Errorf("cannot resolve git ref %q in %s: %w", ref, dir, err)
+		}
+	}
+	return h, nil
+}
+
+// withHistory makes the secret scanners scan the history of h too
+func withHistory(ctx context.Context, h *historyScan) context.Context {
+	return context.WithValue(ctx, historyKey{}, h)
+}
+
+// historyFromContext returns the history scan of the run, or nil when the
+// history is not scanned
+func historyFromContext(ctx context.Context) *historyScan {
+	h, _ := ctx.Value(historyKey{}).(*historyScan)
+	return h
+}
+
+// logRange returns the git log revision range of the scope, or "" for
+// every ref
+func (h *historyScan) logRange() string {
+	switch {
+	case h.SinceCommit != "" && h.Branch != "":
+		return h.SinceCommit + ".." + h.Branch
+	case h.SinceCommit != "":
+		return h.SinceCommit + "..HEAD"
+	default:
+		return h.Branch
+	}
+}
+
+// locate completes a finding of a past commit, whose file is relative to the
+// repository root. secret is the raw value found by the tool. When the
+// secret is still in HEAD, the finding is moved to its location in HEAD;
+// otherwise it keeps its location in the commit. It returns false when the
+// file is outside of the scanned directory.
+func (h *historyScan) locate(ctx context.Context, f *Finding, secret string) bool {
+	file, ok := strings.CutPrefix(path.Clean(f.File), h.prefix)
+	if !ok {
+		return false
+	}
+	f.File = file
+
+	matches, err := h.headMatches(ctx, secret)
+	if err == nil && len(matches) > 0 {
+		// The same file first, in case the secret was copied elsewhere
+		m := matches[0]
+		for _, candidate := range matches {
+			if candidate.file == h.prefix+file {
+				m = candidate
+				break
+			}
+		}
+		if rel, ok := strings.CutPrefix(m.file, h.prefix); ok {
+			f.Commit.InHead = true
+			f.File, f.Line, f.EndLine, f.Column = rel, m.line, 0, 0
+		}
+	}
+
+	short := f.Commit.SHA
+	if len(short) > 12 {
+		short = short[:12]
+	}
+
+	if f.Commit.InHead {
+		f.Fix = fmt.Sprintf("Remove the secret from %s and load it from the environment or a secret manager. "+
+			"Revoke and rotate it: it has been in the git history since commit %s, so deleting it from the code is not enough. "+
+			"Then purge it from the history (git filter-repo --replace-text) and force-push.", f.File, short)
+		f.Fixable = true
+	} else {
+		if _, name, ok := strings.Cut(f.Title, ": "); ok {
+			f.Title = "Secret in git history: " + name
+		}
+		f.Fix = fmt.Sprintf("The secret is no longer in the code but anyone with a clone can read it in commit %s. "+
+			"Revoke and rotate it first, then purge it from the history (git filter-repo --replace-text, or BFG), "+
+			"force-push every branch and tag, and ask collaborators to clone again.", short)
+		f.Fixable = false
+	}
+	return true
+}
+
+// headMatches returns the lines of HEAD that contain secret, looked up once
+// per secret
+func (h *historyScan) headMatches(ctx context.Context, secret string) ([]headMatch, error) {
+	// Multiline secrets (private keys) are looked up by their longest line
+	pattern := ""
+	for _, line := range strings.Split(secret, "\n") {
+		if line = strings.TrimSpace(line); len(line) > len(pattern) {
+			pattern = line
+		}
+	}
+	if pattern == "" {
+		return nil, nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if matches, ok := h.head[pattern]; ok {
+		return matches, nil
+	}
+
+	// The secret is passed on stdin to keep it out of the process list
+	cmd := exec.CommandContext(ctx, "git", "-C", h.root, "-c", "core.quotePath=false",
+		"grep", "--full-name", "-n", "-z", "-I", "-F", "-f", "-", "HEAD", "--")
+	cmd.Stdin = strings.NewReader(pattern + "\n")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		err = nil // no match
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git grep: %s", msg)
+		}
+		return nil, fmt.Errorf("git grep: %w", err)
+	}
+
+	// -z separates the file, line number and content with NUL
+	var matches []headMatch
+	for _, record := range strings.Split(string(out), "\n") {
+		fields := strings.SplitN(record, "\x00", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		line, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		matches = append(matches, headMatch{file: strings.TrimPrefix(fields[0], "HEAD:"), line: line})
+	}
+	h.head[pattern] = matches
+	return matches, nil
+}
+
+// parseCommitDate parses the commit dates written by gitleaks and trufflehog
+func parseCommitDate(s string) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05 -0700", "2006-01-02 15:04:05 -0700 MST"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// parseAuthor splits a "Name <email>" author
+func parseAuthor(s string) (name, email string) {
+	name, email, ok := strings.Cut(s, "<")
+	if !ok {
+		return strings.TrimSpace(s), ""
+	}
+	return strings.TrimSpace(name), strings.TrimSuffix(strings.TrimSpace(email), ">")
+}
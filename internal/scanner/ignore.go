@@ -0,0 +1,122 @@
+package scanner
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/dso-cli/dso-cli/internal/ignore"
+)
+
+type ignoreKey struct{}
+
+// defaultIgnore is used when a scanner runs outside of Run
+var defaultIgnore = sync.OnceValue(ignore.Default)
+
+// withIgnore sets the ignore rules of the scanned directory
+func withIgnore(ctx context.Context, m *ignore.Matcher) context.Context {
+	return context.WithValue(ctx, ignoreKey{}, m)
+}
+
+// ignoreRules returns the ignore rules of the scanned directory
+func ignoreRules(ctx context.Context) *ignore.Matcher {
+	if m, ok := ctx.Value(ignoreKey{}).(*ignore.Matcher); ok {
+		return m
+	}
+	return defaultIgnore()
+}
+
+// excludeArgs translates the excluded paths into repeated command line
+// flags. format returns the flag value of a path relative to the scanned
+// directory, or "" when the tool cannot exclude it.
+func excludeArgs(ctx context.Context, flag string, format func(e ignore.Entry) string) []string {
+	var args []string
+	for _, value := range excludeValues(ctx, format) {
+		args = append(args, flag, value)
+	}
+	return args
+}
+
+// excludeValues returns the formatted excluded paths, for tools that take
+// them as a single list
+func excludeValues(ctx context.Context, format func(e ignore.Entry) string) []string {
+	var values []string
+	for _, e := range ignoreRules(ctx).Excluded() {
+		if value := format(e); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// relPath formats an excluded path as is
+func relPath(e ignore.Entry) string {
+	return e.Path
+}
+
+// excludedDirs formats excluded directories only
+func excludedDirs(e ignore.Entry) string {
+	if !e.IsDir {
+		return ""
+	}
+	return e.Path
+}
+
+// excludedFiles formats excluded files only
+func excludedFiles(e ignore.Entry) string {
+	if e.IsDir {
+		return ""
+	}
+	return e.Path
+}
+
+// absPaths formats excluded paths as absolute paths under root
+func absPaths(root string) func(e ignore.Entry) string {
+	return func(e ignore.Entry) string {
+		return filepath.Join(root, filepath.FromSlash(e.Path))
+	}
+}
+
+// excludeRegexp returns a regular expression matching the excluded paths
+// and their content, or "" when nothing is excluded. The excluded paths are
+// relative to the scanned directory path, so the expression is anchored to
+// it, however the tool prints it: relative to the directory, as given, or
+// absolute. A nested directory of the same name is not excluded.
+func excludeRegexp(ctx context.Context, path string) string {
+	quoted := excludeValues(ctx, func(e ignore.Entry) string {
+		if e.IsDir {
+			return regexp.QuoteMeta(e.Path) + "/"
+		}
+		return regexp.QuoteMeta(e.Path) + "$"
+	})
+	if len(quoted) == 0 {
+		return ""
+	}
+
+	prefixes := []string{`(\./)?`}
+	dirs := []string{filepath.ToSlash(filepath.Clean(path))}
+	if abs, err := filepath.Abs(path); err == nil {
+		dirs = append(dirs, filepath.ToSlash(abs))
+	}
+	for _, dir := range dirs {
+		if dir != "." {
+			prefixes = append(prefixes, regexp.QuoteMeta(strings.TrimSuffix(dir, "/")+"/"))
+		}
+	}
+	return "^(" + strings.Join(prefixes, "|") + ")(" + strings.Join(quoted, "|") + ")"
+}
+
+// filterIgnored drops the findings located in ignored files, for the tools
+// that cannot exclude them. File paths must be relative to the scanned
+// directory, as returned by Correlate.
+func filterIgnored(m *ignore.Matcher, findings []Finding) []Finding {
+	kept := findings[:0]
+	for _, f := range findings {
+		if f.File == "" || !m.Match(f.File, false) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
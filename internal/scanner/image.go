@@ -0,0 +1,190 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ImageSource is where `dso image` reads an image from
+type ImageSource string
+
+const (
+	ImageArchive   ImageSource = "archive"   // Tarball written by docker save
+	ImageOCILayout ImageSource = "oci"       // OCI image layout directory
+	ImageReference ImageSource = "reference" // Image of the local docker daemon
+)
+
+// Image describes a scanned container image
+type Image struct {
+	Target       string      `json:"target"` // Reference, tarball or OCI layout given to dso image
+	Source       ImageSource `json:"source"`
+	Digest       string      `json:"digest,omitempty"` // Digest of the image configuration (image ID)
+	User         string      `json:"user,omitempty"`
+	ExposedPorts []string    `json:"exposed_ports,omitempty"`
+}
+
+// ResolveImage returns the image of target: an existing file is a docker
+// save tarball, a directory an OCI image layout, and anything else a
+// reference of the local docker daemon
+func ResolveImage(target string) (*Image, error) {
+	info, err := os.Stat(target)
+	if errors.Is(err, os.ErrNotExist) {
+		if strings.HasSuffix(target, ".tar") || strings.HasSuffix(target, ".tar.gz") || strings.HasSuffix(target, ".tgz") ||
+			strings.HasPrefix(target, ".") || filepath.IsAbs(target) {
+			return nil, fmt.Errorf("image archive %s does not exist", target)
+		}
+		return &Image{Target: target, Source: ImageReference}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	abs, err := filepath.Abs(target)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return &Image{Target: abs, Source: ImageArchive}, nil
+	}
+	if _, err := os.Stat(filepath.Join(abs, "oci-layout")); err != nil {
+		return nil, fmt.Errorf("%s is not an OCI image layout (no oci-layout file); use dso audit to scan a directory", target)
+	}
+	return &Image{Target: abs, Source: ImageOCILayout}, nil
+}
+
+// Describe returns a short description of the image, like "app.tar (docker save archive)"
+func (img *Image) Describe() string {
+	switch img.Source {
+	case ImageArchive:
+		return filepath.Base(img.Target) + " (docker save archive)"
+	case ImageOCILayout:
+		return filepath.Base(img.Target) + " (OCI layout)"
+	default:
+		return img.Target + " (docker image)"
+	}
+}
+
+// imageScanners returns the scanners of an image: the vulnerabilities and
+// secrets of its layers come from trivy and grype, its configuration is
+// checked natively
+func imageScanners(img *Image) []Scanner {
+	return []Scanner{
+		&toolScanner{
+			name:      "trivy",
+			category:  CategoryContainers,
+			command:   "trivy",
+			scan:      func(ctx context.Context, _ string) ([]Finding, error) { return scanImageWithTrivy(ctx, img) },
+			dbVersion: []string{"--version"},
+		},
+		&toolScanner{
+			name:      "grype",
+			category:  CategoryContainers,
+			command:   "grype",
+			scan:      func(ctx context.Context, _ string) ([]Finding, error) { return scanImageWithGrype(ctx, img) },
+			dbVersion: []string{"db", "status"},
+		},
+		imageConfigScanner{img: img},
+	}
+}
+
+// ScanImage scans a container image with the available image scanners.
+// The results have no path: finding files are paths inside the image, and
+// results.Image describes the image. It fails when no scanner can run.
+func ScanImage(ctx context.Context, img *Image, tracker *ProgressTracker) (*ScanResults, error) {
+	results := &ScanResults{
+		Timestamp: time.Now(),
+		Findings:  []Finding{},
+		Image:     img,
+	}
+	if tracker == nil {
+		tracker = NewProgressTracker(false)
+	}
+
+	scanners := imageScanners(img)
+	runs := make([]ToolRun, len(scanners))
+	var steps []int
+	for i, s := range scanners {
+		runs[i] = ToolRun{Tool: s.Name(), Category: s.Category(), Status: ToolStatusSkipped}
+		if tool, ok := s.(ExternalTool); ok {
+			if err := tool.Available(); err != nil {
+				runs[i].Error = err.Error()
+				continue
+			}
+		}
+		steps = append(steps, i)
+		tracker.AddStep(stepName(s))
+	}
+	if len(steps) == 1 {
+		// The configuration alone is not a scan of the image
+		return nil, errors.New("no image scanner available, install trivy or grype (dso tools --install)")
+	}
+
+	stepFindings := make([][]Finding, len(scanners))
+	var wg sync.WaitGroup
+	for step, i := range steps {
+		wg.Add(1)
+		go func(step, i int) {
+			defer wg.Done()
+			s := scanners[i]
+			tracker.StartStep(step, stepName(s))
+			stepFindings[i] = runScanner(ctx, s, "", &runs[i], nil)
+			if runs[i].Status == ToolStatusOK {
+				tracker.CompleteStep(step, len(stepFindings[i]))
+			} else {
+				tracker.FailStep(step, errors.New(runs[i].Error))
+			}
+		}(step, i)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	for i := range scanners {
+		findings = append(findings, stepFindings[i]...)
+		if runs[i].Status == ToolStatusTimeout {
+			results.Warnings = append(results.Warnings,
+				fmt.Sprintf("%s %s, results are partial", runs[i].Tool, runs[i].Error))
+		}
+	}
+
+	findings = Correlate("", findings)
+	classify(findings)
+
+	results.ToolRuns = runs
+	results.Findings = append(results.Findings, findings...)
+	results.CalculateSummary()
+	tracker.Finish(results.Summary.Total)
+	return results, nil
+}
+
+// scanImageWithTrivy scans the packages and the layer files of an image with trivy
+func scanImageWithTrivy(ctx context.Context, img *Image) ([]Finding, error) {
+	args := []string{"image", "--scanners", "vuln,secret", "--list-all-pkgs"}
+	if img.Source == ImageReference {
+		args = append(args, img.Target)
+	} else {
+		args = append(args, "--input", img.Target)
+	}
+	return runTrivy(ctx, args...)
+}
+
+// scanImageWithGrype scans the packages of an image with grype
+func scanImageWithGrype(ctx context.Context, img *Image) ([]Finding, error) {
+	source := img.Target
+	switch img.Source {
+	case ImageArchive:
+		source = "docker-archive:" + img.Target
+	case ImageOCILayout:
+		source = "oci-dir:" + img.Target
+	}
+	return runGrype(ctx, source, "-o", "json")
+}
@@ -0,0 +1,308 @@
+package scanner
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxImageMetadataSize is the size above which an image manifest or
+// configuration is not read
+const maxImageMetadataSize = 4 << 20
+
+// imageConfig is the configuration of an image, as stored in archives and
+// OCI layouts, and as printed by docker image inspect
+type imageConfig struct {
+	ID     string `json:"Id"` // Only printed by docker image inspect
+	Config struct {
+		User         string              `json:"User"`
+		ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+		Env          []string            `json:"Env"`
+	} `json:"config"`
+	History []struct {
+		CreatedBy string `json:"created_by"`
+	} `json:"history"`
+}
+
+// sensitivePorts are the ports of services that should not be reachable
+// from outside of the container
+var sensitivePorts = map[int]struct {
+	service  string
+	severity Severity
+}{
+	22:    {"SSH", SeverityHigh},
+	23:    {"Telnet", SeverityHigh},
+	2375:  {"Docker API without TLS", SeverityCritical},
+	2376:  {"Docker API", SeverityHigh},
+	2379:  {"etcd", SeverityHigh},
+	3389:  {"RDP", SeverityHigh},
+	5900:  {"VNC", SeverityHigh},
+	3306:  {"MySQL", SeverityMedium},
+	5432:  {"PostgreSQL", SeverityMedium},
+	6379:  {"Redis", SeverityMedium},
+	9200:  {"Elasticsearch", SeverityMedium},
+	11211: {"Memcached", SeverityMedium},
+	27017: {"MongoDB", SeverityMedium},
+}
+
+// imageConfigScanner checks the configuration of an image: its user, its
+// exposed ports, and the secrets of its environment and build history
+type imageConfigScanner struct {
+	img *Image
+}
+
+func (imageConfigScanner) Name() string             { return "dso-image" }
+func (imageConfigScanner) Category() Category       { return CategoryContainers }
+func (imageConfigScanner) Applicable(*Project) bool { return true }
+
+// Scan reads the configuration of the image and records its user and
+// exposed ports in the image
+func (s imageConfigScanner) Scan(ctx context.Context, _ string) ([]Finding, error) {
+	var data []byte
+	var err error
+	switch s.img.Source {
+	case ImageArchive:
+		data, err = readArchiveConfig(s.img.Target)
+	case ImageOCILayout:
+		data, err = readOCIConfig(s.img.Target)
+	default:
+		data, err = inspectImageConfig(ctx, s.img.Target)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg imageConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("cannot parse the configuration of %s: %w", s.img.Target, err)
+	}
+	// The image ID is the digest of its configuration
+	s.img.Digest = cfg.ID
+	if s.img.Digest == "" {
+		sum := sha256.Sum256(data)
+		s.img.Digest = "sha256:" + hex.EncodeToString(sum[:])
+	}
+	s.img.User = cfg.Config.User
+	for port := range cfg.Config.ExposedPorts {
+		s.img.ExposedPorts = append(s.img.ExposedPorts, port)
+	}
+	sort.Strings(s.img.ExposedPorts)
+
+	return imageConfigFindings(s.img, &cfg), nil
+}
+
+// imageConfigFindings returns the problems of the configuration of an image
+func imageConfigFindings(img *Image, cfg *imageConfig) []Finding {
+	var findings []Finding
+	now := time.Now()
+
+	user, _, _ := strings.Cut(cfg.Config.User, ":")
+	if user == "" || user == "root" || user == "0" {
+		description := "The image has no USER, so its processes run as root."
+		if user != "" {
+			description = fmt.Sprintf("The image user is %s.", cfg.Config.User)
+		}
+		findings = append(findings, Finding{
+			ID:          "dso-image-root-user",
+			Type:        "CONTAINER",
+			Severity:    SeverityHigh,
+			Title:       "Image runs as root",
+			Description: description + " A process that escapes the application gets root privileges in the container, and on the host when user namespaces are not used.",
+			RuleID:      "image-root-user",
+			Tool:        "dso-image",
+			Fixable:     true,
+			Fix:         "Create an unprivileged user in the Dockerfile and switch to it before the entrypoint: RUN useradd -r -u 10001 app, then USER 10001",
+			Timestamp:   now,
+		})
+	}
+
+	for _, port := range img.ExposedPorts {
+		number, protocol, _ := strings.Cut(port, "/")
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			continue
+		}
+		sensitive, ok := sensitivePorts[n]
+		if !ok {
+			continue
+		}
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		findings = append(findings, Finding{
+			ID:          fmt.Sprintf("dso-image-port-%d-%s", n, protocol),
+			Type:        "CONTAINER",
+			Severity:    sensitive.severity,
+			Title:       fmt.Sprintf("Image exposes port %d/%s (%s)", n, protocol, sensitive.service),
+			Description: fmt.Sprintf("The image declares EXPOSE %d. %s should not be reachable from outside of the container: it is an administration or data port.", n, sensitive.service),
+			RuleID:      "image-exposed-port",
+			Tool:        "dso-image",
+			Fixable:     true,
+			Fix:         fmt.Sprintf("Remove EXPOSE %d from the Dockerfile and reach %s through the container network or a sidecar", n, sensitive.service),
+			Timestamp:   now,
+		})
+	}
+
+	// Build arguments show up in the commands of the history
+	history := make([]string, len(cfg.History))
+	for i, h := range cfg.History {
+		history[i] = h.CreatedBy
+	}
+	sources := []struct {
+		name  string
+		lines []string
+	}{{"ENV", cfg.Config.Env}, {"build history", history}}
+	for _, source := range sources {
+		for _, f := range detectSecrets(source.name, strings.Join(source.lines, "\n")) {
+			f.ID = fmt.Sprintf("dso-image-%s-%s-%d", f.RuleID, strings.ReplaceAll(source.name, " ", "-"), f.Line)
+			f.Title = fmt.Sprintf("Secret in image %s: %s", source.name, strings.TrimPrefix(f.Title, "Exposed secret: "))
+			f.Description = fmt.Sprintf("%s of the image configuration, entry %d: anyone who pulls the image can read it with docker inspect or docker history", source.name, f.Line)
+			f.File, f.Line, f.Column = "", 0, 0
+			f.Tool = "dso-image"
+			f.Fix = "Revoke and rotate the secret, then rebuild the image without it: pass it at runtime, or with a build secret (RUN --mount=type=secret) instead of ENV or ARG"
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// readArchiveConfig returns the configuration of the first image of a
+// docker save tarball, optionally gzipped
+func readArchiveConfig(file string) ([]byte, error) {
+	data, err := readTarEntry(file, "manifest.json")
+	if err != nil {
+		return nil, err
+	}
+	var manifest []struct {
+		Config string `json:"Config"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("cannot parse manifest.json of %s: %w", file, err)
+	}
+	if len(manifest) == 0 || manifest[0].Config == "" {
+		return nil, fmt.Errorf("no image in manifest.json of %s", file)
+	}
+	return readTarEntry(file, path.Clean(manifest[0].Config))
+}
+
+// readTarEntry returns the content of the file name of a tarball
+func readTarEntry(file, name string) ([]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s: %w", file, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s has no %s, it is not a docker save archive", file, name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s: %w", file, err)
+		}
+		if path.Clean(hdr.Name) == name {
+			return io.ReadAll(io.LimitReader(tr, maxImageMetadataSize))
+		}
+	}
+}
+
+// ociDescriptor points to a blob of an OCI layout
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Platform  *struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+	} `json:"platform"`
+}
+
+// readOCIConfig returns the configuration of the image of an OCI layout,
+// following image indexes down to the manifest of the current platform
+func readOCIConfig(dir string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "index.json"))
+	if err != nil {
+		return nil, err
+	}
+	// Nested indexes are followed a few levels deep at most
+	for depth := 0; depth < 4; depth++ {
+		var doc struct {
+			Manifests []ociDescriptor `json:"manifests"`
+			Config    *ociDescriptor  `json:"config"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("cannot parse the OCI layout %s: %w", dir, err)
+		}
+		if doc.Config != nil {
+			return readOCIBlob(dir, doc.Config.Digest)
+		}
+		if len(doc.Manifests) == 0 {
+			return nil, fmt.Errorf("no image in the OCI layout %s", dir)
+		}
+		next := doc.Manifests[0]
+		for _, m := range doc.Manifests {
+			if m.Platform != nil && m.Platform.OS == "linux" && m.Platform.Architecture == runtime.GOARCH {
+				next = m
+				break
+			}
+		}
+		if data, err = readOCIBlob(dir, next.Digest); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("no image manifest in the OCI layout %s", dir)
+}
+
+// readOCIBlob returns the content of a blob of an OCI layout
+func readOCIBlob(dir, digest string) ([]byte, error) {
+	algorithm, encoded, ok := strings.Cut(digest, ":")
+	if !ok || strings.ContainsAny(algorithm+encoded, `/\.`) {
+		return nil, fmt.Errorf("invalid digest %q in the OCI layout %s", digest, dir)
+	}
+	f, err := os.Open(filepath.Join(dir, "blobs", algorithm, encoded))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, maxImageMetadataSize))
+}
+
+// inspectImageConfig returns the configuration of an image of the local
+// docker daemon
+func inspectImageConfig(ctx context.Context, ref string) ([]byte, error) {
+	output, err := runTool(ctx, "", "docker", "image", "inspect", ref)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the configuration of %s with docker image inspect: %w", ref, err)
+	}
+	var images []json.RawMessage
+	if err := json.Unmarshal(output, &images); err != nil || len(images) == 0 {
+		return nil, fmt.Errorf("cannot parse docker image inspect output for %s", ref)
+	}
+	return images[0], nil
+}
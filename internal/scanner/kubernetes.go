@@ -0,0 +1,455 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dso-cli/dso-cli/internal/yaml"
+)
+
+func init() {
+	Register(kubernetesChecker{})
+}
+
+// nativeRule describes a check of a native scanner
+type nativeRule struct {
+	Title       string
+	Severity    Severity
+	Description string
+	Fix         string
+}
+
+// k8sRules are the checks of the native Kubernetes checker
+var k8sRules = map[string]nativeRule{
+	"k8s-privileged-container": {
+		Title:       "Privileged container",
+		Severity:    SeverityHigh,
+		Description: "A privileged container has every capability and access to the devices of the node: escaping the container is trivial.",
+		Fix:         "Remove securityContext.privileged, or set it to false",
+	},
+	"k8s-host-path": {
+		Title:       "hostPath volume",
+		Severity:    SeverityMedium,
+		Description: "A hostPath volume mounts a directory of the node in the pod, which exposes the node file system and often allows escaping the container.",
+		Fix:         "Replace the hostPath volume with an emptyDir, a ConfigMap or a persistent volume claim",
+	},
+	"k8s-host-network": {
+		Title:       "Pod uses the host network",
+		Severity:    SeverityHigh,
+		Description: "With hostNetwork, the pod shares the network namespace of the node: it can reach the services bound to localhost on the node and sniff its traffic.",
+		Fix:         "Remove hostNetwork: true from the pod spec",
+	},
+	"k8s-host-pid": {
+		Title:       "Pod shares the host PID namespace",
+		Severity:    SeverityHigh,
+		Description: "With hostPID, the containers see every process of the node, and can read their environment and memory when they run as root.",
+		Fix:         "Remove hostPID: true from the pod spec",
+	},
+	"k8s-run-as-root": {
+		Title:       "Container may run as root",
+		Severity:    SeverityMedium,
+		Description: "Without runAsNonRoot, the container runs as root when its image has no USER. A process that escapes the application gets root privileges in the container.",
+		Fix:         "Set securityContext.runAsNonRoot: true and a non-zero runAsUser",
+	},
+	"k8s-writable-root-fs": {
+		Title:       "Writable root filesystem",
+		Severity:    SeverityLow,
+		Description: "The root filesystem of the container is writable, so an attacker can modify its binaries and configuration or drop tools.",
+		Fix:         "Set securityContext.readOnlyRootFilesystem: true, with emptyDir volumes for the directories the application writes to",
+	},
+	"k8s-missing-limits": {
+		Title:       "Missing resource limits",
+		Severity:    SeverityLow,
+		Description: "Without CPU and memory limits, a compromised or faulty container can exhaust the resources of the node and starve the other pods.",
+		Fix:         "Set resources.limits.cpu and resources.limits.memory",
+	},
+	"k8s-latest-image": {
+		Title:       "Image with the latest tag",
+		Severity:    SeverityMedium,
+		Description: "The latest tag changes with every release, so deployments are not reproducible and can pull an untested or compromised image.",
+		Fix:         "Pin the image to a version tag, or better to its digest (image@sha256:...)",
+	},
+	"k8s-dangerous-capability": {
+		Title:       "Dangerous capability added",
+		Severity:    SeverityHigh,
+		Description: "The container is granted a Linux capability that allows taking over the node or the other containers.",
+		Fix:         "Remove the capability from securityContext.capabilities.add, and drop ALL capabilities",
+	},
+	"k8s-secret-data": {
+		Title:       "Plaintext data in Secret",
+		Severity:    SeverityHigh,
+		Description: "The Secret manifest holds its values in clear text (stringData) or base64 (data), which is an encoding, not an encryption: anyone who can read the repository can read them.",
+		Fix:         "Keep the values out of the repository: use Sealed Secrets, SOPS or an external secret store, and rotate the exposed values",
+	},
+}
+
+// podSpecPaths are the paths of the pod spec of the workload kinds
+var podSpecPaths = map[string][]string{
+	"Pod":                   {"spec"},
+	"PodTemplate":           {"template", "spec"},
+	"Deployment":            {"spec", "template", "spec"},
+	"StatefulSet":           {"spec", "template", "spec"},
+	"DaemonSet":             {"spec", "template", "spec"},
+	"ReplicaSet":            {"spec", "template", "spec"},
+	"ReplicationController": {"spec", "template", "spec"},
+	"Job":                   {"spec", "template", "spec"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// dangerousCapabilities are the capabilities that allow escaping a container
+var dangerousCapabilities = map[string]bool{
+	"ALL": true, "SYS_ADMIN": true, "NET_ADMIN": true, "SYS_PTRACE": true, "SYS_MODULE": true,
+	"SYS_RAWIO": true, "SYS_BOOT": true, "DAC_READ_SEARCH": true, "BPF": true,
+}
+
+// sensitiveHostPaths are the host directories whose mount gives control
+// of the node
+var sensitiveHostPaths = []string{"/", "/etc", "/root", "/proc", "/sys", "/var/run", "/run", "/var/lib/kubelet", "/var/lib/docker", "/var/run/docker.sock", "/run/containerd"}
+
+// The apiVersion and kind lines of a Kubernetes object, to detect
+// manifests among YAML files
+var (
+	k8sAPIVersionRegex = regexp.MustCompile(`(?m)^(\s*-)?\s*apiVersion:\s*["']?[a-z0-9./-]*v[0-9]+\w*["']?\s*$`)
+	k8sKindRegex       = regexp.MustCompile(`(?m)^(\s*-)?\s*kind:\s*["']?[A-Z]\w*["']?\s*$`)
+)
+
+// isKubernetesManifest reports whether a YAML file looks like a Kubernetes
+// manifest: it declares an apiVersion and a kind
+func isKubernetesManifest(data []byte) bool {
+	return k8sAPIVersionRegex.Match(data) && k8sKindRegex.Match(data)
+}
+
+// detectKubernetes reports whether root holds a Kubernetes manifest. When
+// files is not nil, only these files are read, else the whole tree.
+func detectKubernetes(root string, files []string) bool {
+	isManifest := func(p string) bool {
+		ext := strings.ToLower(filepath.Ext(p))
+		if ext != ".yaml" && ext != ".yml" {
+			return false
+		}
+		info, err := os.Stat(p)
+		if err != nil || !info.Mode().IsRegular() {
+			return false
+		}
+		data := readTextFile(p, info)
+		return data != nil && isKubernetesManifest(data)
+	}
+
+	if files != nil {
+		for _, file := range files {
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(root, file)
+			}
+			if isManifest(file) {
+				return true
+			}
+		}
+		return false
+	}
+
+	found := errors.New("found")
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			switch d.Name() {
+			case ".git", "node_modules", "vendor":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isManifest(p) {
+			return found
+		}
+		return nil
+	})
+	return err == found
+}
+
+// kubernetesChecker checks the Kubernetes manifests of the project for
+// insecure pod settings and plaintext Secrets, without an external tool
+type kubernetesChecker struct{}
+
+func (kubernetesChecker) Name() string               { return "dso-kubernetes" }
+func (kubernetesChecker) Category() Category         { return CategoryIaC }
+func (kubernetesChecker) Applicable(p *Project) bool { return p.HasK8s }
+
+// Scan walks path and checks every Kubernetes manifest
+func (c kubernetesChecker) Scan(ctx context.Context, path string) ([]Finding, error) {
+	var findings []Finding
+	err := walkFiles(ctx, path, func(p, rel string, info fs.FileInfo) error {
+		fileFindings, err := c.ParseFile(p, rel, info)
+		if err != nil {
+			recordUnparsed(ctx, rel, err)
+		}
+		findings = append(findings, fileFindings...)
+		return nil
+	})
+	return findings, err
+}
+
+// ParseFile checks the objects of a YAML file. Files that are not valid
+// YAML, such as Helm templates, are skipped with an error.
+func (kubernetesChecker) ParseFile(file, rel string, info fs.FileInfo) ([]Finding, error) {
+	ext := strings.ToLower(path.Ext(rel))
+	if ext != ".yaml" && ext != ".yml" {
+		return nil, nil
+	}
+	data := readTextFile(file, info)
+	if data == nil || !isKubernetesManifest(data) {
+		return nil, nil
+	}
+	docs, err := yaml.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &k8sCheck{file: rel, now: time.Now()}
+	for _, doc := range docs {
+		check.object(doc)
+	}
+	return check.findings, nil
+}
+
+// k8sCheck collects the findings of a manifest file
+type k8sCheck struct {
+	file     string
+	now      time.Time
+	findings []Finding
+}
+
+// object checks a Kubernetes object, or the items of a List
+func (c *k8sCheck) object(obj *yaml.Node) {
+	kind := obj.Get("kind").String()
+	if obj.Get("apiVersion").String() == "" || kind == "" {
+		return
+	}
+	if kind == "List" || strings.HasSuffix(kind, "List") {
+		for _, item := range obj.Get("items").Items() {
+			c.object(item)
+		}
+		return
+	}
+
+	name := obj.Path("metadata", "name").String()
+	if name == "" {
+		name = obj.Path("metadata", "generateName").String()
+	}
+	subject := kind + "/" + name
+
+	if kind == "Secret" {
+		c.secret(obj, subject)
+		return
+	}
+	if specPath, ok := podSpecPaths[kind]; ok {
+		if spec := obj.Path(specPath...); spec != nil && spec.Kind == yaml.MappingNode {
+			c.podSpec(spec, subject)
+		}
+	}
+}
+
+// podSpec checks a pod spec and its containers
+func (c *k8sCheck) podSpec(spec *yaml.Node, subject string) {
+	if v := spec.Get("hostNetwork"); v.Bool() {
+		c.report("k8s-host-network", v, subject, "", "")
+	}
+	if v := spec.Get("hostPID"); v.Bool() {
+		c.report("k8s-host-pid", v, subject, "", "")
+	}
+	for _, volume := range spec.Get("volumes").Items() {
+		key, hostPath := volume.Lookup("hostPath")
+		if hostPath == nil {
+			continue
+		}
+		dir := hostPath.Get("path").String()
+		f := c.report("k8s-host-path", key, subject,
+			fmt.Sprintf("hostPath volume %q (%s) in %s", volume.Get("name").String(), dir, subject),
+			fmt.Sprintf("The volume mounts %s of the node.", dir))
+		cleaned := path.Clean("/" + dir)
+		for _, sensitive := range sensitiveHostPaths {
+			if cleaned == sensitive {
+				f.Severity = SeverityHigh
+			}
+		}
+	}
+
+	podContext := spec.Get("securityContext")
+	for _, group := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		for _, container := range spec.Get(group).Items() {
+			c.container(container, podContext, subject)
+		}
+	}
+}
+
+// container checks a container of a pod spec
+func (c *k8sCheck) container(container, podContext *yaml.Node, subject string) {
+	name := container.Get("name").String()
+	in := fmt.Sprintf("container %q of %s", name, subject)
+	sc := container.Get("securityContext")
+
+	if v := sc.Get("privileged"); v.Bool() {
+		c.report("k8s-privileged-container", v, subject, fmt.Sprintf("Privileged %s", in), "")
+	}
+
+	c.runAsNonRoot(container, sc, podContext, in, subject)
+
+	if v := sc.Get("readOnlyRootFilesystem"); !v.Bool() {
+		c.report("k8s-writable-root-fs", firstNode(v, container), subject, fmt.Sprintf("Writable root filesystem in %s", in), "")
+	}
+
+	limits := container.Path("resources", "limits")
+	var missing []string
+	for _, resource := range []string{"cpu", "memory"} {
+		if limits.Get(resource).String() == "" {
+			missing = append(missing, resource)
+		}
+	}
+	if len(missing) > 0 {
+		c.report("k8s-missing-limits", firstNode(container.Get("resources"), container), subject,
+			fmt.Sprintf("Missing %s limits in %s", strings.Join(missing, " and "), in),
+			fmt.Sprintf("No %s limit is set.", strings.Join(missing, " or ")))
+	}
+
+	if image := container.Get("image"); image != nil {
+		if tag, ok := imageTag(image.String()); !ok {
+			detail := ""
+			if tag == "" {
+				detail = "The image has no tag, so the latest tag is pulled."
+			}
+			c.report("k8s-latest-image", image, subject, fmt.Sprintf("Image %s with the latest tag in %s", image.String(), in), detail)
+		}
+	}
+
+	for _, capability := range sc.Path("capabilities", "add").Items() {
+		name := strings.TrimPrefix(strings.ToUpper(capability.String()), "CAP_")
+		if dangerousCapabilities[name] {
+			c.report("k8s-dangerous-capability", capability, subject,
+				fmt.Sprintf("Dangerous capability %s added to %s", name, in), "")
+		}
+	}
+}
+
+// runAsNonRoot reports a container that may run as root: runAsNonRoot is
+// not set and no non-zero runAsUser is set, or the user is root
+func (c *k8sCheck) runAsNonRoot(container, sc, podContext *yaml.Node, in, subject string) {
+	for _, ctx := range []*yaml.Node{sc, podContext} {
+		if user := ctx.Get("runAsUser"); user != nil {
+			if uid, err := strconv.Atoi(user.String()); err == nil {
+				if uid == 0 {
+					f := c.report("k8s-run-as-root", user, subject, fmt.Sprintf("%s runs as root", capitalize(in)), "runAsUser is 0.")
+					f.Severity = SeverityHigh
+					return
+				}
+				if ctx.Get("runAsNonRoot") == nil {
+					return
+				}
+			}
+		}
+		if v := ctx.Get("runAsNonRoot"); v != nil {
+			if !v.Bool() {
+				c.report("k8s-run-as-root", v, subject, fmt.Sprintf("%s may run as root", capitalize(in)), "runAsNonRoot is false.")
+			}
+			return
+		}
+	}
+	c.report("k8s-run-as-root", container, subject, fmt.Sprintf("%s may run as root", capitalize(in)), "")
+}
+
+// secret reports the values of a Secret manifest
+func (c *k8sCheck) secret(obj *yaml.Node, subject string) {
+	for _, field := range []string{"stringData", "data"} {
+		var keys []string
+		for _, entry := range obj.Get(field).Entries() {
+			if entry.Value.String() != "" {
+				keys = append(keys, entry.Key.Value)
+			}
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		encoding := "in clear text"
+		if field == "data" {
+			encoding = "base64-encoded"
+		}
+		k, _ := obj.Lookup(field)
+		c.report("k8s-secret-data", k, subject,
+			fmt.Sprintf("Plaintext %s in %s", field, subject),
+			fmt.Sprintf("%s holds the values of %s %s.", field, strings.Join(keys, ", "), encoding))
+	}
+}
+
+// report adds a finding of rule located at node. title defaults to the
+// title of the rule; detail is added to its description.
+func (c *k8sCheck) report(ruleID string, node *yaml.Node, subject, title, detail string) *Finding {
+	rule := k8sRules[ruleID]
+	if title == "" {
+		title = rule.Title + " in " + subject
+	}
+	description := rule.Description
+	if detail != "" {
+		description = detail + " " + description
+	}
+	line := 0
+	if node != nil {
+		line = node.Line
+	}
+	c.findings = append(c.findings, Finding{
+		ID:          fmt.Sprintf("dso-%s-%s-%d", ruleID, c.file, line),
+		Type:        "IAC",
+		Severity:    rule.Severity,
+		Title:       title,
+		Description: description,
+		File:        c.file,
+		Line:        line,
+		RuleID:      ruleID,
+		Tool:        "dso-kubernetes",
+		Fixable:     true,
+		Fix:         rule.Fix,
+		Timestamp:   c.now,
+	})
+	return &c.findings[len(c.findings)-1]
+}
+
+// imageTag returns the tag of an image reference, and whether the image
+// is pinned: it has a digest or a tag other than latest
+func imageTag(ref string) (string, bool) {
+	if strings.Contains(ref, "@") {
+		return "", true
+	}
+	name := ref
+	if i := strings.LastIndex(ref, "/"); i >= 0 {
+		name = ref[i+1:]
+	}
+	_, tag, ok := strings.Cut(name, ":")
+	if !ok || tag == "" {
+		return "", false
+	}
+	return tag, tag != "latest"
+}
+
+// firstNode returns the first non-nil node
+func firstNode(nodes ...*yaml.Node) *yaml.Node {
+	for _, n := range nodes {
+		if n != nil {
+			return n
+		}
+	}
+	return nil
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,311 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dso-cli/dso-cli/internal/config"
+	"github.com/dso-cli/dso-cli/internal/ignore"
+	"github.com/dso-cli/dso-cli/internal/inventory"
+	"github.com/dso-cli/dso-cli/internal/license"
+)
+
+func init() {
+	Register(licenseScanner{})
+}
+
+// License list keys of the project config, comma-separated SPDX
+// identifiers or patterns (DSO_LICENSES_DENY=AGPL-*,GPL-*)
+const (
+	LicensesAllowKey  = "DSO_LICENSES_ALLOW"
+	LicensesDenyKey   = "DSO_LICENSES_DENY"
+	LicensesReviewKey = "DSO_LICENSES_REVIEW"
+)
+
+// ProjectLicensePolicy returns the license policy of the project at dir,
+// read from its .dso/config file, the user config or the environment. The
+// review list defaults to license.DefaultReview when it is not set.
+func ProjectLicensePolicy(dir string) license.Policy {
+	allow, _ := config.GetProjectList(dir, LicensesAllowKey)
+	deny, _ := config.GetProjectList(dir, LicensesDenyKey)
+	review, ok := config.GetProjectList(dir, LicensesReviewKey)
+	if !ok {
+		review = license.DefaultReview
+	}
+	return license.Policy{Allow: allow, Deny: deny, Review: review}
+}
+
+// LicenseUse is a license found in a project: the license of a dependency,
+// or of a license file for code copied into the project
+type LicenseUse struct {
+	// Package is the dependency, nil for a license file
+	Package *inventory.Package `json:"package,omitempty"`
+	// File is the lockfile of the dependency or the license file, relative
+	// to the scanned directory
+	File string `json:"file"`
+	// Declared is the license as written in the package metadata, "" for a
+	// license file
+	Declared string `json:"declared,omitempty"`
+	// License is the SPDX expression of the license, "" when it is not recognized
+	License string `json:"license,omitempty"`
+	// From is the metadata file the license was read from, when it is not File
+	From    string          `json:"from,omitempty"`
+	Verdict license.Verdict `json:"verdict"`
+}
+
+// Subject names the package or the directory the license applies to
+func (u LicenseUse) Subject() string {
+	if u.Package != nil {
+		return strings.TrimSpace(u.Package.Name + " " + u.Package.Version)
+	}
+	return path.Dir(u.File) + "/"
+}
+
+// Expression returns the SPDX expression of the license, or the declared
+// license when it is not recognized
+func (u LicenseUse) Expression() string {
+	switch {
+	case u.License != "":
+		return u.License
+	case u.Declared != "":
+		return strconv.Quote(u.Declared)
+	}
+	return "unrecognized license text"
+}
+
+// LicenseReport lists the licenses of a project and their evaluation
+type LicenseReport struct {
+	Path   string         `json:"path"`
+	Policy license.Policy `json:"policy"`
+	// Project are the license files at the root of the project, which
+	// hold its own license
+	Project []LicenseUse `json:"project,omitempty"`
+	Uses    []LicenseUse `json:"licenses"`
+	// Undeclared are the dependencies whose license could not be found:
+	// the lockfile does not record it and the package is not installed
+	Undeclared []inventory.Package `json:"undeclared,omitempty"`
+	// Warnings are the lockfiles that could not be read
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Count returns the number of licenses with decision d
+func (r *LicenseReport) Count(d license.Decision) int {
+	n := 0
+	for _, use := range r.Uses {
+		if use.Verdict.Decision == d {
+			n++
+		}
+	}
+	return n
+}
+
+// CollectLicenses finds the licenses of the dependencies and license files
+// of the project at root and evaluates them against its policy. Files
+// excluded by the ignore rules are skipped.
+func CollectLicenses(ctx context.Context, root string) (*LicenseReport, error) {
+	rules, err := ignore.Load(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withIgnore(ctx, rules)
+
+	report := &LicenseReport{Path: root, Policy: ProjectLicensePolicy(root)}
+	uses, undeclared, err := collectLicenses(ctx, root)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	if err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			report.Warnings = append(report.Warnings, "cannot read lockfile "+line)
+		}
+	}
+	report.Undeclared = undeclared
+
+	for _, use := range uses {
+		use.Verdict = report.Policy.EvaluateDeclared(firstNonEmpty(use.License, use.Declared))
+		if use.Package == nil && !strings.Contains(use.File, "/") {
+			report.Project = append(report.Project, use)
+			continue
+		}
+		report.Uses = append(report.Uses, use)
+	}
+	return report, nil
+}
+
+// collectLicenses returns the licenses of the dependencies and license
+// files of the project, not evaluated, and the dependencies without a
+// known license. The error reports the lockfiles that could not be read.
+func collectLicenses(ctx context.Context, root string) ([]LicenseUse, []inventory.Package, error) {
+	packages, loadErr := inventory.LoadSkipping(ctx, root, ignoreRules(ctx).Ignored)
+
+	var uses []LicenseUse
+	var undeclared []inventory.Package
+	for _, p := range packages {
+		declared, from := p.License, ""
+		if declared == "" {
+			declared, from = inventory.InstalledLicense(root, p)
+			if rel, err := filepath.Rel(root, from); from != "" && err == nil && !strings.HasPrefix(rel, "..") {
+				from = filepath.ToSlash(rel)
+			}
+		}
+		if declared == "" {
+			undeclared = append(undeclared, p)
+			continue
+		}
+		pkg := p
+		uses = append(uses, LicenseUse{
+			Package:  &pkg,
+			File:     p.Source,
+			Declared: declared,
+			License:  license.Normalize(declared),
+			From:     from,
+		})
+	}
+
+	var files []LicenseUse
+	err := walkFiles(ctx, root, func(p, rel string, info fs.FileInfo) error {
+		if !license.IsLicenseFile(info.Name()) {
+			return nil
+		}
+		data := readTextFile(p, info)
+		if data == nil {
+			return nil
+		}
+		files = append(files, LicenseUse{File: rel, License: license.Identify(string(data))})
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(uses, files...), undeclared, loadErr
+}
+
+// licenseScanner reports the licenses of the dependencies and of the
+// license files of code copied into the project, read from lockfiles,
+// installed package metadata and license texts. Every license is reported;
+// applyLicensePolicy keeps the ones the project policy does not allow.
+type licenseScanner struct{}
+
+func (licenseScanner) Name() string             { return "dso-licenses" }
+func (licenseScanner) Category() Category       { return CategoryDependencies }
+func (licenseScanner) Applicable(*Project) bool { return true }
+
+// ScansWholeTree reports that unchanged license files are read during an
+// incremental scan too, and dropped with the other unchanged files, so
+// that the results are cached for the whole tree
+func (licenseScanner) ScansWholeTree() bool { return true }
+
+// Scan returns a LICENSE finding for every license found
+func (licenseScanner) Scan(ctx context.Context, path string) ([]Finding, error) {
+	// Unreadable lockfiles are reported by Run
+	uses, _, err := collectLicenses(withFileFilter(ctx, nil), path)
+	if err != nil && len(uses) == 0 {
+		return nil, err
+	}
+
+	var findings []Finding
+	now := time.Now()
+	for _, use := range uses {
+		if use.Package == nil && (use.License == "" || !strings.Contains(use.File, "/")) {
+			// Unrecognized texts, and the license of the project itself
+			continue
+		}
+		findings = append(findings, use.finding(now))
+	}
+	return findings, nil
+}
+
+// finding converts a license use to a LICENSE finding, before evaluation
+func (u LicenseUse) finding(now time.Time) Finding {
+	var desc strings.Builder
+	if u.Package != nil {
+		fmt.Fprintf(&desc, "%s (%s, %s dependency) declares the license %q", u.Subject(), u.Package.Ecosystem, dependencyKind(*u.Package), u.Declared)
+		if u.From != "" {
+			fmt.Fprintf(&desc, " in %s", u.From)
+		}
+	} else {
+		fmt.Fprintf(&desc, "The code in %s is distributed under the license of %s", u.Subject(), u.File)
+	}
+	if u.License != "" && u.License != u.Declared {
+		fmt.Fprintf(&desc, " (SPDX: %s)", u.License)
+	}
+
+	f := Finding{
+		ID:          "dso-license-" + firstNonEmpty(u.License, u.Declared) + "-" + u.Subject(),
+		Type:        "LICENSE",
+		Severity:    SeverityInfo,
+		Title:       fmt.Sprintf("%s license in %s", firstNonEmpty(u.License, u.Declared), u.Subject()),
+		Description: desc.String(),
+		File:        u.File,
+		RuleID:      firstNonEmpty(u.License, u.Declared),
+		Tool:        "dso-licenses",
+		Timestamp:   now,
+	}
+	if u.Package != nil {
+		f.Package = &Package{
+			Ecosystem:        u.Package.Ecosystem,
+			Name:             u.Package.Name,
+			InstalledVersion: u.Package.Version,
+			PURL:             u.Package.PURL(),
+		}
+	}
+	return f
+}
+
+// licenseSeverities are the severities of the findings of the licenses
+// that are not allowed
+var licenseSeverities = map[license.Decision]Severity{
+	license.Denied:  SeverityHigh,
+	license.Review:  SeverityMedium,
+	license.Unknown: SeverityLow,
+}
+
+// applyLicensePolicy evaluates the LICENSE findings against policy. The
+// findings of allowed licenses are dropped; the other ones get the
+// severity and remediation of their verdict.
+func applyLicensePolicy(policy license.Policy, findings []Finding) []Finding {
+	kept := findings[:0]
+	for _, f := range findings {
+		if !strings.EqualFold(f.Type, "LICENSE") {
+			kept = append(kept, f)
+			continue
+		}
+		verdict := policy.EvaluateDeclared(f.RuleID)
+		if verdict.Decision == license.Allowed {
+			continue
+		}
+
+		subject := f.File
+		if f.Package != nil {
+			subject = f.Package.Name
+		}
+		f.Severity = licenseSeverities[verdict.Decision]
+		f.Fixable = false
+		switch verdict.Decision {
+		case license.Denied:
+			f.Title = fmt.Sprintf("Denied license %s in %s", verdict.License, subject)
+			f.Description += fmt.Sprintf("\n\nPolicy: %s matches %q in the deny list", verdict.License, verdict.Rule)
+			f.Fix = fmt.Sprintf("Replace %s with a dependency under an allowed license, or remove it", subject)
+		case license.Review:
+			f.Title = fmt.Sprintf("License %s in %s needs a review", verdict.License, subject)
+			if verdict.Rule != "" {
+				f.Description += fmt.Sprintf("\n\nPolicy: %s matches %q in the review list", verdict.License, verdict.Rule)
+			} else {
+				f.Description += fmt.Sprintf("\n\nPolicy: %s is not in the allow list", verdict.License)
+			}
+			f.Fix = fmt.Sprintf("Have %s reviewed by legal, then add it to %s in .dso/config", verdict.License, LicensesAllowKey)
+		case license.Unknown:
+			f.Title = fmt.Sprintf("Unrecognized license %q in %s", f.RuleID, subject)
+			f.Description += "\n\nPolicy: the license is not a known SPDX expression"
+			f.Fix = fmt.Sprintf("Check the license of %s and add its SPDX identifier to %s in .dso/config", subject, LicensesAllowKey)
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
@@ -1,6 +1,10 @@
 package scanner
 
-import "time"
+import (
+	"time"
+
+	"github.com/dso-cli/dso-cli/internal/inventory"
+)
 
 // Severity représente le niveau de sévérité
 type Severity string
@@ -22,6 +26,7 @@ type Finding struct {
 	Description string    `json:"description"`
 	File        string    `json:"file"`
 	Line        int       `json:"line,omitempty"`
+	EndLine     int       `json:"end_line,omitempty"` // Dernière ligne quand le problème s'étend sur plusieurs lignes
 	Column      int       `json:"column,omitempty"`
 	RuleID      string    `json:"rule_id,omitempty"`
 	Tool        string    `json:"tool"` // trivy, grype, gitleaks, tfsec, etc.
@@ -30,6 +35,52 @@ type Finding struct {
 	Exploitable bool      `json:"exploitable,omitempty"` // Est-ce exploitable en prod ?
 	CVSS        float64   `json:"cvss,omitempty"`
 	Timestamp   time.Time `json:"timestamp"`
+	Aliases     []string  `json:"aliases,omitempty"`     // Autres identifiants (CVE, GHSA, ...)
+	Package     *Package  `json:"package,omitempty"`     // Paquet vulnérable (findings DEPENDENCY)
+	CWE         []string  `json:"cwe,omitempty"`         // Faiblesses CWE (CWE-89)
+	OWASP       []string  `json:"owasp,omitempty"`       // Catégories OWASP Top 10 2021 (A03:2021-Injection)
+	ASVS        []string  `json:"asvs,omitempty"`        // Exigences OWASP ASVS 4.0.3 (V5.3.4)
+	Tools       []string  `json:"tools,omitempty"`       // Tous les outils qui ont signalé le problème
+	Confidence  float64   `json:"confidence,omitempty"`  // Entre 0 et 1, plus élevée quand plusieurs outils concordent
+	Fingerprint string    `json:"fingerprint,omitempty"` // Empreinte stable utilisée pour la corrélation
+	// Suppression est le commentaire dso:ignore qui masque le finding (nil s'il est actif)
+	Suppression *Suppression `json:"suppression,omitempty"`
+	// Commit est le commit qui a introduit le secret (scan de l'historique git)
+	Commit *Commit `json:"commit,omitempty"`
+	// Replacement est la correction proposée sous forme de lignes de
+	// remplacement, applicable par le fixer
+	Replacement *Replacement `json:"replacement,omitempty"`
+}
+
+// Replacement remplace les lignes Line à EndLine du fichier d'un finding
+type Replacement struct {
+	Line    int    `json:"line"`
+	EndLine int    `json:"end_line"`
+	Text    string `json:"text"` // Nouvelles lignes, vide pour supprimer les lignes
+}
+
+// Commit identifie un commit de l'historique git
+type Commit struct {
+	SHA     string    `json:"sha"`
+	Author  string    `json:"author,omitempty"`
+	Email   string    `json:"email,omitempty"`
+	Date    time.Time `json:"date"`
+	Message string    `json:"message,omitempty"`
+	// InHead indique si le secret est encore présent dans HEAD ; File et
+	// Line donnent alors son emplacement dans HEAD, sinon dans le commit
+	InHead bool `json:"in_head"`
+}
+
+// Package identifie le paquet concerné par un finding
+type Package struct {
+	Ecosystem        inventory.Ecosystem `json:"ecosystem,omitempty"` // Nom d'écosystème OSV (npm, PyPI, Go, Maven...)
+	Name             string              `json:"name"`
+	InstalledVersion string              `json:"installed_version,omitempty"`
+	FixedVersions    []string            `json:"fixed_versions,omitempty"` // Versions qui corrigent la vulnérabilité
+	PURL             string              `json:"purl,omitempty"`           // Package URL (pkg:npm/lodash@4.17.20)
+	// Path est la chaîne de dépendances "nom@version", de la dépendance
+	// directe du projet jusqu'au paquet lui-même (vide si inconnue)
+	Path []string `json:"dependency_path,omitempty"`
 }
 
 // ScanResults contient tous les résultats d'un scan
@@ -38,6 +89,50 @@ type ScanResults struct {
 	Timestamp time.Time `json:"timestamp"`
 	Findings  []Finding `json:"findings"`
 	Summary   Summary   `json:"summary"`
+	Warnings  []string  `json:"warnings,omitempty"` // Scanners qui n'ont pas terminé (scan partiel)
+	ToolRuns  []ToolRun `json:"tool_runs"`
+	// Inventory liste les dépendances lues dans les lockfiles du projet
+	Inventory []inventory.Package `json:"inventory,omitempty"`
+	// Diff est le périmètre d'un scan incrémental (nil pour un scan complet)
+	Diff *DiffScope `json:"diff,omitempty"`
+	// History est la plage de commits où les secrets ont été cherchés (nil si l'historique n'a pas été scanné)
+	History *HistoryScope `json:"history,omitempty"`
+	// Image est l'image de conteneur scannée par dso image (nil pour un répertoire)
+	Image *Image `json:"image,omitempty"`
+	// Suppressions liste chaque commentaire dso:ignore et son statut
+	Suppressions []Suppression `json:"suppressions,omitempty"`
+	// Baseline est la comparaison avec les findings acceptés (nil sans --baseline)
+	Baseline *BaselineReport `json:"baseline,omitempty"`
+	// Grouping regroupe les findings par catégorie CWE ou OWASP (nil sans --group-by)
+	Grouping *Grouping `json:"grouping,omitempty"`
+}
+
+// ToolStatus représente le résultat de l'exécution d'un scanner
+type ToolStatus string
+
+const (
+	ToolStatusOK      ToolStatus = "ok"
+	ToolStatusSkipped ToolStatus = "skipped"
+	ToolStatusFailed  ToolStatus = "failed"
+	ToolStatusTimeout ToolStatus = "timeout"
+)
+
+// ToolRun enregistre l'exécution d'un scanner, pour distinguer
+// "aucun finding" de "le scanner n'a jamais tourné"
+type ToolRun struct {
+	Tool     string        `json:"tool"`
+	Category Category      `json:"category"`
+	Version  string        `json:"version,omitempty"`
+	Command  string        `json:"command,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+	ExitCode int           `json:"exit_code"`
+	Stderr   string        `json:"stderr,omitempty"` // Dernières lignes du stderr de l'outil
+	Findings int           `json:"findings"`
+	Status   ToolStatus    `json:"status"`
+	Error    string        `json:"error,omitempty"`    // Raison de l'échec ou du saut
+	Source   string        `json:"source,omitempty"`   // Fichier SARIF dont les résultats ont été importés
+	Cached   bool          `json:"cached,omitempty"`   // Résultats repris du cache
+	Unparsed []string      `json:"unparsed,omitempty"` // Fichiers ignorés faute d'avoir pu être analysés, avec l'erreur
 }
 
 // Summary contient les statistiques du scan
@@ -50,12 +145,17 @@ type Summary struct {
 	Info        int `json:"info"`
 	Fixable     int `json:"fixable"`
 	Exploitable int `json:"exploitable"`
+	Suppressed  int `json:"suppressed"` // Findings masqués par un commentaire dso:ignore, exclus des autres compteurs
 }
 
 // CalculateSummary calcule le résumé à partir des findings
 func (sr *ScanResults) CalculateSummary() {
 	sr.Summary = Summary{}
 	for _, f := range sr.Findings {
+		if f.Suppression != nil {
+			sr.Summary.Suppressed++
+			continue
+		}
 		sr.Summary.Total++
 		switch f.Severity {
 		case SeverityCritical:
@@ -77,3 +177,20 @@ func (sr *ScanResults) CalculateSummary() {
 		}
 	}
 }
+
+// Active retourne les findings qui ne sont pas masqués par un commentaire dso:ignore
+func (sr *ScanResults) Active() []Finding {
+	active := make([]Finding, 0, len(sr.Findings))
+	for _, f := range sr.Findings {
+		if f.Suppression == nil {
+			active = append(active, f)
+		}
+	}
+	return active
+}
+
+// HistoryOnly indique si le finding n'a été trouvé que dans d'anciens
+// commits : le secret n'est plus dans le code mais reste lisible dans l'historique
+func (f *Finding) HistoryOnly() bool {
+	return f.Commit != nil && !f.Commit.InHead
+}
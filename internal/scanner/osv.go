@@ -0,0 +1,146 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/dso-cli/dso-cli/internal/inventory"
+	"github.com/dso-cli/dso-cli/internal/osv"
+)
+
+func init() {
+	Register(osvScanner{})
+}
+
+// osvScanner matches the dependency inventory against the local OSV
+// advisory database imported with "dso db import". It needs no network.
+type osvScanner struct{}
+
+func (osvScanner) Name() string             { return "dso-osv" }
+func (osvScanner) Category() Category       { return CategoryDependencies }
+func (osvScanner) Applicable(*Project) bool { return true }
+
+// Available reports whether an advisory database was imported
+func (osvScanner) Available() error {
+	store, err := osv.OpenDefaultStore()
+	if err != nil {
+		return err
+	}
+	if _, err := store.Metadata(); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("no advisory database, run: dso db import <dir-or-zip>")
+		}
+		return err
+	}
+	return nil
+}
+
+// Version returns the date of the last database import
+func (osvScanner) Version() string {
+	store, err := osv.OpenDefaultStore()
+	if err != nil {
+		return "unknown"
+	}
+	meta, err := store.Metadata()
+	if err != nil {
+		return "unknown"
+	}
+	return "db " + meta.UpdatedAt.Format("2006-01-02")
+}
+
+// Scan reads the project lockfiles and reports the packages affected by an advisory
+func (osvScanner) Scan(ctx context.Context, path string) ([]Finding, error) {
+	store, err := osv.OpenDefaultStore()
+	if err != nil {
+		return nil, err
+	}
+
+	// Unreadable lockfiles are reported by Run; match what could be read
+	packages, err := inventory.LoadSkipping(ctx, path, ignoreRules(ctx).Ignored)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	if len(packages) == 0 && err != nil {
+		return nil, err
+	}
+
+	matches, err := store.Match(ctx, packages)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	now := time.Now()
+	for _, m := range matches {
+		findings = append(findings, osvFinding(m, now))
+	}
+	return findings, nil
+}
+
+// osvFinding converts an advisory match to a DEPENDENCY finding
+func osvFinding(m osv.Match, now time.Time) Finding {
+	adv, pkg := m.Advisory, m.Package
+	level, score := adv.SeverityLevel()
+	severity := mapSeverity(level)
+	if level == "" {
+		// No severity in the advisory: do not hide it as informational
+		severity = SeverityMedium
+	}
+
+	summary := adv.Summary
+	if summary == "" {
+		summary = adv.ID
+	}
+
+	var desc strings.Builder
+	if adv.Details != "" {
+		desc.WriteString(adv.Details)
+	} else {
+		desc.WriteString(summary)
+	}
+	fmt.Fprintf(&desc, "\n\nPackage: %s %s (%s, %s dependency)", pkg.Name, pkg.Version, pkg.Ecosystem, dependencyKind(pkg))
+	if len(m.FixedVersions) > 0 {
+		fmt.Fprintf(&desc, "\nFixed in: %s", strings.Join(m.FixedVersions, ", "))
+	}
+	if len(adv.Aliases) > 0 {
+		fmt.Fprintf(&desc, "\nAliases: %s", strings.Join(adv.Aliases, ", "))
+	}
+
+	finding := Finding{
+		ID:          fmt.Sprintf("%s-%s@%s", adv.ID, pkg.Name, pkg.Version),
+		Type:        "DEPENDENCY",
+		Severity:    severity,
+		Title:       fmt.Sprintf("%s in %s %s: %s", adv.ID, pkg.Name, pkg.Version, summary),
+		Description: desc.String(),
+		File:        pkg.Source,
+		RuleID:      adv.ID,
+		Tool:        "dso-osv",
+		CVSS:        score,
+		Timestamp:   now,
+		Aliases:     adv.Aliases,
+		CWE:         adv.DatabaseSpecific.CWEIDs,
+		Package: &Package{
+			Ecosystem:        pkg.Ecosystem,
+			Name:             pkg.Name,
+			InstalledVersion: pkg.Version,
+			FixedVersions:    m.FixedVersions,
+			PURL:             pkg.PURL(),
+		},
+	}
+	if pkg.Direct {
+		finding.Package.Path = []string{pkg.Name + "@" + pkg.Version}
+	}
+	finding.setUpgrade()
+	return finding
+}
+
+func dependencyKind(pkg inventory.Package) string {
+	if pkg.Direct {
+		return "direct"
+	}
+	return "transitive"
+}
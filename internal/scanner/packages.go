@@ -0,0 +1,251 @@
+package scanner
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/dso-cli/dso-cli/internal/inventory"
+	"github.com/dso-cli/dso-cli/internal/osv"
+)
+
+// purlEcosystems maps package URL types to OSV ecosystems
+var purlEcosystems = map[string]inventory.Ecosystem{
+	"golang":   inventory.EcosystemGo,
+	"npm":      inventory.EcosystemNPM,
+	"pypi":     inventory.EcosystemPyPI,
+	"cargo":    inventory.EcosystemCargo,
+	"composer": inventory.EcosystemPackagist,
+	"gem":      inventory.EcosystemRubyGems,
+	"maven":    inventory.EcosystemMaven,
+	"nuget":    "NuGet",
+}
+
+// distroEcosystems maps the namespace of deb, rpm and apk package URLs
+// to OSV ecosystems
+var distroEcosystems = map[string]inventory.Ecosystem{
+	"debian":    "Debian",
+	"ubuntu":    "Ubuntu",
+	"alpine":    "Alpine",
+	"redhat":    "Red Hat",
+	"rocky":     "Rocky Linux",
+	"almalinux": "AlmaLinux",
+}
+
+// typeEcosystems maps the package types of trivy and grype, and the package
+// managers of snyk, to OSV ecosystems
+var typeEcosystems = map[string]inventory.Ecosystem{
+	"npm":            inventory.EcosystemNPM,
+	"yarn":           inventory.EcosystemNPM,
+	"pnpm":           inventory.EcosystemNPM,
+	"bun":            inventory.EcosystemNPM,
+	"node-pkg":       inventory.EcosystemNPM,
+	"gomod":          inventory.EcosystemGo,
+	"gomodules":      inventory.EcosystemGo,
+	"gobinary":       inventory.EcosystemGo,
+	"go-module":      inventory.EcosystemGo,
+	"pip":            inventory.EcosystemPyPI,
+	"pipenv":         inventory.EcosystemPyPI,
+	"poetry":         inventory.EcosystemPyPI,
+	"uv":             inventory.EcosystemPyPI,
+	"python-pkg":     inventory.EcosystemPyPI,
+	"python":         inventory.EcosystemPyPI,
+	"cargo":          inventory.EcosystemCargo,
+	"rust-binary":    inventory.EcosystemCargo,
+	"rust-crate":     inventory.EcosystemCargo,
+	"composer":       inventory.EcosystemPackagist,
+	"php-composer":   inventory.EcosystemPackagist,
+	"bundler":        inventory.EcosystemRubyGems,
+	"gemspec":        inventory.EcosystemRubyGems,
+	"gem":            inventory.EcosystemRubyGems,
+	"rubygems":       inventory.EcosystemRubyGems,
+	"jar":            inventory.EcosystemMaven,
+	"pom":            inventory.EcosystemMaven,
+	"gradle":         inventory.EcosystemMaven,
+	"sbt":            inventory.EcosystemMaven,
+	"java-archive":   inventory.EcosystemMaven,
+	"nuget":          "NuGet",
+	"dotnet-core":    "NuGet",
+	"dotnet-deps":    "NuGet",
+	"packages-props": "NuGet",
+	// OS packages of container images
+	"debian":    "Debian",
+	"ubuntu":    "Ubuntu",
+	"alpine":    "Alpine",
+	"redhat":    "Red Hat",
+	"rocky":     "Rocky Linux",
+	"alma":      "AlmaLinux",
+	"almalinux": "AlmaLinux",
+}
+
+// packageEcosystem returns the ecosystem of a package type reported by a
+// tool, or "" when it is unknown
+func packageEcosystem(kind string) inventory.Ecosystem {
+	return typeEcosystems[strings.ToLower(kind)]
+}
+
+// purlPackage returns the package identified by a package URL
+// (pkg:type/namespace/name@version), or nil when purl is not one
+func purlPackage(purl string) *Package {
+	rest, ok := strings.CutPrefix(purl, "pkg:")
+	if !ok {
+		return nil
+	}
+	rest, _, _ = strings.Cut(rest, "#")
+	rest, _, _ = strings.Cut(rest, "?")
+	kind, rest, ok := strings.Cut(rest, "/")
+	if !ok || rest == "" {
+		return nil
+	}
+	kind = strings.ToLower(kind)
+
+	version := ""
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		rest, version = rest[:i], unescapePURL(rest[i+1:])
+	}
+	segments := strings.Split(strings.Trim(rest, "/"), "/")
+	for i, s := range segments {
+		segments[i] = unescapePURL(s)
+	}
+	namespace, name := segments[:len(segments)-1], segments[len(segments)-1]
+
+	pkg := &Package{Ecosystem: purlEcosystems[kind], Name: strings.Join(segments, "/"), InstalledVersion: version, PURL: purl}
+	switch kind {
+	case "maven":
+		pkg.Name = strings.Join(namespace, ".") + ":" + name
+	case "deb", "rpm", "apk":
+		// The namespace is the distribution
+		pkg.Name = name
+		if len(namespace) > 0 {
+			pkg.Ecosystem = distroEcosystems[strings.ToLower(namespace[0])]
+		}
+	}
+	return pkg
+}
+
+func unescapePURL(s string) string {
+	if u, err := url.PathUnescape(s); err == nil {
+		return u
+	}
+	return s
+}
+
+// splitVersions splits a comma-separated list of versions, as written by
+// trivy ("2.2.4, 3.0.1")
+func splitVersions(list string) []string {
+	var versions []string
+	for _, v := range strings.Split(list, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
+
+// UpgradeVersion returns the lowest fixed version above the installed
+// version, or "" when no fix is known
+func (p *Package) UpgradeVersion() string {
+	best := ""
+	for _, v := range p.FixedVersions {
+		if p.InstalledVersion != "" && osv.CompareVersions(string(p.Ecosystem), v, p.InstalledVersion) <= 0 {
+			continue
+		}
+		if best == "" || osv.CompareVersions(string(p.Ecosystem), v, best) < 0 {
+			best = v
+		}
+	}
+	return best
+}
+
+// UpgradeCommand returns the command that upgrades the package to its
+// UpgradeVersion, or "" when no fix is known
+func (p *Package) UpgradeCommand() string {
+	version := p.UpgradeVersion()
+	if version == "" {
+		return ""
+	}
+	return upgradeCommand(p.Ecosystem, p.Name, version)
+}
+
+// Direct reports whether the project depends on the package itself. It is
+// false when the dependency path is unknown.
+func (p *Package) Direct() bool {
+	return len(p.Path) == 1
+}
+
+// setUpgrade sets the fix of a dependency finding from its package
+func (f *Finding) setUpgrade() {
+	if f.Package == nil {
+		return
+	}
+	if fix := f.Package.UpgradeCommand(); fix != "" {
+		f.Fixable = true
+		f.Fix = fix
+	}
+}
+
+// upgradeCommand returns the command that upgrades a package to version
+func upgradeCommand(ecosystem inventory.Ecosystem, name, version string) string {
+	switch ecosystem {
+	case inventory.EcosystemNPM:
+		return fmt.Sprintf("npm install %s@%s", name, version)
+	case inventory.EcosystemPyPI:
+		return fmt.Sprintf("pip install '%s>=%s'", name, version)
+	case inventory.EcosystemGo:
+		return fmt.Sprintf("go get %s@v%s", name, strings.TrimPrefix(version, "v"))
+	case inventory.EcosystemCargo:
+		return fmt.Sprintf("cargo update -p %s --precise %s", name, version)
+	case inventory.EcosystemPackagist:
+		return fmt.Sprintf("composer require %s:^%s", name, strings.TrimPrefix(version, "v"))
+	case inventory.EcosystemRubyGems:
+		return fmt.Sprintf("bundle update %s", name)
+	case "Debian", "Ubuntu":
+		return fmt.Sprintf("apt-get install --only-upgrade -y %s=%s", name, version)
+	case "Alpine":
+		return fmt.Sprintf("apk add --upgrade '%s>=%s'", name, version)
+	case "Red Hat", "Rocky Linux", "AlmaLinux":
+		return fmt.Sprintf("dnf upgrade -y %s-%s", name, version)
+	default:
+		return fmt.Sprintf("Upgrade %s to %s", name, version)
+	}
+}
+
+// mergePackage completes the fields of a with those of b, reported by
+// another tool for the same package
+func mergePackage(a, b *Package) *Package {
+	if a == nil || b == nil {
+		if a == nil {
+			return b
+		}
+		return a
+	}
+	merged := *a
+	if merged.Ecosystem == "" {
+		merged.Ecosystem = b.Ecosystem
+	}
+	if merged.InstalledVersion == "" {
+		merged.InstalledVersion = b.InstalledVersion
+	}
+	if merged.PURL == "" {
+		merged.PURL = b.PURL
+	}
+	merged.FixedVersions = append([]string(nil), a.FixedVersions...)
+	for _, v := range b.FixedVersions {
+		if !containsString(merged.FixedVersions, v) {
+			merged.FixedVersions = append(merged.FixedVersions, v)
+		}
+	}
+	if len(merged.Path) == 0 {
+		merged.Path = b.Path
+	}
+	return &merged
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
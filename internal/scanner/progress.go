@@ -2,17 +2,22 @@ package scanner
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"sync"
 	"time"
 )
 
-// ProgressTracker tracks scan progress
+// ProgressTracker tracks scan progress.
+// It is safe for concurrent use by scanners running in parallel.
 type ProgressTracker struct {
+	mu          sync.Mutex
 	totalSteps  int
-	currentStep int
+	completed   int
 	stepNames   []string
 	startTime   time.Time
 	interactive bool
+	out         io.Writer
 }
 
 // NewProgressTracker creates a new progress tracker
@@ -21,46 +26,82 @@ func NewProgressTracker(interactive bool) *ProgressTracker {
 		interactive: interactive,
 		startTime:   time.Now(),
 		stepNames:   []string{},
+		out:         os.Stdout,
 	}
 }
 
+// SetOutput sets where progress is written, stdout by default
+func (pt *ProgressTracker) SetOutput(w io.Writer) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	pt.out = w
+}
+
 // AddStep adds a step to the tracker
 func (pt *ProgressTracker) AddStep(name string) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
 	pt.stepNames = append(pt.stepNames, name)
 	pt.totalSteps = len(pt.stepNames)
 }
 
 // StartStep starts a new step
 func (pt *ProgressTracker) StartStep(stepIndex int, name string) {
-	pt.currentStep = stepIndex + 1
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
 	if pt.interactive {
-		fmt.Printf("\r[%d/%d] %s...", pt.currentStep, pt.totalSteps, name)
-		os.Stdout.Sync()
+		fmt.Fprintf(pt.out, "\r[%d/%d] %s...", pt.completed, pt.totalSteps, name)
+		if f, ok := pt.out.(*os.File); ok {
+			f.Sync()
+		}
 	} else {
-		fmt.Printf("[%d/%d] %s...\n", pt.currentStep, pt.totalSteps, name)
+		fmt.Fprintf(pt.out, "[%d/%d] %s...\n", stepIndex+1, pt.totalSteps, name)
 	}
 }
 
 // CompleteStep marks a step as completed
 func (pt *ProgressTracker) CompleteStep(stepIndex int, findings int) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	pt.completed++
 	if pt.interactive {
-		stepName := "Step"
-		if stepIndex >= 0 && stepIndex < len(pt.stepNames) {
-			stepName = pt.stepNames[stepIndex]
-		}
+		stepName := pt.stepName(stepIndex)
 		if findings > 0 {
-			fmt.Printf("\r[%d/%d] ✅ %s (%d findings)\n", stepIndex+1, pt.totalSteps, stepName, findings)
+			fmt.Fprintf(pt.out, "\r[%d/%d] ✅ %s (%d findings)\n", pt.completed, pt.totalSteps, stepName, findings)
 		} else {
-			fmt.Printf("\r[%d/%d] ✅ %s\n", stepIndex+1, pt.totalSteps, stepName)
+			fmt.Fprintf(pt.out, "\r[%d/%d] ✅ %s\n", pt.completed, pt.totalSteps, stepName)
 		}
 	}
 }
 
+// FailStep marks a step as completed with an error
+func (pt *ProgressTracker) FailStep(stepIndex int, err error) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	pt.completed++
+	if pt.interactive {
+		fmt.Fprintf(pt.out, "\r[%d/%d] ⚠️  %s (error: %v)\n", pt.completed, pt.totalSteps, pt.stepName(stepIndex), err)
+	}
+}
+
+// stepName returns the name of a step; the caller must hold pt.mu
+func (pt *ProgressTracker) stepName(stepIndex int) string {
+	if stepIndex >= 0 && stepIndex < len(pt.stepNames) {
+		return pt.stepNames[stepIndex]
+	}
+	return "Step"
+}
+
 // Finish ends tracking and displays summary
 func (pt *ProgressTracker) Finish(totalFindings int) {
 	duration := time.Since(pt.startTime)
 	if pt.interactive {
-		fmt.Printf("\n✅ Scan completed in %v (%d findings)\n", duration.Round(time.Millisecond), totalFindings)
+		fmt.Fprintf(pt.out, "\n✅ Scan completed in %v (%d findings)\n", duration.Round(time.Millisecond), totalFindings)
 	}
 }
 
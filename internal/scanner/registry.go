@@ -0,0 +1,205 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sync"
+
+	"github.com/dso-cli/dso-cli/internal/tools"
+)
+
+// Category groups scanners by the kind of problem they detect.
+// Values match the categories used by the tools package.
+type Category string
+
+const (
+	CategorySecrets      Category = "Secrets"
+	CategorySAST         Category = "SAST"
+	CategoryDependencies Category = "Dependencies"
+	CategoryIaC          Category = "IaC"
+	CategoryContainers   Category = "Containers"
+)
+
+// Scanner is a pluggable security scanner
+type Scanner interface {
+	// Name returns the unique scanner name (usually the tool name)
+	Name() string
+	// Category returns the scanner category
+	Category() Category
+	// Applicable reports whether the scanner should run on the project
+	Applicable(project *Project) bool
+	// Scan runs the scanner on path and returns its findings.
+	// Implementations must stop when ctx is done.
+	Scan(ctx context.Context, path string) ([]Finding, error)
+}
+
+// ExternalTool is implemented by scanners that depend on something outside
+// dso, such as an external command or a local database. Scanners whose tool
+// is not available are recorded as skipped.
+type ExternalTool interface {
+	// Available returns an error if the tool cannot be run
+	Available() error
+	// Version returns the installed tool version
+	Version() string
+}
+
+// Project describes the file types found in a scanned directory
+type Project struct {
+	Path         string
+	HasDocker    bool
+	HasTerraform bool
+	HasK8s       bool
+	HasCI        bool
+	HasGo        bool
+	HasJS        bool
+	HasPython    bool
+	HasJava      bool
+	HasRuby      bool
+}
+
+// DetectProject inspects path and returns the detected project file types
+func DetectProject(path string) *Project {
+	p := detectProject(path, func(patterns ...string) bool {
+		return detectFileType(path, patterns...)
+	})
+	p.HasK8s = detectKubernetes(path, nil)
+	p.HasCI = detectCI(path, nil)
+	return p
+}
+
+// DetectChangedProject returns the file types found among files, the
+// changes of an incremental scan of path
+func DetectChangedProject(path string, files []string) *Project {
+	p := detectProject(path, func(patterns ...string) bool {
+		for _, file := range files {
+			for _, pattern := range patterns {
+				if matched, _ := filepath.Match(pattern, filepath.Base(file)); matched {
+					return true
+				}
+			}
+		}
+		return false
+	})
+	p.HasK8s = detectKubernetes(path, files)
+	p.HasCI = detectCI(path, files)
+	return p
+}
+
+// detectProject fills a Project with has, which reports whether a file
+// matches one of the patterns
+func detectProject(path string, has func(patterns ...string) bool) *Project {
+	return &Project{
+		Path:         path,
+		HasDocker:    has("Dockerfile", "Dockerfile.*", "*.dockerfile", "*.Dockerfile", "Containerfile", "docker-compose.yml"),
+		HasTerraform: has("*.tf", "*.tfvars"),
+		HasGo:        has("*.go", "go.mod"),
+		HasJS:        has("*.js", "*.ts", "package.json"),
+		HasPython:    has("*.py", "requirements.txt", "Pipfile"),
+		HasJava:      has("*.java", "pom.xml", "build.gradle"),
+		HasRuby:      has("*.rb", "Gemfile"),
+	}
+}
+
+// HasCode reports whether the project contains source code in a supported language
+func (p *Project) HasCode() bool {
+	return p.HasGo || p.HasJS || p.HasPython || p.HasJava || p.HasRuby
+}
+
+// HasIaC reports whether the project contains infrastructure files
+func (p *Project) HasIaC() bool {
+	return p.HasDocker || p.HasTerraform || p.HasK8s
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   []Scanner
+
+	// toolVersions caches tool versions by command
+	toolVersions sync.Map
+)
+
+// Register adds a scanner to the registry.
+// It panics if a scanner with the same name is already registered.
+func Register(s Scanner) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, existing := range registry {
+		if existing.Name() == s.Name() {
+			panic(fmt.Sprintf("scanner: Register called twice for %s", s.Name()))
+		}
+	}
+	registry = append(registry, s)
+}
+
+// Registered returns all registered scanners in registration order
+func Registered() []Scanner {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	scanners := make([]Scanner, len(registry))
+	copy(scanners, registry)
+	return scanners
+}
+
+// toolScanner adapts an external command-line tool to the Scanner interface
+type toolScanner struct {
+	name     string
+	category Category
+	command  string
+	applies  func(p *Project) bool
+	scan     func(ctx context.Context, path string) ([]Finding, error)
+	// history scans the commits of the repository, for secret scanners
+	// that support it (see HistoryScope)
+	history func(ctx context.Context, h *historyScan) ([]Finding, error)
+	// dbVersion are the arguments printing the version of the tool database, if any
+	dbVersion []string
+}
+
+func (t *toolScanner) Name() string       { return t.name }
+func (t *toolScanner) Category() Category { return t.category }
+
+// Applicable reports whether the tool is relevant for the project
+func (t *toolScanner) Applicable(p *Project) bool {
+	return t.applies == nil || t.applies(p)
+}
+
+// Available reports whether the tool is installed
+func (t *toolScanner) Available() error {
+	if _, err := exec.LookPath(t.command); err != nil {
+		return fmt.Errorf("%s not installed", t.command)
+	}
+	return nil
+}
+
+// Version returns the installed tool version, looked up once per command
+func (t *toolScanner) Version() string {
+	if v, ok := toolVersions.Load(t.command); ok {
+		return v.(string)
+	}
+	v := tools.GetVersion(t.command)
+	toolVersions.Store(t.command, v)
+	return v
+}
+
+func (t *toolScanner) Scan(ctx context.Context, path string) ([]Finding, error) {
+	findings, err := t.scan(ctx, path)
+	if err != nil || !t.scansHistory(ctx) {
+		return findings, err
+	}
+	past, err := t.history(ctx, historyFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("history: %w", err)
+	}
+	return append(findings, past...), nil
+}
+
+// scansHistory reports whether the scan also covers the git history
+func (t *toolScanner) scansHistory(ctx context.Context) bool {
+	return t.history != nil && historyFromContext(ctx) != nil
+}
+
+// always is an applicability check for scanners relevant to every project
+func always(*Project) bool { return true }
@@ -0,0 +1,473 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The SARIF 2.1.0 subset read by ImportSARIF. Producers disagree on many
+// optional fields, so only what maps to a Finding is decoded.
+type sarifLog struct {
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool struct {
+		Driver     sarifToolComponent   `json:"driver"`
+		Extensions []sarifToolComponent `json:"extensions"`
+	} `json:"tool"`
+	Invocations []struct {
+		ExecutionSuccessful        *bool `json:"executionSuccessful"`
+		ToolExecutionNotifications []struct {
+			Message sarifMessage `json:"message"`
+		} `json:"toolExecutionNotifications"`
+	} `json:"invocations"`
+	OriginalURIBaseIDs map[string]sarifArtifactLocation `json:"originalUriBaseIds"`
+	Artifacts          []struct {
+		Location sarifArtifactLocation `json:"location"`
+	} `json:"artifacts"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifToolComponent struct {
+	Name            string      `json:"name"`
+	Version         string      `json:"version"`
+	SemanticVersion string      `json:"semanticVersion"`
+	Rules           []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string       `json:"id"`
+	Name                 string       `json:"name"`
+	ShortDescription     sarifMessage `json:"shortDescription"`
+	FullDescription      sarifMessage `json:"fullDescription"`
+	Help                 sarifMessage `json:"help"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+	Properties sarifProperties `json:"properties"`
+}
+
+type sarifResult struct {
+	RuleID    string `json:"ruleId"`
+	RuleIndex *int   `json:"ruleIndex"`
+	Rule      struct {
+		ID            string `json:"id"`
+		Index         *int   `json:"index"`
+		ToolComponent struct {
+			Index *int `json:"index"`
+		} `json:"toolComponent"`
+	} `json:"rule"`
+	Kind      string       `json:"kind"`
+	Level     string       `json:"level"`
+	Message   sarifMessage `json:"message"`
+	Locations []struct {
+		PhysicalLocation struct {
+			ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+			Region           struct {
+				StartLine   int `json:"startLine"`
+				StartColumn int `json:"startColumn"`
+				EndLine     int `json:"endLine"`
+			} `json:"region"`
+		} `json:"physicalLocation"`
+	} `json:"locations"`
+	BaselineState string `json:"baselineState"`
+	Suppressions  []struct {
+		Status string `json:"status"`
+	} `json:"suppressions"`
+	Properties sarifProperties `json:"properties"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+	Index     *int   `json:"index"`
+}
+
+// sarifProperties is a property bag; values keep their JSON type
+type sarifProperties map[string]interface{}
+
+// sarifLinkRegex matches the links to related locations in SARIF messages, like "[user input](1)"
+var sarifLinkRegex = regexp.MustCompile(`\[([^\]]+)\]\(\d+\)`)
+
+// ImportSARIF reads a SARIF 2.1.0 file produced by any tool and converts its
+// results to findings. Each SARIF run gets a ToolRun named after its driver.
+// Relative locations are resolved against root, the scanned directory.
+// Suppressed results, results absent from the current run and passing
+// results are ignored.
+func ImportSARIF(file, root string) ([]ToolRun, []Finding, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, nil, fmt.Errorf("invalid SARIF file %s: %w", file, err)
+	}
+	if log.Version != "" && log.Version != "2.1.0" {
+		return nil, nil, fmt.Errorf("unsupported SARIF version %s in %s (2.1.0 expected)", log.Version, file)
+	}
+
+	var runs []ToolRun
+	var findings []Finding
+	now := time.Now()
+	for i, run := range log.Runs {
+		toolRun := ToolRun{
+			Tool:    run.Tool.Driver.Name,
+			Version: run.Tool.Driver.Version,
+			Source:  file,
+			Status:  ToolStatusOK,
+		}
+		if toolRun.Tool == "" {
+			toolRun.Tool = fmt.Sprintf("%s#%d", filepath.Base(file), i)
+		}
+		if toolRun.Version == "" {
+			toolRun.Version = run.Tool.Driver.SemanticVersion
+		}
+		for _, inv := range run.Invocations {
+			if inv.ExecutionSuccessful != nil && !*inv.ExecutionSuccessful {
+				toolRun.Status = ToolStatusFailed
+				toolRun.Error = "execution failed"
+				for _, n := range inv.ToolExecutionNotifications {
+					if n.Message.Text != "" {
+						toolRun.Error = n.Message.Text
+						break
+					}
+				}
+			}
+		}
+
+		for _, result := range run.Results {
+			if !sarifResultOpen(result) {
+				continue
+			}
+			f := sarifFinding(&run, result, toolRun.Tool, root)
+			f.Timestamp = now
+			findings = append(findings, f)
+			toolRun.Findings++
+			if toolRun.Category == "" {
+				toolRun.Category = typeCategory(f.Type)
+			}
+		}
+		if toolRun.Category == "" {
+			toolRun.Category = CategorySAST
+		}
+		runs = append(runs, toolRun)
+	}
+	return runs, findings, nil
+}
+
+// sarifResultOpen reports whether a result is an open problem
+func sarifResultOpen(result sarifResult) bool {
+	switch result.Kind {
+	case "pass", "notApplicable":
+		return false
+	}
+	if result.BaselineState == "absent" {
+		return false
+	}
+	for _, s := range result.Suppressions {
+		// A suppression without status is accepted
+		if s.Status == "" || s.Status == "accepted" {
+			return false
+		}
+	}
+	return true
+}
+
+// sarifFinding converts a SARIF result to a finding
+func sarifFinding(run *sarifRun, result sarifResult, tool, root string) Finding {
+	rule := run.rule(result)
+
+	ruleID := result.RuleID
+	if ruleID == "" {
+		ruleID = result.Rule.ID
+	}
+	if ruleID == "" && rule != nil {
+		ruleID = rule.ID
+	}
+
+	f := Finding{
+		Type:   sarifFindingType(rule, result),
+		RuleID: ruleID,
+		Tool:   tool,
+	}
+
+	message := sarifLinkRegex.ReplaceAllString(result.Message.Text, "$1")
+	f.Title = firstLine(message)
+	var desc []string
+	if strings.TrimSpace(message) != f.Title {
+		desc = append(desc, message)
+	}
+	if rule != nil {
+		if f.Title == "" {
+			f.Title = rule.ShortDescription.Text
+		}
+		if text := rule.FullDescription.Text; text != "" && text != f.Title {
+			desc = append(desc, text)
+		}
+		if text := rule.Help.Text; text != "" && text != rule.FullDescription.Text {
+			desc = append(desc, text)
+		}
+		if p := rule.Properties.string("precision"); p != "" {
+			f.Confidence = precisionConfidence[p]
+		}
+	}
+	if f.Title == "" {
+		f.Title = ruleID
+	}
+	f.Description = strings.Join(desc, "\n\n")
+
+	if len(result.Locations) > 0 {
+		loc := result.Locations[0].PhysicalLocation
+		f.File = run.resolveURI(loc.ArtifactLocation, root)
+		f.Line = loc.Region.StartLine
+		f.Column = loc.Region.StartColumn
+		if loc.Region.EndLine > f.Line {
+			f.EndLine = loc.Region.EndLine
+		}
+	}
+
+	// security-severity is a CVSS-like score, more precise than the level
+	score, ok := result.Properties.number("security-severity")
+	if !ok && rule != nil {
+		score, ok = rule.Properties.number("security-severity")
+	}
+	switch {
+	case result.Properties.string("severity") != "":
+		// Written by dso, whose security-severity may be derived from it
+		f.Severity = mapSeverity(result.Properties.string("severity"))
+		f.CVSS, _ = result.Properties.number("cvss")
+	case ok && score > 0:
+		f.CVSS = score
+		f.Severity = scoreSeverity(score)
+	default:
+		level := result.Level
+		if level == "" && rule != nil {
+			level = rule.DefaultConfiguration.Level
+		}
+		f.Severity = levelSeverity(level)
+	}
+
+	// Properties written by dso itself survive a round trip
+	f.Fix = result.Properties.string("fix")
+	f.Fixable = f.Fix != ""
+	f.Aliases = result.Properties.strings("aliases")
+	f.CWE = result.Properties.strings("cwe")
+	f.OWASP = result.Properties.strings("owasp")
+	f.ASVS = result.Properties.strings("asvs")
+	if len(f.CWE) == 0 && rule != nil {
+		// CodeQL and other scanners tag their rules with external/cwe/cwe-089
+		for _, tag := range rule.Properties.strings("tags") {
+			if n, ok := strings.CutPrefix(strings.ToLower(tag), "external/cwe/cwe-"); ok {
+				f.CWE = append(f.CWE, n)
+			}
+		}
+	}
+	if raw, ok := result.Properties["package"]; ok {
+		var pkg Package
+		if data, err := json.Marshal(raw); err == nil && json.Unmarshal(data, &pkg) == nil && pkg.Name != "" {
+			f.Package = &pkg
+		}
+	}
+	if raw, ok := result.Properties["commit"]; ok {
+		var commit Commit
+		if data, err := json.Marshal(raw); err == nil && json.Unmarshal(data, &commit) == nil && commit.SHA != "" {
+			f.Commit = &commit
+			// Rewriting the history is not an automatic fix
+			f.Fixable = f.Fixable && commit.InHead
+		}
+	}
+
+	f.ID = fmt.Sprintf("%s-%s-%s-%d", tool, ruleID, f.File, f.Line)
+	return f
+}
+
+// rule returns the rule of a result, or nil when the run does not describe it
+func (run *sarifRun) rule(result sarifResult) *sarifRule {
+	component := &run.Tool.Driver
+	if i := result.Rule.ToolComponent.Index; i != nil && *i >= 0 && *i < len(run.Tool.Extensions) {
+		component = &run.Tool.Extensions[*i]
+	}
+
+	index := result.RuleIndex
+	if index == nil {
+		index = result.Rule.Index
+	}
+	if index != nil && *index >= 0 && *index < len(component.Rules) {
+		return &component.Rules[*index]
+	}
+
+	id := result.RuleID
+	if id == "" {
+		id = result.Rule.ID
+	}
+	if id == "" {
+		return nil
+	}
+	for _, c := range append([]sarifToolComponent{run.Tool.Driver}, run.Tool.Extensions...) {
+		for i := range c.Rules {
+			if c.Rules[i].ID == id {
+				return &c.Rules[i]
+			}
+		}
+	}
+	return nil
+}
+
+// resolveURI converts an artifact location to a file path. Relative URIs
+// whose base is unknown are relative to root.
+func (run *sarifRun) resolveURI(loc sarifArtifactLocation, root string) string {
+	if loc.URI == "" && loc.Index != nil && *loc.Index >= 0 && *loc.Index < len(run.Artifacts) {
+		loc = run.Artifacts[*loc.Index].Location
+	}
+	if loc.URI == "" {
+		return ""
+	}
+
+	u, err := url.Parse(loc.URI)
+	if err != nil {
+		return loc.URI
+	}
+	if u.Scheme == "file" {
+		return filepath.FromSlash(u.Path)
+	}
+	if u.Scheme != "" {
+		return loc.URI
+	}
+
+	if base, ok := run.OriginalURIBaseIDs[loc.URIBaseID]; ok && loc.URIBaseID != "" {
+		if b, err := url.Parse(base.URI); err == nil && b.Scheme == "file" {
+			return filepath.Join(filepath.FromSlash(b.Path), filepath.FromSlash(u.Path))
+		}
+	}
+	return filepath.Join(root, filepath.FromSlash(u.Path))
+}
+
+// sarifFindingType infers the finding type from the properties and tags
+func sarifFindingType(rule *sarifRule, result sarifResult) string {
+	if t := result.Properties.string("type"); t != "" {
+		return strings.ToUpper(t)
+	}
+	if rule == nil {
+		return "SAST"
+	}
+	for _, tag := range rule.Properties.strings("tags") {
+		switch strings.ToLower(tag) {
+		case "secret", "secrets":
+			return "SECRET"
+		case "dependency", "dependencies", "vulnerability", "sca":
+			return "DEPENDENCY"
+		case "iac", "misconfiguration", "terraform", "kubernetes":
+			return "IAC"
+		case "container", "docker", "image":
+			return "CONTAINER"
+		}
+	}
+	return "SAST"
+}
+
+// typeCategory returns the scanner category of a finding type
+func typeCategory(findingType string) Category {
+	switch findingType {
+	case "SECRET":
+		return CategorySecrets
+	case "DEPENDENCY", "LICENSE":
+		return CategoryDependencies
+	case "IAC":
+		return CategoryIaC
+	case "CONTAINER":
+		return CategoryContainers
+	default:
+		return CategorySAST
+	}
+}
+
+// precisionConfidence maps the precision of a rule (as used by CodeQL) to a confidence
+var precisionConfidence = map[string]float64{
+	"very-high": 0.9,
+	"high":      0.8,
+	"medium":    0.6,
+	"low":       0.4,
+}
+
+// levelSeverity maps a SARIF level to a severity
+func levelSeverity(level string) Severity {
+	switch level {
+	case "error":
+		return SeverityHigh
+	case "note":
+		return SeverityLow
+	case "none":
+		return SeverityInfo
+	default:
+		// "warning" is the SARIF default level
+		return SeverityMedium
+	}
+}
+
+// scoreSeverity maps a CVSS score to a severity
+func scoreSeverity(score float64) Severity {
+	switch {
+	case score >= 9.0:
+		return SeverityCritical
+	case score >= 7.0:
+		return SeverityHigh
+	case score >= 4.0:
+		return SeverityMedium
+	case score > 0:
+		return SeverityLow
+	default:
+		return SeverityInfo
+	}
+}
+
+func (p sarifProperties) string(key string) string {
+	s, _ := p[key].(string)
+	return s
+}
+
+// number reads a number, also when written as a string like security-severity
+func (p sarifProperties) number(key string) (float64, bool) {
+	switch v := p[key].(type) {
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func (p sarifProperties) strings(key string) []string {
+	values, _ := p[key].([]interface{})
+	var out []string
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// firstLine returns the first line of a message
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s
+}
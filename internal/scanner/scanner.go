@@ -1,403 +1,491 @@
 package scanner
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"os"
-	"os/exec"
+	"io/fs"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/dso-cli/dso-cli/internal/cache"
+	"github.com/dso-cli/dso-cli/internal/config"
+	"github.com/dso-cli/dso-cli/internal/ignore"
+	"github.com/dso-cli/dso-cli/internal/inventory"
 )
 
+// Options configures a scan run
+type Options struct {
+	// Interactive enables progress output
+	Interactive bool
+	// Tracker reports progress (a new one is created when nil)
+	Tracker *ProgressTracker
+	// Jobs is the maximum number of scanners running concurrently (defaults to the number of CPUs)
+	Jobs int
+	// SARIFImports are SARIF files from other tools whose results are added to the scan
+	SARIFImports []string
+	// Since limits the scan to the files changed since this git ref
+	Since string
+	// Staged limits the scan to the staged changes
+	Staged bool
+	// Cache reuses the results of unchanged files (disabled when nil)
+	Cache *cache.Cache
+	// History makes the secret scanners scan the git history too (disabled when nil)
+	History *HistoryScope
+}
+
 // RunFullScan runs all available scanners
-func RunFullScan(path string) (*ScanResults, error) {
-	return RunFullScanInteractive(path, false, nil)
+func RunFullScan(ctx context.Context, path string) (*ScanResults, error) {
+	return Run(ctx, path, Options{})
 }
 
 // RunFullScanInteractive runs all scanners with progress tracking
-func RunFullScanInteractive(path string, interactive bool, tracker *ProgressTracker) (*ScanResults, error) {
+func RunFullScanInteractive(ctx context.Context, path string, interactive bool, tracker *ProgressTracker) (*ScanResults, error) {
+	return Run(ctx, path, Options{Interactive: interactive, Tracker: tracker})
+}
+
+// Run runs all applicable registered scanners concurrently, bounded by opts.Jobs.
+// Each scanner is limited by its configured timeout; a scanner that times out
+// is reported in ScanResults.Warnings. Every registered scanner gets a
+// ToolRun entry, including the ones that were skipped. Results imported from
+// opts.SARIFImports are correlated with the native findings. Findings are
+// classified by CWE, OWASP Top 10 category and ASVS requirement.
+// LICENSE findings are evaluated against the license policy of the
+// project; the allowed licenses are dropped.
+// Files excluded by the defaults, DSO_IGNORE and the .gitignore and
+// .dsoignore files of path are out of scope for every scanner.
+// Findings matched by an inline dso:ignore comment are kept but marked
+// suppressed, and every such comment is listed in ScanResults.Suppressions.
+// With opts.Since or opts.Staged, only the scanners relevant to the changed
+// files run, native scanners only read the changed files, and findings
+// outside the changed lines are dropped.
+// Run returns ctx.Err() if ctx is cancelled.
+func Run(ctx context.Context, path string, opts Options) (*ScanResults, error) {
 	results := &ScanResults{
 		Path:      path,
 		Timestamp: time.Now(),
 		Findings:  []Finding{},
 	}
 
+	tracker := opts.Tracker
 	if tracker == nil {
-		tracker = NewProgressTracker(interactive)
+		tracker = NewProgressTracker(opts.Interactive)
 	}
 
-	// Automatic file type detection
-	hasDocker := detectFileType(path, "Dockerfile", "docker-compose.yml", "*.dockerfile")
-	hasTerraform := detectFileType(path, "*.tf", "*.tfvars")
-	hasK8s := detectFileType(path, "*.yaml", "*.yml")
-	hasGo := detectFileType(path, "*.go", "go.mod")
-	hasJS := detectFileType(path, "*.js", "*.ts", "package.json")
-	hasPython := detectFileType(path, "*.py", "requirements.txt", "Pipfile")
-	hasJava := detectFileType(path, "*.java", "pom.xml", "build.gradle")
-
-	// Prepare steps with extended scanners
-	steps := []struct {
-		name    string
-		enabled bool
-		scan    func() ([]Finding, error)
-	}{
-		{"Scanning secrets (comprehensive)", true, func() ([]Finding, error) { 
-			// Use extended secret scanning
-			extendedFindings, _ := scanSecretsExtended(path)
-			// Also include original scan
-			originalFindings, _ := scanSecrets(path)
-			// Merge and deduplicate
-			allFindings := append(extendedFindings, originalFindings...)
-			return deduplicateFindings(allFindings), nil
-		}},
-		{"SAST Go", hasGo, func() ([]Finding, error) { 
-			extendedFindings, _ := scanSASTExtended(path, "go")
-			originalFindings, _ := scanSAST(path, "go")
-			return deduplicateFindings(append(extendedFindings, originalFindings...)), nil
-		}},
-		{"SAST JavaScript/TypeScript", hasJS, func() ([]Finding, error) { 
-			extendedFindings, _ := scanSASTExtended(path, "javascript")
-			originalFindings, _ := scanSAST(path, "javascript")
-			return deduplicateFindings(append(extendedFindings, originalFindings...)), nil
-		}},
-		{"SAST Python", hasPython, func() ([]Finding, error) { 
-			extendedFindings, _ := scanSASTExtended(path, "python")
-			originalFindings, _ := scanSAST(path, "python")
-			return deduplicateFindings(append(extendedFindings, originalFindings...)), nil
-		}},
-		{"SAST Java", hasJava, func() ([]Finding, error) { 
-			extendedFindings, _ := scanSASTExtended(path, "java")
-			originalFindings, _ := scanSAST(path, "java")
-			return deduplicateFindings(append(extendedFindings, originalFindings...)), nil
-		}},
-		{"Scanning dependencies (SCA)", true, func() ([]Finding, error) { 
-			extendedFindings, _ := scanDependenciesExtended(path)
-			originalFindings, _ := scanDependencies(path)
-			return deduplicateFindings(append(extendedFindings, originalFindings...)), nil
-		}},
-		{"Scanning Docker", hasDocker, func() ([]Finding, error) { 
-			extendedFindings, _ := scanContainersExtended(path)
-			originalFindings, _ := scanDocker(path)
-			return deduplicateFindings(append(extendedFindings, originalFindings...)), nil
-		}},
-		{"Scanning Terraform", hasTerraform, func() ([]Finding, error) { 
-			extendedFindings, _ := scanIaCExtended(path)
-			originalFindings, _ := scanTerraform(path)
-			return deduplicateFindings(append(extendedFindings, originalFindings...)), nil
-		}},
-		{"Scanning Kubernetes", hasK8s, func() ([]Finding, error) { return scanKubernetes(path) }},
+	jobs := opts.Jobs
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
 	}
 
-	// Add steps to tracker
-	for _, step := range steps {
-		if step.enabled {
-			tracker.AddStep(step.name)
+	// Read the imports first: an unreadable file is a usage error
+	var importedRuns []ToolRun
+	var imported []Finding
+	for _, file := range opts.SARIFImports {
+		runs, findings, err := ImportSARIF(file, path)
+		if err != nil {
+			return nil, err
 		}
+		importedRuns = append(importedRuns, runs...)
+		imported = append(imported, findings...)
 	}
 
-	// Execute scans
-	enabledStepIndex := 0
-	for _, step := range steps {
-		if !step.enabled {
-			continue
+	// Ignore rules apply to every scanner: native walks skip the ignored
+	// files, external tools get exclude flags, and the findings of the
+	// remaining ignored files are dropped after correlation
+	rules, err := ignore.Load(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withIgnore(ctx, rules)
+
+	// Automatic file type detection
+	project := DetectProject(path)
+	scanCtx := ctx
+	var scope *DiffScope
+	if opts.Since != "" || opts.Staged {
+		var err error
+		scope, err = ChangedFiles(ctx, path, opts.Since, opts.Staged)
+		if err != nil {
+			return nil, err
+		}
+		results.Diff = scope
+		project = DetectChangedProject(path, scope.Files)
+		scanCtx = withFileFilter(ctx, scope.Files)
+	}
+	if opts.History != nil {
+		if scope != nil {
+			return nil, errors.New("the git history cannot be scanned during an incremental scan")
 		}
-		tracker.StartStep(enabledStepIndex, step.name)
-		if findings, err := step.scan(); err == nil {
-			results.Findings = append(results.Findings, findings...)
-			tracker.CompleteStep(enabledStepIndex, len(findings))
-		} else if interactive {
-			fmt.Printf("\r[%d/%d] ⚠️  %s (error: %v)\n", enabledStepIndex+1, tracker.totalSteps, step.name, err)
+		h, err := newHistoryScan(ctx, path, opts.History)
+		if err != nil {
+			return nil, err
 		}
-		enabledStepIndex++
+		results.History = opts.History
+		scanCtx = withHistory(scanCtx, h)
 	}
 
-	results.CalculateSummary()
-	tracker.Finish(results.Summary.Total)
-
-	return results, nil
-}
-
-// detectFileType checks if a file type exists in the directory
-func detectFileType(path string, patterns ...string) bool {
-	for _, pattern := range patterns {
-		matches, err := filepath.Glob(filepath.Join(path, pattern))
-		if err == nil && len(matches) > 0 {
-			return true
+	// Dependency inventory from lockfiles; unreadable lockfiles make the
+	// inventory partial, like a scanner timeout
+	packages, err := inventory.LoadSkipping(ctx, path, rules.Ignored)
+	if err != nil && ctx.Err() == nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			results.Warnings = append(results.Warnings, "cannot read lockfile "+line)
+		}
+	}
+	results.Inventory = packages
+
+	registered := Registered()
+	runs := make([]ToolRun, len(registered))
+	var steps []int // indexes in registered of the scanners to run
+	for i, s := range registered {
+		runs[i] = ToolRun{Tool: s.Name(), Category: s.Category(), Status: ToolStatusSkipped}
+		if scope != nil && len(scope.Files) == 0 {
+			runs[i].Error = "no changed files"
+			continue
+		}
+		if scope != nil && s.Category() == CategoryDependencies && !scope.HasDependencyChanges() {
+			runs[i].Error = "no changed dependency files"
+			continue
 		}
-		// Recursive search for certain patterns
-		if strings.Contains(pattern, "*") {
-			err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
-				if err != nil {
-					return nil
-				}
-				matched, matchErr := filepath.Match(pattern, info.Name())
-				if matchErr == nil && matched {
-					return fmt.Errorf("found") // Stop the search
-				}
-				return nil
-			})
-			if err != nil && err.Error() == "found" {
-				return true
+		if !s.Applicable(project) {
+			runs[i].Error = "no matching files"
+			continue
+		}
+		if tool, ok := s.(ExternalTool); ok {
+			if err := tool.Available(); err != nil {
+				runs[i].Error = err.Error()
+				continue
 			}
 		}
+		steps = append(steps, i)
+		tracker.AddStep(stepName(s))
 	}
-	return false
-}
 
-// scanSecrets scans secrets with gitleaks (or trivy)
-func scanSecrets(path string) ([]Finding, error) {
-	var findings []Finding
+	var sc *scanCache
+	if opts.Cache != nil {
+		sc = &scanCache{cache: opts.Cache, root: path}
+	}
 
-	// Try with gitleaks
-	if _, err := exec.LookPath("gitleaks"); err == nil {
-		// Use --no-git and exclude common directories
-		cmd := exec.Command("gitleaks", "detect", "--source", path, "--no-git", 
-			"--format", "json",
-			"--exclude-path", "node_modules",
-			"--exclude-path", "vendor",
-			"--exclude-path", "dist",
-			"--exclude-path", "build",
-			"--exclude-path", ".git",
-			"--exclude-path", ".cache",
-			"--exclude-path", "temp",
-			"--exclude-path", "tmp")
-		output, err := cmd.Output()
-		if err != nil && len(output) > 0 {
-			// gitleaks returns an error code if secrets are found
-			var gitleaksResults []struct {
-				RuleID string `json:"RuleID"`
-				File   string `json:"File"`
-				Line   string `json:"StartLine"`
-				Secret string `json:"Secret"`
+	// Execute scans, keeping each scanner's findings in its own slot so the
+	// merge below does not depend on completion order
+	stepFindings := make([][]Finding, len(registered))
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for step, i := range steps {
+		wg.Add(1)
+		go func(step, i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				return
 			}
-			if json.Unmarshal(output, &gitleaksResults) == nil {
-				for _, r := range gitleaksResults {
-					findings = append(findings, Finding{
-						ID:          fmt.Sprintf("secret-%s-%s", r.RuleID, r.File),
-						Type:        "SECRET",
-						Severity:    SeverityCritical,
-						Title:       fmt.Sprintf("Exposed secret: %s", r.RuleID),
-						Description: fmt.Sprintf("Secret detected in %s", r.File),
-						File:        r.File,
-						Tool:        "gitleaks",
-						Fixable:     true,
-						Exploitable: true,
-					})
-				}
+
+			s := registered[i]
+			tracker.StartStep(step, stepName(s))
+			stepFindings[i] = runScanner(scanCtx, s, path, &runs[i], sc)
+			if runs[i].Status == ToolStatusOK {
+				tracker.CompleteStep(step, len(stepFindings[i]))
+			} else {
+				tracker.FailStep(step, errors.New(runs[i].Error))
 			}
-		}
-		return findings, nil
+		}(step, i)
 	}
+	wg.Wait()
 
-	// Fallback: trivy for secrets
-	return scanWithTrivy(path, "secret")
-}
-
-// scanSAST scans source code with Trivy
-func scanSAST(path string, _ string) ([]Finding, error) {
-	return scanWithTrivy(path, "fs", "--scanners", "vuln,secret,config")
-}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-// scanDependencies scans dependencies with Trivy and Grype
-func scanDependencies(path string) ([]Finding, error) {
 	var findings []Finding
-
-	// Trivy for dependencies
-	if trivyFindings, err := scanWithTrivy(path, "fs", "--scanners", "vuln"); err == nil {
-		findings = append(findings, trivyFindings...)
+	for i := range registered {
+		findings = append(findings, stepFindings[i]...)
+		if runs[i].Status == ToolStatusTimeout {
+			results.Warnings = append(results.Warnings,
+				fmt.Sprintf("%s %s, results are partial", runs[i].Tool, runs[i].Error))
+		}
 	}
 
-	// Grype as complement
-	if _, err := exec.LookPath("grype"); err == nil {
-		// Grype automatically respects .gitignore, but we can add exclusions
-		cmd := exec.Command("grype", path, "-o", "json", "--exclude", "node_modules", "--exclude", "vendor", "--exclude", "dist", "--exclude", "build")
-		output, err := cmd.Output()
-		if err == nil && len(output) > 0 {
-			var grypeResults struct {
-				Matches []struct {
-					Vulnerability struct {
-						ID          string `json:"id"`
-						Severity    string `json:"severity"`
-						Description string `json:"description"`
-						CVSS        []struct {
-							Metrics struct {
-								BaseScore float64 `json:"baseScore"`
-							} `json:"metrics"`
-						} `json:"cvss"`
-					} `json:"vulnerability"`
-					Artifact struct {
-						Name    string `json:"name"`
-						Version string `json:"version"`
-					} `json:"artifact"`
-				} `json:"matches"`
-			}
-			if json.Unmarshal(output, &grypeResults) == nil {
-				for _, m := range grypeResults.Matches {
-					severity := mapSeverity(m.Vulnerability.Severity)
-					cvss := 0.0
-					if len(m.Vulnerability.CVSS) > 0 {
-						cvss = m.Vulnerability.CVSS[0].Metrics.BaseScore
-					}
-					findings = append(findings, Finding{
-						ID:          m.Vulnerability.ID,
-						Type:        "DEPENDENCY",
-						Severity:    severity,
-						Title:       fmt.Sprintf("%s in %s", m.Vulnerability.ID, m.Artifact.Name),
-						Description: m.Vulnerability.Description,
-						Tool:        "grype",
-						CVSS:        cvss,
-						Fixable:     true,
-					})
-				}
-			}
+	findings = append(findings, imported...)
+
+	if opts.Cache != nil {
+		if err := opts.Cache.Save(); err != nil {
+			results.Warnings = append(results.Warnings, "cannot write cache: "+err.Error())
 		}
 	}
 
-	return findings, nil
-}
+	findings = Correlate(path, findings)
+	findings = applyLicensePolicy(ProjectLicensePolicy(path), findings)
+	findings = filterIgnored(rules, findings)
+	classify(findings)
+	if scope != nil {
+		findings = scope.Filter(findings)
+	}
+
+	// Inline dso:ignore comments mark their findings suppressed
+	suppressions, err := CollectSuppressions(scanCtx, path)
+	if err != nil {
+		return nil, err
+	}
+	ApplySuppressions(findings, suppressions, time.Now())
+	results.Suppressions = suppressions
+
+	results.ToolRuns = append(runs, importedRuns...)
+	results.Findings = append(results.Findings, findings...)
+	results.CalculateSummary()
+	tracker.Finish(results.Summary.Total)
 
-// scanDocker scans Dockerfiles
-func scanDocker(path string) ([]Finding, error) {
-	return scanWithTrivy(path, "config", "--scanners", "config")
+	return results, nil
 }
 
-// scanTerraform scans Terraform files
-func scanTerraform(path string) ([]Finding, error) {
+// runScanner runs a single scanner within its configured timeout and
+// records the execution details in run. Cached results are used when sc is not nil.
+func runScanner(ctx context.Context, s Scanner, path string, run *ToolRun, sc *scanCache) []Finding {
+	run.Version = "built-in"
+	if tool, ok := s.(ExternalTool); ok {
+		run.Version = tool.Version()
+	}
+
+	timeout := config.GetToolTimeout(s.Name())
+	scanCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	scanCtx, rec := withRunRecorder(scanCtx)
+
+	start := time.Now()
 	var findings []Finding
+	var err error
+	if sc != nil {
+		findings, run.Cached, err = sc.scan(scanCtx, s, path, run)
+	} else {
+		findings, err = s.Scan(scanCtx, path)
+	}
+	run.Duration = time.Since(start)
+	rec.apply(run)
+
+	switch {
+	case ctx.Err() == nil && errors.Is(scanCtx.Err(), context.DeadlineExceeded):
+		run.Status = ToolStatusTimeout
+		run.Error = fmt.Sprintf("timed out after %v", timeout)
+	case err != nil:
+		run.Status = ToolStatusFailed
+		run.Error = err.Error()
+		return nil
+	default:
+		run.Status = ToolStatusOK
+	}
+
+	run.Findings = len(findings)
+	return findings
+}
 
-	// tfsec
-	if _, err := exec.LookPath("tfsec"); err == nil {
-		cmd := exec.Command("tfsec", path, "--format", "json")
-		output, err := cmd.Output()
-		if err == nil && len(output) > 0 {
-			var tfsecResults struct {
-				Results []struct {
-					RuleID      string `json:"rule_id"`
-					Severity    string `json:"severity"`
-					Description string `json:"description"`
-					Location    struct {
-						Filename  string `json:"filename"`
-						StartLine int    `json:"start_line"`
-					} `json:"location"`
-				} `json:"results"`
+// stepName returns the progress label of a scanner
+func stepName(s Scanner) string {
+	return fmt.Sprintf("%s: %s", s.Category(), s.Name())
+}
+
+// detectFileType checks if a file matching one of the patterns exists in
+// the directory or its subdirectories
+func detectFileType(path string, patterns ...string) bool {
+	found := errors.New("found")
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != path && (d.Name() == ".git" || d.Name() == "node_modules") {
+				return filepath.SkipDir
 			}
-			if json.Unmarshal(output, &tfsecResults) == nil {
-				for _, r := range tfsecResults.Results {
-					findings = append(findings, Finding{
-						ID:          r.RuleID,
-						Type:        "IAC",
-						Severity:    mapSeverity(r.Severity),
-						Title:       r.RuleID,
-						Description: r.Description,
-						File:        r.Location.Filename,
-						Line:        r.Location.StartLine,
-						Tool:        "tfsec",
-						Fixable:     true,
-					})
-				}
+			return nil
+		}
+		for _, pattern := range patterns {
+			if matched, _ := filepath.Match(pattern, d.Name()); matched {
+				return found // Stop the search
 			}
 		}
-	}
+		return nil
+	})
+	return err == found
+}
 
-	// Trivy for Terraform too
-	if trivyFindings, err := scanWithTrivy(path, "config", "--scanners", "config"); err == nil {
-		findings = append(findings, trivyFindings...)
-	}
+func init() {
+	Register(&toolScanner{
+		name:      "trivy",
+		category:  CategoryDependencies,
+		command:   "trivy",
+		applies:   always,
+		scan:      scanWithTrivyFS,
+		dbVersion: []string{"--version"},
+	})
+	Register(&toolScanner{
+		name:      "trivy-config",
+		category:  CategoryIaC,
+		command:   "trivy",
+		applies:   (*Project).HasIaC,
+		scan:      scanWithTrivyConfig,
+		dbVersion: []string{"--version"},
+	})
+	Register(&toolScanner{
+		name:      "grype",
+		category:  CategoryDependencies,
+		command:   "grype",
+		applies:   always,
+		scan:      scanWithGrype,
+		dbVersion: []string{"db", "status"},
+	})
+	Register(&toolScanner{
+		name:     "tfsec",
+		category: CategoryIaC,
+		command:  "tfsec",
+		applies:  func(p *Project) bool { return p.HasTerraform },
+		scan:     scanWithTfsec,
+	})
+}
 
-	return findings, nil
+// scanWithTrivyFS scans the filesystem for vulnerable dependencies, secrets and licenses with Trivy
+func scanWithTrivyFS(ctx context.Context, path string) ([]Finding, error) {
+	return scanWithTrivy(ctx, path, "fs", "--scanners", "vuln,secret,license", "--list-all-pkgs")
 }
 
-// scanKubernetes scans Kubernetes manifests
-func scanKubernetes(path string) ([]Finding, error) {
-	return scanWithTrivy(path, "config", "--scanners", "config")
+// scanWithTrivyConfig scans Dockerfiles, Terraform and Kubernetes manifests with Trivy
+func scanWithTrivyConfig(ctx context.Context, path string) ([]Finding, error) {
+	return scanWithTrivy(ctx, path, "config")
 }
 
-// scanWithTrivy executes Trivy and parses results
-func scanWithTrivy(path string, scanType string, extraArgs ...string) ([]Finding, error) {
-	if _, err := exec.LookPath("trivy"); err != nil {
-		return nil, fmt.Errorf("trivy not found. Install it: https://aquasecurity.github.io/trivy/")
-	}
+// scanWithGrype scans dependencies with Grype
+func scanWithGrype(ctx context.Context, path string) ([]Finding, error) {
+	args := []string{path, "-o", "json"}
+	args = append(args, excludeArgs(ctx, "--exclude", func(e ignore.Entry) string {
+		if e.IsDir {
+			return "./" + e.Path + "/**"
+		}
+		return "./" + e.Path
+	})...)
+	return runGrype(ctx, args...)
+}
 
-	// Common directories to skip
-	skipDirs := []string{
-		"node_modules",
-		"vendor",
-		"dist",
-		"build",
-		".git",
-		".cache",
-		"temp",
-		"tmp",
-		"venv",
-		"__pycache__",
-		".pip",
-		".npm",
-		".yarn",
-		"bower_components",
-		".pnp",
-		"out",
-		"target",
-		"bin",
-		"obj",
-		"coverage",
-		".nyc_output",
-		"logs",
+// runGrype runs grype with args and maps its JSON report to findings
+func runGrype(ctx context.Context, args ...string) ([]Finding, error) {
+	var findings []Finding
+	output, err := runTool(ctx, "", "grype", args...)
+	if err := toolError("grype", output, err); err != nil {
+		return nil, err
 	}
 
-	args := []string{scanType, path, "--format", "json", "--quiet"}
-	// Add skip directories
-	for _, dir := range skipDirs {
-		args = append(args, "--skip-dirs", dir)
+	var grypeResults struct {
+		Matches []struct {
+			Vulnerability struct {
+				ID          string `json:"id"`
+				Severity    string `json:"severity"`
+				Description string `json:"description"`
+				CVSS        []struct {
+					Metrics struct {
+						BaseScore float64 `json:"baseScore"`
+					} `json:"metrics"`
+				} `json:"cvss"`
+				Fix struct {
+					Versions []string `json:"versions"`
+				} `json:"fix"`
+			} `json:"vulnerability"`
+			RelatedVulnerabilities []struct {
+				ID string `json:"id"`
+			} `json:"relatedVulnerabilities"`
+			Artifact struct {
+				Name      string `json:"name"`
+				Version   string `json:"version"`
+				Type      string `json:"type"` // npm, go-module, python, java-archive, deb...
+				PURL      string `json:"purl"`
+				Locations []struct {
+					Path string `json:"path"` // Relative to the scanned directory, with a leading /
+				} `json:"locations"`
+			} `json:"artifact"`
+		} `json:"matches"`
 	}
-	args = append(args, extraArgs...)
-
-	cmd := exec.Command("trivy", args...)
-	output, err := cmd.Output()
-	if err != nil && len(output) == 0 {
-		// Trivy may return an error if vulnerabilities are found
-		// Continue anyway to parse results if we have output
-		return nil, fmt.Errorf("trivy scan failed: %v", err)
+	if err := decodeToolOutput("grype", output, &grypeResults); err != nil {
+		return nil, err
 	}
+	for _, m := range grypeResults.Matches {
+		severity := mapSeverity(m.Vulnerability.Severity)
+		cvss := 0.0
+		if len(m.Vulnerability.CVSS) > 0 {
+			cvss = m.Vulnerability.CVSS[0].Metrics.BaseScore
+		}
+		var aliases []string
+		for _, related := range m.RelatedVulnerabilities {
+			if related.ID != m.Vulnerability.ID {
+				aliases = append(aliases, related.ID)
+			}
+		}
 
-	var trivyResults struct {
-		Results []struct {
-			Target          string `json:"Target"`
-			Vulnerabilities []struct {
-				VulnerabilityID string `json:"VulnerabilityID"`
-				Severity        string `json:"Severity"`
-				Title           string `json:"Title"`
-				Description     string `json:"Description"`
-				CVSS            map[string]struct {
-					V3Score float64 `json:"v3Score"`
-				} `json:"CVSS"`
-			} `json:"Vulnerabilities"`
-		} `json:"Results"`
+		pkg := &Package{Ecosystem: packageEcosystem(m.Artifact.Type)}
+		if p := purlPackage(m.Artifact.PURL); p != nil {
+			pkg = p
+		}
+		pkg.Name = m.Artifact.Name
+		pkg.InstalledVersion = m.Artifact.Version
+		pkg.FixedVersions = m.Vulnerability.Fix.Versions
+		file := ""
+		if len(m.Artifact.Locations) > 0 {
+			file = strings.TrimPrefix(m.Artifact.Locations[0].Path, "/")
+		}
+
+		f := Finding{
+			ID:          m.Vulnerability.ID,
+			Type:        "DEPENDENCY",
+			Severity:    severity,
+			Title:       fmt.Sprintf("%s in %s", m.Vulnerability.ID, m.Artifact.Name),
+			Description: m.Vulnerability.Description,
+			File:        file,
+			Tool:        "grype",
+			CVSS:        cvss,
+			Aliases:     aliases,
+			Package:     pkg,
+		}
+		f.setUpgrade()
+		findings = append(findings, f)
 	}
 
-	if err := json.Unmarshal(output, &trivyResults); err != nil {
+	return findings, nil
+}
+
+// scanWithTfsec scans Terraform files with tfsec
+func scanWithTfsec(ctx context.Context, path string) ([]Finding, error) {
+	var findings []Finding
+
+	args := append([]string{path, "--format", "json"}, excludeArgs(ctx, "--exclude-path", relPath)...)
+	output, err := runTool(ctx, "", "tfsec", args...)
+	if err := toolError("tfsec", output, err); err != nil {
 		return nil, err
 	}
 
-	var findings []Finding
-	for _, result := range trivyResults.Results {
-		for _, vuln := range result.Vulnerabilities {
-			cvss := 0.0
-			if cvssData, ok := vuln.CVSS["nvd"]; ok {
-				cvss = cvssData.V3Score
-			}
-			findings = append(findings, Finding{
-				ID:          vuln.VulnerabilityID,
-				Type:        "DEPENDENCY",
-				Severity:    mapSeverity(vuln.Severity),
-				Title:       vuln.Title,
-				Description: vuln.Description,
-				File:        result.Target,
-				Tool:        "trivy",
-				CVSS:        cvss,
-				Fixable:     true,
-			})
-		}
+	var tfsecResults struct {
+		Results []struct {
+			RuleID      string `json:"rule_id"`
+			Severity    string `json:"severity"`
+			Description string `json:"description"`
+			Location    struct {
+				Filename  string `json:"filename"`
+				StartLine int    `json:"start_line"`
+			} `json:"location"`
+		} `json:"results"`
+	}
+	if err := decodeToolOutput("tfsec", output, &tfsecResults); err != nil {
+		return nil, err
+	}
+	for _, r := range tfsecResults.Results {
+		findings = append(findings, Finding{
+			ID:          r.RuleID,
+			Type:        "IAC",
+			Severity:    mapSeverity(r.Severity),
+			Title:       r.RuleID,
+			Description: r.Description,
+			File:        r.Location.Filename,
+			Line:        r.Location.StartLine,
+			Tool:        "tfsec",
+			Fixable:     true,
+		})
 	}
 
 	return findings, nil
@@ -419,16 +507,3 @@ func mapSeverity(s string) Severity {
 		return SeverityInfo
 	}
 }
-
-// deduplicateFindings removes duplicate findings based on ID
-func deduplicateFindings(findings []Finding) []Finding {
-	seen := make(map[string]bool)
-	var unique []Finding
-	for _, finding := range findings {
-		if !seen[finding.ID] {
-			seen[finding.ID] = true
-			unique = append(unique, finding)
-		}
-	}
-	return unique
-}
@@ -0,0 +1,284 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SuppressionStatus is the state of an inline suppression
+type SuppressionStatus string
+
+const (
+	// SuppressionActive suppresses at least one finding
+	SuppressionActive SuppressionStatus = "active"
+	// SuppressionUnused matches no finding
+	SuppressionUnused SuppressionStatus = "unused"
+	// SuppressionExpired is past its until date; its findings are reported again
+	SuppressionExpired SuppressionStatus = "expired"
+	// SuppressionInvalid is missing its reason or has a malformed date
+	SuppressionInvalid SuppressionStatus = "invalid"
+)
+
+// suppressionMarker starts an inline suppression comment
+const suppressionMarker = "dso:ignore"
+
+// Suppression is a `dso:ignore <rule> reason="..." [until=YYYY-MM-DD]`
+// comment. It applies to the findings of its rule on the line of the
+// comment, or on the next code line when the comment is alone on its line.
+type Suppression struct {
+	File       string            `json:"file"`
+	Line       int               `json:"line"`        // Line of the comment
+	TargetLine int               `json:"target_line"` // Line of the suppressed findings
+	Rule       string            `json:"rule"`        // Rule ID, CVE or other finding identifier
+	Reason     string            `json:"reason,omitempty"`
+	Until      string            `json:"until,omitempty"` // Last day of the suppression (YYYY-MM-DD)
+	Status     SuppressionStatus `json:"status"`
+	Error      string            `json:"error,omitempty"` // Why an invalid suppression is ignored
+	Findings   int               `json:"findings"`        // Number of findings matched
+}
+
+// commentStyle lists the comment prefixes of a language
+type commentStyle []string
+
+var (
+	cStyle     = commentStyle{"//", "/*"}
+	hashStyle  = commentStyle{"#"}
+	hclStyle   = commentStyle{"#", "//", "/*"}
+	styleByExt = map[string]commentStyle{
+		".go":     cStyle,
+		".js":     cStyle,
+		".jsx":    cStyle,
+		".mjs":    cStyle,
+		".cjs":    cStyle,
+		".ts":     cStyle,
+		".tsx":    cStyle,
+		".java":   cStyle,
+		".py":     hashStyle,
+		".rb":     hashStyle,
+		".yml":    hashStyle,
+		".yaml":   hashStyle,
+		".tf":     hclStyle,
+		".hcl":    hclStyle,
+		".tfvars": hclStyle,
+	}
+)
+
+// commentStyleOf returns the comment syntax of a file, or nil if inline
+// suppressions are not supported in it
+func commentStyleOf(name string) commentStyle {
+	base := path.Base(name)
+	switch {
+	case isDockerfile(base):
+		return hashStyle
+	case base == "Gemfile" || (strings.HasPrefix(base, "requirements") && strings.HasSuffix(base, ".txt")):
+		return hashStyle
+	}
+	return styleByExt[strings.ToLower(path.Ext(base))]
+}
+
+// suppressionAttrRegex matches key=value and key="quoted value" attributes
+var suppressionAttrRegex = regexp.MustCompile(`(\w+)=("(?:[^"\\]|\\.)*"|\S+)`)
+
+// parseSuppressions returns the suppressions of a file
+func parseSuppressions(rel string, data []byte, style commentStyle) []Suppression {
+	var sups []Suppression
+	var pending []int // indexes of suppressions waiting for their code line
+
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		lineNum := i + 1
+		trimmed := strings.TrimSpace(line)
+
+		comment, standalone, found := findSuppressionComment(line, style)
+		if !found {
+			if trimmed != "" && !isComment(trimmed, style) {
+				for _, j := range pending {
+					sups[j].TargetLine = lineNum
+				}
+				pending = pending[:0]
+			}
+			continue
+		}
+
+		sup := parseSuppression(comment)
+		sup.File = rel
+		sup.Line = lineNum
+		sup.TargetLine = lineNum
+		sups = append(sups, sup)
+		if standalone {
+			pending = append(pending, len(sups)-1)
+		}
+	}
+	return sups
+}
+
+// findSuppressionComment returns the text following the marker in a comment
+// of line, and whether the comment is alone on its line
+func findSuppressionComment(line string, style commentStyle) (text string, standalone, found bool) {
+	idx := strings.Index(line, suppressionMarker)
+	if idx < 0 {
+		return "", false, false
+	}
+	for _, prefix := range style {
+		start := strings.LastIndex(line[:idx], prefix)
+		if start < 0 || strings.TrimSpace(line[start+len(prefix):idx]) != "" {
+			continue
+		}
+		text = line[idx+len(suppressionMarker):]
+		if prefix == "/*" {
+			text, _, _ = strings.Cut(text, "*/")
+		}
+		return text, strings.TrimSpace(line[:start]) == "", true
+	}
+	return "", false, false
+}
+
+// isComment reports whether a trimmed line is only a comment
+func isComment(trimmed string, style commentStyle) bool {
+	for _, prefix := range style {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return strings.HasPrefix(trimmed, "*") && len(style) > 1 // inside a block comment
+}
+
+// parseSuppression parses `<rule> reason="..." until=YYYY-MM-DD`
+func parseSuppression(text string) Suppression {
+	var sup Suppression
+	fields := strings.Fields(text)
+	if len(fields) == 0 || strings.Contains(fields[0], "=") {
+		sup.Status = SuppressionInvalid
+		sup.Error = "missing rule or CVE"
+		return sup
+	}
+	sup.Rule = fields[0]
+	rest := strings.TrimSpace(text)[len(fields[0]):]
+
+	for _, m := range suppressionAttrRegex.FindAllStringSubmatch(rest, -1) {
+		value := m[2]
+		if strings.HasPrefix(value, `"`) {
+			value = strings.ReplaceAll(value[1:len(value)-1], `\"`, `"`)
+		}
+		switch strings.ToLower(m[1]) {
+		case "reason":
+			sup.Reason = strings.TrimSpace(value)
+		case "until":
+			sup.Until = value
+		}
+	}
+
+	switch {
+	case sup.Reason == "":
+		sup.Status = SuppressionInvalid
+		sup.Error = `missing reason="..."`
+	case sup.Until != "":
+		if _, err := time.Parse(time.DateOnly, sup.Until); err != nil {
+			sup.Status = SuppressionInvalid
+			sup.Error = fmt.Sprintf("invalid until date %q, expected YYYY-MM-DD", sup.Until)
+		}
+	}
+	return sup
+}
+
+// CollectSuppressions reads the inline suppressions of the files under root
+func CollectSuppressions(ctx context.Context, root string) ([]Suppression, error) {
+	var sups []Suppression
+	err := walkFiles(ctx, root, func(p, rel string, info fs.FileInfo) error {
+		style := commentStyleOf(rel)
+		if style == nil {
+			return nil
+		}
+		data := readTextFile(p, info)
+		if !bytes.Contains(data, []byte(suppressionMarker)) {
+			return nil
+		}
+		sups = append(sups, parseSuppressions(rel, data, style)...)
+		return nil
+	})
+	return sups, err
+}
+
+// expired reports whether the until date of a suppression is over at now
+func (s *Suppression) expired(now time.Time) bool {
+	if s.Until == "" {
+		return false
+	}
+	until, err := time.ParseInLocation(time.DateOnly, s.Until, now.Location())
+	if err != nil {
+		return false
+	}
+	return !now.Before(until.AddDate(0, 0, 1))
+}
+
+// matches reports whether a suppression applies to a finding. Findings
+// without a line, like vulnerable dependencies, match a suppression
+// anywhere in their file.
+func (s *Suppression) matches(f *Finding) bool {
+	// A comment of the working tree cannot mark a line of a past commit
+	if f.HistoryOnly() || path.Clean(f.File) != s.File {
+		return false
+	}
+	if f.Line > 0 && f.Line != s.TargetLine && f.Line != s.Line {
+		return false
+	}
+	ids := append([]string{f.RuleID, f.ID}, f.Aliases...)
+	for _, id := range ids {
+		if id != "" && strings.EqualFold(id, s.Rule) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplySuppressions marks the findings matched by a valid suppression as
+// suppressed and sets the status of each suppression. Findings matched
+// only by expired suppressions stay active. File paths must be relative to
+// the scanned directory, as returned by Correlate.
+func ApplySuppressions(findings []Finding, sups []Suppression, now time.Time) {
+	for i := range sups {
+		s := &sups[i]
+		if s.Status == SuppressionInvalid {
+			continue
+		}
+		for j := range findings {
+			if s.matches(&findings[j]) {
+				s.Findings++
+			}
+		}
+		switch {
+		case s.expired(now):
+			s.Status = SuppressionExpired
+		case s.Findings > 0:
+			s.Status = SuppressionActive
+		default:
+			s.Status = SuppressionUnused
+		}
+	}
+
+	for i := range sups {
+		if sups[i].Status != SuppressionActive {
+			continue
+		}
+		for j := range findings {
+			if f := &findings[j]; f.Suppression == nil && sups[i].matches(f) {
+				sup := sups[i]
+				f.Suppression = &sup
+			}
+		}
+	}
+
+	sort.SliceStable(sups, func(i, j int) bool {
+		if sups[i].File != sups[j].File {
+			return sups[i].File < sups[j].File
+		}
+		return sups[i].Line < sups[j].Line
+	})
+}
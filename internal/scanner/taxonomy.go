@@ -0,0 +1,218 @@
+package scanner
+
+// Offline classification tables: CWE names, the CWE lists of the OWASP Top
+// 10 2021 categories, the CWE of the ASVS 4.0.3 requirements and the CWE of
+// well-known rules whose tool output does not carry one.
+
+// cweNames are the short names of the CWE used by the tables below
+var cweNames = map[string]string{
+	"CWE-20":   "Improper Input Validation",
+	"CWE-22":   "Path Traversal",
+	"CWE-78":   "OS Command Injection",
+	"CWE-79":   "Cross-site Scripting",
+	"CWE-80":   "Basic XSS",
+	"CWE-88":   "Argument Injection",
+	"CWE-89":   "SQL Injection",
+	"CWE-90":   "LDAP Injection",
+	"CWE-94":   "Code Injection",
+	"CWE-95":   "Eval Injection",
+	"CWE-118":  "Incorrect Access of Indexable Resource",
+	"CWE-190":  "Integer Overflow or Wraparound",
+	"CWE-200":  "Exposure of Sensitive Information",
+	"CWE-209":  "Error Message Containing Sensitive Information",
+	"CWE-242":  "Use of Inherently Dangerous Function",
+	"CWE-250":  "Execution with Unnecessary Privileges",
+	"CWE-259":  "Use of Hard-coded Password",
+	"CWE-269":  "Improper Privilege Management",
+	"CWE-276":  "Incorrect Default Permissions",
+	"CWE-284":  "Improper Access Control",
+	"CWE-295":  "Improper Certificate Validation",
+	"CWE-310":  "Cryptographic Issues",
+	"CWE-311":  "Missing Encryption of Sensitive Data",
+	"CWE-312":  "Cleartext Storage of Sensitive Information",
+	"CWE-319":  "Cleartext Transmission of Sensitive Information",
+	"CWE-322":  "Key Exchange without Entity Authentication",
+	"CWE-326":  "Inadequate Encryption Strength",
+	"CWE-327":  "Use of a Broken or Risky Cryptographic Algorithm",
+	"CWE-328":  "Use of Weak Hash",
+	"CWE-330":  "Use of Insufficiently Random Values",
+	"CWE-338":  "Use of Cryptographically Weak PRNG",
+	"CWE-346":  "Origin Validation Error",
+	"CWE-352":  "Cross-Site Request Forgery",
+	"CWE-377":  "Insecure Temporary File",
+	"CWE-400":  "Uncontrolled Resource Consumption",
+	"CWE-409":  "Improper Handling of Highly Compressed Data",
+	"CWE-494":  "Download of Code Without Integrity Check",
+	"CWE-502":  "Deserialization of Untrusted Data",
+	"CWE-532":  "Insertion of Sensitive Information into Log File",
+	"CWE-538":  "Insertion of Sensitive Information into Externally-Accessible File",
+	"CWE-601":  "Open Redirect",
+	"CWE-605":  "Multiple Binds to the Same Port",
+	"CWE-611":  "XML External Entity Reference",
+	"CWE-614":  "Sensitive Cookie Without 'Secure' Attribute",
+	"CWE-643":  "XPath Injection",
+	"CWE-668":  "Exposure of Resource to Wrong Sphere",
+	"CWE-676":  "Use of Potentially Dangerous Function",
+	"CWE-703":  "Improper Handling of Exceptional Conditions",
+	"CWE-732":  "Incorrect Permission Assignment for Critical Resource",
+	"CWE-778":  "Insufficient Logging",
+	"CWE-798":  "Use of Hard-coded Credentials",
+	"CWE-829":  "Inclusion of Functionality from Untrusted Control Sphere",
+	"CWE-915":  "Improperly Controlled Modification of Object Attributes",
+	"CWE-918":  "Server-Side Request Forgery",
+	"CWE-1004": "Sensitive Cookie Without 'HttpOnly' Flag",
+	"CWE-1104": "Use of Unmaintained Third Party Components",
+	"CWE-1357": "Reliance on Insufficiently Trustworthy Component",
+}
+
+// owaspCategories are the OWASP Top 10 2021 categories
+var owaspCategories = map[string]string{
+	"A01:2021": "A01:2021-Broken Access Control",
+	"A02:2021": "A02:2021-Cryptographic Failures",
+	"A03:2021": "A03:2021-Injection",
+	"A04:2021": "A04:2021-Insecure Design",
+	"A05:2021": "A05:2021-Security Misconfiguration",
+	"A06:2021": "A06:2021-Vulnerable and Outdated Components",
+	"A07:2021": "A07:2021-Identification and Authentication Failures",
+	"A08:2021": "A08:2021-Software and Data Integrity Failures",
+	"A09:2021": "A09:2021-Security Logging and Monitoring Failures",
+	"A10:2021": "A10:2021-Server-Side Request Forgery",
+}
+
+// owaspCWE lists the CWE mapped to each OWASP Top 10 2021 category
+var owaspCWE = map[string][]int{
+	"A01:2021": {22, 23, 35, 59, 200, 201, 219, 264, 275, 276, 284, 285, 352, 359, 377, 402, 425, 441, 497, 538, 540, 548, 552, 566, 601, 639, 651, 668, 706, 862, 863, 913, 922, 1275},
+	"A02:2021": {259, 261, 296, 310, 319, 321, 322, 323, 324, 325, 326, 327, 328, 329, 330, 331, 335, 336, 337, 338, 340, 347, 523, 720, 757, 759, 760, 780, 818, 916},
+	"A03:2021": {20, 74, 75, 77, 78, 79, 80, 83, 87, 88, 89, 90, 91, 93, 94, 95, 96, 97, 98, 99, 100, 113, 116, 138, 184, 470, 471, 564, 610, 643, 644, 652, 917},
+	"A04:2021": {73, 183, 209, 213, 235, 256, 257, 266, 269, 280, 311, 312, 313, 316, 419, 430, 434, 444, 451, 472, 501, 522, 525, 539, 579, 598, 602, 642, 646, 650, 653, 656, 657, 799, 807, 840, 841, 927, 1021, 1173},
+	"A05:2021": {2, 11, 13, 15, 16, 260, 315, 520, 526, 537, 541, 547, 611, 614, 756, 776, 942, 1004, 1032, 1174},
+	"A06:2021": {937, 1035, 1104},
+	"A07:2021": {255, 259, 287, 288, 290, 294, 295, 297, 300, 302, 304, 306, 307, 346, 384, 521, 613, 620, 640, 798, 940, 1216},
+	"A08:2021": {345, 353, 426, 494, 502, 565, 784, 829, 830, 915},
+	"A09:2021": {117, 223, 532, 778},
+	"A10:2021": {918},
+}
+
+// asvsCWE maps CWE to the ASVS 4.0.3 requirements that prevent them
+var asvsCWE = map[string][]string{
+	"CWE-22":   {"V12.3.1"},
+	"CWE-78":   {"V5.3.8"},
+	"CWE-79":   {"V5.3.3"},
+	"CWE-80":   {"V5.3.3"},
+	"CWE-89":   {"V5.3.4"},
+	"CWE-90":   {"V5.3.7"},
+	"CWE-94":   {"V5.2.5"},
+	"CWE-95":   {"V5.2.4"},
+	"CWE-209":  {"V7.4.1"},
+	"CWE-250":  {"V4.1.3"},
+	"CWE-259":  {"V2.10.4"},
+	"CWE-269":  {"V4.1.3"},
+	"CWE-284":  {"V4.1.3"},
+	"CWE-295":  {"V9.2.1"},
+	"CWE-311":  {"V6.1.1"},
+	"CWE-319":  {"V9.1.1"},
+	"CWE-326":  {"V6.2.5"},
+	"CWE-327":  {"V6.2.2"},
+	"CWE-330":  {"V6.3.1"},
+	"CWE-338":  {"V6.3.1"},
+	"CWE-346":  {"V14.5.3"},
+	"CWE-352":  {"V4.2.2"},
+	"CWE-400":  {"V12.1.1"},
+	"CWE-502":  {"V5.5.3"},
+	"CWE-532":  {"V7.1.1"},
+	"CWE-601":  {"V5.1.5"},
+	"CWE-611":  {"V5.5.2"},
+	"CWE-614":  {"V3.4.1"},
+	"CWE-643":  {"V5.3.10"},
+	"CWE-778":  {"V7.1.3"},
+	"CWE-798":  {"V2.10.4"},
+	"CWE-829":  {"V5.3.9"},
+	"CWE-918":  {"V5.2.6"},
+	"CWE-1004": {"V3.4.2"},
+	"CWE-1104": {"V14.2.1"},
+}
+
+// ruleCWE maps the rules of tools that do not report CWE, or of older
+// versions of them, to their CWE
+var ruleCWE = map[string]string{
+	// gosec
+	"G101": "CWE-798", "G102": "CWE-200", "G103": "CWE-242", "G104": "CWE-703",
+	"G106": "CWE-322", "G107": "CWE-88", "G108": "CWE-200", "G109": "CWE-190",
+	"G110": "CWE-409", "G111": "CWE-22", "G112": "CWE-400", "G114": "CWE-676",
+	"G201": "CWE-89", "G202": "CWE-89", "G203": "CWE-79", "G204": "CWE-78",
+	"G301": "CWE-276", "G302": "CWE-276", "G303": "CWE-377", "G304": "CWE-22",
+	"G305": "CWE-22", "G306": "CWE-276", "G307": "CWE-703", "G401": "CWE-328",
+	"G402": "CWE-295", "G403": "CWE-310", "G404": "CWE-338", "G501": "CWE-327",
+	"G502": "CWE-327", "G503": "CWE-327", "G504": "CWE-327", "G505": "CWE-327",
+	"G601": "CWE-118",
+	// bandit
+	"B102": "CWE-78", "B103": "CWE-732", "B104": "CWE-605", "B105": "CWE-259",
+	"B106": "CWE-259", "B107": "CWE-259", "B108": "CWE-377", "B201": "CWE-94",
+	"B301": "CWE-502", "B303": "CWE-327", "B304": "CWE-327", "B305": "CWE-327",
+	"B306": "CWE-377", "B307": "CWE-78", "B310": "CWE-22", "B311": "CWE-330",
+	"B312": "CWE-319", "B321": "CWE-319", "B323": "CWE-295", "B324": "CWE-327",
+	"B501": "CWE-295", "B502": "CWE-327", "B503": "CWE-327", "B504": "CWE-327",
+	"B505": "CWE-326", "B506": "CWE-20", "B507": "CWE-295", "B601": "CWE-78",
+	"B602": "CWE-78", "B603": "CWE-78", "B604": "CWE-78", "B605": "CWE-78",
+	"B606": "CWE-78", "B607": "CWE-78", "B608": "CWE-89", "B609": "CWE-78",
+	"B610": "CWE-89", "B611": "CWE-89", "B701": "CWE-94", "B702": "CWE-80",
+	"B703": "CWE-80",
+	// eslint-plugin-security and core rules
+	"security/detect-eval-with-expression":           "CWE-95",
+	"security/detect-child-process":                  "CWE-78",
+	"security/detect-non-literal-fs-filename":        "CWE-22",
+	"security/detect-non-literal-require":            "CWE-829",
+	"security/detect-object-injection":               "CWE-915",
+	"security/detect-pseudoRandomBytes":              "CWE-338",
+	"security/detect-unsafe-regex":                   "CWE-400",
+	"security/detect-non-literal-regexp":             "CWE-400",
+	"security/detect-possible-timing-attacks":        "CWE-208",
+	"security/detect-disable-mustache-escape":        "CWE-79",
+	"security/detect-no-csrf-before-method-override": "CWE-352",
+	"no-eval":         "CWE-95",
+	"no-implied-eval": "CWE-95",
+	// hadolint
+	"DL3002": "CWE-250", "DL3006": "CWE-1357", "DL3007": "CWE-1357",
+	// trivy
+	"DS002": "CWE-250", "KSV008": "CWE-668", "KSV009": "CWE-668", "KSV010": "CWE-668",
+	"KSV011": "CWE-400", "KSV012": "CWE-250", "KSV013": "CWE-1357", "KSV014": "CWE-732",
+	"KSV015": "CWE-400", "KSV016": "CWE-400", "KSV017": "CWE-250", "KSV018": "CWE-400",
+	"KSV023": "CWE-668",
+	// checkov
+	"CKV_AWS_1": "CWE-269", "CKV_AWS_16": "CWE-311", "CKV_AWS_18": "CWE-778",
+	"CKV_AWS_19": "CWE-311", "CKV_AWS_20": "CWE-284", "CKV_AWS_24": "CWE-284",
+	"CKV_AWS_25": "CWE-284", "CKV_AWS_57": "CWE-284", "CKV_DOCKER_3": "CWE-250",
+	"CKV_K8S_16": "CWE-250", "CKV_K8S_14": "CWE-1357",
+	// dso image
+	"image-root-user": "CWE-250", "image-exposed-port": "CWE-668",
+	// dso kubernetes
+	"k8s-privileged-container": "CWE-250", "k8s-run-as-root": "CWE-250", "k8s-dangerous-capability": "CWE-250",
+	"k8s-host-path": "CWE-668", "k8s-host-network": "CWE-668", "k8s-host-pid": "CWE-668",
+	"k8s-writable-root-fs": "CWE-732", "k8s-missing-limits": "CWE-400", "k8s-latest-image": "CWE-1357",
+	"k8s-secret-data": "CWE-312",
+	// dso dockerfile
+	"dockerfile-root-user": "CWE-250", "dockerfile-unpinned-base": "CWE-1357", "dockerfile-add-url": "CWE-494",
+	"dockerfile-curl-pipe-shell": "CWE-494", "dockerfile-secret-env": "CWE-798", "dockerfile-secret-arg": "CWE-798",
+	"dockerfile-copy-all": "CWE-538",
+	// dso ci
+	"ci-unpinned-action": "CWE-829", "ci-pull-request-target-checkout": "CWE-829", "ci-script-injection": "CWE-94",
+	"ci-broad-permissions": "CWE-250", "ci-curl-pipe-shell": "CWE-494", "ci-secret-echo": "CWE-532",
+}
+
+// keywordCWE classifies IaC and container findings without a known rule by
+// the words of their title, in order
+var keywordCWE = []struct {
+	keywords []string
+	cwe      string
+}{
+	{[]string{"privileged", "root user", "as root", "runasnonroot", "run as non-root", "capabilit"}, "CWE-250"},
+	{[]string{"hostpath", "host path", "hostnetwork", "host network", "hostpid", "host pid", "hostipc"}, "CWE-668"},
+	{[]string{"wildcard", "*:*", "administrator access", "admin privileges"}, "CWE-269"},
+	{[]string{"0.0.0.0/0", "::/0", "public", "unrestricted", "open to the world"}, "CWE-284"},
+	{[]string{"unencrypted", "encrypt"}, "CWE-311"},
+	{[]string{"https", "tls", "ssl", "plaintext", "cleartext"}, "CWE-319"},
+	{[]string{"logging", "audit log", "flow log"}, "CWE-778"},
+	{[]string{"latest tag", ":latest", "digest", "pinned"}, "CWE-1357"},
+	{[]string{"resource limit", "memory limit", "cpu limit", "limits"}, "CWE-400"},
+	{[]string{"secret", "password", "credential"}, "CWE-798"},
+}
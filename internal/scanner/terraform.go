@@ -0,0 +1,705 @@
+package scanner
+
+import (
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dso-cli/dso-cli/internal/hcl"
+	"github.com/dso-cli/dso-cli/internal/yaml"
+)
+
+func init() {
+	Register(terraformChecker{})
+}
+
+// builtinTerraformRules are the rules of the native Terraform checker
+//
+//go:embed rules/terraform.yaml
+var builtinTerraformRules []byte
+
+// terraformRulesDir holds the project rules of the native Terraform
+// checker, relative to the scanned directory
+const terraformRulesDir = ".dso/rules/terraform"
+
+// tfRule is a declarative rule of the Terraform checker
+type tfRule struct {
+	ID          string
+	Title       string
+	Severity    Severity
+	CWE         []string
+	Description string
+	Fix         string
+	// Resources are the resource types the rule applies to, data.TYPE
+	// for data sources
+	Resources []string
+	Match     *tfCondition
+	Disabled  bool
+}
+
+// tfCondition is a condition on the attributes of a resource. A leaf
+// condition tests the values at Attribute; the others combine conditions.
+type tfCondition struct {
+	// Attribute is a dotted path in the resource. Nested blocks and lists
+	// are traversed item by item, and JSON strings such as policies are
+	// decoded.
+	Attribute []string
+	Exists    *bool
+	Equals    *string
+	In        []string
+	Matches   *regexp.Regexp
+
+	All []*tfCondition
+	Any []*tfCondition
+	Not *tfCondition
+	// Each tests Where on every item at this path, such as the statements
+	// of a policy, so that the conditions apply to the same item
+	Each  []string
+	Where *tfCondition
+}
+
+// tfMatch is the result of a condition: values that are only known after
+// terraform apply can neither match nor fail to match
+type tfMatch int
+
+const (
+	tfNo tfMatch = iota
+	tfUnknown
+	tfYes
+)
+
+var (
+	builtinRulesOnce sync.Once
+	builtinRules     []tfRule
+	builtinRulesErr  error
+)
+
+// loadTerraformRules returns the built-in rules merged with the rules of
+// the project: a project rule replaces the built-in rule with the same id,
+// or disables it with disabled: true
+func loadTerraformRules(root string) ([]tfRule, error) {
+	builtinRulesOnce.Do(func() {
+		builtinRules, builtinRulesErr = parseTerraformRules(builtinTerraformRules, "built-in rules")
+	})
+	if builtinRulesErr != nil {
+		return nil, builtinRulesErr
+	}
+
+	rules := append([]tfRule(nil), builtinRules...)
+	index := make(map[string]int, len(rules))
+	for i, rule := range rules {
+		index[rule.ID] = i
+	}
+	files, err := projectRuleFiles(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		rel, _ := filepath.Rel(root, file)
+		projectRules, err := parseTerraformRules(data, filepath.ToSlash(rel))
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range projectRules {
+			if i, ok := index[rule.ID]; ok {
+				rules[i] = rule
+				continue
+			}
+			index[rule.ID] = len(rules)
+			rules = append(rules, rule)
+		}
+	}
+
+	enabled := rules[:0]
+	for _, rule := range rules {
+		if !rule.Disabled {
+			enabled = append(enabled, rule)
+		}
+	}
+	return enabled, nil
+}
+
+// projectRuleFiles returns the rule files of the project, sorted
+func projectRuleFiles(root string) ([]string, error) {
+	var files []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(root, filepath.FromSlash(terraformRulesDir), pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// parseTerraformRules parses a rule file
+func parseTerraformRules(data []byte, source string) ([]tfRule, error) {
+	docs, err := yaml.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	var rules []tfRule
+	for _, doc := range docs {
+		for _, node := range doc.Get("rules").Items() {
+			rule, err := parseTerraformRule(node)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", source, node.Line, err)
+			}
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func parseTerraformRule(node *yaml.Node) (tfRule, error) {
+	rule := tfRule{
+		ID:          node.Get("id").String(),
+		Title:       node.Get("title").String(),
+		Severity:    Severity(strings.ToUpper(node.Get("severity").String())),
+		Description: node.Get("description").String(),
+		Fix:         node.Get("fix").String(),
+		Disabled:    node.Get("disabled").Bool(),
+	}
+	if rule.ID == "" {
+		return rule, fmt.Errorf("rule without id")
+	}
+	if rule.Disabled {
+		return rule, nil
+	}
+	if rule.Title == "" {
+		return rule, fmt.Errorf("rule %s: missing title", rule.ID)
+	}
+	switch rule.Severity {
+	case SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo:
+	default:
+		return rule, fmt.Errorf("rule %s: invalid severity %q", rule.ID, node.Get("severity").String())
+	}
+	rule.CWE = stringList(node.Get("cwe"))
+	rule.Resources = stringList(node.Get("resources"))
+	if len(rule.Resources) == 0 {
+		return rule, fmt.Errorf("rule %s: missing resources", rule.ID)
+	}
+	match := node.Get("match")
+	if match == nil {
+		return rule, fmt.Errorf("rule %s: missing match", rule.ID)
+	}
+	cond, err := parseTerraformCondition(match)
+	if err != nil {
+		return rule, fmt.Errorf("rule %s: %w", rule.ID, err)
+	}
+	rule.Match = cond
+	return rule, nil
+}
+
+// stringList returns the values of a scalar or a sequence of scalars
+func stringList(node *yaml.Node) []string {
+	if node == nil || node.Null {
+		return nil
+	}
+	if node.Kind == yaml.ScalarNode {
+		return []string{node.Value}
+	}
+	var values []string
+	for _, item := range node.Items() {
+		values = append(values, item.String())
+	}
+	return values
+}
+
+func parseTerraformCondition(node *yaml.Node) (*tfCondition, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: a condition is a mapping", node.Line)
+	}
+	cond := &tfCondition{}
+	leaf := false
+	for _, entry := range node.Entries() {
+		key, value := entry.Key.Value, entry.Value
+		switch key {
+		case "attribute":
+			cond.Attribute = strings.Split(value.String(), ".")
+		case "exists":
+			b := !value.IsFalse()
+			cond.Exists, leaf = &b, true
+		case "equals":
+			s := value.String()
+			cond.Equals, leaf = &s, true
+		case "in":
+			cond.In, leaf = stringList(value), true
+		case "matches":
+			re, err := regexp.Compile(value.String())
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", value.Line, err)
+			}
+			cond.Matches, leaf = re, true
+		case "all", "any":
+			var conds []*tfCondition
+			for _, item := range value.Items() {
+				c, err := parseTerraformCondition(item)
+				if err != nil {
+					return nil, err
+				}
+				conds = append(conds, c)
+			}
+			if len(conds) == 0 {
+				return nil, fmt.Errorf("line %d: %s needs a list of conditions", value.Line, key)
+			}
+			if key == "all" {
+				cond.All = conds
+			} else {
+				cond.Any = conds
+			}
+		case "not", "where":
+			c, err := parseTerraformCondition(value)
+			if err != nil {
+				return nil, err
+			}
+			if key == "not" {
+				cond.Not = c
+			} else {
+				cond.Where = c
+			}
+		case "each":
+			cond.Each = strings.Split(value.String(), ".")
+		default:
+			return nil, fmt.Errorf("line %d: unknown condition %q", entry.Key.Line, key)
+		}
+	}
+
+	switch {
+	case cond.Attribute != nil && !leaf:
+		exists := true
+		cond.Exists = &exists
+	case cond.Attribute == nil && leaf:
+		return nil, fmt.Errorf("line %d: missing attribute", node.Line)
+	case (cond.Each == nil) != (cond.Where == nil):
+		return nil, fmt.Errorf("line %d: each and where go together", node.Line)
+	case cond.Attribute == nil && cond.Each == nil && cond.All == nil && cond.Any == nil && cond.Not == nil:
+		return nil, fmt.Errorf("line %d: empty condition", node.Line)
+	}
+	return cond, nil
+}
+
+// terraformChecker checks the Terraform configurations of the project with
+// declarative rules, without an external tool
+type terraformChecker struct{}
+
+func (terraformChecker) Name() string               { return "dso-terraform" }
+func (terraformChecker) Category() Category         { return CategoryIaC }
+func (terraformChecker) Applicable(p *Project) bool { return p.HasTerraform }
+
+// Scan evaluates the resources of every module of the project: the .tf
+// files of a directory, with the defaults of its variables, its tfvars
+// files and its locals. Files that cannot be parsed are skipped.
+func (terraformChecker) Scan(ctx context.Context, root string) ([]Finding, error) {
+	rules, err := loadTerraformRules(root)
+	if err != nil {
+		return nil, err
+	}
+
+	// Variables and locals can be declared in files that did not change,
+	// so modules are read whole during an incremental scan too; the
+	// findings of unchanged files are dropped afterwards
+	modules := make(map[string]*tfModule)
+	err = walkFiles(withFileFilter(ctx, nil), root, func(p, rel string, info fs.FileInfo) error {
+		name := info.Name()
+		isVars := name == "terraform.tfvars" || strings.HasSuffix(name, ".auto.tfvars")
+		if !isVars && path.Ext(name) != ".tf" {
+			return nil
+		}
+		data := readTextFile(p, info)
+		if data == nil {
+			return nil
+		}
+		body, err := hcl.Parse(data)
+		if err != nil {
+			return nil
+		}
+		dir := path.Dir(rel)
+		m := modules[dir]
+		if m == nil {
+			m = &tfModule{}
+			modules[dir] = m
+		}
+		if isVars {
+			m.tfvars = append(m.tfvars, tfFile{rel: rel, body: body})
+		} else {
+			m.files = append(m.files, tfFile{rel: rel, body: body})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := make([]string, 0, len(modules))
+	for dir := range modules {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	now := time.Now()
+	var findings []Finding
+	for _, dir := range dirs {
+		findings = append(findings, modules[dir].check(rules, now)...)
+	}
+	return findings, nil
+}
+
+// ProjectRuleSet identifies the rule files of the project
+func (terraformChecker) ProjectRuleSet(root string) string {
+	files, err := projectRuleFiles(root)
+	if err != nil || len(files) == 0 {
+		return ""
+	}
+	h := sha256.New()
+	for _, file := range files {
+		data, _ := os.ReadFile(file)
+		fmt.Fprintf(h, "%s\x00%d\x00", filepath.Base(file), len(data))
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// tfModule is a Terraform module: the files of a directory
+type tfModule struct {
+	files  []tfFile
+	tfvars []tfFile
+
+	ctx       *hcl.Context
+	locals    map[string]*hcl.Attribute
+	values    map[string]hcl.Value
+	resolving map[string]bool
+}
+
+// tfFile is a parsed .tf file
+type tfFile struct {
+	rel  string
+	body *hcl.Body
+}
+
+// check evaluates the rules on the resources and data sources of the module
+func (m *tfModule) check(rules []tfRule, now time.Time) []Finding {
+	m.evalContext()
+
+	var findings []Finding
+	for _, file := range m.files {
+		for _, block := range file.body.Blocks {
+			if (block.Type != "resource" && block.Type != "data") || len(block.Labels) != 2 {
+				continue
+			}
+			kind := block.Labels[0]
+			if block.Type == "data" {
+				kind = "data." + kind
+			}
+			var resource *hcl.Value
+			for _, rule := range rules {
+				if !containsString(rule.Resources, kind) {
+					continue
+				}
+				if resource == nil {
+					v := m.blockValue(block.Body, block.Line)
+					resource = &v
+				}
+				match, line := rule.Match.eval(*resource)
+				if match != tfYes {
+					continue
+				}
+				if line == 0 {
+					line = block.Line
+				}
+				findings = append(findings, tfFinding(rule, file.rel, line, kind+"."+block.Labels[1], now))
+			}
+		}
+	}
+	return findings
+}
+
+// evalContext prepares the values of var and local: the variable defaults
+// overridden by the tfvars files, and the locals evaluated on first use
+func (m *tfModule) evalContext() {
+	vars := hcl.ObjectValue(0)
+	m.locals = make(map[string]*hcl.Attribute)
+	for _, file := range m.files {
+		for _, block := range file.body.Blocks {
+			switch {
+			case block.Type == "variable" && len(block.Labels) == 1:
+				if def := block.Body.Attribute("default"); def != nil {
+					vars.Set(block.Labels[0], hcl.Eval(def.Expr, nil))
+				}
+			case block.Type == "locals":
+				for _, a := range block.Body.Attributes {
+					m.locals[a.Name] = a
+				}
+			}
+		}
+	}
+	// terraform.tfvars is loaded first, then the *.auto.tfvars files in
+	// lexical order, each overriding the previous ones
+	sort.Slice(m.tfvars, func(i, j int) bool {
+		a, b := path.Base(m.tfvars[i].rel), path.Base(m.tfvars[j].rel)
+		if (a == "terraform.tfvars") != (b == "terraform.tfvars") {
+			return a == "terraform.tfvars"
+		}
+		return a < b
+	})
+	for _, file := range m.tfvars {
+		for _, a := range file.body.Attributes {
+			vars.Set(a.Name, hcl.Eval(a.Expr, nil))
+		}
+	}
+
+	m.values = make(map[string]hcl.Value)
+	m.resolving = make(map[string]bool)
+	m.ctx = &hcl.Context{
+		Variables: map[string]hcl.Value{"var": vars},
+		Resolve:   m.resolve,
+	}
+}
+
+// resolve evaluates a local value, once
+func (m *tfModule) resolve(name, attr string) (hcl.Value, bool) {
+	if name != "local" {
+		return hcl.Value{}, false
+	}
+	if v, ok := m.values[attr]; ok {
+		return v, true
+	}
+	a := m.locals[attr]
+	if a == nil || m.resolving[attr] {
+		return hcl.Unknown(0), true
+	}
+	m.resolving[attr] = true
+	v := hcl.Eval(a.Expr, m.ctx)
+	delete(m.resolving, attr)
+	m.values[attr] = v
+	return v, true
+}
+
+// blockValue evaluates the attributes of a block body. Nested blocks are
+// lists of objects named after their type, with the content of dynamic
+// blocks.
+func (m *tfModule) blockValue(body *hcl.Body, line int) hcl.Value {
+	v := hcl.ObjectValue(line)
+	for _, a := range body.Attributes {
+		v.Set(a.Name, relocate(hcl.Eval(a.Expr, m.ctx), a.Line, a.EndLine))
+	}
+	for _, block := range body.Blocks {
+		kind, inner := block.Type, block.Body
+		if kind == "dynamic" && len(block.Labels) == 1 {
+			kind, inner = block.Labels[0], nil
+			for _, content := range block.Body.Blocks {
+				if content.Type == "content" {
+					inner = content.Body
+				}
+			}
+			if inner == nil {
+				continue
+			}
+		}
+		list := v.Attr(kind)
+		if list.Kind != hcl.ListKind {
+			list = hcl.ListValue(nil, block.Line)
+		}
+		list.Items = append(list.Items, m.blockValue(inner, block.Line))
+		v.Set(kind, list)
+	}
+	return v
+}
+
+// relocate moves the values declared outside of the attribute, such as
+// variable defaults, to the line of the attribute that uses them
+func relocate(v hcl.Value, line, endLine int) hcl.Value {
+	if v.Line < line || v.Line > endLine {
+		v.Line = line
+	}
+	if v.Items != nil {
+		items := make([]hcl.Value, len(v.Items))
+		for i, item := range v.Items {
+			items[i] = relocate(item, line, endLine)
+		}
+		v.Items = items
+	}
+	if v.Attrs != nil {
+		attrs := make(map[string]hcl.Value, len(v.Attrs))
+		for k, item := range v.Attrs {
+			attrs[k] = relocate(item, line, endLine)
+		}
+		v.Attrs = attrs
+	}
+	return v
+}
+
+// eval evaluates the condition on v. line is the line of the value that
+// matched, or 0 when the match is the absence of a value.
+func (c *tfCondition) eval(v hcl.Value) (tfMatch, int) {
+	switch {
+	case c.Not != nil:
+		switch match, _ := c.Not.eval(v); match {
+		case tfYes:
+			return tfNo, 0
+		case tfNo:
+			return tfYes, 0
+		}
+		return tfUnknown, 0
+	case c.All != nil:
+		result, line := tfYes, 0
+		for _, cond := range c.All {
+			match, l := cond.eval(v)
+			if match == tfNo {
+				return tfNo, 0
+			}
+			if match == tfUnknown {
+				result = tfUnknown
+			}
+			if line == 0 {
+				line = l
+			}
+		}
+		return result, line
+	case c.Any != nil:
+		result := tfNo
+		for _, cond := range c.Any {
+			match, line := cond.eval(v)
+			if match == tfYes {
+				return tfYes, line
+			}
+			if match == tfUnknown {
+				result = tfUnknown
+			}
+		}
+		return result, 0
+	case c.Each != nil:
+		items, unknown := tfLookup(v, c.Each)
+		result := tfNo
+		if unknown {
+			result = tfUnknown
+		}
+		for _, item := range items {
+			match, line := c.Where.eval(item)
+			if match == tfYes {
+				if line == 0 {
+					line = item.Line
+				}
+				return tfYes, line
+			}
+			if match == tfUnknown {
+				result = tfUnknown
+			}
+		}
+		return result, 0
+	}
+
+	values, unknown := tfLookup(v, c.Attribute)
+	if c.Exists != nil {
+		switch {
+		case len(values) > 0 && *c.Exists:
+			return tfYes, values[0].Line
+		case len(values) > 0:
+			return tfNo, 0
+		case unknown:
+			return tfUnknown, 0
+		case *c.Exists:
+			return tfNo, 0
+		}
+		return tfYes, 0
+	}
+	for _, value := range values {
+		s, ok := value.AsString()
+		if !ok {
+			continue
+		}
+		if c.Equals != nil && strings.EqualFold(s, *c.Equals) ||
+			c.Matches != nil && c.Matches.MatchString(s) {
+			return tfYes, value.Line
+		}
+		for _, candidate := range c.In {
+			if strings.EqualFold(s, candidate) {
+				return tfYes, value.Line
+			}
+		}
+	}
+	if unknown {
+		return tfUnknown, 0
+	}
+	return tfNo, 0
+}
+
+// tfLookup returns the values at a path of v. Lists are flattened, and JSON
+// strings are decoded to look up their keys. unknown is true when a value
+// on the path is only known after terraform apply.
+func tfLookup(v hcl.Value, keys []string) (values []hcl.Value, unknown bool) {
+	switch v.Kind {
+	case hcl.UnknownKind:
+		return nil, true
+	case hcl.NullKind:
+		return nil, false
+	case hcl.ListKind:
+		for _, item := range v.Items {
+			itemValues, itemUnknown := tfLookup(item, keys)
+			values = append(values, itemValues...)
+			unknown = unknown || itemUnknown
+		}
+		return values, unknown
+	}
+	if len(keys) == 0 {
+		return []hcl.Value{v}, false
+	}
+	if v.Kind == hcl.StringKind {
+		decoded, ok := hcl.ParseJSON(v.Str, v.Line)
+		if !ok {
+			return nil, false
+		}
+		v = decoded
+	}
+	if v.Kind != hcl.ObjectKind {
+		return nil, false
+	}
+	attr, ok := v.Attrs[keys[0]]
+	if !ok {
+		// IAM policy keys are case-insensitive
+		for _, key := range v.Keys {
+			if strings.EqualFold(key, keys[0]) {
+				attr, ok = v.Attrs[key], true
+				break
+			}
+		}
+	}
+	if !ok {
+		return nil, false
+	}
+	return tfLookup(attr, keys[1:])
+}
+
+// tfFinding returns the finding of a rule on a resource
+func tfFinding(rule tfRule, file string, line int, address string, now time.Time) Finding {
+	return Finding{
+		ID:          fmt.Sprintf("dso-%s-%s-%d", rule.ID, file, line),
+		Type:        "IAC",
+		Severity:    rule.Severity,
+		Title:       fmt.Sprintf("%s (%s)", rule.Title, address),
+		Description: rule.Description,
+		File:        file,
+		Line:        line,
+		RuleID:      rule.ID,
+		Tool:        "dso-terraform",
+		Fixable:     rule.Fix != "",
+		Fix:         rule.Fix,
+		CWE:         rule.CWE,
+		Timestamp:   now,
+	}
+}
@@ -0,0 +1,627 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// scanTerraform writes files to a temporary project and runs the native
+// Terraform checker on it
+func scanTerraform(t *testing.T, files map[string]string) []Finding {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	findings, err := terraformChecker{}.Scan(context.Background(), root)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	return findings
+}
+
+// findingsOf returns the findings of a rule
+func findingsOf(findings []Finding, ruleID string) []Finding {
+	var matched []Finding
+	for _, f := range findings {
+		if f.RuleID == ruleID {
+			matched = append(matched, f)
+		}
+	}
+	return matched
+}
+
+// terraformRuleCases are a resource that each built-in rule reports, and
+// the same resource configured safely
+var terraformRuleCases = []struct {
+	rule     string
+	positive string
+	negative string
+}{
+	{
+		"aws-s3-public-acl",
+		`resource "aws_s3_bucket" "b" { acl = "public-read" }`,
+		`resource "aws_s3_bucket" "b" { acl = "private" }`,
+	},
+	{
+		"aws-s3-public-access-block-disabled",
+		`resource "aws_s3_bucket_public_access_block" "b" {
+  block_public_acls       = true
+  block_public_policy     = false
+  ignore_public_acls      = true
+  restrict_public_buckets = true
+}`,
+		`resource "aws_s3_bucket_public_access_block" "b" {
+  block_public_acls       = true
+  block_public_policy     = true
+  ignore_public_acls      = true
+  restrict_public_buckets = true
+}`,
+	},
+	{
+		"aws-s3-public-policy",
+		`resource "aws_s3_bucket_policy" "b" {
+  policy = jsonencode({
+    Statement = [{ Effect = "Allow", Principal = "*", Action = "s3:GetObject" }]
+  })
+}`,
+		`resource "aws_s3_bucket_policy" "b" {
+  policy = jsonencode({
+    Statement = [
+      { Effect = "Allow", Principal = { AWS = "arn:aws:iam::123456789012:root" }, Action = "s3:GetObject" },
+      { Effect = "Allow", Principal = "*", Action = "s3:GetObject", Condition = { IpAddress = { "aws:SourceIp" = "10.0.0.0/8" } } },
+      { Effect = "Deny", Principal = "*", Action = "s3:*" },
+    ]
+  })
+}`,
+	},
+	{
+		"aws-ebs-unencrypted",
+		`resource "aws_ebs_volume" "v" { size = 10 }`,
+		`resource "aws_ebs_volume" "v" {
+  size      = 10
+  encrypted = true
+}`,
+	},
+	{
+		"aws-rds-unencrypted",
+		`resource "aws_db_instance" "db" { engine = "postgres" }`,
+		`resource "aws_db_instance" "db" {
+  engine            = "postgres"
+  storage_encrypted = true
+}`,
+	},
+	{
+		"aws-rds-public",
+		`resource "aws_db_instance" "db" { publicly_accessible = true }`,
+		`resource "aws_db_instance" "db" { publicly_accessible = false }`,
+	},
+	{
+		"aws-efs-unencrypted",
+		`resource "aws_efs_file_system" "fs" {}`,
+		`resource "aws_efs_file_system" "fs" { encrypted = true }`,
+	},
+	{
+		"aws-security-group-open-ingress",
+		`resource "aws_security_group" "sg" {
+  ingress {
+    from_port   = 22
+    to_port     = 22
+    protocol    = "tcp"
+    cidr_blocks = ["10.0.0.0/8", "0.0.0.0/0"]
+  }
+}`,
+		`resource "aws_security_group" "sg" {
+  ingress {
+    from_port   = 22
+    to_port     = 22
+    protocol    = "tcp"
+    cidr_blocks = ["10.0.0.0/8"]
+  }
+  egress {
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+}`,
+	},
+	{
+		"aws-security-group-rule-open-ingress",
+		`resource "aws_security_group_rule" "r" {
+  type        = "ingress"
+  cidr_blocks = ["0.0.0.0/0"]
+}`,
+		`resource "aws_security_group_rule" "r" {
+  type        = "egress"
+  cidr_blocks = ["0.0.0.0/0"]
+}`,
+	},
+	{
+		"aws-iam-wildcard-action",
+		`resource "aws_iam_policy" "p" {
+  policy = <<EOF
+{
+  "Version": "2012-10-17",
+  "Statement": [{"Effect": "Allow", "Action": ["s3:GetObject", "iam:*"], "Resource": "*"}]
+}
+EOF
+}`,
+		`resource "aws_iam_policy" "p" {
+  policy = jsonencode({
+    Statement = [
+      { Effect = "Allow", Action = ["s3:GetObject"], Resource = "*" },
+      { Effect = "Deny", Action = "*", Resource = "*" },
+    ]
+  })
+}`,
+	},
+	{
+		"aws-iam-policy-document-wildcard-action",
+		`data "aws_iam_policy_document" "d" {
+  statement {
+    actions   = ["*"]
+    resources = ["*"]
+  }
+}`,
+		`data "aws_iam_policy_document" "d" {
+  statement {
+    actions   = ["s3:GetObject"]
+    resources = ["*"]
+  }
+  statement {
+    effect  = "Deny"
+    actions = ["*"]
+  }
+}`,
+	},
+	{
+		"aws-cloudtrail-logging-disabled",
+		`resource "aws_cloudtrail" "t" { enable_logging = false }`,
+		`resource "aws_cloudtrail" "t" { name = "trail" }`,
+	},
+	{
+		"aws-lb-access-logs-disabled",
+		`resource "aws_lb" "lb" { name = "lb" }`,
+		`resource "aws_lb" "lb" {
+  access_logs {
+    bucket  = "logs"
+    enabled = true
+  }
+}`,
+	},
+	{
+		"gcp-storage-public-iam",
+		`resource "google_storage_bucket_iam_member" "m" {
+  role   = "roles/storage.objectViewer"
+  member = "allUsers"
+}`,
+		`resource "google_storage_bucket_iam_member" "m" {
+  role   = "roles/storage.objectViewer"
+  member = "user:jane@example.com"
+}`,
+	},
+	{
+		"gcp-storage-uniform-access-disabled",
+		`resource "google_storage_bucket" "b" { name = "b" }`,
+		`resource "google_storage_bucket" "b" { uniform_bucket_level_access = true }`,
+	},
+	{
+		"gcp-sql-open-authorized-network",
+		`resource "google_sql_database_instance" "db" {
+  settings {
+    ip_configuration {
+      authorized_networks {
+        value = "0.0.0.0/0"
+      }
+    }
+  }
+}`,
+		`resource "google_sql_database_instance" "db" {
+  settings {
+    ip_configuration {
+      authorized_networks {
+        value = "203.0.113.0/24"
+      }
+    }
+  }
+}`,
+	},
+	{
+		"gcp-sql-ssl-not-required",
+		`resource "google_sql_database_instance" "db" {
+  settings {
+    ip_configuration {
+      ipv4_enabled = true
+    }
+  }
+}`,
+		`resource "google_sql_database_instance" "db" {
+  settings {
+    ip_configuration {
+      ssl_mode = "ENCRYPTED_ONLY"
+    }
+  }
+}`,
+	},
+	{
+		"gcp-firewall-open-ingress",
+		`resource "google_compute_firewall" "f" {
+  allow {
+    protocol = "tcp"
+    ports    = ["22"]
+  }
+  source_ranges = ["0.0.0.0/0"]
+}`,
+		`resource "google_compute_firewall" "f" {
+  allow {
+    protocol = "tcp"
+    ports    = ["22"]
+  }
+  source_ranges = ["35.235.240.0/20"]
+}`,
+	},
+	{
+		"gcp-iam-primitive-role",
+		`resource "google_project_iam_member" "m" {
+  role   = "roles/editor"
+  member = "user:jane@example.com"
+}`,
+		`resource "google_project_iam_member" "m" {
+  role   = "roles/viewer"
+  member = "user:jane@example.com"
+}`,
+	},
+	{
+		"gcp-subnet-flow-logs-disabled",
+		`resource "google_compute_subnetwork" "s" { ip_cidr_range = "10.0.0.0/24" }`,
+		`resource "google_compute_subnetwork" "s" {
+  log_config {
+    aggregation_interval = "INTERVAL_5_SEC"
+  }
+}`,
+	},
+	{
+		"azure-storage-public-container",
+		`resource "azurerm_storage_container" "c" { container_access_type = "blob" }`,
+		`resource "azurerm_storage_container" "c" { container_access_type = "private" }`,
+	},
+	{
+		"azure-storage-public-access",
+		`resource "azurerm_storage_account" "a" { allow_nested_items_to_be_public = true }`,
+		`resource "azurerm_storage_account" "a" { allow_nested_items_to_be_public = false }`,
+	},
+	{
+		"azure-storage-https-disabled",
+		`resource "azurerm_storage_account" "a" { https_traffic_only_enabled = false }`,
+		`resource "azurerm_storage_account" "a" { https_traffic_only_enabled = true }`,
+	},
+	{
+		"azure-nsg-open-ingress",
+		`resource "azurerm_network_security_group" "g" {
+  security_rule {
+    direction             = "Inbound"
+    access                = "Allow"
+    source_address_prefix = "*"
+  }
+}`,
+		`resource "azurerm_network_security_group" "g" {
+  security_rule {
+    direction             = "Inbound"
+    access                = "Deny"
+    source_address_prefix = "*"
+  }
+  security_rule {
+    direction             = "Inbound"
+    access                = "Allow"
+    source_address_prefix = "10.0.0.0/8"
+  }
+}`,
+	},
+	{
+		"azure-sql-tde-disabled",
+		`resource "azurerm_mssql_database" "d" { transparent_data_encryption_enabled = false }`,
+		`resource "azurerm_mssql_database" "d" { transparent_data_encryption_enabled = true }`,
+	},
+	{
+		"azure-sql-firewall-open",
+		`resource "azurerm_mssql_firewall_rule" "r" {
+  start_ip_address = "0.0.0.0"
+  end_ip_address   = "255.255.255.255"
+}`,
+		`resource "azurerm_mssql_firewall_rule" "r" {
+  start_ip_address = "0.0.0.0"
+  end_ip_address   = "0.0.0.0"
+}`,
+	},
+	{
+		"azure-role-wildcard-action",
+		`resource "azurerm_role_definition" "r" {
+  permissions {
+    actions = ["*"]
+  }
+}`,
+		`resource "azurerm_role_definition" "r" {
+  permissions {
+    actions = ["Microsoft.Storage/*/read"]
+  }
+}`,
+	},
+}
+
+func TestTerraformBuiltinRules(t *testing.T) {
+	rules, err := parseTerraformRules(builtinTerraformRules, "built-in rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tested := make(map[string]bool)
+	for _, tc := range terraformRuleCases {
+		tested[tc.rule] = true
+	}
+	for _, rule := range rules {
+		if !tested[rule.ID] {
+			t.Errorf("built-in rule %s has no test case", rule.ID)
+		}
+	}
+
+	for _, tc := range terraformRuleCases {
+		t.Run(tc.rule, func(t *testing.T) {
+			positive := findingsOf(scanTerraform(t, map[string]string{"main.tf": tc.positive + "\n"}), tc.rule)
+			if len(positive) != 1 {
+				t.Fatalf("got %d findings on the positive fixture, want 1", len(positive))
+			}
+			f := positive[0]
+			if f.File != "main.tf" || f.Line < 1 || f.Tool != "dso-terraform" || f.Type != "IAC" {
+				t.Errorf("unexpected finding %+v", f)
+			}
+			if negative := findingsOf(scanTerraform(t, map[string]string{"main.tf": tc.negative + "\n"}), tc.rule); len(negative) != 0 {
+				t.Errorf("got %d findings on the negative fixture, want 0: %+v", len(negative), negative)
+			}
+		})
+	}
+}
+
+func TestTerraformVariables(t *testing.T) {
+	const bucket = `variable "acl" {
+  default = "private"
+}
+
+resource "aws_s3_bucket" "b" {
+  acl = var.acl
+}
+`
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  bool
+	}{
+		{"default", map[string]string{"main.tf": bucket}, false},
+		{"public default", map[string]string{"main.tf": strings.Replace(bucket, `"private"`, `"public-read"`, 1)}, true},
+		{"terraform.tfvars", map[string]string{
+			"main.tf":          bucket,
+			"terraform.tfvars": `acl = "public-read"`,
+		}, true},
+		{"auto.tfvars override terraform.tfvars", map[string]string{
+			"main.tf":          bucket,
+			"terraform.tfvars": `acl = "public-read"`,
+			"a.auto.tfvars":    `acl = "private"`,
+		}, false},
+		{"auto.tfvars in lexical order", map[string]string{
+			"main.tf":       bucket,
+			"b.auto.tfvars": `acl = "public-read"`,
+			"a.auto.tfvars": `acl = "private"`,
+		}, true},
+		{"variable without a value", map[string]string{
+			"main.tf": `variable "acl" {}
+resource "aws_s3_bucket" "b" { acl = var.acl }
+`,
+		}, false},
+		{"variables of another module", map[string]string{
+			"main.tf":           bucket,
+			"other/main.tf":     `variable "acl" { default = "public-read" }`,
+			"other/vars.tfvars": `acl = "public-read"`,
+		}, false},
+		{"locals", map[string]string{
+			"main.tf": `variable "visibility" { default = "public" }
+locals {
+  read = "${local.prefix}-read"
+  prefix = var.visibility
+}
+resource "aws_s3_bucket" "b" { acl = local.read }
+`,
+		}, true},
+		{"local cycle", map[string]string{
+			"main.tf": `locals {
+  a = local.b
+  b = local.a
+}
+resource "aws_s3_bucket" "b" { acl = local.a }
+`,
+		}, false},
+		{"conditional", map[string]string{
+			"main.tf": `variable "public" { default = true }
+resource "aws_s3_bucket" "b" { acl = var.public ? "public-read" : "private" }
+`,
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := len(findingsOf(scanTerraform(t, tt.files), "aws-s3-public-acl")) > 0
+			if got != tt.want {
+				t.Errorf("reported: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTerraformUnknownValues(t *testing.T) {
+	// Values only known after terraform apply never trigger a finding,
+	// whether the rule tests a value or its absence
+	tests := []struct {
+		name  string
+		value string
+		want  bool
+	}{
+		{"false", `false`, true},
+		{"true", `true`, false},
+		{"resource attribute", `aws_kms_key.k.enabled`, false},
+		{"comparison with a resource attribute", `aws_kms_key.k.arn != ""`, false},
+		{"undefined variable", `var.encrypted`, false},
+		{"for expression", `[for k in aws_kms_key.k : true][0]`, false},
+		{"unsupported function", `can(aws_kms_key.k.arn)`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := scanTerraform(t, map[string]string{
+				"main.tf": `resource "aws_db_instance" "db" {
+  storage_encrypted = ` + tt.value + `
+}
+`,
+			})
+			if got := len(findingsOf(findings, "aws-rds-unencrypted")) > 0; got != tt.want {
+				t.Errorf("reported: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTerraformFindingLocation(t *testing.T) {
+	findings := scanTerraform(t, map[string]string{
+		"infra/main.tf": `variable "cidr" {
+  default = "0.0.0.0/0"
+}
+
+resource "aws_efs_file_system" "fs" {
+  tags = {}
+}
+
+resource "aws_security_group" "sg" {
+  dynamic "ingress" {
+    for_each = [22]
+    content {
+      cidr_blocks = [var.cidr]
+    }
+  }
+}
+
+resource "aws_db_instance" "db" {
+  engine              = "postgres"
+  publicly_accessible = true
+}
+`,
+		"infra/broken.tf": `resource "aws_db_instance" {`,
+	})
+
+	tests := []struct {
+		rule, title string
+		line        int
+	}{
+		// A missing attribute is reported on the block
+		{"aws-efs-unencrypted", "(aws_efs_file_system.fs)", 5},
+		// Variable values are reported where they are used
+		{"aws-security-group-open-ingress", "(aws_security_group.sg)", 13},
+		{"aws-rds-public", "(aws_db_instance.db)", 20},
+	}
+	for _, tt := range tests {
+		matched := findingsOf(findings, tt.rule)
+		if len(matched) != 1 {
+			t.Errorf("%s: got %d findings, want 1", tt.rule, len(matched))
+			continue
+		}
+		f := matched[0]
+		if f.File != "infra/main.tf" || f.Line != tt.line || !strings.HasSuffix(f.Title, tt.title) {
+			t.Errorf("%s: got %s:%d %q, want infra/main.tf:%d ...%s", tt.rule, f.File, f.Line, f.Title, tt.line, tt.title)
+		}
+	}
+	for _, f := range findings {
+		if f.File == "infra/broken.tf" {
+			t.Errorf("files that cannot be parsed must be skipped, got %+v", f)
+		}
+	}
+}
+
+func TestTerraformProjectRules(t *testing.T) {
+	files := map[string]string{
+		"main.tf": `resource "aws_s3_bucket" "b" {
+  bucket = "logs"
+  acl    = "public-read"
+}
+resource "aws_efs_file_system" "fs" {}
+`,
+		".dso/rules/terraform/company.yaml": `rules:
+  - id: s3-bucket-without-prefix
+    title: S3 bucket without the company prefix
+    severity: LOW
+    resources: [aws_s3_bucket]
+    match:
+      not: {attribute: bucket, matches: '^acme-'}
+  - id: aws-s3-public-acl
+    title: Public bucket
+    severity: CRITICAL
+    resources: [aws_s3_bucket]
+    match: {attribute: acl, equals: public-read}
+  - id: aws-efs-unencrypted
+    disabled: true
+`,
+	}
+	findings := scanTerraform(t, files)
+
+	var rules []string
+	for _, f := range findings {
+		rules = append(rules, f.RuleID+" "+string(f.Severity))
+	}
+	sort.Strings(rules)
+	want := []string{"aws-s3-public-acl CRITICAL", "s3-bucket-without-prefix LOW"}
+	if strings.Join(rules, ", ") != strings.Join(want, ", ") {
+		t.Errorf("got %v, want %v", rules, want)
+	}
+
+	files[".dso/rules/terraform/broken.yaml"] = "rules:\n  - id: no-title\n    severity: LOW\n"
+	root := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := (terraformChecker{}).Scan(context.Background(), root); err == nil {
+		t.Error("an invalid project rule file must fail the scan")
+	}
+	if (terraformChecker{}).ProjectRuleSet(root) == "" {
+		t.Error("ProjectRuleSet must identify the project rules")
+	}
+}
+
+func TestTerraformRuleErrors(t *testing.T) {
+	tests := []struct {
+		name, rule string
+	}{
+		{"missing id", "title: x\nseverity: LOW\nresources: [a]\nmatch: {attribute: a}"},
+		{"missing title", "id: x\nseverity: LOW\nresources: [a]\nmatch: {attribute: a}"},
+		{"invalid severity", "id: x\ntitle: x\nseverity: URGENT\nresources: [a]\nmatch: {attribute: a}"},
+		{"missing resources", "id: x\ntitle: x\nseverity: LOW\nmatch: {attribute: a}"},
+		{"missing match", "id: x\ntitle: x\nseverity: LOW\nresources: [a]"},
+		{"unknown condition", "id: x\ntitle: x\nseverity: LOW\nresources: [a]\nmatch: {attribute: a, like: b}"},
+		{"leaf without attribute", "id: x\ntitle: x\nseverity: LOW\nresources: [a]\nmatch: {equals: b}"},
+		{"each without where", "id: x\ntitle: x\nseverity: LOW\nresources: [a]\nmatch: {each: a}"},
+		{"invalid regular expression", "id: x\ntitle: x\nseverity: LOW\nresources: [a]\nmatch: {attribute: a, matches: '('}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := "rules:\n  - " + strings.ReplaceAll(tt.rule, "\n", "\n    ") + "\n"
+			if _, err := parseTerraformRules([]byte(data), "test.yaml"); err == nil {
+				t.Errorf("parseTerraformRules succeeded on\n%s", data)
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	spdx "github.com/dso-cli/dso-cli/internal/license"
+)
+
+// trivyReport is the JSON report of `trivy fs` and `trivy config`
+type trivyReport struct {
+	Results []trivyResult `json:"Results"`
+}
+
+// trivyResult groups the findings of a target (a file, or a package
+// directory for language packages)
+type trivyResult struct {
+	Target            string                  `json:"Target"`
+	Class             string                  `json:"Class"` // os-pkgs, lang-pkgs, config, secret, license, license-file
+	Type              string                  `json:"Type"`  // dockerfile, terraform, kubernetes, npm, ...
+	Vulnerabilities   []trivyVulnerability    `json:"Vulnerabilities"`
+	Misconfigurations []trivyMisconfiguration `json:"Misconfigurations"`
+	Secrets           []trivySecret           `json:"Secrets"`
+	Licenses          []trivyLicense          `json:"Licenses"`
+	Packages          []trivyPackage          `json:"Packages"` // Only with --list-all-pkgs
+}
+
+// trivyPackage is a package of a lockfile and its dependencies
+type trivyPackage struct {
+	ID           string   `json:"ID"`           // name@version
+	Relationship string   `json:"Relationship"` // root, workspace, direct or indirect
+	DependsOn    []string `json:"DependsOn"`
+}
+
+type trivyVulnerability struct {
+	VulnerabilityID  string  `json:"VulnerabilityID"`
+	PkgID            string  `json:"PkgID"`
+	PkgName          string  `json:"PkgName"`
+	PkgPath          string  `json:"PkgPath"` // Jar or binary the package was found in
+	InstalledVersion string  `json:"InstalledVersion"`
+	FixedVersion     string  `json:"FixedVersion"` // Comma-separated
+	CweIDs           refList `json:"CweIDs"`
+	Severity         string  `json:"Severity"`
+	Title            string  `json:"Title"`
+	Description      string  `json:"Description"`
+	CVSS             map[string]struct {
+		V3Score float64 `json:"v3Score"`
+	} `json:"CVSS"`
+	PkgIdentifier struct {
+		PURL string `json:"PURL"`
+	} `json:"PkgIdentifier"`
+}
+
+type trivyMisconfiguration struct {
+	ID            string `json:"ID"`    // DS002, KSV001, AVD-AWS-0086...
+	AVDID         string `json:"AVDID"` // AVD-DS-0002
+	Title         string `json:"Title"`
+	Description   string `json:"Description"`
+	Message       string `json:"Message"` // What is wrong in this target
+	Resolution    string `json:"Resolution"`
+	Severity      string `json:"Severity"`
+	PrimaryURL    string `json:"PrimaryURL"`
+	Status        string `json:"Status"` // FAIL, PASS or EXCEPTION
+	CauseMetadata struct {
+		Resource  string `json:"Resource"`
+		StartLine int    `json:"StartLine"`
+		EndLine   int    `json:"EndLine"`
+	} `json:"CauseMetadata"`
+}
+
+type trivySecret struct {
+	RuleID    string `json:"RuleID"`
+	Category  string `json:"Category"`
+	Severity  string `json:"Severity"`
+	Title     string `json:"Title"`
+	StartLine int    `json:"StartLine"`
+	EndLine   int    `json:"EndLine"`
+	Match     string `json:"Match"` // Matched line, with the secret masked
+	Layer     struct {
+		CreatedBy string `json:"CreatedBy"` // Instruction that added the file, for images
+	} `json:"Layer"`
+}
+
+type trivyLicense struct {
+	Severity   string  `json:"Severity"`
+	Category   string  `json:"Category"` // forbidden, restricted, reciprocal, notice, permissive, unencumbered, unknown
+	PkgName    string  `json:"PkgName"`
+	FilePath   string  `json:"FilePath"`
+	Name       string  `json:"Name"`
+	Confidence float64 `json:"Confidence"`
+	Link       string  `json:"Link"`
+}
+
+// scanWithTrivy executes Trivy and parses results
+func scanWithTrivy(ctx context.Context, path string, scanType string, extraArgs ...string) ([]Finding, error) {
+	if _, err := exec.LookPath("trivy"); err != nil {
+		return nil, fmt.Errorf("trivy not found. Install it: https://aquasecurity.github.io/trivy/")
+	}
+
+	args := []string{scanType, path}
+	args = append(args, excludeArgs(ctx, "--skip-dirs", excludedDirs)...)
+	args = append(args, excludeArgs(ctx, "--skip-files", excludedFiles)...)
+	return runTrivy(ctx, append(args, extraArgs...)...)
+}
+
+// runTrivy runs trivy with args and maps its JSON report to findings
+func runTrivy(ctx context.Context, args ...string) ([]Finding, error) {
+	args = append(args, "--format", "json", "--quiet")
+	output, err := runTool(ctx, "", "trivy", args...)
+	if err := toolError("trivy", output, err); err != nil {
+		return nil, err
+	}
+
+	var report trivyReport
+	if err := decodeToolOutput("trivy", output, &report); err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	for _, result := range report.Results {
+		graph := result.dependencyGraph()
+		for _, vuln := range result.Vulnerabilities {
+			f := vuln.finding(result)
+			f.Package.Path = graph.path(vuln.PkgID)
+			findings = append(findings, f)
+		}
+		for _, misconf := range result.Misconfigurations {
+			// Passed checks are only listed with --include-non-failures
+			if misconf.Status != "" && misconf.Status != "FAIL" {
+				continue
+			}
+			findings = append(findings, misconf.finding(result))
+		}
+		for _, secret := range result.Secrets {
+			findings = append(findings, secret.finding(result))
+		}
+		for _, license := range result.Licenses {
+			findings = append(findings, license.finding(result))
+		}
+	}
+
+	return findings, nil
+}
+
+func (vuln trivyVulnerability) finding(result trivyResult) Finding {
+	cvss := 0.0
+	if cvssData, ok := vuln.CVSS["nvd"]; ok {
+		cvss = cvssData.V3Score
+	}
+	pkg := &Package{Ecosystem: packageEcosystem(result.Type)}
+	if p := purlPackage(vuln.PkgIdentifier.PURL); p != nil {
+		pkg = p
+	}
+	pkg.Name = vuln.PkgName
+	pkg.InstalledVersion = vuln.InstalledVersion
+	pkg.FixedVersions = splitVersions(vuln.FixedVersion)
+
+	f := Finding{
+		ID:          vuln.VulnerabilityID,
+		Type:        "DEPENDENCY",
+		Severity:    mapSeverity(vuln.Severity),
+		Title:       vuln.Title,
+		Description: vuln.Description,
+		File:        firstNonEmpty(vuln.PkgPath, result.Target),
+		Tool:        "trivy",
+		CVSS:        cvss,
+		Package:     pkg,
+		CWE:         vuln.CweIDs,
+	}
+	f.setUpgrade()
+	return f
+}
+
+// dependencyGraph is the reverse dependency graph of the packages of a
+// trivy result
+type dependencyGraph struct {
+	parents map[string][]string
+	direct  map[string]bool
+}
+
+func (result trivyResult) dependencyGraph() dependencyGraph {
+	g := dependencyGraph{parents: make(map[string][]string), direct: make(map[string]bool)}
+	for _, p := range result.Packages {
+		if p.Relationship == "direct" {
+			g.direct[p.ID] = true
+		}
+		for _, child := range p.DependsOn {
+			g.parents[child] = append(g.parents[child], p.ID)
+		}
+	}
+	return g
+}
+
+// path returns the shortest chain of package IDs from a direct dependency
+// to the package id, or nil when it is unknown
+func (g dependencyGraph) path(id string) []string {
+	if id == "" {
+		return nil
+	}
+	// Breadth-first search from the package up to a direct dependency;
+	// next links each visited package to its child on the way back
+	next := map[string]string{id: ""}
+	queue := []string{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if g.direct[current] {
+			var path []string
+			for p := current; p != ""; p = next[p] {
+				path = append(path, p)
+			}
+			return path
+		}
+		for _, parent := range g.parents[current] {
+			if _, seen := next[parent]; !seen {
+				next[parent] = current
+				queue = append(queue, parent)
+			}
+		}
+	}
+	return nil
+}
+
+func (misconf trivyMisconfiguration) finding(result trivyResult) Finding {
+	findingType := "IAC"
+	if strings.EqualFold(result.Type, "dockerfile") {
+		findingType = "CONTAINER"
+	}
+
+	// The message is specific to the target, the description to the check
+	description := misconf.Message
+	if misconf.Description != "" && misconf.Description != misconf.Message {
+		description = strings.TrimSpace(description + "\n\n" + misconf.Description)
+	}
+	if misconf.CauseMetadata.Resource != "" {
+		description += "\n\nResource: " + misconf.CauseMetadata.Resource
+	}
+	if misconf.PrimaryURL != "" {
+		description += "\n\nMore information: " + misconf.PrimaryURL
+	}
+
+	title := misconf.Title
+	if title == "" {
+		title = misconf.ID
+	}
+	var aliases []string
+	if misconf.AVDID != "" && misconf.AVDID != misconf.ID {
+		aliases = []string{misconf.AVDID}
+	}
+
+	line := misconf.CauseMetadata.StartLine
+	endLine := misconf.CauseMetadata.EndLine
+	if endLine <= line {
+		endLine = 0
+	}
+	return Finding{
+		ID:          fmt.Sprintf("trivy-%s-%s-%d", misconf.ID, result.Target, line),
+		Type:        findingType,
+		Severity:    mapSeverity(misconf.Severity),
+		Title:       title,
+		Description: description,
+		File:        result.Target,
+		Line:        line,
+		EndLine:     endLine,
+		RuleID:      misconf.ID,
+		Tool:        "trivy",
+		Fixable:     misconf.Resolution != "",
+		Fix:         misconf.Resolution,
+		Aliases:     aliases,
+		Timestamp:   time.Now(),
+	}
+}
+
+func (secret trivySecret) finding(result trivyResult) Finding {
+	endLine := secret.EndLine
+	if endLine <= secret.StartLine {
+		endLine = 0
+	}
+	description := fmt.Sprintf("Secret detected in %s at line %d", result.Target, secret.StartLine)
+	if match := strings.TrimSpace(secret.Match); match != "" {
+		description += ": " + match
+	}
+	if secret.Layer.CreatedBy != "" {
+		description += ". Added by the image layer: " + secret.Layer.CreatedBy
+	}
+	return Finding{
+		ID:          fmt.Sprintf("trivy-%s-%s-%d", secret.RuleID, result.Target, secret.StartLine),
+		Type:        "SECRET",
+		Severity:    mapSeverity(secret.Severity),
+		Title:       fmt.Sprintf("Exposed secret: %s", firstNonEmpty(secret.Title, secret.RuleID)),
+		Description: description,
+		File:        result.Target,
+		Line:        secret.StartLine,
+		EndLine:     endLine,
+		RuleID:      secret.RuleID,
+		Tool:        "trivy",
+		Fixable:     true,
+		Exploitable: true,
+		Timestamp:   time.Now(),
+	}
+}
+
+func (license trivyLicense) finding(result trivyResult) Finding {
+	// The target of package licenses is a language name, not a file
+	file := license.FilePath
+	if file == "" && result.Class != "license" {
+		file = result.Target
+	}
+	subject := license.PkgName
+	if subject == "" {
+		subject = file
+	}
+
+	id := firstNonEmpty(spdx.Normalize(license.Name), license.Name)
+	description := fmt.Sprintf("%s is distributed under the %s license (category: %s)", subject, license.Name, license.Category)
+	if license.Link != "" {
+		description += "\n\nMore information: " + license.Link
+	}
+	f := Finding{
+		ID:          fmt.Sprintf("trivy-license-%s-%s", license.Name, subject),
+		Type:        "LICENSE",
+		Severity:    mapSeverity(license.Severity), // Replaced by the verdict of the project license policy
+		Title:       fmt.Sprintf("%s license in %s", id, subject),
+		Description: description,
+		File:        file,
+		RuleID:      id,
+		Tool:        "trivy",
+		Timestamp:   time.Now(),
+	}
+	if license.PkgName != "" {
+		f.Package = &Package{Name: license.PkgName}
+	}
+	return f
+}
+
+// firstNonEmpty returns the first non-empty string
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
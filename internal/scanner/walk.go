@@ -0,0 +1,92 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// maxScannedFileSize is the size above which native scanners skip a file
+const maxScannedFileSize = 2 << 20
+
+type fileFilterKey struct{}
+
+// withFileFilter limits the files visited by walkFiles to files, paths
+// relative to the scanned directory. A nil files removes the limit.
+func withFileFilter(ctx context.Context, files []string) context.Context {
+	var set map[string]bool
+	if files != nil {
+		set = make(map[string]bool, len(files))
+		for _, file := range files {
+			set[file] = true
+		}
+	}
+	return context.WithValue(ctx, fileFilterKey{}, set)
+}
+
+// fileFiltered reports whether walkFiles visits only some files in ctx
+func fileFiltered(ctx context.Context) bool {
+	filter, _ := ctx.Value(fileFilterKey{}).(map[string]bool)
+	return filter != nil
+}
+
+// walkFiles calls fn for every regular file under root that is not
+// ignored (see ignoreRules). rel is the slash-separated path relative to
+// root. During an incremental scan, only the changed files are visited.
+// The walk stops when ctx is done.
+func walkFiles(ctx context.Context, root string, fn func(path, rel string, info fs.FileInfo) error) error {
+	rules := ignoreRules(ctx)
+	filter, _ := ctx.Value(fileFilterKey{}).(map[string]bool)
+
+	return filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if p == root {
+			return nil
+		}
+		rel, relErr := filepath.Rel(root, p)
+		if relErr != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if rules.Ignored(rel, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() || !info.Mode().IsRegular() {
+			return nil
+		}
+		if filter != nil && !filter[rel] {
+			return nil
+		}
+		return fn(p, rel, info)
+	})
+}
+
+// readTextFile reads a file for native scanning. It returns nil for files
+// that are too large or look binary.
+func readTextFile(path string, info fs.FileInfo) []byte {
+	if info.Size() > maxScannedFileSize {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	head := data
+	if len(head) > 8000 {
+		head = head[:8000]
+	}
+	if bytes.IndexByte(head, 0) != -1 {
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,459 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dso-cli/dso-cli/internal/yaml"
+)
+
+func init() {
+	Register(workflowChecker{})
+}
+
+// ciRules are the checks of the native CI workflow checker
+var ciRules = map[string]nativeRule{
+	"ci-unpinned-action": {
+		Title:       "Action not pinned to a commit SHA",
+		Severity:    SeverityMedium,
+		Description: "Tags and branches can be moved to another commit: whoever controls the action repository can change the code that runs in the workflow, with its secrets and token.",
+		Fix:         "Pin the action to the full commit SHA of a release, with the version in a comment: uses: owner/action@<sha> # v1.2.3",
+	},
+	"ci-pull-request-target-checkout": {
+		Title:       "pull_request_target workflow checks out the pull request",
+		Severity:    SeverityCritical,
+		Description: "pull_request_target workflows run with the secrets and a write token of the base repository. Checking out the head of the pull request lets anyone who opens one run their code with them, through build scripts, tests or dependencies.",
+		Fix:         "Use the pull_request trigger to build untrusted code, or split the workflow and only process its artifacts in a workflow_run workflow",
+	},
+	"ci-script-injection": {
+		Title:       "Untrusted input in run script",
+		Severity:    SeverityHigh,
+		Description: "The expression is replaced in the script before the shell runs it, so a crafted title, branch name or comment injects shell commands that run with the secrets of the workflow.",
+		Fix:         "Pass the value through an environment variable (env: TITLE: ${{ github.event.issue.title }}) and use \"$TITLE\" in the script",
+	},
+	"ci-broad-permissions": {
+		Title:       "Broad GITHUB_TOKEN permissions",
+		Severity:    SeverityMedium,
+		Description: "The GITHUB_TOKEN of the workflow can write to the repository, its releases and packages: a compromised step or action can push code or publish artifacts.",
+		Fix:         "Set permissions: contents: read at the workflow level and grant each job the write scopes it needs",
+	},
+	"ci-curl-pipe-shell": {
+		Title:       "Remote script piped to a shell",
+		Severity:    SeverityHigh,
+		Description: "The script is run as soon as it is downloaded, without any verification: a compromised server runs arbitrary commands in the pipeline, with its secrets.",
+		Fix:         "Download a pinned release, verify its checksum or signature, then install it; or use a container image with the tool",
+	},
+	"ci-secret-echo": {
+		Title:       "Secret printed to the build log",
+		Severity:    SeverityHigh,
+		Description: "The command prints a secret to the job log. Masking only hides exact values: encoded, split or transformed secrets are readable by everyone who can read the logs.",
+		Fix:         "Do not print secrets; pass them to commands through environment variables or standard input",
+	},
+}
+
+var (
+	// commitSHARegex matches a full commit SHA
+	commitSHARegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+	// expressionRegex matches a workflow expression
+	expressionRegex = regexp.MustCompile(`\$\{\{(.*?)\}\}`)
+	// untrustedContextRegex matches the contexts that the author of a pull
+	// request, an issue or a comment controls
+	untrustedContextRegex = regexp.MustCompile(`\bgithub\.(event\.[\w.*'"\[\]-]+|head_ref)`)
+	// trustedFieldRegex matches the event fields that cannot hold text,
+	// such as numbers, ids and commit SHAs
+	trustedFieldRegex = regexp.MustCompile(`[._](number|id|sha|before|after|at|count|size|draft|merged)$`)
+	// pullRequestHeadRegex matches the references to the head of a pull request
+	pullRequestHeadRegex = regexp.MustCompile(`github\.event\.pull_request\.head\.(sha|ref)|github\.head_ref|refs/pull/|\bgh pr checkout\b`)
+	// secretExpressionRegex matches a secret in a workflow expression
+	secretExpressionRegex = regexp.MustCompile(`\$\{\{\s*secrets\.\w+\s*\}\}`)
+	// shellVariableRegex matches a shell variable, $NAME or ${NAME}
+	shellVariableRegex = regexp.MustCompile(`\$\{?([A-Za-z_]\w*)`)
+	// echoRegex matches the commands that print their arguments
+	echoRegex = regexp.MustCompile(`(^|[;&|(\s])(echo|printf)\s`)
+)
+
+// isCIConfig reports whether rel, a slash-separated path relative to the
+// scanned directory, is a GitHub Actions workflow or a GitLab CI pipeline
+func isCIConfig(rel string) bool {
+	dir, name := path.Split(rel)
+	ext := path.Ext(name)
+	if ext != ".yml" && ext != ".yaml" {
+		return false
+	}
+	return strings.HasSuffix("/"+dir, "/.github/workflows/") || strings.HasPrefix(name, ".gitlab-ci")
+}
+
+// detectCI reports whether root holds a CI workflow. When files is not
+// nil, only these files are considered.
+func detectCI(root string, files []string) bool {
+	if files != nil {
+		for _, file := range files {
+			if filepath.IsAbs(file) {
+				file, _ = filepath.Rel(root, file)
+			}
+			if isCIConfig(filepath.ToSlash(file)) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, pattern := range []string{".github/workflows/*.y*ml", ".gitlab-ci*.y*ml"} {
+		if matches, _ := filepath.Glob(filepath.Join(root, filepath.FromSlash(pattern))); len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// workflowChecker checks the GitHub Actions workflows and GitLab CI
+// pipelines of the project, without an external tool
+type workflowChecker struct{}
+
+func (workflowChecker) Name() string               { return "dso-ci" }
+func (workflowChecker) Category() Category         { return CategoryIaC }
+func (workflowChecker) Applicable(p *Project) bool { return p.HasCI }
+
+// Scan walks path and checks every CI workflow
+func (c workflowChecker) Scan(ctx context.Context, path string) ([]Finding, error) {
+	var findings []Finding
+	err := walkFiles(ctx, path, func(p, rel string, info fs.FileInfo) error {
+		fileFindings, err := c.ParseFile(p, rel, info)
+		if err != nil {
+			recordUnparsed(ctx, rel, err)
+		}
+		findings = append(findings, fileFindings...)
+		return nil
+	})
+	return findings, err
+}
+
+// ParseFile checks a workflow file. Files that are not valid YAML are
+// skipped with an error.
+func (workflowChecker) ParseFile(file, rel string, info fs.FileInfo) ([]Finding, error) {
+	if !isCIConfig(rel) {
+		return nil, nil
+	}
+	data := readTextFile(file, info)
+	if data == nil {
+		return nil, nil
+	}
+	return checkWorkflow(data, rel)
+}
+
+// checkWorkflow returns the findings of a GitHub Actions workflow or a
+// GitLab CI pipeline, rel being its path
+func checkWorkflow(data []byte, rel string) ([]Finding, error) {
+	docs, err := yaml.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	check := &ciCheck{file: rel, lines: strings.Split(string(data), "\n"), now: time.Now()}
+	for _, doc := range docs {
+		if strings.HasPrefix(path.Base(rel), ".gitlab-ci") {
+			check.gitlab(doc)
+		} else {
+			check.github(doc)
+		}
+	}
+	return check.findings, nil
+}
+
+// ciCheck collects the findings of a workflow file
+type ciCheck struct {
+	file     string
+	lines    []string
+	now      time.Time
+	findings []Finding
+}
+
+// github checks a GitHub Actions workflow
+func (c *ciCheck) github(doc *yaml.Node) {
+	jobs := doc.Get("jobs")
+	if jobs == nil {
+		return
+	}
+	onKey, on := doc.Lookup("on")
+	// on is a trigger, a list or a mapping of triggers
+	_, pullRequestTarget := on.Lookup("pull_request_target")
+	targetTriggered := pullRequestTarget != nil || on.String() == "pull_request_target"
+	for _, trigger := range on.Items() {
+		targetTriggered = targetTriggered || trigger.String() == "pull_request_target"
+	}
+
+	workflowPermissions := doc.Get("permissions")
+	c.permissions(workflowPermissions, "the workflow")
+	secrets := secretEnv(nil, doc.Get("env"))
+
+	unrestricted := 0
+	for _, entry := range jobs.Entries() {
+		job, subject := entry.Value, "job "+entry.Key.Value
+		if permissions := job.Get("permissions"); permissions != nil {
+			c.permissions(permissions, subject)
+		} else if workflowPermissions == nil {
+			unrestricted++
+		}
+		// Reusable workflow
+		if uses := job.Get("uses"); uses != nil {
+			c.action(uses, subject)
+		}
+
+		jobSecrets := secretEnv(secrets, job.Get("env"))
+		for _, step := range job.Get("steps").Items() {
+			stepSecrets := secretEnv(jobSecrets, step.Get("env"))
+			uses := step.Get("uses")
+			action, _, _ := strings.Cut(uses.String(), "@")
+			if uses != nil {
+				c.action(uses, subject)
+			}
+			if targetTriggered && action == "actions/checkout" {
+				if ref := step.Path("with", "ref"); pullRequestHeadRegex.MatchString(ref.String()) {
+					c.report("ci-pull-request-target-checkout", ref, subject, "", "")
+				}
+			}
+			if run := step.Get("run"); run != nil {
+				c.script(run, subject, stepSecrets, true, targetTriggered)
+			}
+			if action == "actions/github-script" {
+				if script := step.Path("with", "script"); script != nil {
+					c.script(script, subject, stepSecrets, true, false)
+				}
+			}
+		}
+	}
+
+	if unrestricted > 0 {
+		line := doc
+		if onKey != nil {
+			line = onKey
+		}
+		f := c.report("ci-broad-permissions", line, "", "Workflow without permissions",
+			"The workflow does not set permissions, so the GITHUB_TOKEN gets the default permissions of the repository, which can include write access to every scope.")
+		f.Severity = SeverityLow
+	}
+}
+
+// secretEnv returns the environment variables that hold secrets: those of
+// parent, and those of env whose value is a secret expression
+func secretEnv(parent map[string]bool, env *yaml.Node) map[string]bool {
+	secrets := make(map[string]bool, len(parent))
+	for name := range parent {
+		secrets[name] = true
+	}
+	for _, entry := range env.Entries() {
+		if strings.Contains(entry.Value.String(), "secrets.") {
+			secrets[entry.Key.Value] = true
+		}
+	}
+	return secrets
+}
+
+// action reports the action or reusable workflow of a uses key that is
+// not pinned to a commit SHA, or a Docker action not pinned to a digest
+func (c *ciCheck) action(uses *yaml.Node, subject string) {
+	ref := uses.String()
+	if ref == "" || strings.HasPrefix(ref, "./") {
+		return
+	}
+	if image, ok := strings.CutPrefix(ref, "docker://"); ok {
+		if !strings.Contains(image, "@sha256:") {
+			c.report("ci-unpinned-action", uses, subject,
+				fmt.Sprintf("Docker action %s not pinned to a digest in %s", image, subject), "")
+		}
+		return
+	}
+	_, version, _ := strings.Cut(ref, "@")
+	if commitSHARegex.MatchString(version) {
+		return
+	}
+	detail := ""
+	if version == "" {
+		detail = "The action has no version, so its default branch runs."
+	}
+	c.report("ci-unpinned-action", uses, subject, fmt.Sprintf("Action %s not pinned to a commit SHA in %s", ref, subject), detail)
+}
+
+// permissions reports a permissions key that grants write access to every scope
+func (c *ciCheck) permissions(node *yaml.Node, subject string) {
+	if node.String() == "write-all" {
+		f := c.report("ci-broad-permissions", node, subject, fmt.Sprintf("permissions: write-all in %s", subject), "")
+		f.Severity = SeverityHigh
+	}
+}
+
+// script checks the commands of a run script, a github-script or a GitLab
+// script. expressions tells whether workflow expressions are expanded in
+// the script, checkout whether checking out a pull request is reported.
+func (c *ciCheck) script(node *yaml.Node, subject string, secrets map[string]bool, expressions, checkout bool) {
+	for _, line := range c.scriptLines(node) {
+		if expressions {
+			for _, expr := range expressionRegex.FindAllStringSubmatch(line.text, -1) {
+				for _, m := range untrustedContextRegex.FindAllString(expr[1], -1) {
+					if trustedFieldRegex.MatchString(m) {
+						continue
+					}
+					c.report("ci-script-injection", line.node, subject,
+						fmt.Sprintf("Untrusted %s in script of %s", m, subject),
+						fmt.Sprintf("%s is expanded in the script.", expr[0]))
+				}
+			}
+		}
+		if checkout && pullRequestHeadRegex.MatchString(line.text) && strings.Contains(line.text, "checkout") {
+			c.report("ci-pull-request-target-checkout", line.node, subject, "",
+				fmt.Sprintf("The script runs %q.", strings.TrimSpace(line.text)))
+		}
+		if m := curlPipeShellRegex.FindString(line.text); m != "" {
+			c.report("ci-curl-pipe-shell", line.node, subject, "", fmt.Sprintf("The script runs %q.", m))
+		}
+		if secret := echoedSecret(line.text, secrets); secret != "" {
+			c.report("ci-secret-echo", line.node, subject,
+				fmt.Sprintf("Secret %s printed to the log in %s", secret, subject), "")
+		}
+	}
+}
+
+// echoedSecret returns the secret that an echo or printf command of text
+// prints to the log, or "". The output of a command that is piped or
+// redirected is not logged.
+func echoedSecret(text string, secrets map[string]bool) string {
+	loc := echoRegex.FindStringIndex(text)
+	if loc == nil || strings.Contains(text, "::add-mask::") {
+		return ""
+	}
+	args := text[loc[1]:]
+	for i := 0; i < len(args); i++ {
+		if args[i] == '>' {
+			return ""
+		}
+		if args[i] == '|' {
+			if i+1 < len(args) && args[i+1] == '|' {
+				args = args[:i]
+				break
+			}
+			return ""
+		}
+	}
+	if m := secretExpressionRegex.FindString(args); m != "" {
+		return strings.TrimSpace(strings.Trim(m, "${}"))
+	}
+	for _, m := range shellVariableRegex.FindAllStringSubmatch(args, -1) {
+		name := m[1]
+		if secrets[name] || (secretVariableRegex.MatchString(name) && !publicVariableRegex.MatchString(name)) {
+			return "$" + name
+		}
+	}
+	return ""
+}
+
+// scriptLine is a line of a script and its location in the file
+type scriptLine struct {
+	text string
+	node *yaml.Node
+}
+
+// scriptLines splits a script into lines located in the file. The lines of
+// block scalars are searched in the file after the key; a line that is
+// not found, such as a folded one, gets the line of the previous one.
+func (c *ciCheck) scriptLines(node *yaml.Node) []scriptLine {
+	var lines []scriptLine
+	located := &yaml.Node{Line: node.Line}
+	next := node.Line - 1 // index in c.lines of the next line to search
+	if node.Block {
+		next = node.Line
+	}
+	for _, text := range strings.Split(node.Value, "\n") {
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" {
+			continue
+		}
+		if node.Block {
+			for i := next; i < len(c.lines); i++ {
+				if strings.Contains(c.lines[i], trimmed) {
+					located, next = &yaml.Node{Line: i + 1}, i+1
+					break
+				}
+			}
+		}
+		lines = append(lines, scriptLine{text: text, node: located})
+	}
+	return lines
+}
+
+// gitlabKeywords are the top-level keys of a GitLab CI pipeline that are
+// not jobs
+var gitlabKeywords = map[string]bool{
+	"default": true, "include": true, "stages": true, "variables": true, "workflow": true,
+	"image": true, "services": true, "cache": true, "before_script": true, "after_script": true,
+}
+
+// gitlab checks the scripts of a GitLab CI pipeline
+func (c *ciCheck) gitlab(doc *yaml.Node) {
+	for _, entry := range doc.Entries() {
+		name, job := entry.Key.Value, entry.Value
+		subject := "job " + name
+		switch {
+		case name == "default":
+			subject = "the default job settings"
+		case name == "before_script" || name == "after_script":
+			c.gitlabScript(job, "the pipeline")
+			continue
+		case gitlabKeywords[name] || job.Kind != yaml.MappingNode:
+			continue
+		}
+		for _, key := range []string{"before_script", "script", "after_script"} {
+			c.gitlabScript(job.Get(key), subject)
+		}
+	}
+}
+
+// gitlabScript checks a script of a GitLab job: a string, or a list of
+// strings and of nested lists
+func (c *ciCheck) gitlabScript(node *yaml.Node, subject string) {
+	if node == nil {
+		return
+	}
+	if node.Kind == yaml.ScalarNode {
+		c.script(node, subject, nil, false, false)
+		return
+	}
+	for _, item := range node.Items() {
+		c.gitlabScript(item, subject)
+	}
+}
+
+// report adds a finding of rule located at node. title defaults to the
+// title of the rule in subject; detail is added to its description.
+func (c *ciCheck) report(ruleID string, node *yaml.Node, subject, title, detail string) *Finding {
+	rule := ciRules[ruleID]
+	if title == "" {
+		title = rule.Title + " in " + subject
+	}
+	description := rule.Description
+	if detail != "" {
+		description = detail + " " + description
+	}
+	line := 0
+	if node != nil {
+		line = node.Line
+	}
+	c.findings = append(c.findings, Finding{
+		ID:          fmt.Sprintf("dso-%s-%s-%d", ruleID, c.file, line),
+		Type:        "IAC",
+		Severity:    rule.Severity,
+		Title:       title,
+		Description: description,
+		File:        c.file,
+		Line:        line,
+		RuleID:      ruleID,
+		Tool:        "dso-ci",
+		Fixable:     true,
+		Fix:         rule.Fix,
+		Timestamp:   c.now,
+	})
+	return &c.findings[len(c.findings)-1]
+}
@@ -256,7 +256,7 @@ func DetectTools() []Tool {
 	for _, tool := range Tools {
 		tool.Installed = isInstalled(tool.Command)
 		if tool.Installed {
-			tool.Version = getVersion(tool.Command)
+			tool.Version = GetVersion(tool.Command)
 		}
 		detected = append(detected, tool)
 	}
@@ -270,8 +270,8 @@ func isInstalled(command string) bool {
 	return err == nil
 }
 
-// getVersion retrieves the version of a tool
-func getVersion(command string) string {
+// GetVersion retrieves the version of a tool
+func GetVersion(command string) string {
 	cmd := exec.Command(command, "--version")
 	output, err := cmd.Output()
 	if err != nil || len(output) == 0 {
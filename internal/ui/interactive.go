@@ -268,7 +268,11 @@ func (m model) renderSummary() string {
 	b.WriteString(m.renderSeverityBar("Medium", m.results.Summary.Medium, m.results.Summary.Total, mediumStyle))
 	b.WriteString("\n")
 	b.WriteString(m.renderSeverityBar("Low", m.results.Summary.Low, m.results.Summary.Total, lowStyle))
-	b.WriteString("\n\n")
+	b.WriteString("\n")
+	if m.results.Summary.Suppressed > 0 {
+		b.WriteString(fmt.Sprintf("  🔕 Suppressed: %d (dso:ignore)\n", m.results.Summary.Suppressed))
+	}
+	b.WriteString("\n")
 
 	// Business Impact
 	if m.analysis != nil && m.analysis.BusinessImpact != "" {
@@ -324,11 +328,11 @@ func (m *model) updateList() {
 			}
 		}
 	case 2: // Critical
-		for _, f := range m.results.Findings {
+		for _, f := range m.results.Active() {
 			if f.Severity == scanner.SeverityCritical {
 				items = append(items, item{
 					title:       f.Title,
-					description: f.Description,
+					description: findingDescription(f),
 					severity:    f.Severity,
 					file:        f.File,
 					line:        f.Line,
@@ -336,11 +340,11 @@ func (m *model) updateList() {
 			}
 		}
 	case 3: // High
-		for _, f := range m.results.Findings {
+		for _, f := range m.results.Active() {
 			if f.Severity == scanner.SeverityHigh {
 				items = append(items, item{
 					title:       f.Title,
-					description: f.Description,
+					description: findingDescription(f),
 					severity:    f.Severity,
 					file:        f.File,
 					line:        f.Line,
@@ -349,9 +353,14 @@ func (m *model) updateList() {
 		}
 	case 4: // All Findings
 		for _, f := range m.results.Findings {
+			title, description := f.Title, findingDescription(f)
+			if f.Suppression != nil {
+				title += " (suppressed)"
+				description = "🔕 " + f.Suppression.Reason + "\n" + description
+			}
 			items = append(items, item{
-				title:       f.Title,
-				description: f.Description,
+				title:       title,
+				description: description,
 				severity:    f.Severity,
 				file:        f.File,
 				line:        f.Line,
@@ -387,6 +396,23 @@ func (m *model) updateList() {
 	}
 }
 
+// findingDescription returns the description of a finding, preceded by its
+// vulnerable package and upgrade, its CWE, OWASP and ASVS references, and
+// the commit that introduced a secret
+func findingDescription(f scanner.Finding) string {
+	var lines []string
+	if f.Package != nil && f.Type == "DEPENDENCY" {
+		lines = append(lines, "📦 "+packageLine(f.Package))
+	}
+	if c := classificationLine(f); c != "" {
+		lines = append(lines, "🏷️  "+c)
+	}
+	if f.Commit != nil {
+		lines = append(lines, "📜 "+commitLine(f.Commit))
+	}
+	return strings.Join(append(lines, f.Description), "\n")
+}
+
 func (m model) keys() keyMap {
 	return newKeyMap()
 }
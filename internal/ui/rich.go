@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/dso-cli/dso-cli/internal/llm"
@@ -72,8 +73,38 @@ func PrintBeautifulSummary(analysis *llm.AnalysisResult, results *scanner.ScanRe
 	fmt.Printf("  %s Low: %d\n", lowStyle.Render("🔵"), results.Summary.Low)
 	fmt.Printf("  ✅ Fixable: %d\n", results.Summary.Fixable)
 	fmt.Printf("  ⚠️  Exploitable: %d\n", results.Summary.Exploitable)
+	if results.Summary.Suppressed > 0 {
+		fmt.Printf("  🔕 Suppressed: %d\n", results.Summary.Suppressed)
+	}
+	if len(results.Inventory) > 0 {
+		direct := 0
+		for _, pkg := range results.Inventory {
+			if pkg.Direct {
+				direct++
+			}
+		}
+		fmt.Printf("  📦 Dependencies: %d (%d direct)\n", len(results.Inventory), direct)
+	}
+	if results.Diff != nil {
+		fmt.Printf("  🔀 Scope: %s\n", results.Diff.Describe())
+	}
+	if results.History != nil {
+		fmt.Printf("  📜 Git history: %s\n", results.History.Describe())
+	}
+	if results.Image != nil {
+		fmt.Printf("  🐳 Image: %s\n", results.Image.Describe())
+	}
+	if results.Baseline != nil {
+		fmt.Printf("  📌 Baseline: %d existing findings not reported\n", results.Baseline.Existing)
+	}
 	fmt.Println()
 
+	PrintCoverage(results)
+	printWarnings(results)
+	printSuppressions(results)
+	printBaselineFixed(results)
+	printGrouping(results)
+
 	// Business impact
 	if analysis.BusinessImpact != "" {
 		fmt.Println(infoStyle.Render("💼 Business Impact"))
@@ -158,26 +189,58 @@ func PrintRawResults(results *scanner.ScanResults) {
 	fmt.Println()
 
 	fmt.Printf("Total: %d findings\n", results.Summary.Total)
-	fmt.Printf("Critical: %d, High: %d, Medium: %d, Low: %d\n\n",
+	fmt.Printf("Critical: %d, High: %d, Medium: %d, Low: %d\n",
 		results.Summary.Critical, results.Summary.High, results.Summary.Medium, results.Summary.Low)
+	if results.Summary.Suppressed > 0 {
+		fmt.Printf("Suppressed: %d\n", results.Summary.Suppressed)
+	}
+	fmt.Println()
+	if results.Diff != nil {
+		fmt.Printf("Scope: %s\n\n", results.Diff.Describe())
+	}
+	if results.History != nil {
+		fmt.Printf("Git history: %s\n\n", results.History.Describe())
+	}
+	if results.Image != nil {
+		fmt.Printf("Image: %s\n\n", results.Image.Describe())
+	}
+	if results.Baseline != nil {
+		fmt.Printf("Baseline: %d existing findings not reported\n\n", results.Baseline.Existing)
+	}
 
-	for _, f := range results.Findings {
-		severity := lowStyle
-		switch f.Severity {
-		case scanner.SeverityCritical:
-			severity = criticalStyle
-		case scanner.SeverityHigh:
-			severity = highStyle
-		case scanner.SeverityMedium:
-			severity = mediumStyle
-		}
+	PrintCoverage(results)
+	printWarnings(results)
+	printSuppressions(results)
+	printBaselineFixed(results)
+	printGrouping(results)
+
+	for _, f := range results.Active() {
+		severity := severityStyle(f.Severity)
 
-		fmt.Printf("%s [%s] %s\n", severity.Render(string(f.Severity)), f.Tool, f.Title)
-		fmt.Printf("  📁 %s", f.File)
-		if f.Line > 0 {
-			fmt.Printf(":%d", f.Line)
+		tools := f.Tool
+		if len(f.Tools) > 1 {
+			tools = strings.Join(f.Tools, ", ")
+		}
+		fmt.Printf("%s [%s] %s\n", severity.Render(string(f.Severity)), tools, f.Title)
+		if len(f.Tools) > 1 {
+			fmt.Printf("  🤝 Confirmed by %d tools (confidence %.0f%%)\n", len(f.Tools), f.Confidence*100)
+		}
+		if f.File != "" {
+			fmt.Printf("  📁 %s", f.File)
+			if f.Line > 0 {
+				fmt.Printf(":%d", f.Line)
+			}
+			fmt.Println()
+		}
+		if f.Package != nil && f.Type == "DEPENDENCY" {
+			fmt.Printf("  📦 %s\n", packageLine(f.Package))
+		}
+		if c := classificationLine(f); c != "" {
+			fmt.Printf("  🏷️  %s\n", c)
+		}
+		if f.Commit != nil {
+			fmt.Printf("  📜 %s\n", commitLine(f.Commit))
 		}
-		fmt.Println()
 		if f.Description != "" {
 			fmt.Printf("  %s\n", f.Description)
 		}
@@ -185,6 +248,234 @@ func PrintRawResults(results *scanner.ScanResults) {
 	}
 }
 
+// packageLine describes the vulnerable package of a finding and its upgrade:
+// "lodash 4.17.20 → 4.17.21 (npm, via express@4.17.1 > lodash@4.17.20)"
+func packageLine(p *scanner.Package) string {
+	line := strings.TrimSpace(p.Name + " " + p.InstalledVersion)
+	var details []string
+	if version := p.UpgradeVersion(); version != "" {
+		line += " → " + version
+	} else {
+		details = append(details, "no fixed version")
+	}
+	if p.Ecosystem != "" {
+		details = append(details, string(p.Ecosystem))
+	}
+	if len(p.Path) > 1 {
+		details = append(details, "via "+strings.Join(p.Path, " > "))
+	} else if p.Direct() {
+		details = append(details, "direct dependency")
+	}
+	if len(details) > 0 {
+		line += " (" + strings.Join(details, ", ") + ")"
+	}
+	return line
+}
+
+// PrintCoverage displays which scanners ran, so that "no findings" can be
+// told apart from "scanner never ran"
+func PrintCoverage(results *scanner.ScanResults) {
+	if len(results.ToolRuns) == 0 {
+		return
+	}
+
+	fmt.Println(infoStyle.Render("🧪 Scanner Coverage"))
+	fmt.Println(strings.Repeat("─", 60))
+	fmt.Printf("  %-3s %-18s %-8s %8s %9s  %s\n", "", "Tool", "Status", "Findings", "Duration", "Details")
+	for _, run := range results.ToolRuns {
+		icon := "✅"
+		switch run.Status {
+		case scanner.ToolStatusSkipped:
+			icon = "⏭️"
+		case scanner.ToolStatusFailed:
+			icon = "❌"
+		case scanner.ToolStatusTimeout:
+			icon = "⏱️"
+		}
+
+		findings, duration := "-", "-"
+		if run.Status != scanner.ToolStatusSkipped {
+			findings = fmt.Sprintf("%d", run.Findings)
+		}
+		if run.Status != scanner.ToolStatusSkipped && run.Source == "" {
+			duration = run.Duration.Round(time.Millisecond).String()
+		}
+
+		details := run.Error
+		if details == "" {
+			details = run.Version
+		}
+		if run.Status == scanner.ToolStatusFailed && run.Stderr != "" {
+			details += ": " + lastLine(run.Stderr)
+		}
+		if run.Source != "" {
+			details = strings.TrimSpace(details + " (imported from " + run.Source + ")")
+		}
+		if run.Cached {
+			details += " (cached)"
+		}
+		if len(run.Unparsed) > 0 {
+			details += fmt.Sprintf(" (%d files not parsed)", len(run.Unparsed))
+		}
+
+		fmt.Printf("  %-3s %-18s %-8s %8s %9s  %s\n", icon, run.Tool, run.Status, findings, duration, details)
+	}
+	fmt.Println()
+}
+
+// lastLine returns the last non-empty line of s
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// printWarnings displays scanners that did not complete
+func printWarnings(results *scanner.ScanResults) {
+	if len(results.Warnings) == 0 {
+		return
+	}
+	fmt.Println(highStyle.Render("⚠️  Partial Scan"))
+	fmt.Println(strings.Repeat("─", 60))
+	for _, warning := range results.Warnings {
+		fmt.Printf("  • %s\n", warning)
+	}
+	fmt.Println()
+}
+
+// printSuppressions lists the inline dso:ignore comments with their reason.
+// Expired and invalid ones no longer suppress anything.
+func printSuppressions(results *scanner.ScanResults) {
+	if len(results.Suppressions) == 0 {
+		return
+	}
+	fmt.Println(infoStyle.Render("🔕 Suppressions"))
+	fmt.Println(strings.Repeat("─", 60))
+	for _, s := range results.Suppressions {
+		icon := "🔕"
+		switch s.Status {
+		case scanner.SuppressionExpired:
+			icon = "⌛"
+		case scanner.SuppressionInvalid:
+			icon = "❌"
+		case scanner.SuppressionUnused:
+			icon = "💤"
+		}
+
+		fmt.Printf("  %s %s:%d %s [%s", icon, s.File, s.Line, s.Rule, s.Status)
+		if s.Status == scanner.SuppressionActive {
+			fmt.Printf(", %d findings", s.Findings)
+		}
+		if s.Until != "" {
+			fmt.Printf(", until %s", s.Until)
+		}
+		fmt.Println("]")
+		if s.Reason != "" {
+			fmt.Printf("     %s\n", s.Reason)
+		}
+		if s.Error != "" {
+			fmt.Printf("     %s\n", s.Error)
+		}
+	}
+	fmt.Println()
+}
+
+// severityStyle returns the style of a severity
+func severityStyle(s scanner.Severity) lipgloss.Style {
+	switch s {
+	case scanner.SeverityCritical:
+		return criticalStyle
+	case scanner.SeverityHigh:
+		return highStyle
+	case scanner.SeverityMedium:
+		return mediumStyle
+	default:
+		return lowStyle
+	}
+}
+
+// classificationLine returns the CWE, OWASP Top 10 and ASVS references of a
+// finding: "CWE-89 · A03:2021-Injection · ASVS V5.3.4"
+func classificationLine(f scanner.Finding) string {
+	parts := append([]string{}, f.CWE...)
+	parts = append(parts, f.OWASP...)
+	if len(f.ASVS) > 0 {
+		parts = append(parts, "ASVS "+strings.Join(f.ASVS, ", "))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// commitLine describes the commit that introduced a secret:
+// "1a2b3c4d5e6f by Jane Doe on 2024-03-01, still in HEAD"
+func commitLine(c *scanner.Commit) string {
+	sha := c.SHA
+	if len(sha) > 12 {
+		sha = sha[:12]
+	}
+	line := sha
+	if c.Author != "" {
+		line += " by " + c.Author
+	}
+	if !c.Date.IsZero() {
+		line += " on " + c.Date.Format("2006-01-02")
+	}
+	if c.InHead {
+		return line + ", still in HEAD"
+	}
+	return line + ", history only"
+}
+
+// printGrouping lists the findings by CWE or OWASP category (--group-by)
+func printGrouping(results *scanner.ScanResults) {
+	if results.Grouping == nil {
+		return
+	}
+	byFingerprint := make(map[string]scanner.Finding, len(results.Findings))
+	for _, f := range results.Findings {
+		byFingerprint[f.Fingerprint] = f
+	}
+
+	fmt.Println(infoStyle.Render("🏷️  Findings by " + strings.ToUpper(results.Grouping.By)))
+	fmt.Println(strings.Repeat("─", 60))
+	for _, g := range results.Grouping.Groups {
+		label := g.Key
+		if g.Name != "" {
+			label += " " + g.Name
+		}
+		fmt.Printf("  %s %s (%d)\n", severityStyle(g.Severity).Render(string(g.Severity)), label, len(g.Findings))
+		for _, fp := range g.Findings {
+			f := byFingerprint[fp]
+			fmt.Printf("     • %s", f.Title)
+			if f.File != "" {
+				fmt.Printf(" (%s", f.File)
+				if f.Line > 0 {
+					fmt.Printf(":%d", f.Line)
+				}
+				fmt.Print(")")
+			}
+			fmt.Println()
+		}
+	}
+	fmt.Println()
+}
+
+// printBaselineFixed lists the baseline entries that are no longer found
+func printBaselineFixed(results *scanner.ScanResults) {
+	if results.Baseline == nil || len(results.Baseline.Fixed) == 0 {
+		return
+	}
+	fmt.Println(successStyle.Render("🎉 Fixed Since Baseline"))
+	fmt.Println(strings.Repeat("─", 60))
+	for _, e := range results.Baseline.Fixed {
+		fmt.Printf("  ✅ %s", e.Title)
+		if e.File != "" {
+			fmt.Printf(" (%s)", e.File)
+		}
+		fmt.Println()
+	}
+	fmt.Println("  💡 Remove them from the baseline with: dso baseline update")
+	fmt.Println()
+}
+
 // printJSON affiche les résultats en JSON
 func printJSON(analysis *llm.AnalysisResult, results *scanner.ScanResults) {
 	output := map[string]interface{}{
@@ -0,0 +1,928 @@
+// Package yaml parses YAML documents into a tree of nodes that keep their
+// line numbers, for the native scanners. It supports the block and flow
+// styles, block scalars, explicit keys, anchors, aliases and merge keys,
+// multi-document streams and comments; tags are ignored and scalars are not
+// resolved to typed values.
+package yaml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind is the kind of a node
+type Kind int
+
+const (
+	ScalarNode Kind = iota
+	MappingNode
+	SequenceNode
+)
+
+// Node is a node of a YAML document
+type Node struct {
+	Kind Kind
+	// Value is the value of a scalar, "" for null
+	Value string
+	// Null is true for an empty or null scalar
+	Null bool
+	// Block is true for a literal (|) or folded (>) block scalar, whose
+	// content starts on the line after Line
+	Block bool
+	// Line and Column locate the node, 1-based. The line of a mapping or a
+	// sequence is the line of its first entry.
+	Line   int
+	Column int
+	// Content holds the items of a sequence, or the keys and values of a
+	// mapping, alternated
+	Content []*Node
+}
+
+// Get returns the value of key in a mapping, or nil. Keys merged with
+// "<<" are looked up after the keys of the mapping itself.
+func (n *Node) Get(key string) *Node {
+	if _, v := n.Lookup(key); v != nil {
+		return v
+	}
+	return nil
+}
+
+// Lookup returns the key and value nodes of key in a mapping, or nil
+func (n *Node) Lookup(key string) (k, v *Node) {
+	if n == nil || n.Kind != MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key && n.Content[i].Kind == ScalarNode {
+			return n.Content[i], n.Content[i+1]
+		}
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value != "<<" {
+			continue
+		}
+		merged := n.Content[i+1]
+		sources := []*Node{merged}
+		if merged.Kind == SequenceNode {
+			sources = merged.Content
+		}
+		for _, source := range sources {
+			if source == n {
+				continue
+			}
+			if k, v := source.Lookup(key); v != nil {
+				return k, v
+			}
+		}
+	}
+	return nil, nil
+}
+
+// Path follows keys from n, returning nil when one is missing
+func (n *Node) Path(keys ...string) *Node {
+	for _, key := range keys {
+		n = n.Get(key)
+	}
+	return n
+}
+
+// Items returns the items of a sequence, or nil
+func (n *Node) Items() []*Node {
+	if n == nil || n.Kind != SequenceNode {
+		return nil
+	}
+	return n.Content
+}
+
+// Entry is a key and its value in a mapping
+type Entry struct {
+	Key   *Node
+	Value *Node
+}
+
+// Entries returns the entries of a mapping in document order, or nil.
+// Merge keys are returned as they are.
+func (n *Node) Entries() []Entry {
+	if n == nil || n.Kind != MappingNode {
+		return nil
+	}
+	entries := make([]Entry, 0, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		entries = append(entries, Entry{Key: n.Content[i], Value: n.Content[i+1]})
+	}
+	return entries
+}
+
+// String returns the value of a scalar, or ""
+func (n *Node) String() string {
+	if n == nil || n.Kind != ScalarNode {
+		return ""
+	}
+	return n.Value
+}
+
+// Bool reports whether n is a true scalar: true, yes or on, in any case
+func (n *Node) Bool() bool {
+	switch strings.ToLower(n.String()) {
+	case "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// IsFalse reports whether n is a false scalar: false, no or off, in any case
+func (n *Node) IsFalse() bool {
+	switch strings.ToLower(n.String()) {
+	case "false", "no", "off":
+		return true
+	}
+	return false
+}
+
+// line is a line of the document: its indentation and its content
+type line struct {
+	num     int
+	indent  int
+	content string
+}
+
+type parser struct {
+	lines   []line
+	pos     int
+	anchors map[string]*Node
+}
+
+// Parse parses a stream of YAML documents. Empty documents are returned
+// as nil nodes. A syntax error stops the parsing of the stream.
+func Parse(data []byte) ([]*Node, error) {
+	p := &parser{}
+	text := strings.TrimSuffix(string(data), "\n")
+	for i, raw := range strings.Split(text, "\n") {
+		raw = strings.TrimRight(raw, "\r")
+		content := strings.TrimLeft(raw, " ")
+		p.lines = append(p.lines, line{num: i + 1, indent: len(raw) - len(content), content: content})
+	}
+
+	var docs []*Node
+	started := false
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.lines) {
+			break
+		}
+		l := &p.lines[p.pos]
+		if l.indent == 0 && strings.HasPrefix(l.content, "%") {
+			p.pos++ // Directive
+			continue
+		}
+		if marker, rest := documentMarker(*l); marker != "" {
+			if marker == "---" && started {
+				docs = append(docs, nil)
+			}
+			started = marker == "---"
+			if rest == "" {
+				p.pos++
+			} else {
+				// "--- value": the value starts after the marker
+				l.indent += len(l.content) - len(rest)
+				l.content = rest
+			}
+			continue
+		}
+
+		p.anchors = make(map[string]*Node)
+		start := p.pos
+		root, err := p.parseBlock(-1)
+		if err != nil {
+			return docs, err
+		}
+		if p.pos == start {
+			return docs, fmt.Errorf("line %d: unexpected content %q", l.num, l.content)
+		}
+		docs = append(docs, root)
+		started = false
+
+		// Anything but the end of the document is an error
+		p.skipBlank()
+		if p.pos < len(p.lines) {
+			if marker, _ := documentMarker(p.lines[p.pos]); marker == "" {
+				return docs, fmt.Errorf("line %d: unexpected content %q", p.lines[p.pos].num, p.lines[p.pos].content)
+			}
+		}
+	}
+	if started {
+		docs = append(docs, nil)
+	}
+	return docs, nil
+}
+
+// documentMarker returns the document start (---) or end (...) marker of
+// a line, and the content that follows it
+func documentMarker(l line) (string, string) {
+	if l.indent != 0 {
+		return "", ""
+	}
+	for _, marker := range []string{"---", "..."} {
+		if l.content == marker {
+			return marker, ""
+		}
+		if rest, ok := strings.CutPrefix(l.content, marker+" "); ok {
+			rest = strings.TrimLeft(rest, " ")
+			if strings.HasPrefix(rest, "#") {
+				rest = ""
+			}
+			return marker, rest
+		}
+	}
+	return "", ""
+}
+
+// skipBlank skips the empty and comment lines
+func (p *parser) skipBlank() {
+	for p.pos < len(p.lines) {
+		c := strings.TrimLeft(p.lines[p.pos].content, " \t")
+		if c != "" && !strings.HasPrefix(c, "#") && strings.TrimSpace(c) != "" {
+			return
+		}
+		p.pos++
+	}
+}
+
+// next returns the next content line if it is indented more than parent
+func (p *parser) next(parent int) (*line, bool) {
+	p.skipBlank()
+	if p.pos >= len(p.lines) {
+		return nil, false
+	}
+	l := &p.lines[p.pos]
+	if marker, _ := documentMarker(*l); marker != "" || l.indent <= parent {
+		return nil, false
+	}
+	return l, true
+}
+
+// checkIndent rejects a line indented with tabs, which YAML forbids: the
+// line would otherwise be read at the wrong level
+func checkIndent(l *line) error {
+	if strings.HasPrefix(l.content, "\t") {
+		return fmt.Errorf("line %d: tabs are not allowed in indentation", l.num)
+	}
+	return nil
+}
+
+// parseBlock parses the node whose lines are indented more than parent,
+// or returns nil if there is none
+func (p *parser) parseBlock(parent int) (*Node, error) {
+	l, ok := p.next(parent)
+	if !ok {
+		return nil, nil
+	}
+	if err := checkIndent(l); err != nil {
+		return nil, err
+	}
+	switch {
+	case isSequenceItem(l.content):
+		return p.parseSequence(l.indent)
+	case isIndicator(l.content, '?') || mappingColon(l.content) >= 0:
+		return p.parseMapping(l.indent)
+	}
+	p.pos++
+	return p.parseValue(l.content, l.num, l.indent+1, parent)
+}
+
+// parseMapping parses the entries of a block mapping at indent
+func (p *parser) parseMapping(indent int) (*Node, error) {
+	node := &Node{Kind: MappingNode}
+	for {
+		l, ok := p.next(indent - 1)
+		if !ok {
+			break
+		}
+		if err := checkIndent(l); err != nil {
+			return nil, err
+		}
+		if l.indent != indent || isSequenceItem(l.content) {
+			break
+		}
+		if node.Line == 0 {
+			node.Line, node.Column = l.num, l.indent+1
+		}
+		if isIndicator(l.content, '?') {
+			key, value, err := p.parseExplicitEntry(indent)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, key, value)
+			continue
+		}
+		colon := mappingColon(l.content)
+		if colon < 0 {
+			return nil, fmt.Errorf("line %d: expected a mapping key, found %q", l.num, l.content)
+		}
+
+		key := &Node{Kind: ScalarNode, Value: scalarValue(strings.TrimSpace(l.content[:colon])), Line: l.num, Column: l.indent + 1}
+		rest := strings.TrimLeft(l.content[colon+1:], " \t")
+		valueColumn := l.indent + len(l.content) - len(rest) + 1
+		p.pos++
+
+		value, err := p.parseValue(rest, l.num, valueColumn, indent)
+		if err != nil {
+			return nil, err
+		}
+		if value.Null && !value.Block {
+			// "key:" followed by a sequence at the same indentation
+			if next, ok := p.next(indent - 1); ok && next.indent == indent && isSequenceItem(next.content) {
+				if value, err = p.parseSequence(indent); err != nil {
+					return nil, err
+				}
+			}
+		}
+		node.Content = append(node.Content, key, value)
+	}
+	return node, nil
+}
+
+// parseSequence parses the items of a block sequence at indent
+func (p *parser) parseSequence(indent int) (*Node, error) {
+	node := &Node{Kind: SequenceNode}
+	for {
+		l, ok := p.next(indent - 1)
+		if !ok {
+			break
+		}
+		if err := checkIndent(l); err != nil {
+			return nil, err
+		}
+		if l.indent != indent || !isSequenceItem(l.content) {
+			break
+		}
+		if node.Line == 0 {
+			node.Line, node.Column = l.num, l.indent+1
+		}
+
+		item, err := p.parseIndicated(indent)
+		if err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, item)
+	}
+	return node, nil
+}
+
+// parseExplicitEntry parses an entry of a block mapping at indent whose
+// key is introduced by "?", and its optional ":" value line
+func (p *parser) parseExplicitEntry(indent int) (*Node, *Node, error) {
+	l := &p.lines[p.pos]
+	num, column := l.num, l.indent+1
+	key, err := p.parseIndicated(indent)
+	if err != nil {
+		return nil, nil, err
+	}
+	l, ok := p.next(indent - 1)
+	if !ok || l.indent != indent || !isIndicator(l.content, ':') {
+		return key, &Node{Kind: ScalarNode, Null: true, Line: num, Column: column}, nil
+	}
+	value, err := p.parseIndicated(indent)
+	return key, value, err
+}
+
+// parseIndicated parses the node that follows the indicator ("-", "?" or
+// ":") of the current line, at indent, on the same line or below it
+func (p *parser) parseIndicated(indent int) (*Node, error) {
+	l := &p.lines[p.pos]
+	rest := strings.TrimLeft(l.content[1:], " \t")
+	if rest == "" || strings.HasPrefix(rest, "#") {
+		num, column := l.num, l.indent+1
+		p.pos++
+		node, err := p.parseBlock(indent)
+		if node == nil && err == nil {
+			node = &Node{Kind: ScalarNode, Null: true, Line: num, Column: column}
+		}
+		return node, err
+	}
+	// The node starts on the line of the indicator: parse the rest of the
+	// line as if it was on its own line, at its column
+	l.indent += len(l.content) - len(rest)
+	l.content = rest
+	return p.parseBlock(indent)
+}
+
+// parseValue parses a value that starts inline, after a mapping key or on
+// its own line. The line of text was consumed; block scalars, flow
+// collections and plain scalars continue on the following lines indented
+// more than parent.
+func (p *parser) parseValue(text string, num, column, parent int) (*Node, error) {
+	anchor := ""
+	for {
+		text = strings.TrimLeft(text, " \t")
+		switch {
+		case strings.HasPrefix(text, "&"):
+			name, rest, _ := strings.Cut(text[1:], " ")
+			anchor, text = name, rest
+			continue
+		case strings.HasPrefix(text, "!"):
+			// Tags are ignored
+			_, rest, _ := strings.Cut(text, " ")
+			text = rest
+			continue
+		}
+		break
+	}
+	if strings.HasPrefix(text, "#") {
+		text = ""
+	}
+
+	var node *Node
+	var err error
+	switch {
+	case text == "":
+		node, err = p.parseBlock(parent)
+		if node == nil && err == nil {
+			node = &Node{Kind: ScalarNode, Null: true, Line: num, Column: column}
+		}
+	case strings.HasPrefix(text, "*"):
+		name := strings.Fields(text[1:])[0]
+		node = p.anchors[name]
+		if node == nil {
+			return nil, fmt.Errorf("line %d: unknown anchor %q", num, name)
+		}
+	case text[0] == '|' || text[0] == '>':
+		node = p.parseBlockScalar(text, num, column, parent)
+	case text[0] == '[' || text[0] == '{':
+		node, err = p.parseFlow(text, num, column, parent)
+	case text[0] == '"' || text[0] == '\'':
+		node, err = p.parseQuoted(text, num, column)
+	default:
+		node, err = p.parsePlain(text, num, column, parent)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if anchor != "" {
+		p.anchors[anchor] = node
+	}
+	return node, nil
+}
+
+// parseBlockScalar parses a literal or folded block scalar
+func (p *parser) parseBlockScalar(header string, num, column, parent int) *Node {
+	header = strings.TrimSpace(strings.SplitN(header, " #", 2)[0])
+	folded := header[0] == '>'
+	chomp := byte(0)
+	indent := 0
+	for _, c := range header[1:] {
+		switch {
+		case c == '-' || c == '+':
+			chomp = byte(c)
+		case c >= '1' && c <= '9':
+			indent = max(parent, 0) + int(c-'0')
+		}
+	}
+
+	var lines []string
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		raw := strings.Repeat(" ", l.indent) + l.content
+		if strings.TrimSpace(raw) == "" {
+			lines = append(lines, "")
+			p.pos++
+			continue
+		}
+		if indent == 0 {
+			if l.indent <= parent {
+				break
+			}
+			indent = l.indent
+		}
+		if l.indent < indent {
+			break
+		}
+		lines = append(lines, raw[indent:])
+		p.pos++
+	}
+
+	// Trailing empty lines belong to the scalar only with the keep indicator
+	content := len(lines)
+	for content > 0 && lines[content-1] == "" {
+		content--
+	}
+	if content < len(lines) && chomp != '+' {
+		// Leave the empty lines to the next node, to keep line numbers
+		p.pos -= len(lines) - content
+		lines = lines[:content]
+	}
+
+	var value string
+	if folded {
+		// A line break between two lines is folded into a space; empty
+		// lines are kept as line breaks instead, and the lines around
+		// more indented lines are not folded
+		var sb strings.Builder
+		previous := ""
+		for i, l := range lines {
+			if l == "" {
+				sb.WriteByte('\n')
+				continue
+			}
+			if previous != "" {
+				switch {
+				case strings.HasPrefix(l, " ") || strings.HasPrefix(previous, " "):
+					sb.WriteByte('\n')
+				case lines[i-1] != "":
+					sb.WriteByte(' ')
+				}
+			}
+			sb.WriteString(l)
+			previous = l
+		}
+		value = sb.String()
+	} else {
+		value = strings.Join(lines, "\n")
+	}
+	if chomp != '-' && content > 0 {
+		value += "\n"
+	}
+	return &Node{Kind: ScalarNode, Value: value, Block: true, Line: num, Column: column}
+}
+
+// parseQuoted parses a single or double-quoted scalar, which may span lines
+func (p *parser) parseQuoted(text string, num, column int) (*Node, error) {
+	quote := text[0]
+	for {
+		if end := closingQuote(text, quote); end > 0 {
+			value, err := unquote(text[:end+1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", num, err)
+			}
+			return &Node{Kind: ScalarNode, Value: value, Line: num, Column: column}, nil
+		}
+		if p.pos >= len(p.lines) {
+			return nil, fmt.Errorf("line %d: unterminated quoted string", num)
+		}
+		// Line breaks of multi-line quoted scalars are folded
+		next := strings.TrimSpace(p.lines[p.pos].content)
+		if next == "" {
+			text += "\\n"
+		} else {
+			text += " " + next
+		}
+		p.pos++
+	}
+}
+
+// parsePlain parses a plain scalar, joining its continuation lines
+func (p *parser) parsePlain(text string, num, column, parent int) (*Node, error) {
+	if mappingColon(text) >= 0 {
+		// "a: b: c"
+		return nil, fmt.Errorf("line %d: mapping values are not allowed in %q", num, text)
+	}
+	value := stripComment(text)
+	for {
+		l, ok := p.next(parent)
+		if !ok || isSequenceItem(l.content) || mappingColon(l.content) >= 0 {
+			break
+		}
+		value += " " + stripComment(l.content)
+		p.pos++
+	}
+	node := &Node{Kind: ScalarNode, Value: value, Line: num, Column: column}
+	if value == "~" || value == "null" || value == "Null" || value == "NULL" {
+		node.Value, node.Null = "", true
+	}
+	return node, nil
+}
+
+// parseFlow parses a flow sequence or mapping, which may span lines
+func (p *parser) parseFlow(text string, num, column, parent int) (*Node, error) {
+	for flowDepth(text) > 0 {
+		if p.pos >= len(p.lines) {
+			return nil, fmt.Errorf("line %d: unterminated flow collection", num)
+		}
+		// Keep the indentation for the columns of the nodes
+		text += "\n" + strings.Repeat(" ", p.lines[p.pos].indent) + p.lines[p.pos].content
+		p.pos++
+	}
+	f := &flowParser{text: text, line: num, column: column, anchors: p.anchors}
+	node, err := f.parse()
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// isSequenceItem reports whether content is a block sequence item
+func isSequenceItem(content string) bool {
+	return isIndicator(content, '-')
+}
+
+// isIndicator reports whether content starts with the block indicator c
+// ("-", "?" or ":") followed by a space or the end of the line
+func isIndicator(content string, c byte) bool {
+	return content != "" && content[0] == c && (len(content) == 1 || content[1] == ' ' || content[1] == '\t')
+}
+
+// mappingColon returns the index of the colon that ends the key of a block
+// mapping entry, or -1 if content is not a mapping entry
+func mappingColon(content string) int {
+	i := 0
+	if content != "" && (content[0] == '"' || content[0] == '\'') {
+		end := closingQuote(content, content[0])
+		if end < 0 {
+			return -1
+		}
+		i = end + 1
+	} else if content != "" && strings.ContainsRune("[{&*!|>%@`", rune(content[0])) {
+		return -1
+	}
+	for ; i < len(content); i++ {
+		switch content[i] {
+		case ':':
+			if i+1 == len(content) || content[i+1] == ' ' || content[i+1] == '\t' {
+				return i
+			}
+		case '#':
+			if i > 0 && (content[i-1] == ' ' || content[i-1] == '\t') {
+				return -1
+			}
+		}
+	}
+	return -1
+}
+
+// closingQuote returns the index of the quote closing the string that
+// starts at s[0], or -1
+func closingQuote(s string, quote byte) int {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case quote == '\'' && s[i] == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// unquote decodes a quoted scalar
+func unquote(s string) (string, error) {
+	if s[0] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	var sb strings.Builder
+	body := s[1 : len(s)-1]
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if c != '\\' || i+1 == len(body) {
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+		switch body[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case '0':
+			sb.WriteByte(0)
+		case ' ', '"', '\\', '/':
+			sb.WriteByte(body[i])
+		case 'x', 'u', 'U':
+			size := map[byte]int{'x': 2, 'u': 4, 'U': 8}[body[i]]
+			if i+size >= len(body) {
+				return "", fmt.Errorf("invalid escape in %s", s)
+			}
+			r, err := strconv.ParseUint(body[i+1:i+1+size], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape in %s", s)
+			}
+			sb.WriteRune(rune(r))
+			i += size
+		default:
+			sb.WriteByte('\\')
+			sb.WriteByte(body[i])
+		}
+	}
+	return sb.String(), nil
+}
+
+// scalarValue returns the value of a key, quoted or plain
+func scalarValue(s string) string {
+	if s != "" && (s[0] == '"' || s[0] == '\'') {
+		if end := closingQuote(s, s[0]); end == len(s)-1 {
+			if v, err := unquote(s); err == nil {
+				return v
+			}
+		}
+	}
+	return s
+}
+
+// stripComment removes the comment at the end of a plain scalar
+func stripComment(s string) string {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '#' && (s[i-1] == ' ' || s[i-1] == '\t') {
+			return strings.TrimSpace(s[:i])
+		}
+	}
+	return strings.TrimSpace(s)
+}
+
+// flowDepth returns the number of unclosed brackets and braces of s
+func flowDepth(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\'':
+			if end := closingQuote(s[i:], c); end > 0 {
+				i += end
+			} else {
+				return depth
+			}
+		case '#':
+			if i > 0 && (s[i-1] == ' ' || s[i-1] == '\n') {
+				// Comment up to the end of the line
+				for i < len(s) && s[i] != '\n' {
+					i++
+				}
+			}
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+// flowParser parses a flow collection
+type flowParser struct {
+	text    string
+	pos     int
+	line    int // line of text[0]
+	column  int // column of text[0]
+	anchors map[string]*Node
+}
+
+// location returns the line and column of the current position
+func (f *flowParser) location() (int, int) {
+	before := f.text[:f.pos]
+	nl := strings.Count(before, "\n")
+	if nl == 0 {
+		return f.line, f.column + f.pos
+	}
+	return f.line + nl, f.pos - strings.LastIndex(before, "\n")
+}
+
+func (f *flowParser) skipSpace() {
+	for f.pos < len(f.text) {
+		switch f.text[f.pos] {
+		case ' ', '\t', '\n', '\r':
+			f.pos++
+		case '#':
+			for f.pos < len(f.text) && f.text[f.pos] != '\n' {
+				f.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (f *flowParser) errorf(format string, args ...any) error {
+	line, _ := f.location()
+	return fmt.Errorf("line %d: "+format, append([]any{line}, args...)...)
+}
+
+func (f *flowParser) parse() (*Node, error) {
+	f.skipSpace()
+	if f.pos >= len(f.text) {
+		return nil, f.errorf("unexpected end of flow collection")
+	}
+	line, column := f.location()
+	switch f.text[f.pos] {
+	case '[':
+		f.pos++
+		node := &Node{Kind: SequenceNode, Line: line, Column: column}
+		for {
+			f.skipSpace()
+			if f.pos < len(f.text) && f.text[f.pos] == ']' {
+				f.pos++
+				return node, nil
+			}
+			item, err := f.parse()
+			if err != nil {
+				return nil, err
+			}
+			f.skipSpace()
+			if f.pos < len(f.text) && f.text[f.pos] == ':' {
+				// Single pair mapping: [a: b]
+				f.pos++
+				value, err := f.parse()
+				if err != nil {
+					return nil, err
+				}
+				item = &Node{Kind: MappingNode, Line: item.Line, Column: item.Column, Content: []*Node{item, value}}
+			}
+			node.Content = append(node.Content, item)
+			if err := f.separator(']'); err != nil {
+				return nil, err
+			}
+		}
+	case '{':
+		f.pos++
+		node := &Node{Kind: MappingNode, Line: line, Column: column}
+		for {
+			f.skipSpace()
+			if f.pos < len(f.text) && f.text[f.pos] == '}' {
+				f.pos++
+				return node, nil
+			}
+			key, err := f.parse()
+			if err != nil {
+				return nil, err
+			}
+			f.skipSpace()
+			value := &Node{Kind: ScalarNode, Null: true, Line: key.Line, Column: key.Column}
+			if f.pos < len(f.text) && f.text[f.pos] == ':' {
+				f.pos++
+				f.skipSpace()
+				if f.pos < len(f.text) && f.text[f.pos] != ',' && f.text[f.pos] != '}' {
+					if value, err = f.parse(); err != nil {
+						return nil, err
+					}
+				}
+			}
+			node.Content = append(node.Content, key, value)
+			if err := f.separator('}'); err != nil {
+				return nil, err
+			}
+		}
+	case '"', '\'':
+		end := closingQuote(f.text[f.pos:], f.text[f.pos])
+		if end < 0 {
+			return nil, f.errorf("unterminated quoted string")
+		}
+		value, err := unquote(f.text[f.pos : f.pos+end+1])
+		if err != nil {
+			return nil, f.errorf("%v", err)
+		}
+		f.pos += end + 1
+		return &Node{Kind: ScalarNode, Value: value, Line: line, Column: column}, nil
+	case '*':
+		start := f.pos + 1
+		for f.pos < len(f.text) && !strings.ContainsRune(" \t\n,]}", rune(f.text[f.pos])) {
+			f.pos++
+		}
+		node := f.anchors[f.text[start:f.pos]]
+		if node == nil {
+			return nil, f.errorf("unknown anchor %q", f.text[start:f.pos])
+		}
+		return node, nil
+	case ']', '}', ',':
+		return nil, f.errorf("unexpected %q", f.text[f.pos])
+	}
+
+	// Plain scalar, up to an indicator of the flow collection
+	start := f.pos
+	for f.pos < len(f.text) {
+		c := f.text[f.pos]
+		if c == ',' || c == ']' || c == '}' || c == '\n' {
+			break
+		}
+		if c == ':' && (f.pos+1 == len(f.text) || strings.ContainsRune(" \t\n,]}", rune(f.text[f.pos+1]))) {
+			break
+		}
+		if c == '#' && f.pos > start && f.text[f.pos-1] == ' ' {
+			break
+		}
+		f.pos++
+	}
+	value := strings.TrimSpace(f.text[start:f.pos])
+	node := &Node{Kind: ScalarNode, Value: value, Line: line, Column: column}
+	if value == "" || value == "~" || value == "null" {
+		node.Value, node.Null = "", true
+	}
+	return node, nil
+}
+
+// separator consumes the comma between two entries, or checks for the end
+// of the collection
+func (f *flowParser) separator(end byte) error {
+	f.skipSpace()
+	if f.pos >= len(f.text) {
+		return f.errorf("unterminated flow collection")
+	}
+	switch f.text[f.pos] {
+	case ',':
+		f.pos++
+		return nil
+	case end:
+		return nil
+	}
+	return f.errorf("expected ',' or %q, found %q", end, f.text[f.pos])
+}
@@ -0,0 +1,278 @@
+package yaml
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// dump renders a node compactly: {k: v}, [a, b], "scalar" and ~ for null
+func dump(n *Node) string {
+	if n == nil {
+		return "<nil>"
+	}
+	switch n.Kind {
+	case MappingNode:
+		var entries []string
+		for _, e := range n.Entries() {
+			entries = append(entries, dump(e.Key)+": "+dump(e.Value))
+		}
+		return "{" + strings.Join(entries, ", ") + "}"
+	case SequenceNode:
+		var items []string
+		for _, item := range n.Items() {
+			items = append(items, dump(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	if n.Null {
+		return "~"
+	}
+	return fmt.Sprintf("%q", n.Value)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"block mapping", "a: 1\nb: two\n", `{"a": "1", "b": "two"}`},
+		{"nested mapping", "a:\n  b:\n    c: d\n", `{"a": {"b": {"c": "d"}}}`},
+		{"block sequence", "- a\n- b\n", `["a", "b"]`},
+		{"sequence at key indentation", "a:\n- 1\n- 2\nb: 3\n", `{"a": ["1", "2"], "b": "3"}`},
+		{"mapping in sequence", "- name: x\n  run: y\n- name: z\n", `[{"name": "x", "run": "y"}, {"name": "z"}]`},
+		{"nested sequences", "- - a\n  - b\n- c\n", `[["a", "b"], "c"]`},
+		{"null values", "a:\nb: ~\nc: null\n", `{"a": ~, "b": ~, "c": ~}`},
+		{"comments", "# head\na: 1 # trailing\n\n# between\nb: '#not a comment'\n", `{"a": "1", "b": "#not a comment"}`},
+		{"tabs outside indentation", "a:\t1\n\t# comment\nb: |\n  \tx\nc: one\n  \ttwo\n", `{"a": "1", "b": "\tx\n", "c": "one two"}`},
+		{"plain scalar with colons", "url: http://example.com:8080/x\n", `{"url": "http://example.com:8080/x"}`},
+		{"multi-line plain scalar", "a: one\n  two\n  three\n", `{"a": "one two three"}`},
+		{"double-quoted", `a: "x\ty \"z\" \u00e9"` + "\n", `{"a": "x\ty \"z\" é"}`},
+		{"single-quoted", "a: 'it''s'\n", `{"a": "it's"}`},
+		{"quoted key", "\"a b\": 1\n'on': 2\n", `{"a b": "1", "on": "2"}`},
+		{"multi-line quoted", "a: \"one\n  two\"\n", `{"a": "one two"}`},
+		{"flow sequence", "a: [1, 'two', \"three\"]\n", `{"a": ["1", "two", "three"]}`},
+		{"flow mapping", "a: {b: 1, c: [x, y], d: }\n", `{"a": {"b": "1", "c": ["x", "y"], "d": ~}}`},
+		{"multi-line flow", "a: [\n  1,\n  2, # two\n]\n", `{"a": ["1", "2"]}`},
+		{"flow single pair", "a: [b: c]\n", `{"a": [{"b": "c"}]}`},
+		{"empty flow", "a: []\nb: {}\n", `{"a": [], "b": {}}`},
+		{"literal block", "a: |\n  one\n   two\n\nb: x\n", `{"a": "one\n two\n", "b": "x"}`},
+		{"literal strip", "a: |-\n  one\n  two\n", `{"a": "one\ntwo"}`},
+		{"literal keep", "a: |+\n  one\n\n", `{"a": "one\n\n"}`},
+		{"folded block", "a: >\n  one\n  two\n\n  three\n", `{"a": "one two\nthree\n"}`},
+		{"folded more indented", "a: >\n  one\n    two\n  three\n", `{"a": "one\n  two\nthree\n"}`},
+		{"explicit indentation", "a: |2\n    x\n  y\n", `{"a": "  x\ny\n"}`},
+		{"block scalar in sequence", "- |\n  x\n- y\n", `["x\n", "y"]`},
+		{"anchor and alias", "a: &v 1\nb: *v\n", `{"a": "1", "b": "1"}`},
+		{"anchored mapping", "base: &base\n  x: 1\nother: *base\n", `{"base": {"x": "1"}, "other": {"x": "1"}}`},
+		{"alias in flow", "a: &v x\nb: [*v, y]\n", `{"a": "x", "b": ["x", "y"]}`},
+		{"tags are ignored", "a: !!str 1\nb: !Ref x\n", `{"a": "1", "b": "x"}`},
+		{"explicit key", "? a\n: 1\n? b\n", `{"a": "1", "b": ~}`},
+		{"explicit complex key", "? [a, b]\n: c\n", `{["a", "b"]: "c"}`},
+		{"explicit key with block value", "? a\n:\n  b: c\nd: e\n", `{"a": {"b": "c"}, "d": "e"}`},
+		{"document start", "---\na: 1\n", `{"a": "1"}`},
+		{"directive", "%YAML 1.2\n---\na: 1\n", `{"a": "1"}`},
+		{"scalar document", "--- hello\n", `"hello"`},
+		{"crlf", "a: 1\r\nb:\r\n  - x\r\n", `{"a": "1", "b": ["x"]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := Parse([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if len(docs) != 1 {
+				t.Fatalf("got %d documents, want 1", len(docs))
+			}
+			if got := dump(docs[0]); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStream(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"empty", "", nil},
+		{"comments only", "# nothing\n", nil},
+		{"two documents", "a: 1\n---\nb: 2\n", []string{`{"a": "1"}`, `{"b": "2"}`}},
+		{"empty document", "---\n---\na: 1\n", []string{"<nil>", `{"a": "1"}`}},
+		{"document end", "a: 1\n...\n---\nb: 2\n...\n", []string{`{"a": "1"}`, `{"b": "2"}`}},
+		{"trailing marker", "a: 1\n---\n", []string{`{"a": "1"}`, "<nil>"}},
+		{"anchors are per document", "a: &v 1\n---\nb: &v 2\nc: *v\n", []string{`{"a": "1"}`, `{"b": "2", "c": "2"}`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := Parse([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			var got []string
+			for _, doc := range docs {
+				got = append(got, dump(doc))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		line string
+	}{
+		{"mapping value in plain scalar", "a: b: c\n", "line 1:"},
+		{"nested mapping value", "x:\n  a: b: c\n", "line 2:"},
+		{"mapping value in sequence", "- a: b: c\n", "line 1:"},
+		{"unknown alias", "a: *nope\n", "line 1:"},
+		{"unterminated quote", "a: 'x\n", "line 1:"},
+		{"unterminated flow", "a: [1, 2\n", "line 1:"},
+		{"unclosed flow mapping", "a: {b: 1 c: 2}\n", "line 1:"},
+		{"bad indentation", "a: 1\n  b: 2\n", "line 2:"},
+		{"dedented content", "a:\n    b: 1\n  c: 2\n", "line 3:"},
+		{"unknown alias in flow", "a: [*nope]\n", "line 1:"},
+		{"tab indentation", "a:\n\tb: 1\n", "line 2:"},
+		{"tab after spaces", "a:\n  b: 1\n  \tc: 2\n", "line 3:"},
+		{"tab in sequence", "a:\n  - 1\n\t- 2\n", "line 3:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.in))
+			if err == nil {
+				t.Fatal("Parse succeeded, want an error")
+			}
+			if !strings.HasPrefix(err.Error(), tt.line) {
+				t.Errorf("error %q does not start with %q", err, tt.line)
+			}
+		})
+	}
+}
+
+func TestLines(t *testing.T) {
+	in := `# workflow
+name: ci
+on: [push]
+
+jobs:
+  build:
+    steps:
+      - uses: actions/checkout@v4
+      - name: test
+        run: |
+          go test ./...
+
+          go vet ./...
+      - run: >
+          echo
+        env: {A: "1",
+          B: x}
+`
+	docs, err := Parse([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := docs[0]
+	steps := doc.Path("jobs", "build", "steps")
+	env := steps.Items()[2].Get("env")
+
+	tests := []struct {
+		name         string
+		node         *Node
+		line, column int
+	}{
+		{"document", doc, 2, 1},
+		{"scalar", doc.Get("name"), 2, 7},
+		{"flow sequence", doc.Get("on"), 3, 5},
+		{"flow item", doc.Get("on").Items()[0], 3, 6},
+		{"nested mapping", doc.Path("jobs", "build"), 7, 5},
+		{"sequence", steps, 8, 7},
+		{"item mapping", steps.Items()[0], 8, 9},
+		{"item value", steps.Items()[0].Get("uses"), 8, 15},
+		{"literal block", steps.Items()[1].Get("run"), 10, 14},
+		{"folded block", steps.Items()[2].Get("run"), 14, 14},
+		{"flow mapping", env, 16, 14},
+		{"flow value on a continuation line", env.Get("B"), 17, 14},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.node == nil {
+				t.Fatal("node not found")
+			}
+			if tt.node.Line != tt.line || tt.node.Column != tt.column {
+				t.Errorf("got %d:%d, want %d:%d", tt.node.Line, tt.node.Column, tt.line, tt.column)
+			}
+		})
+	}
+
+	k, _ := doc.Path("jobs", "build").Lookup("steps")
+	if k == nil || k.Line != 7 {
+		t.Errorf("key steps: got %+v, want line 7", k)
+	}
+}
+
+func TestMergeKeys(t *testing.T) {
+	in := `defaults: &defaults
+  image: golang
+  timeout: 10
+extra: &extra
+  retries: 2
+job:
+  <<: [*defaults, *extra]
+  timeout: 20
+single:
+  <<: *defaults
+`
+	docs, err := Parse([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := docs[0]
+
+	tests := []struct {
+		path []string
+		want string
+	}{
+		{[]string{"job", "image"}, "golang"},
+		{[]string{"job", "timeout"}, "20"}, // Own keys first
+		{[]string{"job", "retries"}, "2"},
+		{[]string{"single", "timeout"}, "10"},
+		{[]string{"single", "retries"}, ""},
+	}
+	for _, tt := range tests {
+		if got := doc.Path(tt.path...).String(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", strings.Join(tt.path, "."), got, tt.want)
+		}
+	}
+	if entries := doc.Get("job").Entries(); len(entries) != 2 || entries[0].Key.Value != "<<" {
+		t.Errorf("Entries must return the merge key as it is, got %d entries", len(entries))
+	}
+}
+
+func TestScalarHelpers(t *testing.T) {
+	docs, err := Parse([]byte("a: Yes\nb: off\nc: 1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := docs[0]
+	if !doc.Get("a").Bool() || doc.Get("a").IsFalse() {
+		t.Error("Yes must be true")
+	}
+	if doc.Get("b").Bool() || !doc.Get("b").IsFalse() {
+		t.Error("off must be false")
+	}
+	if doc.Get("c").Bool() || doc.Get("c").IsFalse() {
+		t.Error("1 is neither true nor false")
+	}
+	var missing *Node
+	if missing.String() != "" || missing.Items() != nil || missing.Get("x") != nil || doc.Path("a", "b") != nil {
+		t.Error("helpers must accept nil and non-mapping nodes")
+	}
+}